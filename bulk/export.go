@@ -0,0 +1,154 @@
+package bulk
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/mohdrashid9678/dcore/chash"
+)
+
+// ErrNoExporter is returned when an export is configured without an Export function
+var ErrNoExporter = errors.New("bulk export requires an Export function")
+
+// ExportFunc exports the records stored on node whose key hashes fall in rng,
+// typically with a range scan
+type ExportFunc func(ctx context.Context, node string, rng chash.Range) error
+
+// RangeTask is one unit of export work: a hash range and the node that owns it
+type RangeTask struct {
+	Node  string      `json:"node"`
+	Range chash.Range `json:"range"`
+}
+
+// ExportCheckpoint lists the range tasks an export has finished
+// Ranges come from the ring topology at the start of the export; resuming
+// after a topology change re-exports every range that no longer matches
+type ExportCheckpoint struct {
+	Completed []RangeTask `json:"completed"`
+}
+
+// ExportConfig holds configuration options for an export
+type ExportConfig struct {
+	// Ring provides the owned ranges to export (required)
+	Ring *chash.Ring
+
+	// Export exports one range (required)
+	Export ExportFunc
+
+	// Nodes limits the export to these nodes
+	// Default: every node in the ring
+	Nodes []string
+
+	// Concurrency is the number of ranges exported in parallel
+	// Default: 4
+	Concurrency int
+
+	// Resume skips the ranges completed by a previous run
+	// Default: none
+	Resume ExportCheckpoint
+
+	// Checkpoint is called after every completed range with all ranges
+	// completed so far, including resumed ones
+	// Default: nil (no checkpoints)
+	Checkpoint func(ExportCheckpoint) error
+}
+
+// Export exports every range owned by the configured nodes and returns the
+// final checkpoint
+// On error, the returned checkpoint holds the ranges that did complete, so
+// the export can be resumed from it
+func Export(ctx context.Context, config ExportConfig) (ExportCheckpoint, error) {
+	if config.Ring == nil {
+		return config.Resume, ErrNoRing
+	}
+	if config.Export == nil {
+		return config.Resume, ErrNoExporter
+	}
+
+	if config.Concurrency <= 0 {
+		config.Concurrency = 4 // Default export concurrency
+	}
+
+	nodes := config.Nodes
+	if len(nodes) == 0 {
+		nodes = config.Ring.Nodes()
+	}
+
+	done := make(map[RangeTask]struct{}, len(config.Resume.Completed))
+	for _, task := range config.Resume.Completed {
+		done[task] = struct{}{}
+	}
+
+	var tasks []RangeTask
+	for _, node := range nodes {
+		for _, rng := range config.Ring.OwnedRanges(node) {
+			task := RangeTask{Node: node, Range: rng}
+			if _, ok := done[task]; !ok {
+				tasks = append(tasks, task)
+			}
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu         sync.Mutex
+		checkpoint = ExportCheckpoint{Completed: append([]RangeTask(nil), config.Resume.Completed...)}
+		firstErr   error
+		wg         sync.WaitGroup
+	)
+
+	fail := func(err error) {
+		mu.Lock()
+		if firstErr == nil {
+			firstErr = err
+		}
+		mu.Unlock()
+		cancel()
+	}
+
+	sem := make(chan struct{}, config.Concurrency)
+	for _, task := range tasks {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(task RangeTask) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			if err := config.Export(ctx, task.Node, task.Range); err != nil {
+				fail(err)
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			checkpoint.Completed = append(checkpoint.Completed, task)
+			if config.Checkpoint != nil && firstErr == nil {
+				// Hand out a copy: later completions keep appending
+				snapshot := ExportCheckpoint{Completed: append([]RangeTask(nil), checkpoint.Completed...)}
+				if err := config.Checkpoint(snapshot); err != nil {
+					firstErr = err
+					cancel()
+				}
+			}
+		}(task)
+	}
+
+	wg.Wait()
+
+	// A cancelled parent context stops the export before every task ran
+	if firstErr == nil && len(checkpoint.Completed) < len(config.Resume.Completed)+len(tasks) {
+		firstErr = ctx.Err()
+	}
+	return checkpoint, firstErr
+}
@@ -0,0 +1,116 @@
+package bulk
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/mohdrashid9678/dcore/chash"
+)
+
+func TestExportCoversRing(t *testing.T) {
+	ring := chash.NewWithNodes(chash.Config{Replicas: 10}, []string{"server1", "server2", "server3"})
+
+	var mu sync.Mutex
+	exported := make(map[string]float64)
+	checkpoints := 0
+
+	final, err := Export(context.Background(), ExportConfig{
+		Ring: ring,
+		Export: func(ctx context.Context, node string, rng chash.Range) error {
+			mu.Lock()
+			exported[node] += rng.Fraction()
+			mu.Unlock()
+			return nil
+		},
+		Checkpoint: func(ExportCheckpoint) error { checkpoints++; return nil },
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	total := 0.0
+	for _, f := range exported {
+		total += f
+	}
+	if total < 0.999999 || total > 1.000001 {
+		t.Errorf("expected export to cover the ring once, got %v", total)
+	}
+	if checkpoints != len(final.Completed) {
+		t.Errorf("expected a checkpoint per range, got %d for %d ranges", checkpoints, len(final.Completed))
+	}
+}
+
+func TestExportResume(t *testing.T) {
+	ring := chash.NewWithNodes(chash.Config{Replicas: 10}, []string{"server1", "server2"})
+	boom := errors.New("scan failed")
+
+	calls := 0
+	partial, err := Export(context.Background(), ExportConfig{
+		Ring:        ring,
+		Concurrency: 1,
+		Export: func(ctx context.Context, node string, rng chash.Range) error {
+			calls++
+			if calls == 5 {
+				return boom
+			}
+			return nil
+		},
+	})
+	if err != boom {
+		t.Fatalf("expected scan error, got %v", err)
+	}
+	if len(partial.Completed) != 4 {
+		t.Fatalf("expected 4 completed ranges, got %d", len(partial.Completed))
+	}
+
+	done := make(map[RangeTask]bool)
+	for _, task := range partial.Completed {
+		done[task] = true
+	}
+
+	final, err := Export(context.Background(), ExportConfig{
+		Ring:   ring,
+		Resume: partial,
+		Export: func(ctx context.Context, node string, rng chash.Range) error {
+			if done[RangeTask{Node: node, Range: rng}] {
+				t.Errorf("range %v on %s exported twice", rng, node)
+			}
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	expected := len(ring.OwnedRanges("server1")) + len(ring.OwnedRanges("server2"))
+	if len(final.Completed) != expected {
+		t.Errorf("expected %d completed ranges, got %d", expected, len(final.Completed))
+	}
+}
+
+func TestExportNodesAndValidation(t *testing.T) {
+	ring := chash.NewWithNodes(chash.Config{Replicas: 10}, []string{"server1", "server2"})
+
+	final, err := Export(context.Background(), ExportConfig{
+		Ring:  ring,
+		Nodes: []string{"server2"},
+		Export: func(ctx context.Context, node string, rng chash.Range) error {
+			if node != "server2" {
+				t.Errorf("unexpected node %s", node)
+			}
+			return nil
+		},
+	})
+	if err != nil || len(final.Completed) != len(ring.OwnedRanges("server2")) {
+		t.Errorf("expected server2 ranges only, got %d (%v)", len(final.Completed), err)
+	}
+
+	if _, err := Export(context.Background(), ExportConfig{Ring: ring}); err != ErrNoExporter {
+		t.Errorf("expected ErrNoExporter, got %v", err)
+	}
+	if _, err := Export(context.Background(), ExportConfig{}); err != ErrNoRing {
+		t.Errorf("expected ErrNoRing, got %v", err)
+	}
+}
@@ -0,0 +1,231 @@
+package bulk
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/mohdrashid9678/dcore/chash"
+	"github.com/mohdrashid9678/dcore/clock"
+)
+
+// ErrNoCollector is returned when a collection is configured without Digest or Delete
+var ErrNoCollector = errors.New("bulk gc requires Digest and Delete functions")
+
+// Orphan is a hash range a node still stores after a ring change although it
+// is no longer one of the range's replicas
+type Orphan struct {
+	// Node holds the stale copy
+	Node string `json:"node"`
+
+	// Owner is the range's primary owner after the change, whose copy must
+	// match the stale one before it is deleted
+	Owner string `json:"owner"`
+
+	Range chash.Range `json:"range"`
+}
+
+// Orphans returns the ranges each node stopped replicating between two ring
+// states, with replicas copies of each key placed clockwise as by
+// chash.Ring.GetNodes; replicas below 1 means 1
+// A node that only moves within a range's replica set, such as from primary
+// to secondary, keeps the range. Ownership is measured on virtual nodes alone,
+// so node states do not apply, and nodes that left the ring are skipped:
+// their data leaves with them
+func Orphans(before, after *chash.Ring, replicas int) []Orphan {
+	replicas = max(replicas, 1)
+
+	members := make(map[string]struct{})
+	for _, node := range after.Nodes() {
+		members[node] = struct{}{}
+	}
+
+	vb := sortedVirtualNodes(before)
+	va := sortedVirtualNodes(after)
+	if len(vb) == 0 || len(va) == 0 {
+		return nil
+	}
+
+	bounds := make([]uint64, 0, len(vb)+len(va))
+	for _, v := range vb {
+		bounds = append(bounds, v.Hash)
+	}
+	for _, v := range va {
+		bounds = append(bounds, v.Hash)
+	}
+	slices.Sort(bounds)
+	bounds = slices.Compact(bounds)
+
+	var orphans []Orphan
+	last := make(map[string]int) // node to the index of its latest orphan
+	for i, end := range bounds {
+		start := bounds[(i+len(bounds)-1)%len(bounds)]
+
+		old := replicaSet(vb, end, replicas)
+		current := replicaSet(va, end, replicas)
+		for _, node := range old {
+			if _, ok := members[node]; !ok || slices.Contains(current, node) {
+				continue
+			}
+
+			// Extend the node's previous orphan if this segment continues it
+			if k, ok := last[node]; ok && orphans[k].Range.End == start && orphans[k].Owner == current[0] {
+				orphans[k].Range.End = end
+				continue
+			}
+			last[node] = len(orphans)
+			orphans = append(orphans, Orphan{Node: node, Owner: current[0], Range: chash.Range{Start: start, End: end}})
+		}
+	}
+	return orphans
+}
+
+// sortedVirtualNodes returns the virtual nodes of ring sorted by position;
+// colliding virtual nodes keep the ring's order
+func sortedVirtualNodes(ring *chash.Ring) []chash.VirtualNode {
+	vnodes := ring.VirtualNodesBetween(0, 0)
+	slices.SortStableFunc(vnodes, func(a, b chash.VirtualNode) int {
+		return cmp.Compare(a.Hash, b.Hash)
+	})
+	return vnodes
+}
+
+// replicaSet returns up to count distinct nodes clockwise from hash
+func replicaSet(vnodes []chash.VirtualNode, hash uint64, count int) []string {
+	idx, _ := slices.BinarySearchFunc(vnodes, hash, func(v chash.VirtualNode, h uint64) int {
+		return cmp.Compare(v.Hash, h)
+	})
+
+	var nodes []string
+	for k := 0; k < len(vnodes) && len(nodes) < count; k++ {
+		node := vnodes[(idx+k)%len(vnodes)].Node
+		if !slices.Contains(nodes, node) {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+// DigestFunc returns a digest of the records stored on node whose key hashes
+// fall in rng, such as a hash over their keys and versions
+type DigestFunc func(ctx context.Context, node string, rng chash.Range) ([]byte, error)
+
+// DeleteFunc deletes the records stored on node whose key hashes fall in rng
+type DeleteFunc func(ctx context.Context, node string, rng chash.Range) error
+
+// CollectConfig holds configuration options for a collection
+type CollectConfig struct {
+	// Before and After are the ring states around the change (required)
+	Before *chash.Ring
+	After  *chash.Ring
+
+	// Replicas is the number of copies of each key the store keeps on
+	// consecutive distinct nodes clockwise, as GetNodes returns them
+	// Default: 1
+	Replicas int
+
+	// Digest digests a range on a node (required)
+	Digest DigestFunc
+
+	// Delete deletes a range from a node (required)
+	Delete DeleteFunc
+
+	// Delay is waited before the first deletion, so reads routed with the old
+	// ring and late replication have finished
+	// Default: 0
+	Delay time.Duration
+
+	// Interval is waited between deletions to bound the load on the nodes
+	// Default: 0 (no throttling)
+	Interval time.Duration
+
+	// Clock is the time source for Delay and Interval
+	// Default: the wall clock
+	Clock clock.Clock
+}
+
+// CollectResult reports what a collection did
+type CollectResult struct {
+	// Deleted lists the orphans whose stale copy was deleted
+	Deleted []Orphan `json:"deleted"`
+
+	// Unverified lists the orphans kept because the new owner's digest did
+	// not match the stale copy; the migration for them is incomplete
+	Unverified []Orphan `json:"unverified"`
+}
+
+// Collect deletes the data nodes no longer replicate after a ring change and a
+// completed migration
+// Each orphaned range is deleted only once the new owner's digest matches the
+// stale copy's, so data is never lost to a migration that skipped a range.
+// Deletions run one at a time after Delay, spaced by Interval
+// On error, the result holds the ranges handled so far; deletions are
+// idempotent, so the collection can simply be run again
+func Collect(ctx context.Context, config CollectConfig) (CollectResult, error) {
+	var result CollectResult
+
+	if config.Before == nil || config.After == nil {
+		return result, ErrNoRing
+	}
+	if config.Digest == nil || config.Delete == nil {
+		return result, ErrNoCollector
+	}
+	clk := clock.OrReal(config.Clock)
+
+	orphans := Orphans(config.Before, config.After, config.Replicas)
+	if len(orphans) == 0 {
+		return result, nil
+	}
+
+	if err := wait(ctx, clk, config.Delay); err != nil {
+		return result, err
+	}
+
+	for i, orphan := range orphans {
+		if i > 0 {
+			if err := wait(ctx, clk, config.Interval); err != nil {
+				return result, err
+			}
+		}
+
+		stale, err := config.Digest(ctx, orphan.Node, orphan.Range)
+		if err != nil {
+			return result, err
+		}
+		current, err := config.Digest(ctx, orphan.Owner, orphan.Range)
+		if err != nil {
+			return result, err
+		}
+		if !bytes.Equal(stale, current) {
+			result.Unverified = append(result.Unverified, orphan)
+			continue
+		}
+
+		if err := config.Delete(ctx, orphan.Node, orphan.Range); err != nil {
+			return result, err
+		}
+		result.Deleted = append(result.Deleted, orphan)
+	}
+
+	return result, nil
+}
+
+// wait blocks for d or until ctx is done
+func wait(ctx context.Context, clk clock.Clock, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := clk.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,201 @@
+package bulk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/mohdrashid9678/dcore/chash"
+	"github.com/mohdrashid9678/dcore/clock"
+)
+
+func TestOrphans(t *testing.T) {
+	before := chash.NewWithNodes(chash.Config{Replicas: 10}, []string{"server1", "server2", "server3"})
+	after := chash.NewWithNodes(chash.Config{Replicas: 10}, []string{"server1", "server2", "server3", "server4"})
+
+	orphans := Orphans(before, after, 1)
+	if len(orphans) == 0 {
+		t.Fatal("expected orphaned ranges after adding a node")
+	}
+
+	var fraction float64
+	for _, o := range orphans {
+		if o.Owner != "server4" || o.Node == "server4" {
+			t.Errorf("expected ranges moving to server4, got %+v", o)
+		}
+		fraction += o.Range.Fraction()
+	}
+	if want := chash.MovedFraction(before.Diff(after)); math.Abs(fraction-want) > 1e-9 {
+		t.Errorf("expected %v of the keyspace, got %v", want, fraction)
+	}
+
+	// A removed node's data leaves with it
+	shrunk := chash.NewWithNodes(chash.Config{Replicas: 10}, []string{"server1", "server2"})
+	for _, o := range Orphans(before, shrunk, 1) {
+		if o.Node == "server3" {
+			t.Errorf("expected no orphans on the removed node, got %+v", o)
+		}
+	}
+}
+
+func TestOrphansWithReplicas(t *testing.T) {
+	before := chash.NewWithNodes(chash.Config{Replicas: 10}, []string{"server1", "server2", "server3", "server4"})
+	after := chash.NewWithNodes(chash.Config{Replicas: 10}, []string{"server1", "server2", "server3", "server4", "server5"})
+	orphans := Orphans(before, after, 2)
+
+	demoted := 0
+	for i := 0; i < 2000; i++ {
+		key := fmt.Sprintf("key%d", i)
+		hash := chash.DefaultHashFunc(key)
+		old, _ := before.GetNodes(key, 2)
+		current, _ := after.GetNodes(key, 2)
+
+		if old[0] != current[0] && slices.Contains(current, old[0]) {
+			demoted++
+		}
+
+		for _, node := range old {
+			kept := slices.Contains(current, node)
+			orphaned := false
+			for _, o := range orphans {
+				if o.Node == node && o.Range.Contains(hash) {
+					orphaned = true
+					if o.Owner != current[0] {
+						t.Errorf("%s: expected owner %s, got %+v", key, current[0], o)
+					}
+				}
+			}
+			if kept == orphaned {
+				t.Fatalf("%s on %s: replica kept=%v but orphaned=%v", key, node, kept, orphaned)
+			}
+		}
+	}
+
+	// A primary that becomes a secondary still holds a replica
+	if demoted == 0 {
+		t.Error("expected some keys whose primary became a secondary")
+	}
+}
+
+func TestCollect(t *testing.T) {
+	before := chash.NewWithNodes(chash.Config{Replicas: 10}, []string{"server1", "server2", "server3"})
+	after := chash.NewWithNodes(chash.Config{Replicas: 10}, []string{"server1", "server2", "server3", "server4"})
+	orphans := Orphans(before, after, 1)
+
+	// The first orphan was not copied to its new owner
+	skip := orphans[0]
+	var deleted []Orphan
+
+	result, err := Collect(context.Background(), CollectConfig{
+		Before: before,
+		After:  after,
+		Digest: func(ctx context.Context, node string, rng chash.Range) ([]byte, error) {
+			if node == skip.Owner && rng == skip.Range {
+				return []byte("missing"), nil
+			}
+			return []byte(rng.String()), nil
+		},
+		Delete: func(ctx context.Context, node string, rng chash.Range) error {
+			deleted = append(deleted, Orphan{Node: node, Owner: "server4", Range: rng})
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(result.Unverified) != 1 || result.Unverified[0] != skip {
+		t.Errorf("expected %+v unverified, got %+v", skip, result.Unverified)
+	}
+	if len(result.Deleted) != len(orphans)-1 || len(deleted) != len(orphans)-1 {
+		t.Errorf("expected %d deletions, got %d", len(orphans)-1, len(result.Deleted))
+	}
+	for _, o := range deleted {
+		if o == skip {
+			t.Error("expected the unverified range to be kept")
+		}
+	}
+}
+
+func TestCollectWaitsAndThrottles(t *testing.T) {
+	before := chash.NewWithNodes(chash.Config{Replicas: 10}, []string{"server1", "server2"})
+	after := chash.NewWithNodes(chash.Config{Replicas: 10}, []string{"server1", "server2", "server3"})
+	orphans := Orphans(before, after, 1)
+	if len(orphans) < 2 {
+		t.Fatalf("expected at least 2 orphans, got %d", len(orphans))
+	}
+
+	clk := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	start := clk.Now()
+	var times []time.Duration
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := Collect(context.Background(), CollectConfig{
+			Before:   before,
+			After:    after,
+			Digest:   func(context.Context, string, chash.Range) ([]byte, error) { return nil, nil },
+			Delete:   func(context.Context, string, chash.Range) error { times = append(times, clk.Since(start)); return nil },
+			Delay:    time.Minute,
+			Interval: time.Second,
+			Clock:    clk,
+		})
+		done <- err
+	}()
+
+	// The safety delay, then one interval per further deletion
+	clk.BlockUntil(1)
+	clk.Advance(time.Minute)
+	for range orphans[1:] {
+		clk.BlockUntil(1)
+		clk.Advance(time.Second)
+	}
+
+	if err := <-done; err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	for i, at := range times {
+		if want := time.Minute + time.Duration(i)*time.Second; at != want {
+			t.Errorf("deletion %d: expected at %v, got %v", i, want, at)
+		}
+	}
+}
+
+func TestCollectErrors(t *testing.T) {
+	ring := chash.NewWithNodes(chash.Config{Replicas: 10}, []string{"server1"})
+	if _, err := Collect(context.Background(), CollectConfig{Before: ring}); err != ErrNoRing {
+		t.Errorf("expected ErrNoRing, got %v", err)
+	}
+	if _, err := Collect(context.Background(), CollectConfig{Before: ring, After: ring}); err != ErrNoCollector {
+		t.Errorf("expected ErrNoCollector, got %v", err)
+	}
+
+	after := chash.NewWithNodes(chash.Config{Replicas: 10}, []string{"server1", "server2"})
+	boom := errors.New("boom")
+	_, err := Collect(context.Background(), CollectConfig{
+		Before: ring,
+		After:  after,
+		Digest: func(context.Context, string, chash.Range) ([]byte, error) { return nil, boom },
+		Delete: func(context.Context, string, chash.Range) error { return nil },
+	})
+	if err != boom {
+		t.Errorf("expected digest error, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = Collect(ctx, CollectConfig{
+		Before: ring,
+		After:  after,
+		Digest: func(context.Context, string, chash.Range) ([]byte, error) { return nil, nil },
+		Delete: func(context.Context, string, chash.Range) error { return nil },
+		Delay:  time.Hour,
+	})
+	if err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
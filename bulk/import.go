@@ -0,0 +1,370 @@
+// Package bulk moves large datasets in and out of a sharded store: imports
+// are pre-partitioned by ring owner and written as per-shard batches in
+// parallel with backpressure, exports walk each node's owned hash ranges, and
+// both checkpoint so multi-hour jobs can resume after a crash. After a
+// resharding, it deletes the ranges nodes no longer own once their new owners
+// are verified to hold the data.
+
+package bulk
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/mohdrashid9678/dcore/chash"
+)
+
+var (
+	// ErrNoRing is returned when an import or export is configured without a ring
+	ErrNoRing = errors.New("bulk job requires a ring")
+
+	// ErrNoWriter is returned when an import is configured without Key or Write
+	ErrNoWriter = errors.New("bulk import requires Key and Write functions")
+
+	// ErrClosed is returned when adding records to a closed importer
+	ErrClosed = errors.New("importer is closed")
+)
+
+// WriteFunc writes a batch of records to the node that owns them
+// Retries belong inside the function; an error aborts the import
+type WriteFunc[T any] func(ctx context.Context, node string, batch []T) error
+
+// ImportConfig holds configuration options for creating a new Importer
+type ImportConfig[T any] struct {
+	// Ring decides which node each record belongs to (required)
+	Ring *chash.Ring
+
+	// Key returns the routing key of a record (required)
+	Key func(T) string
+
+	// Write stores a batch on a node (required)
+	Write WriteFunc[T]
+
+	// BatchSize is the number of records per write
+	// Default: 500
+	BatchSize int
+
+	// QueueDepth is the number of full batches buffered per node; Add blocks
+	// when a node's queue is full, so a slow shard throttles the producer
+	// Default: 4
+	QueueDepth int
+
+	// Resume skips this many records from the start of the source, taken from
+	// the Records field of a saved checkpoint
+	// Default: 0
+	Resume uint64
+
+	// Checkpoint is called when every record before Records has been written,
+	// at most once per CheckpointEvery records and once more on Close
+	// Default: nil (no checkpoints)
+	Checkpoint func(ImportCheckpoint) error
+
+	// CheckpointEvery is the minimum number of records between checkpoints
+	// Default: 10000
+	CheckpointEvery uint64
+}
+
+// ImportCheckpoint marks how far an import is durably complete
+type ImportCheckpoint struct {
+	// Records is the number of records from the start of the source that have
+	// all been written; resuming skips exactly these
+	Records uint64
+}
+
+// ImportProgress reports the state of an import
+type ImportProgress struct {
+	// Added is the number of records passed to Add, including skipped ones
+	Added uint64
+
+	// Written is the number of records written to their nodes
+	Written uint64
+
+	// Checkpoint is the latest durable position
+	Checkpoint ImportCheckpoint
+}
+
+// Importer partitions records by owner and writes them in per-node batches
+// Each node has one writer goroutine, so nodes are written in parallel and a
+// node receives its batches in order
+type Importer[T any] struct {
+	config ImportConfig[T]
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	// mu protects all fields below
+	mu      sync.Mutex
+	shards  map[string]*shard[T]
+	next    uint64 // sequence number of the next record
+	skip    uint64 // records still to skip when resuming
+	written uint64
+	err     error
+	closed  bool
+
+	// done marks completed sequence numbers at or above watermark
+	done      map[uint64]struct{}
+	watermark uint64
+
+	// saveMu serialises checkpoint callbacks; saved is guarded by it
+	saveMu sync.Mutex
+	saved  uint64
+}
+
+// shard buffers records for one node
+type shard[T any] struct {
+	records []T
+	seqs    []uint64
+	queue   chan batch[T]
+}
+
+// batch is a unit of work for a node writer
+type batch[T any] struct {
+	records []T
+	seqs    []uint64
+}
+
+// NewImporter creates an importer; ctx bounds every write
+func NewImporter[T any](ctx context.Context, config ImportConfig[T]) (*Importer[T], error) {
+	if config.Ring == nil {
+		return nil, ErrNoRing
+	}
+	if config.Key == nil || config.Write == nil {
+		return nil, ErrNoWriter
+	}
+
+	if config.BatchSize <= 0 {
+		config.BatchSize = 500 // Default batch size
+	}
+	if config.QueueDepth <= 0 {
+		config.QueueDepth = 4 // Default per-node queue depth
+	}
+	if config.CheckpointEvery == 0 {
+		config.CheckpointEvery = 10000 // Default checkpoint interval
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	return &Importer[T]{
+		config:    config,
+		ctx:       ctx,
+		cancel:    cancel,
+		shards:    make(map[string]*shard[T]),
+		next:      config.Resume,
+		skip:      config.Resume,
+		done:      make(map[uint64]struct{}),
+		watermark: config.Resume,
+		saved:     config.Resume,
+	}, nil
+}
+
+// Add routes a record to its owner's batch, blocking while that node's queue
+// is full
+// Records must be added in source order from a single goroutine, and Add must
+// not race with Close. The first Resume records are skipped so a restarted job
+// can replay its source from the beginning
+func (im *Importer[T]) Add(record T) error {
+	im.mu.Lock()
+
+	if im.closed {
+		im.mu.Unlock()
+		return ErrClosed
+	}
+	if im.err != nil {
+		im.mu.Unlock()
+		return im.err
+	}
+
+	if im.skip > 0 {
+		im.skip--
+		im.mu.Unlock()
+		return nil
+	}
+
+	node, err := im.config.Ring.GetNode(im.config.Key(record))
+	if err != nil {
+		im.mu.Unlock()
+		return err
+	}
+
+	s := im.shardLocked(node)
+	s.records = append(s.records, record)
+	s.seqs = append(s.seqs, im.next)
+	im.next++
+
+	if len(s.records) < im.config.BatchSize {
+		im.mu.Unlock()
+		return nil
+	}
+
+	b := batch[T]{records: s.records, seqs: s.seqs}
+	s.records, s.seqs = nil, nil
+	im.mu.Unlock()
+
+	// Enqueue outside the lock: this is where backpressure blocks the producer
+	select {
+	case s.queue <- b:
+		return nil
+	case <-im.ctx.Done():
+		return im.failure()
+	}
+}
+
+// Close flushes partial batches, waits for every write, saves a final
+// checkpoint and returns the first error
+func (im *Importer[T]) Close() error {
+	im.mu.Lock()
+	if im.closed {
+		im.mu.Unlock()
+		return im.err
+	}
+	im.closed = true
+
+	var pending []func()
+	for _, s := range im.shards {
+		s := s
+		if len(s.records) > 0 {
+			b := batch[T]{records: s.records, seqs: s.seqs}
+			s.records, s.seqs = nil, nil
+			pending = append(pending, func() {
+				select {
+				case s.queue <- b:
+				case <-im.ctx.Done():
+				}
+			})
+		}
+	}
+	im.mu.Unlock()
+
+	for _, flush := range pending {
+		flush()
+	}
+
+	im.mu.Lock()
+	for _, s := range im.shards {
+		close(s.queue)
+	}
+	im.mu.Unlock()
+
+	im.wg.Wait()
+	err := im.failure()
+	im.cancel()
+
+	if err == nil {
+		err = im.checkpoint(true)
+	}
+	return err
+}
+
+// Progress returns the import counters
+func (im *Importer[T]) Progress() ImportProgress {
+	im.mu.Lock()
+	defer im.mu.Unlock()
+
+	return ImportProgress{
+		Added:      im.next,
+		Written:    im.written,
+		Checkpoint: ImportCheckpoint{Records: im.watermark},
+	}
+}
+
+// shardLocked returns the buffer for node, starting its writer on first use
+// Caller must hold im.mu
+func (im *Importer[T]) shardLocked(node string) *shard[T] {
+	s, ok := im.shards[node]
+	if ok {
+		return s
+	}
+
+	s = &shard[T]{queue: make(chan batch[T], im.config.QueueDepth)}
+	im.shards[node] = s
+
+	im.wg.Add(1)
+	go im.writer(node, s.queue)
+	return s
+}
+
+// writer drains a node's queue until it is closed or the import fails
+func (im *Importer[T]) writer(node string, queue <-chan batch[T]) {
+	defer im.wg.Done()
+
+	for b := range queue {
+		if im.ctx.Err() != nil {
+			continue // Drain so producers never block on a failed import
+		}
+
+		if err := im.config.Write(im.ctx, node, b.records); err != nil {
+			im.fail(err)
+			continue
+		}
+
+		im.complete(b.seqs)
+		if err := im.checkpoint(false); err != nil {
+			im.fail(err)
+		}
+	}
+}
+
+// complete records written sequence numbers and advances the watermark past
+// every contiguous completed record
+func (im *Importer[T]) complete(seqs []uint64) {
+	im.mu.Lock()
+	defer im.mu.Unlock()
+
+	im.written += uint64(len(seqs))
+	for _, seq := range seqs {
+		im.done[seq] = struct{}{}
+	}
+	for {
+		if _, ok := im.done[im.watermark]; !ok {
+			break
+		}
+		delete(im.done, im.watermark)
+		im.watermark++
+	}
+}
+
+// checkpoint reports the watermark if it moved far enough, or at all if final
+func (im *Importer[T]) checkpoint(final bool) error {
+	if im.config.Checkpoint == nil {
+		return nil
+	}
+
+	im.saveMu.Lock()
+	defer im.saveMu.Unlock()
+
+	im.mu.Lock()
+	watermark := im.watermark
+	im.mu.Unlock()
+
+	if watermark == im.saved || (!final && watermark-im.saved < im.config.CheckpointEvery) {
+		return nil
+	}
+
+	if err := im.config.Checkpoint(ImportCheckpoint{Records: watermark}); err != nil {
+		return err
+	}
+	im.saved = watermark
+	return nil
+}
+
+// fail records the first error and stops all writers
+func (im *Importer[T]) fail(err error) {
+	im.mu.Lock()
+	if im.err == nil {
+		im.err = err
+	}
+	im.mu.Unlock()
+
+	im.cancel()
+}
+
+// failure returns the recorded error, or the context error if none was recorded
+func (im *Importer[T]) failure() error {
+	im.mu.Lock()
+	defer im.mu.Unlock()
+
+	if im.err != nil {
+		return im.err
+	}
+	return im.ctx.Err()
+}
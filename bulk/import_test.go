@@ -0,0 +1,203 @@
+package bulk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mohdrashid9678/dcore/chash"
+)
+
+// store records writes per node
+type store struct {
+	mu     sync.Mutex
+	writes map[string][]string
+	fail   map[string]error
+}
+
+func newStore() *store {
+	return &store{writes: make(map[string][]string), fail: make(map[string]error)}
+}
+
+func (s *store) write(ctx context.Context, node string, batch []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.fail[node]; err != nil {
+		return err
+	}
+	s.writes[node] = append(s.writes[node], batch...)
+	return nil
+}
+
+func identity(s string) string { return s }
+
+func TestImportPartitionsByOwner(t *testing.T) {
+	ring := chash.NewWithNodes(chash.Config{Replicas: 20}, []string{"server1", "server2", "server3"})
+	st := newStore()
+
+	var checkpoints []ImportCheckpoint
+	im, err := NewImporter(context.Background(), ImportConfig[string]{
+		Ring:            ring,
+		Key:             identity,
+		Write:           st.write,
+		BatchSize:       16,
+		Checkpoint:      func(c ImportCheckpoint) error { checkpoints = append(checkpoints, c); return nil },
+		CheckpointEvery: 100,
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	for i := 0; i < 1000; i++ {
+		if err := im.Add(fmt.Sprintf("key%d", i)); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+	if err := im.Close(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	total := 0
+	for node, keys := range st.writes {
+		for _, key := range keys {
+			if owner, _ := ring.GetNode(key); owner != node {
+				t.Errorf("key %s written to %s, owned by %s", key, node, owner)
+			}
+		}
+		total += len(keys)
+	}
+	if total != 1000 {
+		t.Errorf("expected 1000 records written, got %d", total)
+	}
+
+	if p := im.Progress(); p.Added != 1000 || p.Written != 1000 || p.Checkpoint.Records != 1000 {
+		t.Errorf("unexpected progress %+v", p)
+	}
+	if len(checkpoints) == 0 || checkpoints[len(checkpoints)-1].Records != 1000 {
+		t.Errorf("expected final checkpoint at 1000, got %v", checkpoints)
+	}
+	for i := 1; i < len(checkpoints); i++ {
+		if checkpoints[i].Records <= checkpoints[i-1].Records {
+			t.Errorf("expected increasing checkpoints, got %v", checkpoints)
+		}
+	}
+}
+
+func TestImportResume(t *testing.T) {
+	ring := chash.NewWithNodes(chash.Config{Replicas: 20}, []string{"server1", "server2"})
+	st := newStore()
+
+	im, _ := NewImporter(context.Background(), ImportConfig[string]{
+		Ring:   ring,
+		Key:    identity,
+		Write:  st.write,
+		Resume: 600,
+	})
+
+	// The restarted job replays its source from the beginning
+	for i := 0; i < 1000; i++ {
+		im.Add(fmt.Sprintf("key%d", i))
+	}
+	im.Close()
+
+	seen := make(map[string]bool)
+	for _, keys := range st.writes {
+		for _, key := range keys {
+			seen[key] = true
+		}
+	}
+	if len(seen) != 400 || seen["key599"] || !seen["key600"] {
+		t.Errorf("expected only records from 600 on, got %d records", len(seen))
+	}
+	if p := im.Progress(); p.Checkpoint.Records != 1000 {
+		t.Errorf("expected checkpoint at 1000, got %+v", p)
+	}
+}
+
+func TestImportBackpressure(t *testing.T) {
+	ring := chash.NewWithNodes(chash.Config{Replicas: 20}, []string{"server1"})
+
+	release := make(chan struct{})
+	im, _ := NewImporter(context.Background(), ImportConfig[string]{
+		Ring: ring,
+		Key:  identity,
+		Write: func(ctx context.Context, node string, batch []string) error {
+			<-release
+			return nil
+		},
+		BatchSize:  1,
+		QueueDepth: 1,
+	})
+
+	// One batch in the writer, one queued; the third Add must block
+	added := make(chan int, 10)
+	go func() {
+		for i := 0; i < 3; i++ {
+			im.Add(fmt.Sprintf("key%d", i))
+			added <- i
+		}
+	}()
+
+	<-added
+	<-added
+	select {
+	case <-added:
+		t.Fatal("expected Add to block while the node queue is full")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(release)
+	<-added
+	if err := im.Close(); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
+
+func TestImportWriteError(t *testing.T) {
+	ring := chash.NewWithNodes(chash.Config{Replicas: 20}, []string{"server1", "server2"})
+	st := newStore()
+	boom := errors.New("disk full")
+	st.fail["server2"] = boom
+
+	var saved []ImportCheckpoint
+	im, _ := NewImporter(context.Background(), ImportConfig[string]{
+		Ring:       ring,
+		Key:        identity,
+		Write:      st.write,
+		BatchSize:  4,
+		Checkpoint: func(c ImportCheckpoint) error { saved = append(saved, c); return nil },
+	})
+
+	var addErr error
+	for i := 0; i < 1000 && addErr == nil; i++ {
+		addErr = im.Add(fmt.Sprintf("key%d", i))
+	}
+
+	if err := im.Close(); err != boom {
+		t.Errorf("expected write error from Close, got %v", err)
+	}
+	if addErr != nil && addErr != boom {
+		t.Errorf("expected Add to surface the write error, got %v", addErr)
+	}
+	if len(saved) != 0 {
+		t.Errorf("expected no final checkpoint after a failure, got %v", saved)
+	}
+	if err := im.Add("key"); err != ErrClosed {
+		t.Errorf("expected ErrClosed, got %v", err)
+	}
+}
+
+func TestImportValidation(t *testing.T) {
+	if _, err := NewImporter(context.Background(), ImportConfig[string]{}); err != ErrNoRing {
+		t.Errorf("expected ErrNoRing, got %v", err)
+	}
+
+	ring := chash.New(chash.Config{})
+	if _, err := NewImporter(context.Background(), ImportConfig[string]{Ring: ring}); err != ErrNoWriter {
+		t.Errorf("expected ErrNoWriter, got %v", err)
+	}
+}
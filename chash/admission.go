@@ -0,0 +1,57 @@
+package chash
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// ErrAdmissionDenied is returned when a node fails an admission check
+var ErrAdmissionDenied = errors.New("node admission denied")
+
+// AdmissionCheck validates a node before it joins the ring
+// Returning an error blocks the addition; the error should tell the operator
+// what to fix (e.g. "version 1.2 below minimum 1.4")
+type AdmissionCheck func(node string) error
+
+// AdmissionError describes which node failed admission and why
+type AdmissionError struct {
+	// Node is the node that was rejected
+	Node string
+
+	// Err is the error returned by the failing check
+	Err error
+}
+
+// Error implements the error interface
+func (e *AdmissionError) Error() string {
+	return fmt.Sprintf("node %s not admitted: %v", e.Node, e.Err)
+}
+
+// Unwrap returns both the sentinel and the check's error so callers can match either
+func (e *AdmissionError) Unwrap() []error {
+	return []error{ErrAdmissionDenied, e.Err}
+}
+
+// admit runs every admission check against node and returns the first failure
+func (r *Ring) admit(node string) error {
+	for _, check := range r.admissionChecks {
+		if err := check(node); err != nil {
+			return &AdmissionError{Node: node, Err: err}
+		}
+	}
+	return nil
+}
+
+// DialCheck returns an admission check that requires the node name to be a
+// TCP address accepting connections within timeout
+func DialCheck(timeout time.Duration) AdmissionCheck {
+	return func(node string) error {
+		conn, err := net.DialTimeout("tcp", node, timeout)
+		if err != nil {
+			return fmt.Errorf("node unreachable: %w", err)
+		}
+		return conn.Close()
+	}
+}
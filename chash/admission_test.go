@@ -0,0 +1,90 @@
+package chash
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAdmissionChecks(t *testing.T) {
+	errTooSmall := errors.New("capacity 4GB below minimum 8GB")
+
+	var checked []string
+	ring := New(Config{
+		Replicas: 3,
+		AdmissionChecks: []AdmissionCheck{
+			func(node string) error {
+				checked = append(checked, node)
+				return nil
+			},
+			func(node string) error {
+				if strings.HasPrefix(node, "small") {
+					return errTooSmall
+				}
+				return nil
+			},
+		},
+	})
+
+	// Test node passing every check
+	if err := ring.AddNode("server1"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// Test node failing a check
+	err := ring.AddNode("small-server")
+	if !errors.Is(err, ErrAdmissionDenied) {
+		t.Errorf("expected ErrAdmissionDenied, got %v", err)
+	}
+	if !errors.Is(err, errTooSmall) {
+		t.Errorf("expected check error to be wrapped, got %v", err)
+	}
+
+	var admissionErr *AdmissionError
+	if !errors.As(err, &admissionErr) || admissionErr.Node != "small-server" {
+		t.Errorf("expected AdmissionError for small-server, got %v", err)
+	}
+
+	if ring.NodeCount() != 1 {
+		t.Errorf("expected rejected node not to be added, got %d nodes", ring.NodeCount())
+	}
+
+	if len(checked) != 2 {
+		t.Errorf("expected checks to run for both nodes, got %v", checked)
+	}
+}
+
+func TestDialCheck(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen: %v", err)
+	}
+	defer listener.Close()
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	check := DialCheck(time.Second)
+
+	if err := check(listener.Addr().String()); err != nil {
+		t.Errorf("expected reachable node to pass, got %v", err)
+	}
+
+	// Grab a free port and close it so nothing is listening there
+	closed, _ := net.Listen("tcp", "127.0.0.1:0")
+	addr := closed.Addr().String()
+	closed.Close()
+
+	if err := check(addr); err == nil {
+		t.Error("expected unreachable node to fail")
+	}
+}
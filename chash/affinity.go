@@ -0,0 +1,158 @@
+package chash
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var (
+	// ErrGroupNotFound is returned when removing a non-existent affinity group
+	ErrGroupNotFound = errors.New("affinity group not found")
+
+	// ErrPrefixConflict is returned when a prefix is already claimed by another group
+	ErrPrefixConflict = errors.New("prefix already belongs to another affinity group")
+)
+
+// groupPrefix maps a key prefix to the affinity group that claims it
+type groupPrefix struct {
+	prefix string
+	group  string
+}
+
+// AffinityGroup co-locates every key starting with one of its prefixes
+type AffinityGroup struct {
+	// Name is the group key that member keys are routed on
+	Name string
+
+	// Prefixes are the key prefixes belonging to the group
+	Prefixes []string
+}
+
+// AddAffinityGroup declares that every key starting with one of prefixes
+// resolves to the same node, the one that owns name
+// When prefixes of different groups overlap, the longest matching prefix wins
+func (r *Ring) AddAffinityGroup(name string, prefixes ...string) error {
+	if name == "" {
+		return ErrEmptyKey
+	}
+	if len(prefixes) == 0 {
+		return errors.New("affinity group needs at least one prefix")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.groups[name]; exists {
+		return fmt.Errorf("affinity group %s already exists", name)
+	}
+
+	for _, prefix := range prefixes {
+		if prefix == "" {
+			return ErrEmptyKey
+		}
+		for _, gp := range r.groupPrefixes {
+			if gp.prefix == prefix {
+				return fmt.Errorf("%w: %s is in group %s", ErrPrefixConflict, prefix, gp.group)
+			}
+		}
+	}
+
+	r.groups[name] = append([]string(nil), prefixes...)
+	for _, prefix := range prefixes {
+		r.groupPrefixes = append(r.groupPrefixes, groupPrefix{prefix: prefix, group: name})
+	}
+
+	// Longest prefixes first so the most specific group wins
+	sort.SliceStable(r.groupPrefixes, func(i, j int) bool {
+		return len(r.groupPrefixes[i].prefix) > len(r.groupPrefixes[j].prefix)
+	})
+
+	return nil
+}
+
+// RemoveAffinityGroup removes a group; its keys are routed individually again
+func (r *Ring) RemoveAffinityGroup(name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.groups[name]; !exists {
+		return ErrGroupNotFound
+	}
+
+	delete(r.groups, name)
+
+	kept := r.groupPrefixes[:0]
+	for _, gp := range r.groupPrefixes {
+		if gp.group != name {
+			kept = append(kept, gp)
+		}
+	}
+	r.groupPrefixes = kept
+
+	return nil
+}
+
+// AffinityGroups returns every declared group, sorted by name
+func (r *Ring) AffinityGroups() []AffinityGroup {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	groups := make([]AffinityGroup, 0, len(r.groups))
+	for name, prefixes := range r.groups {
+		groups = append(groups, AffinityGroup{
+			Name:     name,
+			Prefixes: append([]string(nil), prefixes...),
+		})
+	}
+
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Name < groups[j].Name })
+	return groups
+}
+
+// GroupKey returns the key that key is routed on: the name of the affinity
+// group claiming it, or the key itself if no group does
+func (r *Ring) GroupKey(key string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.groupKeyLocked(key)
+}
+
+// GroupsOwnedBy returns the names of the affinity groups whose keys resolve to node
+func (r *Ring) GroupsOwnedBy(node string) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(r.ring) == 0 {
+		return nil
+	}
+
+	var owned []string
+	for name := range r.groups {
+		if r.primaryLocked(r.searchLocked(r.partitionHash(r.hashFunc(name)))) == node {
+			owned = append(owned, name)
+		}
+	}
+
+	sort.Strings(owned)
+	return owned
+}
+
+// groupKeyLocked returns the routing key of key: its affinity group, else its
+// hash tag when hash tags are enabled, else the key itself
+// Caller must hold r.mu
+func (r *Ring) groupKeyLocked(key string) string {
+	for _, gp := range r.groupPrefixes {
+		if strings.HasPrefix(key, gp.prefix) {
+			return gp.group
+		}
+	}
+	if r.hashTags {
+		if tag, ok := HashTag(key); ok {
+			return tag
+		}
+	}
+	return key
+}
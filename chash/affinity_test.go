@@ -0,0 +1,124 @@
+package chash
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestAffinityGroupRouting(t *testing.T) {
+	ring := NewWithNodes(Config{Replicas: 20}, []string{"server1", "server2", "server3", "server4"})
+
+	if err := ring.AddAffinityGroup("user:42", "user:42:", "cart:42:"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	owner, _ := ring.GetNode("user:42")
+	for _, key := range []string{"user:42:profile", "user:42:settings", "cart:42:items"} {
+		node, err := ring.GetNode(key)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if node != owner {
+			t.Errorf("expected %s to be co-located on %s, got %s", key, owner, node)
+		}
+
+		nodes, _ := ring.GetNodes(key, 2)
+		if nodes[0] != owner {
+			t.Errorf("expected %s primary replica %s, got %s", key, owner, nodes[0])
+		}
+	}
+
+	// Keys outside the group are routed on themselves
+	if ring.GroupKey("user:43:profile") != "user:43:profile" {
+		t.Error("expected ungrouped key to route on itself")
+	}
+	if ring.GroupKey("user:42:profile") != "user:42" {
+		t.Error("expected grouped key to route on the group name")
+	}
+}
+
+func TestAffinityGroupLongestPrefixWins(t *testing.T) {
+	ring := NewWithNodes(Config{Replicas: 20}, []string{"server1", "server2"})
+
+	ring.AddAffinityGroup("tenant:a", "tenant:a:")
+	ring.AddAffinityGroup("tenant:a:billing", "tenant:a:billing:")
+
+	if key := ring.GroupKey("tenant:a:billing:invoice1"); key != "tenant:a:billing" {
+		t.Errorf("expected most specific group, got %s", key)
+	}
+	if key := ring.GroupKey("tenant:a:users:1"); key != "tenant:a" {
+		t.Errorf("expected tenant group, got %s", key)
+	}
+}
+
+func TestAffinityGroupValidation(t *testing.T) {
+	ring := New(Config{Replicas: 5})
+
+	if err := ring.AddAffinityGroup("", "x:"); err != ErrEmptyKey {
+		t.Errorf("expected ErrEmptyKey, got %v", err)
+	}
+	if err := ring.AddAffinityGroup("g"); err == nil {
+		t.Error("expected error for group without prefixes")
+	}
+
+	ring.AddAffinityGroup("g1", "shared:")
+	if err := ring.AddAffinityGroup("g1", "other:"); err == nil {
+		t.Error("expected error for duplicate group")
+	}
+	if err := ring.AddAffinityGroup("g2", "shared:"); !errors.Is(err, ErrPrefixConflict) {
+		t.Errorf("expected ErrPrefixConflict, got %v", err)
+	}
+
+	if err := ring.RemoveAffinityGroup("g1"); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if err := ring.RemoveAffinityGroup("g1"); err != ErrGroupNotFound {
+		t.Errorf("expected ErrGroupNotFound, got %v", err)
+	}
+	if ring.GroupKey("shared:1") != "shared:1" {
+		t.Error("expected removed group to stop routing")
+	}
+}
+
+func TestGroupsOwnedBy(t *testing.T) {
+	nodes := []string{"server1", "server2", "server3"}
+
+	for _, config := range []Config{
+		{Replicas: 20},
+		{Replicas: 20, Partitions: 64},
+	} {
+		ring := NewWithNodes(config, nodes)
+
+		for i := 0; i < 30; i++ {
+			name := fmt.Sprintf("user:%d", i)
+			ring.AddAffinityGroup(name, name+":")
+		}
+
+		if len(ring.AffinityGroups()) != 30 {
+			t.Fatalf("expected 30 groups, got %d", len(ring.AffinityGroups()))
+		}
+
+		total := 0
+		for _, node := range nodes {
+			owned := ring.GroupsOwnedBy(node)
+			total += len(owned)
+
+			for _, group := range owned {
+				owner, _ := ring.GetNode(group + ":anything")
+				if owner != node {
+					t.Errorf("partitions=%d: group %s listed for %s but routes to %s",
+						config.Partitions, group, node, owner)
+				}
+			}
+		}
+
+		if total != 30 {
+			t.Errorf("partitions=%d: expected every group owned exactly once, got %d", config.Partitions, total)
+		}
+	}
+
+	if owned := New(Config{}).GroupsOwnedBy("server1"); owned != nil {
+		t.Errorf("expected no groups on empty ring, got %v", owned)
+	}
+}
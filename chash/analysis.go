@@ -0,0 +1,122 @@
+package chash
+
+import (
+	"math"
+	"sort"
+	"strconv"
+)
+
+// DistributionReport describes how a ring spreads a sample of synthetic keys
+type DistributionReport struct {
+	// Keys is the number of keys sampled
+	Keys int
+
+	// Counts maps each node to the number of sampled keys it owns
+	Counts map[string]int
+
+	// Skew maps each node to its count divided by its fair share of the keys,
+	// weights included; 1.0 is perfectly balanced
+	Skew map[string]float64
+
+	// MinSkew, MedianSkew, P90Skew, P99Skew and MaxSkew are percentiles of
+	// Skew across nodes
+	MinSkew    float64
+	MedianSkew float64
+	P90Skew    float64
+	P99Skew    float64
+	MaxSkew    float64
+
+	// AddMovement is the fraction of keys that would move if a node of
+	// weight 1 were added
+	AddMovement float64
+
+	// RemoveMovement maps each node to the fraction of keys that would move
+	// if it were removed; only its own keys move
+	RemoveMovement map[string]float64
+}
+
+// AnalyzeDistribution routes sampleKeys synthetic keys and reports how evenly
+// they spread and how many would move on a membership change
+// Default sampleKeys: 100000
+// Keys are routed to their owners on the ring, ignoring node states and pins;
+// the analysis copies the ring to simulate the addition, so it is meant for
+// tooling and benchmarks rather than the request path
+func (r *Ring) AnalyzeDistribution(sampleKeys int) DistributionReport {
+	if sampleKeys <= 0 {
+		sampleKeys = 100000 // Default sample size
+	}
+
+	probe := New(Config{
+		Replicas:   1,
+		HashFunc:   r.hashFunc,
+		Ketama:     r.ketama,
+		Partitions: len(r.partitions),
+	})
+	probe.restore(r.snapshot())
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	report := DistributionReport{
+		Keys:           sampleKeys,
+		Counts:         make(map[string]int, len(r.nodeSet)),
+		Skew:           make(map[string]float64, len(r.nodeSet)),
+		RemoveMovement: make(map[string]float64, len(r.nodeSet)),
+	}
+	if len(r.ring) == 0 {
+		return report
+	}
+
+	// Pick a name for the simulated node that is not in the ring
+	candidate := "analysis-node"
+	for i := 0; r.nodeSet[candidate] != nil; i++ {
+		candidate = "analysis-node-" + strconv.Itoa(i)
+	}
+	probe.AddNode(candidate)
+
+	probe.mu.RLock()
+	defer probe.mu.RUnlock()
+
+	moved := 0
+	for i := 0; i < sampleKeys; i++ {
+		key := "analysis:" + strconv.Itoa(i)
+		owner := r.ownerLocked(r.searchLocked(r.hashKeyLocked(key)))
+		report.Counts[owner]++
+
+		if probe.ownerLocked(probe.searchLocked(probe.hashKeyLocked(key))) != owner {
+			moved++
+		}
+	}
+	report.AddMovement = float64(moved) / float64(sampleKeys)
+
+	var totalWeight float64
+	for _, entry := range r.nodeSet {
+		totalWeight += entry.weight
+	}
+
+	skews := make([]float64, 0, len(r.nodeSet))
+	for node, entry := range r.nodeSet {
+		count := report.Counts[node]
+		report.Counts[node] = count // Nodes owning no sampled key still appear
+
+		fair := float64(sampleKeys) * entry.weight / totalWeight
+		report.Skew[node] = float64(count) / fair
+		report.RemoveMovement[node] = float64(count) / float64(sampleKeys)
+		skews = append(skews, report.Skew[node])
+	}
+
+	sort.Float64s(skews)
+	report.MinSkew = skews[0]
+	report.MedianSkew = percentile(skews, 0.5)
+	report.P90Skew = percentile(skews, 0.9)
+	report.P99Skew = percentile(skews, 0.99)
+	report.MaxSkew = skews[len(skews)-1]
+
+	return report
+}
+
+// percentile returns the nearest-rank percentile p of sorted values
+func percentile(sorted []float64, p float64) float64 {
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	return sorted[max(rank, 0)]
+}
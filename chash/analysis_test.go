@@ -0,0 +1,71 @@
+package chash
+
+import (
+	"math"
+	"testing"
+)
+
+func TestAnalyzeDistribution(t *testing.T) {
+	ring := NewWithNodes(Config{Replicas: 150}, []string{"server1", "server2", "server3", "server4"})
+
+	report := ring.AnalyzeDistribution(20000)
+	if report.Keys != 20000 {
+		t.Errorf("expected 20000 keys, got %d", report.Keys)
+	}
+
+	total := 0
+	for _, count := range report.Counts {
+		total += count
+	}
+	if total != 20000 || len(report.Counts) != 4 {
+		t.Errorf("expected 20000 keys over 4 nodes, got %v", report.Counts)
+	}
+
+	if !(report.MinSkew <= report.MedianSkew && report.MedianSkew <= report.P90Skew &&
+		report.P90Skew <= report.P99Skew && report.P99Skew <= report.MaxSkew) {
+		t.Errorf("expected ordered percentiles, got %+v", report)
+	}
+	if report.MinSkew < 0.8 || report.MaxSkew > 1.2 {
+		t.Errorf("expected skew within 20%% with 150 replicas, got %v..%v", report.MinSkew, report.MaxSkew)
+	}
+
+	// A fifth node takes about a fifth of the keys
+	if report.AddMovement < 0.12 || report.AddMovement > 0.28 {
+		t.Errorf("expected about 20%% of keys to move on addition, got %v", report.AddMovement)
+	}
+	for node, moved := range report.RemoveMovement {
+		if want := float64(report.Counts[node]) / 20000; moved != want {
+			t.Errorf("%s: expected removal to move %v, got %v", node, want, moved)
+		}
+	}
+
+	// The analysis leaves the ring untouched
+	if len(ring.Nodes()) != 4 || ring.GetStats().Lookups != 0 {
+		t.Error("expected the ring to be unchanged")
+	}
+}
+
+func TestAnalyzeDistributionWeighted(t *testing.T) {
+	ring := New(Config{Replicas: 150})
+	ring.AddNode("server1")
+	ring.AddNodeWithWeight("server2", 3)
+
+	report := ring.AnalyzeDistribution(0)
+	if report.Keys != 100000 {
+		t.Errorf("expected default sample of 100000, got %d", report.Keys)
+	}
+
+	// Skew is relative to the weighted fair share
+	for node, skew := range report.Skew {
+		if math.Abs(skew-1) > 0.25 {
+			t.Errorf("%s: expected skew near 1, got %v", node, skew)
+		}
+	}
+}
+
+func TestAnalyzeDistributionEmpty(t *testing.T) {
+	report := New(Config{}).AnalyzeDistribution(100)
+	if len(report.Counts) != 0 || report.AddMovement != 0 {
+		t.Errorf("expected an empty report, got %+v", report)
+	}
+}
@@ -0,0 +1,62 @@
+package chash
+
+import (
+	"math"
+	"sort"
+)
+
+// ReplicasFor returns the number of virtual nodes per node that keeps the
+// standard deviation of node keyspace shares, relative to the fair share,
+// at about stdDev
+// The node count has no effect: a node's share is the sum of the gaps before
+// its virtual nodes, so its relative standard deviation is 1/√Replicas in a
+// ring of any size. 0.1 takes 100 virtual nodes and 0.05 takes 400. Returns 1
+// for stdDev >= 1
+func ReplicasFor(stdDev float64) int {
+	if stdDev >= 1 {
+		return 1
+	}
+	return int(math.Ceil(1 / (stdDev * stdDev)))
+}
+
+// autoReplicas returns ReplicasFor(stdDev), lowered so that nodes nodes fit
+// in maxVirtualNodes, at least one
+func autoReplicas(stdDev float64, maxVirtualNodes, nodes int) int {
+	replicas := ReplicasFor(stdDev)
+	if nodes > 0 {
+		replicas = min(replicas, maxVirtualNodes/nodes)
+	}
+	return max(replicas, 1)
+}
+
+// retuneLocked derives the replica count again in TargetStdDev mode and, if
+// it changed, places every node's virtual nodes again
+// Positions depend only on node names, so the result matches a ring built
+// from scratch with the new count
+// Caller must hold r.mu for writing and have sorted the ring
+func (r *Ring) retuneLocked() {
+	if r.targetStdDev <= 0 || r.ketama {
+		return
+	}
+
+	replicas := autoReplicas(r.targetStdDev, r.maxVirtualNodes, len(r.nodeSet))
+	if replicas == r.replicas {
+		return
+	}
+	r.replicas = replicas
+
+	names := make([]string, 0, len(r.nodeSet))
+	for name := range r.nodeSet {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	r.ring = r.ring[:0]
+	r.owners = r.owners[:0]
+	r.names = r.names[:0]
+	for _, name := range names {
+		r.placeLocked(name, r.nodeSet[name])
+	}
+	r.sortLocked()
+	r.resolveCollisionsLocked()
+}
@@ -0,0 +1,94 @@
+package chash
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestReplicasFor(t *testing.T) {
+	for stdDev, want := range map[float64]int{0.1: 100, 0.05: 400, 0.3: 12, 1: 1, 2: 1} {
+		if got := ReplicasFor(stdDev); got != want {
+			t.Errorf("ReplicasFor(%v) = %d, want %d", stdDev, got, want)
+		}
+	}
+}
+
+func TestTargetStdDev(t *testing.T) {
+	ring := New(Config{Replicas: 7, TargetStdDev: 0.1})
+	for i := 0; i < 200; i++ {
+		ring.AddNode(fmt.Sprintf("server%d", i))
+	}
+
+	stats := ring.GetStats()
+	if stats.Replicas != 100 || stats.LoadFactor != 100 {
+		t.Fatalf("Expected 100 virtual nodes per node, got %d (load factor %v)", stats.Replicas, stats.LoadFactor)
+	}
+
+	// The achieved spread is close to the target
+	relative := stats.KeyspaceStdDev * float64(stats.PhysicalNodes)
+	if math.Abs(relative-0.1) > 0.02 {
+		t.Errorf("Expected relative keyspace stddev near 0.1, got %.3f", relative)
+	}
+}
+
+func TestTargetStdDevIgnoresNodeCount(t *testing.T) {
+	for _, nodes := range []int{50, 400} {
+		names := make([]string, nodes)
+		for i := range names {
+			names[i] = fmt.Sprintf("server%d", i)
+		}
+		ring := NewWithNodes(Config{TargetStdDev: 0.1}, names)
+
+		stats := ring.GetStats()
+		if stats.Replicas != 100 {
+			t.Errorf("%d nodes: expected 100 virtual nodes per node, got %d", nodes, stats.Replicas)
+		}
+		relative := stats.KeyspaceStdDev * float64(stats.PhysicalNodes)
+		if math.Abs(relative-0.1) > 0.03 {
+			t.Errorf("%d nodes: expected relative keyspace stddev near 0.1, got %.3f", nodes, relative)
+		}
+	}
+}
+
+func TestMaxVirtualNodes(t *testing.T) {
+	ring := New(Config{TargetStdDev: 0.05, MaxVirtualNodes: 1000})
+
+	for i, want := range []int{400, 400, 333, 250, 200} {
+		ring.AddNode(fmt.Sprintf("server%d", i))
+		if got := ring.GetStats().Replicas; got != want {
+			t.Errorf("With %d nodes: expected %d replicas, got %d", i+1, want, got)
+		}
+		if n := ring.VirtualNodeCount(); n > 1000 {
+			t.Errorf("With %d nodes: %d virtual nodes exceed the limit", i+1, n)
+		}
+	}
+
+	// The retuned layout matches a ring built directly with the count
+	direct := NewWithNodes(Config{Replicas: 200}, ring.Nodes())
+	if moves := direct.Diff(ring); len(moves) != 0 {
+		t.Errorf("Expected the layout of a 200-replica ring, got %d moved ranges", len(moves))
+	}
+
+	// Nodes leaving give the others their virtual nodes back
+	ring.RemoveNodes([]string{"server3", "server4"})
+	if got := ring.GetStats().Replicas; got != 333 {
+		t.Errorf("Expected 333 replicas after removals, got %d", got)
+	}
+	if n, _ := ring.VirtualNodes("server0"); n != 333 {
+		t.Errorf("Expected server0 to have 333 virtual nodes, got %d", n)
+	}
+}
+
+func TestTargetStdDevKeepsOverrides(t *testing.T) {
+	ring := New(Config{TargetStdDev: 0.05, MaxVirtualNodes: 800})
+	ring.AddNodeWithVirtualNodes("fixed", 10)
+	ring.AddNodeWithTokens("tokens", []uint64{1, 2, 3})
+	ring.AddNode("auto")
+
+	for node, want := range map[string]int{"fixed": 10, "tokens": 3, "auto": 266} {
+		if got, _ := ring.VirtualNodes(node); got != want {
+			t.Errorf("%s: expected %d virtual nodes, got %d", node, want, got)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package chash
+
+import (
+	"fmt"
+	"sort"
+)
+
+// AddNodes adds every node with weight 1 under one lock and a single sort of
+// the ring, instead of one sort per node
+// The batch is atomic: if any node is empty, listed twice, already present,
+// quarantined or rejected by an admission check, or the batch would exceed
+// the movement budget, none are added
+func (r *Ring) AddNodes(nodes []string) error {
+	if err := checkBatch(nodes); err != nil {
+		return err
+	}
+
+	// Run admission checks before locking; probes may be slow
+	for _, node := range nodes {
+		if err := r.admit(node); err != nil {
+			return err
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.clock.Now()
+	r.pruneTombstonesLocked(now)
+
+	for _, node := range nodes {
+		if _, exists := r.nodeSet[node]; exists {
+			return fmt.Errorf("node %s already exists", node)
+		}
+		if err := r.checkQuarantineLocked(node, now); err != nil {
+			return err
+		}
+	}
+
+	if len(nodes) == 0 {
+		return nil
+	}
+
+	err := r.spendMovementLocked(now, false, func(p *Ring) { p.insertNodesLocked(nodes) })
+	if err != nil {
+		return err
+	}
+
+	before := r.watchLocked()
+	defer r.publishLocked(NodeAdded, append([]string(nil), nodes...), before)
+
+	r.insertNodesLocked(nodes)
+	return nil
+}
+
+// RemoveNodes removes every node under one lock and a single pass over the ring
+// The batch is atomic: if any node is empty, listed twice or not in the ring,
+// or the batch would exceed the movement budget, none are removed
+func (r *Ring) RemoveNodes(nodes []string) error {
+	if err := checkBatch(nodes); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	remove := make(map[string]struct{}, len(nodes))
+	vnodes := 0
+	for _, node := range nodes {
+		entry, exists := r.nodeSet[node]
+		if !exists {
+			return fmt.Errorf("%w: %s", ErrNodeNotFound, node)
+		}
+		remove[node] = struct{}{}
+		vnodes += entry.vnodes
+	}
+
+	if len(nodes) == 0 {
+		return nil
+	}
+
+	err := r.spendMovementLocked(r.clock.Now(), false, func(p *Ring) {
+		p.removeNodesLocked(remove, vnodes, "")
+	})
+	if err != nil {
+		return err
+	}
+
+	before := r.watchLocked()
+	defer r.publishLocked(NodeRemoved, append([]string(nil), nodes...), before)
+
+	r.removeNodesLocked(remove, vnodes, "")
+	return nil
+}
+
+// checkBatch rejects empty and repeated node names
+func checkBatch(nodes []string) error {
+	seen := make(map[string]struct{}, len(nodes))
+	for _, node := range nodes {
+		if node == "" {
+			return ErrEmptyKey
+		}
+		if _, dup := seen[node]; dup {
+			return fmt.Errorf("node %s listed more than once", node)
+		}
+		seen[node] = struct{}{}
+	}
+	return nil
+}
+
+// insertNodesLocked adds nodes with weight 1 and sorts the ring once
+// Caller must hold r.mu for writing and have checked the nodes are new
+func (r *Ring) insertNodesLocked(nodes []string) {
+	for _, node := range nodes {
+		entry := &nodeEntry{weight: 1}
+		r.nodeSet[node] = entry
+		delete(r.tombstones, node)
+		r.topologyChanges++
+
+		if !r.ketama {
+			r.placeLocked(node, entry)
+		}
+	}
+
+	if r.ketama {
+		// Every node's share depends on the total weight, so rebuild the continuum
+		r.rebuildKetamaLocked()
+		return
+	}
+
+	r.sortLocked()
+	r.resolveCollisionsLocked()
+	r.retuneLocked()
+}
+
+// SetNodes atomically replaces the ring membership with nodes: nodes not yet in
+// the ring are added with weight 1, nodes missing from the list are removed, and
+// nodes in both keep their weight and metadata
+// It suits service-discovery watchers that deliver full snapshots. Like AddNodes
+// it is all or nothing; subscribers see a NodeRemoved event followed by a
+// NodeAdded event, each only if it has nodes
+func (r *Ring) SetNodes(nodes []string) error {
+	if err := checkBatch(nodes); err != nil {
+		return err
+	}
+
+	want := make(map[string]struct{}, len(nodes))
+	for _, node := range nodes {
+		want[node] = struct{}{}
+	}
+
+	// Admission checks run unlocked, so a node may be admitted that turns out to
+	// be present already; that only costs a redundant probe
+	for _, node := range nodes {
+		if !r.hasNode(node) {
+			if err := r.admit(node); err != nil {
+				return err
+			}
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.clock.Now()
+	r.pruneTombstonesLocked(now)
+
+	var added []string
+	for _, node := range nodes {
+		if _, exists := r.nodeSet[node]; exists {
+			continue
+		}
+		if err := r.checkQuarantineLocked(node, now); err != nil {
+			return err
+		}
+		added = append(added, node)
+	}
+
+	var removed []string
+	remove := make(map[string]struct{})
+	vnodes := 0
+	for node, entry := range r.nodeSet {
+		if _, keep := want[node]; !keep {
+			removed = append(removed, node)
+			remove[node] = struct{}{}
+			vnodes += entry.vnodes
+		}
+	}
+	sort.Strings(removed)
+
+	if len(removed) > 0 || len(added) > 0 {
+		err := r.spendMovementLocked(now, false, func(p *Ring) {
+			if len(removed) > 0 {
+				p.removeNodesLocked(remove, vnodes, "")
+			}
+			if len(added) > 0 {
+				p.insertNodesLocked(added)
+			}
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(removed) > 0 {
+		before := r.watchLocked()
+		r.removeNodesLocked(remove, vnodes, "")
+		r.publishLocked(NodeRemoved, removed, before)
+	}
+
+	if len(added) > 0 {
+		before := r.watchLocked()
+		r.insertNodesLocked(added)
+		r.publishLocked(NodeAdded, added, before)
+	}
+
+	return nil
+}
+
+// hasNode returns true if node is in the ring
+func (r *Ring) hasNode(node string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, exists := r.nodeSet[node]
+	return exists
+}
@@ -0,0 +1,182 @@
+package chash
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestAddNodesMatchesSequentialAdds(t *testing.T) {
+	nodes := make([]string, 50)
+	for i := range nodes {
+		nodes[i] = fmt.Sprintf("server%d", i)
+	}
+
+	batch := New(Config{Replicas: 20})
+	if err := batch.AddNodes(nodes); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	sequential := NewWithNodes(Config{Replicas: 20}, nodes)
+
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("key%d", i)
+		a, _ := batch.GetNode(key)
+		b, _ := sequential.GetNode(key)
+		if a != b {
+			t.Errorf("expected %s on %s, got %s", key, b, a)
+		}
+	}
+
+	if stats := batch.GetStats(); stats.PhysicalNodes != 50 || stats.TopologyChanges != 50 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestRemoveNodes(t *testing.T) {
+	ring := NewWithNodes(Config{Replicas: 20}, []string{"server1", "server2", "server3", "server4"})
+
+	if err := ring.RemoveNodes([]string{"server2", "server4"}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	expected := NewWithNodes(Config{Replicas: 20}, []string{"server1", "server3"})
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("key%d", i)
+		a, _ := ring.GetNode(key)
+		b, _ := expected.GetNode(key)
+		if a != b {
+			t.Errorf("expected %s on %s, got %s", key, b, a)
+		}
+	}
+
+	if len(ring.Tombstones()) != 2 {
+		t.Errorf("expected 2 tombstones, got %v", ring.Tombstones())
+	}
+	if stats := ring.GetStats(); stats.VirtualNodes != 40 {
+		t.Errorf("expected 40 virtual nodes, got %d", stats.VirtualNodes)
+	}
+}
+
+func TestBatchIsAtomic(t *testing.T) {
+	ring := NewWithNodes(Config{Replicas: 5, Quarantine: time.Hour}, []string{"server1", "server2"})
+	ring.RemoveNode("server2")
+
+	tests := map[string][]string{
+		"existing":    {"server3", "server1"},
+		"quarantined": {"server3", "server2"},
+		"duplicate":   {"server3", "server3"},
+		"empty":       {"server3", ""},
+	}
+	for name, nodes := range tests {
+		if err := ring.AddNodes(nodes); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+	if nodes := ring.Nodes(); len(nodes) != 1 {
+		t.Errorf("expected no nodes added, got %v", nodes)
+	}
+
+	if err := ring.RemoveNodes([]string{"server1", "server9"}); !errors.Is(err, ErrNodeNotFound) {
+		t.Errorf("expected ErrNodeNotFound, got %v", err)
+	}
+	if nodes := ring.Nodes(); len(nodes) != 1 {
+		t.Errorf("expected no nodes removed, got %v", nodes)
+	}
+
+	rejected := errors.New("rejected")
+	checked := New(Config{AdmissionChecks: []AdmissionCheck{func(node string) error {
+		if node == "bad" {
+			return rejected
+		}
+		return nil
+	}}})
+	if err := checked.AddNodes([]string{"good", "bad"}); !errors.Is(err, rejected) {
+		t.Errorf("expected admission error, got %v", err)
+	}
+	if len(checked.Nodes()) != 0 {
+		t.Errorf("expected no nodes added, got %v", checked.Nodes())
+	}
+}
+
+func TestBatchPublishesOneEvent(t *testing.T) {
+	ring := New(Config{Replicas: 5})
+	events, unsubscribe := ring.Subscribe()
+	defer unsubscribe()
+
+	ring.AddNodes([]string{"server1", "server2", "server3"})
+	ring.RemoveNodes([]string{"server1", "server2"})
+
+	added := nextEvent(t, events)
+	if added.Type != NodeAdded || len(added.Nodes) != 3 || added.Node != "" || added.Epoch != 3 {
+		t.Errorf("unexpected add event: %+v", added)
+	}
+
+	removed := nextEvent(t, events)
+	if removed.Type != NodeRemoved || len(removed.Nodes) != 2 || len(removed.Moves) == 0 {
+		t.Errorf("unexpected remove event: %+v", removed)
+	}
+}
+
+func BenchmarkAddNodes(b *testing.B) {
+	nodes := make([]string, 500)
+	for i := range nodes {
+		nodes[i] = fmt.Sprintf("server%d", i)
+	}
+
+	for i := 0; i < b.N; i++ {
+		New(Config{Replicas: 150}).AddNodes(nodes)
+	}
+}
+
+func TestSetNodes(t *testing.T) {
+	ring := NewWithNodes(Config{Replicas: 20}, []string{"server1", "server2", "server3"})
+	ring.AddNodeWithWeight("server4", 2)
+
+	events, unsubscribe := ring.Subscribe()
+	defer unsubscribe()
+
+	if err := ring.SetNodes([]string{"server2", "server4", "server5"}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	nodes := ring.Nodes()
+	if fmt.Sprint(nodes) != "[server2 server4 server5]" {
+		t.Errorf("unexpected membership %v", nodes)
+	}
+	if w, _ := ring.Weight("server4"); w != 2 {
+		t.Errorf("expected kept node to keep weight 2, got %v", w)
+	}
+
+	removed := nextEvent(t, events)
+	if removed.Type != NodeRemoved || fmt.Sprint(removed.Nodes) != "[server1 server3]" {
+		t.Errorf("unexpected remove event: %+v", removed)
+	}
+	added := nextEvent(t, events)
+	if added.Type != NodeAdded || added.Node != "server5" || added.Epoch <= removed.Epoch {
+		t.Errorf("unexpected add event: %+v", added)
+	}
+
+	// Applying the same snapshot again is a no-op
+	epoch := ring.GetStats().TopologyChanges
+	ring.SetNodes([]string{"server5", "server4", "server2"})
+	if ring.GetStats().TopologyChanges != epoch {
+		t.Error("expected unchanged snapshot to leave the ring alone")
+	}
+}
+
+func TestSetNodesIsAtomic(t *testing.T) {
+	ring := NewWithNodes(Config{Replicas: 5, Quarantine: time.Hour}, []string{"server1", "server2"})
+	ring.RemoveNode("server2")
+
+	if err := ring.SetNodes([]string{"server2", "server3"}); !errors.Is(err, ErrQuarantined) {
+		t.Errorf("expected ErrQuarantined, got %v", err)
+	}
+	if fmt.Sprint(ring.Nodes()) != "[server1]" {
+		t.Errorf("expected membership unchanged, got %v", ring.Nodes())
+	}
+
+	if err := ring.SetNodes([]string{"server3", "server3"}); err == nil {
+		t.Error("expected error for duplicate node")
+	}
+}
@@ -0,0 +1,68 @@
+package chash
+
+import "errors"
+
+// GetNodeBytes is GetNode for a byte-slice key
+// It hashes the key with HashFuncBytes, so lookups on binary keys avoid
+// allocating a string
+func (r *Ring) GetNodeBytes(key []byte) (string, error) {
+	if len(key) == 0 {
+		return "", ErrEmptyKey
+	}
+
+	r.lookups.Add(1)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(r.ring) == 0 {
+		return "", ErrNoNodes
+	}
+
+	if len(r.pins) > 0 {
+		if node, ok := r.pinnedLocked(string(key), r.clock.Now()); ok {
+			return node, nil
+		}
+	}
+
+	return servingNode(r.primaryLocked(r.searchLocked(r.hashKeyBytesLocked(key))))
+}
+
+// GetNodesBytes is GetNodes for a byte-slice key
+func (r *Ring) GetNodesBytes(key []byte, count int) ([]string, error) {
+	if len(key) == 0 {
+		return nil, ErrEmptyKey
+	}
+
+	if count <= 0 {
+		return nil, errors.New("count must be positive")
+	}
+
+	r.lookups.Add(1)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(r.ring) == 0 {
+		return nil, ErrNoNodes
+	}
+
+	return nodesOrErr(r.walkLocked(r.searchLocked(r.hashKeyBytesLocked(key)), count))
+}
+
+// hashKeyBytesLocked is hashKeyLocked for a byte-slice key
+// Caller must hold r.mu
+func (r *Ring) hashKeyBytesLocked(key []byte) uint64 {
+	for _, gp := range r.groupPrefixes {
+		// Comparing a converted slice does not allocate
+		if len(key) >= len(gp.prefix) && string(key[:len(gp.prefix)]) == gp.prefix {
+			return r.partitionHash(r.hashFunc(gp.group))
+		}
+	}
+	if r.hashTags {
+		if tag, ok := hashTagBytes(key); ok {
+			return r.partitionHash(r.hashFuncBytes(tag))
+		}
+	}
+	return r.partitionHash(r.hashFuncBytes(key))
+}
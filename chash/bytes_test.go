@@ -0,0 +1,105 @@
+package chash
+
+import (
+	"fmt"
+	"hash/fnv"
+	"testing"
+	"time"
+)
+
+func TestGetNodeBytesMatchesString(t *testing.T) {
+	configs := map[string]Config{
+		"default": {Replicas: 20},
+		"custom":  {Replicas: 20, HashFunc: fnvHash},
+		"ketama":  {Ketama: true},
+		"custom bytes": {Replicas: 20, HashFunc: fnvHash, HashFuncBytes: func(key []byte) uint64 {
+			h := fnv.New64a()
+			h.Write(key)
+			return h.Sum64()
+		}},
+	}
+
+	for name, config := range configs {
+		t.Run(name, func(t *testing.T) {
+			ring := NewWithNodes(config, []string{"server1:1", "server2:1", "server3:1"})
+			ring.AddAffinityGroup("user:7", "user:7:")
+
+			for i := 0; i < 500; i++ {
+				key := fmt.Sprintf("key%d", i)
+				if i%10 == 0 {
+					key = fmt.Sprintf("user:7:%d", i)
+				}
+
+				expected, _ := ring.GetNode(key)
+				node, err := ring.GetNodeBytes([]byte(key))
+				if err != nil || node != expected {
+					t.Fatalf("key %s: expected %s, got %s (%v)", key, expected, node, err)
+				}
+
+				expectedNodes, _ := ring.GetNodes(key, 2)
+				nodes, _ := ring.GetNodesBytes([]byte(key), 2)
+				if fmt.Sprint(nodes) != fmt.Sprint(expectedNodes) {
+					t.Fatalf("key %s: expected %v, got %v", key, expectedNodes, nodes)
+				}
+			}
+		})
+	}
+}
+
+func TestGetNodeBytesPinned(t *testing.T) {
+	ring := NewWithNodes(Config{Replicas: 20}, []string{"server1"})
+	owners, _ := ring.Pin(time.Hour, "key1")
+	ring.AddNode("server2")
+	ring.AddNode("server3")
+
+	if node, _ := ring.GetNodeBytes([]byte("key1")); node != owners["key1"] {
+		t.Errorf("expected pinned owner %s, got %s", owners["key1"], node)
+	}
+}
+
+func TestGetNodeBytesErrors(t *testing.T) {
+	ring := New(Config{Replicas: 5})
+
+	if _, err := ring.GetNodeBytes(nil); err != ErrEmptyKey {
+		t.Errorf("expected ErrEmptyKey, got %v", err)
+	}
+	if _, err := ring.GetNodeBytes([]byte("key")); err != ErrNoNodes {
+		t.Errorf("expected ErrNoNodes, got %v", err)
+	}
+	if _, err := ring.GetNodesBytes([]byte("key"), 0); err == nil {
+		t.Error("expected error for non-positive count")
+	}
+	if _, err := ring.GetNodesBytes([]byte("key"), 1); err != ErrNoNodes {
+		t.Errorf("expected ErrNoNodes, got %v", err)
+	}
+}
+
+func TestGetNodeBytesDoesNotAllocate(t *testing.T) {
+	ring := NewWithNodes(Config{Replicas: 100}, []string{"server1", "server2", "server3"})
+	key := []byte("user:12345")
+
+	allocs := testing.AllocsPerRun(100, func() {
+		ring.GetNodeBytes(key)
+	})
+	if allocs != 0 {
+		t.Errorf("expected no allocations, got %v", allocs)
+	}
+}
+
+func BenchmarkGetNodeBytes(b *testing.B) {
+	ring := New(Config{Replicas: 150})
+	for i := 0; i < 10; i++ {
+		ring.AddNode(fmt.Sprintf("server%d", i))
+	}
+
+	keys := make([][]byte, 1000)
+	for i := range keys {
+		keys[i] = []byte(fmt.Sprintf("key%d", i))
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ring.GetNodeBytes(keys[i%len(keys)])
+	}
+}
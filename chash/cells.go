@@ -0,0 +1,317 @@
+package chash
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+var (
+	// ErrCellNotFound is returned when a cell is not in the cell ring
+	ErrCellNotFound = errors.New("cell not found")
+
+	// ErrCellExists is returned when adding a cell that is already present
+	ErrCellExists = errors.New("cell already exists")
+
+	// ErrCellUnavailable is returned when a key's cell has failed and none of
+	// its backup cells is active
+	ErrCellUnavailable = errors.New("cell unavailable and no backup cell is active")
+
+	// ErrInvalidCellState is returned when setting a cell state that is not defined
+	ErrInvalidCellState = errors.New("invalid cell state")
+)
+
+// CellState is the operator-controlled health of a cell
+type CellState int
+
+const (
+	// CellActive cells serve their own keyspace
+	CellActive CellState = iota
+
+	// CellDraining cells hand their keyspace to the first active backup cell,
+	// but keep serving it if no backup is active
+	CellDraining
+
+	// CellFailed cells hand their keyspace to the first active backup cell;
+	// with none active, their keys fail with ErrCellUnavailable
+	CellFailed
+)
+
+// String returns the state name
+func (s CellState) String() string {
+	switch s {
+	case CellActive:
+		return "active"
+	case CellDraining:
+		return "draining"
+	case CellFailed:
+		return "failed"
+	default:
+		return fmt.Sprintf("CellState(%d)", int(s))
+	}
+}
+
+// CellRing routes keys in two steps: to a cell on a ring of cells, then to a
+// node on that cell's own ring
+// Each cell has independent membership, so node churn only moves keys within
+// its cell, and each ring stays small enough to rebuild cheaply in clusters
+// with thousands of nodes
+// A draining or failed cell's keyspace moves as a whole to a designated backup
+// cell rather than spreading over its neighbours, which limits the blast
+// radius of a correlated failure
+type CellRing struct {
+	// mu protects the fields below; cells and each cell ring have their own locks
+	mu sync.RWMutex
+
+	// cells places cell names on the top-level ring
+	cells *Ring
+
+	// nodeConfig is the template for every cell's ring
+	nodeConfig Config
+
+	// rings maps cell names to their node rings
+	rings map[string]*Ring
+
+	// states holds cells that are not active
+	states map[string]CellState
+
+	// backups lists each cell's backup cells in preference order
+	backups map[string][]string
+}
+
+// NewCellRing creates an empty cell ring
+// cellConfig configures the ring of cells and nodeConfig each cell's ring;
+// Ketama is not supported at either level and is ignored
+func NewCellRing(cellConfig, nodeConfig Config) *CellRing {
+	cellConfig.Ketama = false
+	nodeConfig.Ketama = false
+
+	return &CellRing{
+		cells:      New(cellConfig),
+		nodeConfig: nodeConfig,
+		rings:      make(map[string]*Ring),
+		states:     make(map[string]CellState),
+		backups:    make(map[string][]string),
+	}
+}
+
+// cellHashFunc salts hashFunc with the cell name
+// Keys reaching a cell all come from the cell's share of the hash space;
+// mixing in the cell name spreads them evenly over the cell's nodes instead
+// of only over the nodes whose virtual nodes fall in that share
+func cellHashFunc(hashFunc HashFunc, cell string) HashFunc {
+	salt := hashFunc(cell)
+	return func(key string) uint64 {
+		return mix64(hashFunc(key) ^ salt)
+	}
+}
+
+// AddCell adds an empty cell with the given weight and returns its ring, to
+// which the cell's nodes are added
+// Keys move only between the new cell and its neighbours on the cell ring
+func (c *CellRing) AddCell(cell string, weight float64) (*Ring, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.rings[cell]; exists {
+		return nil, fmt.Errorf("%w: %s", ErrCellExists, cell)
+	}
+
+	config := c.nodeConfig
+	hashFunc := config.HashFunc
+	if hashFunc == nil {
+		config.HashName, hashFunc, _ = builtinHash(config.HashName, config.Seed)
+	}
+	config.HashFunc = cellHashFunc(hashFunc, cell)
+	config.HashFuncBytes = nil
+
+	if err := c.cells.AddNodeWithWeight(cell, weight); err != nil {
+		return nil, err
+	}
+
+	ring := New(config)
+	c.rings[cell] = ring
+	return ring, nil
+}
+
+// RemoveCell removes a cell and its ring; its keys move to the neighbouring cells
+func (c *CellRing) RemoveCell(cell string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.rings[cell]; !exists {
+		return fmt.Errorf("%w: %s", ErrCellNotFound, cell)
+	}
+	if err := c.cells.RemoveNode(cell); err != nil {
+		return err
+	}
+
+	delete(c.rings, cell)
+	delete(c.states, cell)
+	delete(c.backups, cell)
+	return nil
+}
+
+// Cell returns the node ring of a cell
+func (c *CellRing) Cell(cell string) (*Ring, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	ring, exists := c.rings[cell]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrCellNotFound, cell)
+	}
+	return ring, nil
+}
+
+// Cells returns the cell names, sorted
+func (c *CellRing) Cells() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	cells := make([]string, 0, len(c.rings))
+	for cell := range c.rings {
+		cells = append(cells, cell)
+	}
+	sort.Strings(cells)
+	return cells
+}
+
+// GetCell returns the cell responsible for key
+func (c *CellRing) GetCell(key string) (string, error) {
+	return c.cells.GetNode(key)
+}
+
+// SetCellState changes the state of a cell; redirection to backup cells
+// follows immediately
+func (c *CellRing) SetCellState(cell string, state CellState) error {
+	if state < CellActive || state > CellFailed {
+		return fmt.Errorf("%w: %d", ErrInvalidCellState, int(state))
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.rings[cell]; !exists {
+		return fmt.Errorf("%w: %s", ErrCellNotFound, cell)
+	}
+
+	if state == CellActive {
+		delete(c.states, cell)
+	} else {
+		c.states[cell] = state
+	}
+	return nil
+}
+
+// CellState returns the state of a cell
+func (c *CellRing) CellState(cell string) (CellState, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if _, exists := c.rings[cell]; !exists {
+		return CellActive, fmt.Errorf("%w: %s", ErrCellNotFound, cell)
+	}
+	return c.states[cell], nil
+}
+
+// SetBackupCells designates the cells that take over cell's keyspace while it
+// is draining or failed, in preference order; no backups clears the list
+// Backups are not followed transitively
+func (c *CellRing) SetBackupCells(cell string, backups ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.rings[cell]; !exists {
+		return fmt.Errorf("%w: %s", ErrCellNotFound, cell)
+	}
+	for _, backup := range backups {
+		if backup == cell {
+			return fmt.Errorf("cell %s cannot back itself up", cell)
+		}
+		if _, exists := c.rings[backup]; !exists {
+			return fmt.Errorf("%w: %s", ErrCellNotFound, backup)
+		}
+	}
+
+	if len(backups) == 0 {
+		delete(c.backups, cell)
+	} else {
+		c.backups[cell] = append([]string(nil), backups...)
+	}
+	return nil
+}
+
+// BackupCells returns the backup cells of cell in preference order
+func (c *CellRing) BackupCells(cell string) []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return append([]string(nil), c.backups[cell]...)
+}
+
+// cellRing returns the cell serving key and its ring: the key's own cell, or
+// the first active backup while that cell is draining or failed
+func (c *CellRing) cellRing(key string) (string, *Ring, error) {
+	cell, err := c.cells.GetNode(key)
+	if err != nil {
+		return "", nil, err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	ring := c.rings[cell]
+	if ring == nil {
+		// The cell was removed between the two lookups
+		return "", nil, fmt.Errorf("%w: %s", ErrCellNotFound, cell)
+	}
+
+	state := c.states[cell]
+	if state == CellActive {
+		return cell, ring, nil
+	}
+
+	for _, backup := range c.backups[cell] {
+		// Backups removed since they were designated are skipped
+		if c.rings[backup] != nil && c.states[backup] == CellActive {
+			return backup, c.rings[backup], nil
+		}
+	}
+
+	if state == CellDraining {
+		return cell, ring, nil
+	}
+	return "", nil, fmt.Errorf("%w: %s", ErrCellUnavailable, cell)
+}
+
+// GetNode returns the cell and node serving key
+// Returns ErrNoNodes if the serving cell has no nodes; keys only leave their
+// cell for a designated backup, never for a neighbour
+func (c *CellRing) GetNode(key string) (cell, node string, err error) {
+	cell, ring, err := c.cellRing(key)
+	if err != nil {
+		return "", "", err
+	}
+
+	node, err = ring.GetNode(key)
+	if err != nil {
+		return "", "", fmt.Errorf("cell %s: %w", cell, err)
+	}
+	return cell, node, nil
+}
+
+// GetNodes returns the cell serving key and count replicas within it
+func (c *CellRing) GetNodes(key string, count int) (string, []string, error) {
+	cell, ring, err := c.cellRing(key)
+	if err != nil {
+		return "", nil, err
+	}
+
+	nodes, err := ring.GetNodes(key, count)
+	if err != nil {
+		return "", nil, fmt.Errorf("cell %s: %w", cell, err)
+	}
+	return cell, nodes, nil
+}
@@ -0,0 +1,222 @@
+package chash
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+)
+
+// newCellRing builds a ring of three cells with four nodes each
+func newCellRing(t *testing.T) *CellRing {
+	t.Helper()
+
+	cells := NewCellRing(Config{Replicas: 50}, Config{Replicas: 50})
+	for _, cell := range []string{"cell-a", "cell-b", "cell-c"} {
+		ring, err := cells.AddCell(cell, 1)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		for n := 1; n <= 4; n++ {
+			ring.AddNode(fmt.Sprintf("%s-n%d", cell, n))
+		}
+	}
+	return cells
+}
+
+func TestCellRingRouting(t *testing.T) {
+	cells := newCellRing(t)
+
+	for i := 0; i < 500; i++ {
+		key := fmt.Sprintf("key%d", i)
+
+		cell, node, err := cells.GetNode(key)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if want, _ := cells.GetCell(key); cell != want {
+			t.Errorf("%s: expected cell %s, got %s", key, want, cell)
+		}
+
+		ring, _ := cells.Cell(cell)
+		if want, _ := ring.GetNode(key); node != want {
+			t.Errorf("%s: expected node %s, got %s", key, want, node)
+		}
+
+		_, replicas, err := cells.GetNodes(key, 2)
+		if err != nil || len(replicas) != 2 || replicas[0] != node {
+			t.Errorf("%s: expected 2 replicas led by %s, got %v, %v", key, node, replicas, err)
+		}
+	}
+}
+
+func TestCellRingChurnStaysInCell(t *testing.T) {
+	cells := newCellRing(t)
+
+	before := make(map[string]string)
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("key%d", i)
+		_, before[key], _ = cells.GetNode(key)
+	}
+
+	ring, _ := cells.Cell("cell-b")
+	ring.AddNode("cell-b-n5")
+
+	for key, old := range before {
+		cell, node, _ := cells.GetNode(key)
+		if node != old && cell != "cell-b" {
+			t.Errorf("%s moved from %s to %s outside cell-b", key, old, node)
+		}
+	}
+}
+
+func TestCellRingSpreadsKeysWithinCell(t *testing.T) {
+	cells := newCellRing(t)
+
+	counts := make(map[string]int)
+	total := 0
+	for i := 0; i < 30000; i++ {
+		cell, node, _ := cells.GetNode(fmt.Sprintf("key%d", i))
+		if cell == "cell-a" {
+			counts[node]++
+			total++
+		}
+	}
+
+	// Without salting, a cell's keys crowd onto the nodes whose virtual
+	// nodes fall in the cell's share of the hash space
+	expected := float64(total) / 4
+	for node, n := range counts {
+		if math.Abs(float64(n)-expected)/expected > 0.35 {
+			t.Errorf("%s: expected about %.0f keys, got %d", node, expected, n)
+		}
+	}
+}
+
+func TestCellRingErrors(t *testing.T) {
+	cells := NewCellRing(Config{}, Config{})
+
+	if _, _, err := cells.GetNode("key"); err != ErrNoNodes {
+		t.Errorf("expected ErrNoNodes, got %v", err)
+	}
+
+	cells.AddCell("cell-a", 1)
+	if _, err := cells.AddCell("cell-a", 1); !errors.Is(err, ErrCellExists) {
+		t.Errorf("expected ErrCellExists, got %v", err)
+	}
+	if _, err := cells.AddCell("cell-b", 0); err != ErrInvalidWeight {
+		t.Errorf("expected ErrInvalidWeight, got %v", err)
+	}
+	if _, _, err := cells.GetNode("key"); !errors.Is(err, ErrNoNodes) {
+		t.Errorf("expected ErrNoNodes for an empty cell, got %v", err)
+	}
+
+	if err := cells.RemoveCell("missing"); !errors.Is(err, ErrCellNotFound) {
+		t.Errorf("expected ErrCellNotFound, got %v", err)
+	}
+	if _, err := cells.Cell("missing"); !errors.Is(err, ErrCellNotFound) {
+		t.Errorf("expected ErrCellNotFound, got %v", err)
+	}
+
+	if err := cells.RemoveCell("cell-a"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(cells.Cells()) != 0 {
+		t.Errorf("expected no cells, got %v", cells.Cells())
+	}
+}
+
+func TestCellFailover(t *testing.T) {
+	cells := newCellRing(t)
+	if err := cells.SetBackupCells("cell-a", "cell-b", "cell-c"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// Keys of every cell, captured while all are active
+	home := make(map[string]string)
+	before := make(map[string]string)
+	for i := 0; i < 500; i++ {
+		key := fmt.Sprintf("key%d", i)
+		home[key], before[key], _ = cells.GetNode(key)
+	}
+
+	cells.SetCellState("cell-a", CellFailed)
+	for key, cell := range home {
+		served, node, err := cells.GetNode(key)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		switch {
+		case cell == "cell-a" && served != "cell-b":
+			t.Errorf("%s: expected cell-a's keys on cell-b, got %s", key, served)
+		case cell != "cell-a" && node != before[key]:
+			t.Errorf("%s: expected %s to keep serving, got %s", key, before[key], node)
+		}
+	}
+
+	// The next backup takes over once the first is unavailable too
+	cells.SetCellState("cell-b", CellDraining)
+	for key, cell := range home {
+		if served, _, _ := cells.GetNode(key); cell == "cell-a" && served != "cell-c" {
+			t.Errorf("%s: expected cell-a's keys on cell-c, got %s", key, served)
+		}
+	}
+
+	// With no active backup, a failed cell is unavailable and a draining one serves itself
+	cells.SetCellState("cell-c", CellFailed)
+	for key, cell := range home {
+		served, _, err := cells.GetNode(key)
+		switch cell {
+		case "cell-a":
+			if !errors.Is(err, ErrCellUnavailable) {
+				t.Errorf("%s: expected ErrCellUnavailable, got %v", key, err)
+			}
+		case "cell-b":
+			if err != nil || served != "cell-b" {
+				t.Errorf("%s: expected draining cell-b to serve itself, got %s, %v", key, served, err)
+			}
+		}
+	}
+
+	// Failing back restores the original placement
+	for _, cell := range []string{"cell-a", "cell-b", "cell-c"} {
+		cells.SetCellState(cell, CellActive)
+	}
+	for key, node := range before {
+		if _, got, _ := cells.GetNode(key); got != node {
+			t.Errorf("%s: expected %s after failback, got %s", key, node, got)
+		}
+	}
+}
+
+func TestCellStateErrors(t *testing.T) {
+	cells := newCellRing(t)
+
+	if err := cells.SetCellState("missing", CellFailed); !errors.Is(err, ErrCellNotFound) {
+		t.Errorf("expected ErrCellNotFound, got %v", err)
+	}
+	if err := cells.SetCellState("cell-a", CellState(9)); !errors.Is(err, ErrInvalidCellState) {
+		t.Errorf("expected ErrInvalidCellState, got %v", err)
+	}
+	if err := cells.SetBackupCells("cell-a", "cell-a"); err == nil {
+		t.Error("expected an error for a cell backing itself up")
+	}
+	if err := cells.SetBackupCells("cell-a", "missing"); !errors.Is(err, ErrCellNotFound) {
+		t.Errorf("expected ErrCellNotFound, got %v", err)
+	}
+
+	cells.SetBackupCells("cell-a", "cell-b")
+	cells.SetCellState("cell-a", CellDraining)
+	if state, _ := cells.CellState("cell-a"); state != CellDraining {
+		t.Errorf("expected draining, got %v", state)
+	}
+
+	// Removing a cell forgets its state and backups
+	cells.RemoveCell("cell-a")
+	if _, err := cells.CellState("cell-a"); !errors.Is(err, ErrCellNotFound) {
+		t.Errorf("expected ErrCellNotFound, got %v", err)
+	}
+	if backups := cells.BackupCells("cell-a"); len(backups) != 0 {
+		t.Errorf("expected no backups, got %v", backups)
+	}
+}
@@ -4,13 +4,16 @@
 package chash
 
 import (
-	"crypto/sha256"
-	"encoding/binary"
 	"errors"
 	"fmt"
+	"math"
 	"sort"
-	"strconv"
 	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mohdrashid9678/dcore/chash/core"
+	"github.com/mohdrashid9678/dcore/clock"
 )
 
 var (
@@ -22,6 +25,9 @@ var (
 
 	// ErrEmptyKey is returned when an empty key is provided
 	ErrEmptyKey = errors.New("key cannot be empty")
+
+	// ErrInvalidWeight is returned when a node weight is not a positive finite number
+	ErrInvalidWeight = errors.New("weight must be a positive finite number")
 )
 
 // HashFunc represents a hash function that takes a string and returns a uint64 hash
@@ -29,8 +35,16 @@ type HashFunc func(string) uint64
 
 // DefaultHashFunc provides a default SHA-256 based hash function
 func DefaultHashFunc(key string) uint64 {
-	h := sha256.Sum256([]byte(key))
-	return binary.BigEndian.Uint64(h[:8])
+	return core.Hash([]byte(key))
+}
+
+// HashFuncBytes is a HashFunc over byte slices
+// It must return the same hash as the ring's HashFunc for the equivalent string
+type HashFuncBytes func([]byte) uint64
+
+// DefaultHashFuncBytes is DefaultHashFunc over a byte slice
+func DefaultHashFuncBytes(key []byte) uint64 {
+	return core.Hash(key)
 }
 
 // Ring represents a consistent hash ring with virtual nodes
@@ -41,29 +55,246 @@ type Ring struct {
 	// hashFunc is the hash function used for generating hashes
 	hashFunc HashFunc
 
+	// hashFuncBytes hashes byte-slice keys without converting them to strings
+	hashFuncBytes HashFuncBytes
+
+	// hashName identifies hashFunc in fingerprints
+	hashName string
+
 	// replicas is the number of virtual nodes per physical node
 	replicas int
 
-	// ring stores the hash ring as sorted slice of hash values
+	// targetStdDev and maxVirtualNodes derive replicas from the membership,
+	// see Config.TargetStdDev
+	targetStdDev    float64
+	maxVirtualNodes int
+
+	// ketama places virtual nodes with libketama's layout instead of replicas
+	ketama bool
+
+	// partitions holds the ring position of each fixed partition, see Config.Partitions
+	partitions []uint64
+
+	// hashTags routes keys on their {tag}, see Config.HashTags
+	hashTags bool
+
+	// replication chooses replicas; nil takes them clockwise like SimpleStrategy
+	replication ReplicationStrategy
+
+	// clock supplies the time for pins, tombstones and quarantine
+	clock clock.Clock
+
+	// ring stores the virtual node positions as a sorted slice of hash values
 	ring []uint64
 
-	// nodes maps hash values to node names
-	nodes map[uint64]string
+	// owners holds, for each position in ring, the index of its physical node
+	// in names; parallel slices keep the binary search over plain uint64s and
+	// replace a map lookup per query with a slice index
+	owners []uint32
+
+	// names is the node table that owners index into
+	names []string
 
 	// nodeSet keeps track of all physical nodes for O(1) existence checks
-	nodeSet map[string]struct{}
+	nodeSet map[string]*nodeEntry
+
+	// inactive counts nodes that are draining or disabled; while it is zero
+	// lookups skip state checks entirely
+	inactive int
+
+	// admissionChecks validate nodes before they are added
+	admissionChecks []AdmissionCheck
+
+	// groups maps affinity group names to their key prefixes
+	groups map[string][]string
+
+	// groupPrefixes indexes every group prefix, longest first
+	groupPrefixes []groupPrefix
+
+	// pins holds keys temporarily fixed to an owner, see Pin
+	pins map[string]pin
+
+	// tombstones records recently removed nodes
+	tombstones map[string]Tombstone
+
+	// quarantine is how long a removed node name is blocked from re-adding
+	quarantine time.Duration
+
+	// tombstoneRetention is how long tombstones are kept
+	tombstoneRetention time.Duration
+
+	// movementBudget and movementWindow limit key movement, see Config.MovementBudget
+	movementBudget float64
+	movementWindow time.Duration
+
+	// movements records the keyspace moved by recent changes, oldest first
+	movements []movement
+
+	// resolveCollisions moves colliding virtual nodes, see Config.ResolveCollisions
+	resolveCollisions bool
+
+	// displaced counts the virtual nodes moved off a colliding position
+	displaced int
+
+	// subscribers receive topology change events
+	subscribers      map[int]*subscriber
+	nextSubscriberID int
+
+	// stopExpiry ends the sweeper started by StartExpiry; nil when not running
+	stopExpiry chan struct{}
+
+	// lookups counts key lookups for statistics
+	lookups atomic.Uint64
+
+	// topologyChanges counts node additions, removals, resizes and state
+	// changes; it is the ring version
+	topologyChanges uint64
+
+	// checkedVersion is the version seen by the last invariant check
+	checkedVersion uint64
+}
+
+// nodeEntry holds the state of a physical node
+type nodeEntry struct {
+	// weight scales the node's share of virtual nodes relative to Replicas
+	weight float64
+
+	// vnodes is the number of virtual nodes placed for the node
+	vnodes int
+
+	// fixed overrides the virtual node count derived from weight; 0 if unset
+	fixed int
+
+	// index is the node's position in the ring's node table
+	index uint32
+
+	// topology labels the node's failure domains
+	topology Topology
+
+	// meta is caller-supplied data returned with lookups
+	meta any
+
+	// state controls how lookups treat the node, see SetNodeState
+	state NodeState
+
+	// role selects the node for writes or reads, see SetNodeRole
+	role NodeRole
+
+	// tokens holds the sorted manual positions of the virtual nodes; nil if
+	// they are hashed from the node name, see AddNodeWithTokens
+	tokens []uint64
+
+	// ttl is how long the node stays after its last Touch; 0 if it never
+	// expires, see AddNodeTTL
+	ttl time.Duration
+
+	// expires is when the node is removed unless touched
+	expires time.Time
 }
 
 // Config holds configuration options for creating a new Ring
 type Config struct {
 	// Replicas specifies the number of virtual nodes per physical node
-	// Higher values provide better distribution but use more memory
+	// Higher values provide better distribution but use more memory; see
+	// TargetStdDev to derive it instead
 	// Default: 150
 	Replicas int
 
 	// HashFunc specifies the hash function to use
-	// Default: DefaultHashFunc (SHA-256 based)
+	// Default: the built-in function named by HashName
 	HashFunc HashFunc
+
+	// HashName selects a built-in hash function when HashFunc is nil: "sha256",
+	// "xxhash64", "fnv1a", "murmur3" or "siphash"; New panics on any other name
+	// With a custom HashFunc it only names it in Fingerprint, so rings built
+	// with different hash functions never report the same fingerprint
+	// Default: "siphash" if Seed is set, otherwise "sha256"; "ketama" in Ketama mode
+	HashName string
+
+	// Seed keys the SipHash built-in so keys cannot be chosen to land on one
+	// node; rings that share a seed route identically. Other hash functions
+	// ignore it
+	// Default: zero (unset)
+	Seed [16]byte
+
+	// HashFuncBytes hashes keys passed to GetNodeBytes and GetNodesBytes
+	// It must agree with HashFunc; set it alongside a custom HashFunc to avoid
+	// a string conversion per lookup
+	// Default: DefaultHashFuncBytes, or HashFunc over a converted string if HashFunc is set
+	HashFuncBytes HashFuncBytes
+
+	// AdmissionChecks run before a node is added; any failure blocks the addition
+	// Default: none
+	AdmissionChecks []AdmissionCheck
+
+	// Quarantine blocks re-adding a removed node name for this long unless forced
+	// Default: 0 (disabled)
+	Quarantine time.Duration
+
+	// Ketama reproduces libketama's MD5 continuum so keys route identically to
+	// existing memcached clients; nodes should be named "host:port"
+	// Replicas and HashFunc are ignored in this mode
+	// Default: false
+	Ketama bool
+
+	// TombstoneRetention is how long removed nodes stay queryable via Tombstones
+	// It is never shorter than Quarantine
+	// Default: 1 hour
+	TombstoneRetention time.Duration
+
+	// Partitions hashes keys into this many fixed partitions and places the
+	// partitions, rather than the keys, on the ring, so ownership moves in
+	// whole partitions; see GetPartition and PartitionOwner
+	// Default: 0 (disabled)
+	Partitions int
+
+	// HashTags routes keys containing a Redis-style {tag} on the tag alone, so
+	// "user:{42}:profile" and "user:{42}:cart" share a node; see HashTag
+	// Like HashFunc it is not part of snapshots
+	// Default: false
+	HashTags bool
+
+	// Replication chooses the replicas returned by GetNodes and the lookups
+	// built on it; the ring always chooses the primary
+	// Default: SimpleStrategy
+	Replication ReplicationStrategy
+
+	// MovementBudget caps the share of the keyspace that node additions,
+	// removals, weight and virtual node changes may move within
+	// MovementWindow; a change that would exceed it fails with
+	// ErrMovementBudgetExceeded unless forced
+	// Default: 0 (disabled)
+	MovementBudget float64
+
+	// MovementWindow is the sliding window MovementBudget applies to
+	// Default: 1 hour
+	MovementWindow time.Duration
+
+	// ResolveCollisions moves virtual nodes that land on a position already
+	// taken, so every virtual node owns keys even with a weak hash function;
+	// see Collisions. Like HashTags it is not part of snapshots, and it does
+	// not apply in Ketama mode
+	// Default: false
+	ResolveCollisions bool
+
+	// TargetStdDev picks Replicas automatically: nodes get ReplicasFor
+	// (TargetStdDev) virtual nodes, enough that the standard deviation of
+	// their keyspace shares relative to the fair share is expected to stay
+	// at or below it. That count does not depend on the node count; it is
+	// derived again whenever nodes join or leave only so MaxVirtualNodes can
+	// lower it. Replicas is ignored
+	// Default: 0 (use Replicas)
+	TargetStdDev float64
+
+	// MaxVirtualNodes limits the ring size in TargetStdDev mode: once the
+	// target would take more virtual nodes than this across all nodes, the
+	// per-node count shrinks as nodes join, trading balance for memory
+	// Default: 1048576
+	MaxVirtualNodes int
+
+	// Clock supplies the time for pins, tombstones and quarantine
+	// Default: the wall clock
+	Clock clock.Clock
 }
 
 // New creates a new consistent hash ring with the given configuration
@@ -72,34 +303,131 @@ func New(config Config) *Ring {
 		config.Replicas = 150 // Default number of replicas
 	}
 
+	if config.Ketama {
+		config.HashFunc = KetamaHashFunc
+		config.HashFuncBytes = ketamaHashFuncBytes
+		config.HashName = "ketama"
+	}
+
 	if config.HashFunc == nil {
-		config.HashFunc = DefaultHashFunc
+		var hashFunc HashFunc
+		var hashFuncBytes HashFuncBytes
+		config.HashName, hashFunc, hashFuncBytes = builtinHash(config.HashName, config.Seed)
+		config.HashFunc = hashFunc
+		if config.HashFuncBytes == nil {
+			config.HashFuncBytes = hashFuncBytes
+		}
+	}
+
+	if config.HashFuncBytes == nil {
+		hashFunc := config.HashFunc
+		config.HashFuncBytes = func(key []byte) uint64 { return hashFunc(string(key)) }
+	}
+
+	if _, simple := config.Replication.(SimpleStrategy); simple {
+		config.Replication = nil // Walk the ring directly
+	}
+
+	if config.TombstoneRetention <= 0 {
+		config.TombstoneRetention = time.Hour // Default tombstone retention
+	}
+	if config.TombstoneRetention < config.Quarantine {
+		config.TombstoneRetention = config.Quarantine
+	}
+
+	if config.MovementWindow <= 0 {
+		config.MovementWindow = time.Hour // Default movement window
+	}
+
+	if config.TargetStdDev > 0 {
+		if config.MaxVirtualNodes <= 0 {
+			config.MaxVirtualNodes = 1 << 20 // Default ring size limit
+		}
+		config.Replicas = autoReplicas(config.TargetStdDev, config.MaxVirtualNodes, 0)
 	}
 
 	return &Ring{
-		hashFunc: config.HashFunc,
-		replicas: config.Replicas,
-		nodes:    make(map[uint64]string),
-		nodeSet:  make(map[string]struct{}),
+		hashFunc:        config.HashFunc,
+		hashFuncBytes:   config.HashFuncBytes,
+		hashName:        config.HashName,
+		replicas:        config.Replicas,
+		targetStdDev:    config.TargetStdDev,
+		maxVirtualNodes: config.MaxVirtualNodes,
+		ketama:          config.Ketama,
+		partitions:      partitionPositions(config.HashFunc, config.Partitions),
+		hashTags:        config.HashTags,
+		replication:     config.Replication,
+		clock:           clock.OrReal(config.Clock),
+		nodeSet:         make(map[string]*nodeEntry),
+		groups:          make(map[string][]string),
+		pins:            make(map[string]pin),
+		subscribers:     make(map[int]*subscriber),
+		admissionChecks: append([]AdmissionCheck(nil), config.AdmissionChecks...),
+
+		tombstones:         make(map[string]Tombstone),
+		quarantine:         config.Quarantine,
+		tombstoneRetention: config.TombstoneRetention,
+
+		movementBudget: config.MovementBudget,
+		movementWindow: config.MovementWindow,
+
+		resolveCollisions: config.ResolveCollisions,
 	}
 }
 
 // NewWithNodes creates a new consistent hash ring with initial nodes
 func NewWithNodes(config Config, nodes []string) *Ring {
 	ring := New(config)
+
+	// The initial nodes hold no data yet, so they neither check nor spend the
+	// movement budget
+	ring.movementBudget = 0
 	for _, node := range nodes {
 		ring.AddNode(node)
 	}
+	ring.movementBudget = config.MovementBudget
 	return ring
 }
 
 // AddNode adds a physical node to the hash ring with virtual nodes
-// Returns an error if the node already exists
+// Returns an error if the node already exists or fails an admission check
 func (r *Ring) AddNode(node string) error {
+	return r.AddNodeWithWeight(node, 1)
+}
+
+// AddNodeWithWeight adds a physical node that owns a share of the keyspace
+// proportional to its weight, by placing round(weight × Replicas) virtual nodes
+// A weight of 1 is equivalent to AddNode; every node gets at least one virtual node
+func (r *Ring) AddNodeWithWeight(node string, weight float64) error {
+	return r.addNode(node, &nodeEntry{weight: weight}, false)
+}
+
+// ForceAddNode adds a node with weight 1 even if it is still quarantined
+// after a recent removal or would exceed the movement budget
+func (r *Ring) ForceAddNode(node string) error {
+	return r.addNode(node, &nodeEntry{weight: 1}, true)
+}
+
+// ForceAddNodeWithWeight is ForceAddNode for a weighted node
+func (r *Ring) ForceAddNodeWithWeight(node string, weight float64) error {
+	return r.addNode(node, &nodeEntry{weight: weight}, true)
+}
+
+// addNode places the virtual nodes of a new entry; force skips the quarantine check
+func (r *Ring) addNode(node string, entry *nodeEntry, force bool) error {
 	if node == "" {
 		return ErrEmptyKey
 	}
 
+	if weight := entry.weight; weight <= 0 || math.IsNaN(weight) || math.IsInf(weight, 0) {
+		return ErrInvalidWeight
+	}
+
+	// Run admission checks before locking; probes may be slow
+	if err := r.admit(node); err != nil {
+		return err
+	}
+
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
@@ -108,28 +436,199 @@ func (r *Ring) AddNode(node string) error {
 		return fmt.Errorf("node %s already exists", node)
 	}
 
-	// Add virtual nodes
-	for i := 0; i < r.replicas; i++ {
-		virtualNode := node + "#" + strconv.Itoa(i)
-		hash := r.hashFunc(virtualNode)
+	now := r.clock.Now()
+	r.pruneTombstonesLocked(now)
 
-		r.nodes[hash] = node
-		r.ring = append(r.ring, hash)
+	if !force {
+		if err := r.checkQuarantineLocked(node, now); err != nil {
+			return err
+		}
 	}
 
-	// Sort ring to maintain order
-	sort.Slice(r.ring, func(i, j int) bool {
-		return r.ring[i] < r.ring[j]
-	})
+	probe := *entry
+	err := r.spendMovementLocked(now, force, func(p *Ring) { p.insertNodeLocked(node, &probe) })
+	if err != nil {
+		return err
+	}
 
-	r.nodeSet[node] = struct{}{}
+	before := r.watchLocked()
+	defer r.publishLocked(NodeAdded, []string{node}, before)
 
+	r.insertNodeLocked(node, entry)
+	return nil
+}
+
+// insertNodeLocked adds entry under node and places its virtual nodes
+// Caller must hold r.mu for writing and have checked the node is new
+func (r *Ring) insertNodeLocked(node string, entry *nodeEntry) {
+	r.nodeSet[node] = entry
+	delete(r.tombstones, node)
+	r.topologyChanges++
+
+	if r.ketama {
+		// Every node's share depends on the total weight, so rebuild the continuum
+		r.rebuildKetamaLocked()
+		return
+	}
+
+	r.placeLocked(node, entry)
+	r.sortLocked()
+	r.resolveCollisionsLocked()
+	r.retuneLocked()
+}
+
+// placeLocked appends node to the node table and its virtual nodes to the
+// ring, leaving the ring unsorted
+// Caller must hold r.mu for writing
+func (r *Ring) placeLocked(node string, entry *nodeEntry) {
+	r.indexLocked(node, entry)
+
+	entry.vnodes = 0
+	r.appendVirtualNodesLocked(node, entry, r.vnodeCountLocked(entry))
+}
+
+// vnodeCountLocked returns the number of virtual nodes entry should have: one
+// per manual token, its fixed count if set, otherwise round(weight × Replicas),
+// at least one
+// Caller must hold r.mu
+func (r *Ring) vnodeCountLocked(entry *nodeEntry) int {
+	if entry.tokens != nil {
+		return len(entry.tokens)
+	}
+	if entry.fixed > 0 {
+		return entry.fixed
+	}
+	return max(int(math.Round(entry.weight*float64(r.replicas))), 1)
+}
+
+// appendVirtualNodesLocked appends virtual nodes vnodes..n-1 of node to the
+// ring, leaving it unsorted
+// Caller must hold r.mu for writing
+func (r *Ring) appendVirtualNodesLocked(node string, entry *nodeEntry, n int) {
+	for i := entry.vnodes; i < n; i++ {
+		r.ring = append(r.ring, r.virtualNodeHashLocked(node, entry, i))
+		r.owners = append(r.owners, entry.index)
+	}
+	entry.vnodes = max(entry.vnodes, n)
+}
+
+// indexLocked appends node to the node table
+// Caller must hold r.mu for writing
+func (r *Ring) indexLocked(node string, entry *nodeEntry) {
+	entry.index = uint32(len(r.names))
+	r.names = append(r.names, node)
+}
+
+// ownerLocked returns the physical node owning ring position i
+// Caller must hold r.mu
+func (r *Ring) ownerLocked(i int) string {
+	return r.names[r.owners[i]]
+}
+
+// sortLocked sorts the virtual nodes by hash
+// Colliding positions are ordered by node name, so the owner of a collision
+// does not depend on the order nodes were added
+// Caller must hold r.mu for writing
+func (r *Ring) sortLocked() {
+	sort.Sort(ringOrder{r})
+}
+
+// ringOrder sorts the parallel ring and owners slices together
+type ringOrder struct{ r *Ring }
+
+func (o ringOrder) Len() int { return len(o.r.ring) }
+
+func (o ringOrder) Less(i, j int) bool {
+	if o.r.ring[i] != o.r.ring[j] {
+		return o.r.ring[i] < o.r.ring[j]
+	}
+	return o.r.ownerLocked(i) < o.r.ownerLocked(j)
+}
+
+func (o ringOrder) Swap(i, j int) {
+	o.r.ring[i], o.r.ring[j] = o.r.ring[j], o.r.ring[i]
+	o.r.owners[i], o.r.owners[j] = o.r.owners[j], o.r.owners[i]
+}
+
+// Weight returns the weight the node was added with
+func (r *Ring) Weight(node string) (float64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entry, exists := r.nodeSet[node]
+	if !exists {
+		return 0, ErrNodeNotFound
+	}
+
+	return entry.weight, nil
+}
+
+// UpdateWeight changes a node's weight in place and publishes a NodeUpdated
+// event; virtual nodes are added or removed at the end of the node's sequence,
+// so keys only move to or from that node
+// A node with a virtual node override keeps its count until the override is
+// cleared with SetVirtualNodes; on a ketama ring the continuum is rebuilt
+// Returns ErrMovementBudgetExceeded if the keys moved do not fit the movement
+// budget
+func (r *Ring) UpdateWeight(node string, weight float64) error {
+	if weight <= 0 || math.IsNaN(weight) || math.IsInf(weight, 0) {
+		return ErrInvalidWeight
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, exists := r.nodeSet[node]
+	if !exists {
+		return ErrNodeNotFound
+	}
+	if entry.weight == weight {
+		return nil
+	}
+	previous := entry.weight
+	entry.weight = weight
+
+	if r.ketama {
+		err := r.spendMovementLocked(r.clock.Now(), false, func(p *Ring) { p.rebuildKetamaLocked() })
+		if err != nil {
+			entry.weight = previous
+			return err
+		}
+
+		before := r.watchLocked()
+		defer r.publishLocked(NodeUpdated, []string{node}, before)
+		r.topologyChanges++
+
+		// Every node's share depends on the total weight, so rebuild the continuum
+		r.rebuildKetamaLocked()
+		return nil
+	}
+
+	if err := r.resizeLocked(node, entry); err != nil {
+		entry.weight = previous
+		return err
+	}
 	return nil
 }
 
 // RemoveNode removes a physical node and all its virtual nodes from the ring
 // Returns an error if the node doesn't exist
 func (r *Ring) RemoveNode(node string) error {
+	return r.RemoveNodeWithReason(node, "")
+}
+
+// RemoveNodeWithReason removes a node and records the reason in its tombstone
+func (r *Ring) RemoveNodeWithReason(node, reason string) error {
+	return r.removeNode(node, reason, false)
+}
+
+// ForceRemoveNode removes a node even if it would exceed the movement budget
+func (r *Ring) ForceRemoveNode(node string) error {
+	return r.removeNode(node, "", true)
+}
+
+// removeNode drops a node; force skips the movement budget check
+func (r *Ring) removeNode(node, reason string, force bool) error {
 	if node == "" {
 		return ErrEmptyKey
 	}
@@ -138,53 +637,164 @@ func (r *Ring) RemoveNode(node string) error {
 	defer r.mu.Unlock()
 
 	// Check if node exists
-	if _, exists := r.nodeSet[node]; !exists {
+	entry, exists := r.nodeSet[node]
+	if !exists {
 		return ErrNodeNotFound
 	}
 
-	// Remove virtual nodes
-	newRing := make([]uint64, 0, len(r.ring)-r.replicas)
-	for _, hash := range r.ring {
-		if r.nodes[hash] != node {
-			newRing = append(newRing, hash)
-		} else {
-			delete(r.nodes, hash)
-		}
+	remove := map[string]struct{}{node: {}}
+	err := r.spendMovementLocked(r.clock.Now(), force, func(p *Ring) {
+		p.removeNodesLocked(remove, entry.vnodes, reason)
+	})
+	if err != nil {
+		return err
 	}
 
-	r.ring = newRing
-	delete(r.nodeSet, node)
+	before := r.watchLocked()
+	defer r.publishLocked(NodeRemoved, []string{node}, before)
+
+	r.removeNodesLocked(remove, entry.vnodes, reason)
 
 	return nil
 }
 
+// removeNodesLocked drops nodes and their virtual nodes in a single pass over
+// the ring and leaves a tombstone for each
+// Caller must hold r.mu for writing
+func (r *Ring) removeNodesLocked(nodes map[string]struct{}, vnodes int, reason string) {
+	for node := range nodes {
+		if r.nodeSet[node].state != NodeActive {
+			r.inactive--
+		}
+		delete(r.nodeSet, node)
+	}
+
+	// Compact the node table; remaining nodes get new indices
+	const removedIndex = math.MaxUint32
+	remap := make([]uint32, len(r.names))
+	names := make([]string, 0, len(r.nodeSet))
+	for i, name := range r.names {
+		entry, kept := r.nodeSet[name]
+		if !kept {
+			remap[i] = removedIndex
+			continue
+		}
+		entry.index = uint32(len(names))
+		remap[i] = entry.index
+		names = append(names, name)
+	}
+	r.names = names
+
+	// Filtering keeps the remaining positions sorted
+	ring := make([]uint64, 0, len(r.ring)-vnodes)
+	owners := make([]uint32, 0, len(r.ring)-vnodes)
+	for i, hash := range r.ring {
+		if index := remap[r.owners[i]]; index != removedIndex {
+			ring = append(ring, hash)
+			owners = append(owners, index)
+		}
+	}
+	r.ring = ring
+	r.owners = owners
+	r.topologyChanges += uint64(len(nodes))
+
+	r.resolveCollisionsLocked()
+	r.retuneLocked()
+
+	if r.ketama {
+		r.rebuildKetamaLocked()
+	}
+
+	now := r.clock.Now()
+	r.pruneTombstonesLocked(now)
+	for node := range nodes {
+		r.tombstones[node] = Tombstone{
+			Node:      node,
+			RemovedAt: now,
+			Epoch:     r.topologyChanges,
+			Reason:    reason,
+		}
+	}
+}
+
 // GetNode returns the node responsible for the given key
-// Uses clockwise traversal to find the closest node
+// Uses clockwise traversal to find the closest node; pinned keys return their pinned owner
 func (r *Ring) GetNode(key string) (string, error) {
+	node, _, err := r.GetNodeWithHash(key)
+	return node, err
+}
+
+// GetNodeWithHash is GetNode that also returns the ring position key routes
+// on, so callers can reuse it for sharded locks, logging or checks against
+// OwnedRanges without hashing the key again
+// The position accounts for affinity groups, hash tags and partitions, so it
+// is the value GetNodeForHash routes to the same node, pins aside; it is
+// returned even when no node can serve the key
+func (r *Ring) GetNodeWithHash(key string) (string, uint64, error) {
 	if key == "" {
-		return "", ErrEmptyKey
+		return "", 0, ErrEmptyKey
 	}
 
+	r.lookups.Add(1)
+
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
+	hash := r.hashKeyLocked(key)
+
 	if len(r.ring) == 0 {
-		return "", ErrNoNodes
+		return "", hash, ErrNoNodes
 	}
 
-	hash := r.hashFunc(key)
+	if len(r.pins) > 0 {
+		if node, ok := r.pinnedLocked(key, r.clock.Now()); ok {
+			return node, hash, nil
+		}
+	}
 
-	// Binary search for the first node with hash >= key hash
-	idx := sort.Search(len(r.ring), func(i int) bool {
-		return r.ring[i] >= hash
-	})
+	node, err := servingNode(r.primaryLocked(r.searchLocked(hash)))
+	return node, hash, err
+}
 
-	// If no node found, wrap around to the first node
-	if idx == len(r.ring) {
-		idx = 0
+// MustGetNode is GetNode for hot loops where the ring is known to be
+// populated: it panics instead of returning an error
+// With the built-in hash functions it performs no allocations
+// Panics if key is empty or no node can serve it
+func (r *Ring) MustGetNode(key string) string {
+	node, err := r.GetNode(key)
+	if err != nil {
+		panic("chash: MustGetNode: " + err.Error())
 	}
+	return node
+}
+
+// GetNodeForHash returns the node responsible for the given position on the ring
+// Useful when the caller has already hashed the key or routes on raw hash values
+func (r *Ring) GetNodeForHash(hash uint64) (string, error) {
+	r.lookups.Add(1)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(r.ring) == 0 {
+		return "", ErrNoNodes
+	}
+
+	return servingNode(r.primaryLocked(r.searchLocked(hash)))
+}
+
+// hashKeyLocked returns the ring position used to route key
+// Keys belonging to an affinity group are routed on the group name
+// Caller must hold r.mu
+func (r *Ring) hashKeyLocked(key string) uint64 {
+	return r.partitionHash(r.hashFunc(r.groupKeyLocked(key)))
+}
 
-	return r.nodes[r.ring[idx]], nil
+// searchLocked returns the index of the first virtual node clockwise from hash
+// Caller must hold r.mu and ensure the ring is not empty
+func (r *Ring) searchLocked(hash uint64) int {
+	// Binary search for the first node with hash >= key hash, wrapping to the first
+	return core.Search(r.ring, hash)
 }
 
 // GetNodes returns the top N nodes responsible for the given key
@@ -198,6 +808,8 @@ func (r *Ring) GetNodes(key string, count int) ([]string, error) {
 		return nil, errors.New("count must be positive")
 	}
 
+	r.lookups.Add(1)
+
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
@@ -205,37 +817,74 @@ func (r *Ring) GetNodes(key string, count int) ([]string, error) {
 		return nil, ErrNoNodes
 	}
 
-	if count > len(r.nodeSet) {
-		count = len(r.nodeSet)
-	}
+	return nodesOrErr(r.walkLocked(r.searchLocked(r.hashKeyLocked(key)), count))
+}
 
-	hash := r.hashFunc(key)
+// walkLocked returns up to count distinct nodes clockwise from ring index idx,
+// as chosen by the replication strategy
+// Caller must hold r.mu and ensure the ring is not empty
+func (r *Ring) walkLocked(idx, count int) []string {
+	return r.walkExcludingLocked(idx, count, nil)
+}
 
-	// Binary search for the first node with hash >= key hash
-	idx := sort.Search(len(r.ring), func(i int) bool {
-		return r.ring[i] >= hash
-	})
+// walkExcludingLocked is walkLocked skipping the nodes in exclude
+// Caller must hold r.mu and ensure the ring is not empty
+func (r *Ring) walkExcludingLocked(idx, count int, exclude []string) []string {
+	// seen is indexed by node table position, marking nodes already returned
+	// or excluded
+	seen := make([]bool, len(r.names))
+	available := len(r.names)
+	for _, node := range exclude {
+		if entry, exists := r.nodeSet[node]; exists && !seen[entry.index] {
+			seen[entry.index] = true
+			available--
+		}
+	}
 
-	// If no node found, wrap around to the first node
-	if idx == len(r.ring) {
-		idx = 0
+	if count > available {
+		count = available
+	}
+
+	if r.replication != nil {
+		result := r.replication.Select(r.candidatesLocked(idx, seen), count)
+		return result[:min(len(result), count)]
 	}
 
 	result := make([]string, 0, count)
-	seen := make(map[string]struct{})
 
 	// Traverse the ring clockwise until we have enough unique nodes
-	for i := 0; i < len(r.ring) && len(result) < count; i++ {
-		currentIdx := (idx + i) % len(r.ring)
-		node := r.nodes[r.ring[currentIdx]]
+	r.visitLocked(idx, seen, func(owner uint32) bool {
+		result = append(result, r.names[owner])
+		return len(result) < count
+	})
 
-		if _, exists := seen[node]; !exists {
-			result = append(result, node)
-			seen[node] = struct{}{}
-		}
+	return result
+}
+
+// GetNodesExcluding returns up to count distinct nodes for key, in GetNodes
+// order, skipping the nodes in exclude
+// Replication clients use it to find the next healthy owners without mutating
+// the ring; excluded names that are not in the ring are ignored
+// Returns ErrNoNodes if every node is excluded
+func (r *Ring) GetNodesExcluding(key string, count int, exclude []string) ([]string, error) {
+	if key == "" {
+		return nil, ErrEmptyKey
+	}
+
+	if count <= 0 {
+		return nil, errors.New("count must be positive")
+	}
+
+	r.lookups.Add(1)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(r.ring) == 0 {
+		return nil, ErrNoNodes
 	}
 
-	return result, nil
+	return nodesOrErr(r.walkExcludingLocked(r.searchLocked(r.hashKeyLocked(key)), count, exclude))
 }
 
 // Nodes returns a list of all physical nodes in the ring
@@ -283,17 +932,67 @@ type Stats struct {
 	VirtualNodes  int
 	Replicas      int
 	LoadFactor    float64 // Average number of virtual nodes per physical node
+
+	// VirtualNodeShare maps each node to its fraction of the virtual nodes
+	VirtualNodeShare map[string]float64
+
+	// Keyspace maps each node to the fraction of the hash space it owns
+	// Fractions sum to 1; with weights or few virtual nodes they can differ
+	// noticeably from VirtualNodeShare
+	Keyspace map[string]float64
+
+	// MinKeyspace and MaxKeyspace are the smallest and largest fractions of
+	// the hash space owned by a single node
+	MinKeyspace float64
+	MaxKeyspace float64
+
+	// KeyspaceStdDev is the standard deviation of the fractions in Keyspace
+	// Alert on it relative to the fair share 1/PhysicalNodes
+	KeyspaceStdDev float64
+
+	// Lookups is the total number of key lookups served since creation
+	Lookups uint64
+
+	// TopologyChanges is the total number of node additions, removals,
+	// virtual node changes and state changes since creation; see Version
+	TopologyChanges uint64
 }
 
 // GetStats returns statistical information about the hash ring
+// It walks every virtual node to measure the distribution; use Version to
+// poll for topology changes
 func (r *Ring) GetStats() Stats {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
-	return Stats{
-		PhysicalNodes: len(r.nodeSet),
-		VirtualNodes:  len(r.ring),
-		Replicas:      r.replicas,
-		LoadFactor:    0,
+	stats := Stats{
+		PhysicalNodes:    len(r.nodeSet),
+		VirtualNodes:     len(r.ring),
+		Replicas:         r.replicas,
+		VirtualNodeShare: make(map[string]float64, len(r.nodeSet)),
+		Keyspace:         ownership(r.pointsLocked()),
+
+		Lookups:         r.lookups.Load(),
+		TopologyChanges: r.topologyChanges,
+	}
+	if len(r.nodeSet) == 0 {
+		return stats
+	}
+
+	stats.LoadFactor = float64(len(r.ring)) / float64(len(r.nodeSet))
+	for node, entry := range r.nodeSet {
+		stats.VirtualNodeShare[node] = float64(entry.vnodes) / float64(len(r.ring))
+	}
+
+	stats.MinKeyspace = 1
+	var sum, sumSquares float64
+	for _, share := range stats.Keyspace {
+		stats.MinKeyspace = min(stats.MinKeyspace, share)
+		stats.MaxKeyspace = max(stats.MaxKeyspace, share)
+		sum += share
+		sumSquares += share * share
 	}
+	mean := sum / float64(len(stats.Keyspace))
+	stats.KeyspaceStdDev = math.Sqrt(max(sumSquares/float64(len(stats.Keyspace))-mean*mean, 0))
+	return stats
 }
@@ -2,6 +2,7 @@ package chash
 
 import (
 	"fmt"
+	"math"
 	"strconv"
 	"sync"
 	"testing"
@@ -78,6 +79,65 @@ func TestAddNode(t *testing.T) {
 	}
 }
 
+func TestAddNodeWithWeight(t *testing.T) {
+	ring := New(Config{Replicas: 10})
+
+	if err := ring.AddNodeWithWeight("big-server", 2.5); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if ring.VirtualNodeCount() != 25 {
+		t.Errorf("expected 25 virtual nodes, got %d", ring.VirtualNodeCount())
+	}
+
+	weight, err := ring.Weight("big-server")
+	if err != nil || weight != 2.5 {
+		t.Errorf("expected weight 2.5, got %v (err=%v)", weight, err)
+	}
+
+	// Tiny weights still get one virtual node
+	ring.AddNodeWithWeight("tiny-server", 0.01)
+	if ring.VirtualNodeCount() != 26 {
+		t.Errorf("expected 26 virtual nodes, got %d", ring.VirtualNodeCount())
+	}
+
+	// Removing a weighted node removes all its virtual nodes
+	ring.RemoveNode("big-server")
+	if ring.VirtualNodeCount() != 1 {
+		t.Errorf("expected 1 virtual node after removal, got %d", ring.VirtualNodeCount())
+	}
+
+	if _, err := ring.Weight("big-server"); err != ErrNodeNotFound {
+		t.Errorf("expected ErrNodeNotFound, got %v", err)
+	}
+
+	// Test invalid weights
+	for _, w := range []float64{0, -1, math.NaN(), math.Inf(1)} {
+		if err := ring.AddNodeWithWeight("server9", w); err != ErrInvalidWeight {
+			t.Errorf("expected ErrInvalidWeight for %v, got %v", w, err)
+		}
+	}
+}
+
+func TestWeightedDistribution(t *testing.T) {
+	ring := New(Config{Replicas: 150})
+	ring.AddNodeWithWeight("small", 1)
+	ring.AddNodeWithWeight("large", 3)
+
+	distribution := make(map[string]int)
+	numKeys := 20000
+	for i := 0; i < numKeys; i++ {
+		node, _ := ring.GetNode(fmt.Sprintf("key%d", i))
+		distribution[node]++
+	}
+
+	// The large node should own roughly three quarters of the keys
+	share := float64(distribution["large"]) / float64(numKeys)
+	if share < 0.65 || share > 0.85 {
+		t.Errorf("expected large node share near 0.75, got %.3f", share)
+	}
+}
+
 func TestRemoveNode(t *testing.T) {
 	ring := New(Config{Replicas: 3})
 	ring.AddNode("server1")
@@ -149,6 +209,40 @@ func TestGetNode(t *testing.T) {
 	}
 }
 
+func TestGetNodeForHash(t *testing.T) {
+	ring := New(Config{Replicas: 3})
+
+	// Test with empty ring
+	_, err := ring.GetNodeForHash(42)
+	if err != ErrNoNodes {
+		t.Errorf("expected ErrNoNodes, got %v", err)
+	}
+
+	ring.AddNode("server1")
+	ring.AddNode("server2")
+
+	// Looking up the key's hash must agree with looking up the key
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("key%d", i)
+		expected, _ := ring.GetNode(key)
+
+		node, err := ring.GetNodeForHash(DefaultHashFunc(key))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if node != expected {
+			t.Errorf("expected %s for %s, got %s", expected, key, node)
+		}
+	}
+
+	// Hashes past the last virtual node wrap around to the first
+	last, _ := ring.GetNodeForHash(^uint64(0))
+	first, _ := ring.GetNodeForHash(0)
+	if last != first {
+		t.Errorf("expected wrap-around to %s, got %s", first, last)
+	}
+}
+
 func TestGetNodes(t *testing.T) {
 	ring := New(Config{Replicas: 3})
 
@@ -206,6 +300,40 @@ func TestGetNodes(t *testing.T) {
 	}
 }
 
+func TestGetNodesExcluding(t *testing.T) {
+	ring := NewWithNodes(Config{Replicas: 10}, []string{"server1", "server2", "server3", "server4"})
+
+	for i := 0; i < 200; i++ {
+		key := fmt.Sprintf("key%d", i)
+		all, _ := ring.GetNodes(key, 4)
+
+		// Excluding the primary promotes the rest of the preference list in order
+		result, err := ring.GetNodesExcluding(key, 2, []string{all[0], "unknown"})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(result) != 2 || result[0] != all[1] || result[1] != all[2] {
+			t.Errorf("expected %v, got %v", all[1:3], result)
+		}
+
+		// Count is capped at the nodes left after exclusion
+		result, _ = ring.GetNodesExcluding(key, 10, []string{all[1], all[1]})
+		if len(result) != 3 || result[0] != all[0] || result[1] != all[2] {
+			t.Errorf("expected %v without %s, got %v", all, all[1], result)
+		}
+	}
+
+	if _, err := ring.GetNodesExcluding("key1", 1, ring.Nodes()); err != ErrNoNodes {
+		t.Errorf("expected ErrNoNodes when every node is excluded, got %v", err)
+	}
+	if _, err := ring.GetNodesExcluding("", 1, nil); err != ErrEmptyKey {
+		t.Errorf("expected ErrEmptyKey, got %v", err)
+	}
+	if _, err := ring.GetNodesExcluding("key1", 0, nil); err == nil {
+		t.Error("expected error for zero count")
+	}
+}
+
 func TestConsistentDistribution(t *testing.T) {
 	ring := New(Config{Replicas: 150})
 
@@ -399,6 +527,54 @@ func TestGetStats(t *testing.T) {
 	}
 }
 
+func TestGetStatsDistribution(t *testing.T) {
+	ring := New(Config{Replicas: 100})
+	ring.AddNode("server1")
+	ring.AddNodeWithWeight("server2", 3)
+
+	stats := ring.GetStats()
+
+	if stats.LoadFactor != 200 {
+		t.Errorf("expected load factor 200, got %v", stats.LoadFactor)
+	}
+	if share := stats.VirtualNodeShare["server2"]; share != 0.75 {
+		t.Errorf("expected server2 to hold 75%% of virtual nodes, got %v", share)
+	}
+
+	var total float64
+	for _, share := range stats.Keyspace {
+		total += share
+	}
+	if math.Abs(total-1) > 1e-9 {
+		t.Errorf("expected keyspace fractions to sum to 1, got %v", total)
+	}
+
+	if stats.MinKeyspace != stats.Keyspace["server1"] || stats.MaxKeyspace != stats.Keyspace["server2"] {
+		t.Errorf("expected min/max to be server1/server2, got %+v", stats.Keyspace)
+	}
+	if stats.MaxKeyspace < 0.6 || stats.MaxKeyspace > 0.9 {
+		t.Errorf("expected server2 to own about 75%% of the keyspace, got %v", stats.MaxKeyspace)
+	}
+
+	// Two nodes deviate from their mean by half their difference
+	want := (stats.MaxKeyspace - stats.MinKeyspace) / 2
+	if math.Abs(stats.KeyspaceStdDev-want) > 1e-9 {
+		t.Errorf("expected standard deviation %v, got %v", want, stats.KeyspaceStdDev)
+	}
+}
+
+func TestGetStatsEmpty(t *testing.T) {
+	stats := New(Config{}).GetStats()
+	if stats.LoadFactor != 0 || stats.MaxKeyspace != 0 || len(stats.Keyspace) != 0 {
+		t.Errorf("expected zero distribution stats, got %+v", stats)
+	}
+
+	stats = NewWithNodes(Config{Replicas: 10}, []string{"server1"}).GetStats()
+	if stats.Keyspace["server1"] != 1 || stats.KeyspaceStdDev != 0 {
+		t.Errorf("expected a single node to own everything, got %+v", stats)
+	}
+}
+
 func TestCustomHashFunction(t *testing.T) {
 	// Create a simple hash function for testing
 	simpleHash := func(key string) uint64 {
@@ -427,6 +603,51 @@ func TestCustomHashFunction(t *testing.T) {
 	}
 }
 
+func TestNodeTableCompaction(t *testing.T) {
+	ring := NewWithNodes(Config{Replicas: 10}, []string{"server1", "server2", "server3", "server4"})
+	ring.RemoveNode("server2")
+	ring.AddNode("server5")
+	ring.RemoveNodes([]string{"server1", "server5"})
+
+	if len(ring.names) != len(ring.nodeSet) || len(ring.owners) != len(ring.ring) {
+		t.Fatalf("node table out of sync: %d names, %d nodes", len(ring.names), len(ring.nodeSet))
+	}
+	for node, entry := range ring.nodeSet {
+		if ring.names[entry.index] != node {
+			t.Errorf("expected index %d to name %s, got %s", entry.index, node, ring.names[entry.index])
+		}
+	}
+
+	expected := NewWithNodes(Config{Replicas: 10}, []string{"server3", "server4"})
+	for i := 0; i < 500; i++ {
+		key := fmt.Sprintf("key%d", i)
+		a, _ := ring.GetNode(key)
+		b, _ := expected.GetNode(key)
+		if a != b {
+			t.Errorf("expected %s on %s, got %s", key, b, a)
+		}
+	}
+}
+
+func TestCollisionsIndependentOfOrder(t *testing.T) {
+	// Every virtual node lands on one of four positions
+	coarse := func(key string) uint64 {
+		return (DefaultHashFunc(key) % 4) << 62
+	}
+
+	forward := NewWithNodes(Config{Replicas: 3, HashFunc: coarse}, []string{"a", "b", "c"})
+	backward := NewWithNodes(Config{Replicas: 3, HashFunc: coarse}, []string{"c", "b", "a"})
+
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("key%d", i)
+		a, _ := forward.GetNode(key)
+		b, _ := backward.GetNode(key)
+		if a != b {
+			t.Errorf("expected %s on the same node regardless of add order, got %s and %s", key, a, b)
+		}
+	}
+}
+
 // Benchmarks
 func BenchmarkAddNode(b *testing.B) {
 	ring := New(Config{Replicas: 150})
@@ -452,6 +673,85 @@ func BenchmarkGetNode(b *testing.B) {
 	}
 }
 
+func TestMustGetNode(t *testing.T) {
+	for name := range builtinHashes {
+		ring := NewWithNodes(Config{HashName: name}, []string{"server1", "server2", "server3"})
+
+		want, _ := ring.GetNode("user:123")
+		if got := ring.MustGetNode("user:123"); got != want {
+			t.Errorf("%s: expected %s, got %s", name, want, got)
+		}
+
+		allocs := testing.AllocsPerRun(100, func() { ring.MustGetNode("user:123") })
+		if allocs != 0 {
+			t.Errorf("%s: expected no allocations, got %v", name, allocs)
+		}
+	}
+
+	for _, tt := range []struct {
+		ring *Ring
+		key  string
+	}{
+		{New(Config{}), "user:123"},
+		{NewWithNodes(Config{}, []string{"server1"}), ""},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected a panic for key %q", tt.key)
+				}
+			}()
+			tt.ring.MustGetNode(tt.key)
+		}()
+	}
+}
+
+func BenchmarkMustGetNode(b *testing.B) {
+	ring := New(Config{Replicas: 150})
+	for i := 0; i < 100; i++ {
+		ring.AddNode("server" + strconv.Itoa(i))
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ring.MustGetNode("user:123")
+	}
+}
+
+func TestGetNodeWithHash(t *testing.T) {
+	ring := NewWithNodes(Config{Replicas: 10, HashTags: true}, []string{"server1", "server2", "server3"})
+
+	node, hash, err := ring.GetNodeWithHash("user:{42}:profile")
+	if err != nil {
+		t.Fatalf("GetNodeWithHash failed: %v", err)
+	}
+	if want, _ := ring.GetNode("user:{42}:profile"); node != want {
+		t.Errorf("expected %s, got %s", want, node)
+	}
+	if hash != ring.hashFunc("42") {
+		t.Error("expected the position of the hash tag")
+	}
+	if byHash, _ := ring.GetNodeForHash(hash); byHash != node {
+		t.Errorf("expected the position to route to %s, got %s", node, byHash)
+	}
+
+	owned := false
+	for _, rg := range ring.OwnedRanges(node) {
+		owned = owned || rg.Contains(hash)
+	}
+	if !owned {
+		t.Error("expected the position within the node's owned ranges")
+	}
+
+	if _, _, err := ring.GetNodeWithHash(""); err != ErrEmptyKey {
+		t.Errorf("expected ErrEmptyKey, got %v", err)
+	}
+	if _, hash, err := New(Config{}).GetNodeWithHash("key"); err != ErrNoNodes || hash == 0 {
+		t.Errorf("expected ErrNoNodes with the position, got %v %d", err, hash)
+	}
+}
+
 func BenchmarkGetNodeConcurrent(b *testing.B) {
 	ring := New(Config{Replicas: 150})
 
@@ -485,3 +785,84 @@ func BenchmarkRemoveNode(b *testing.B) {
 		ring.RemoveNode(nodes[i])
 	}
 }
+
+func TestUpdateWeight(t *testing.T) {
+	ring := NewWithNodes(Config{Replicas: 40}, []string{"server1", "server2", "server3"})
+
+	events, unsubscribe := ring.Subscribe()
+	defer unsubscribe()
+
+	before := owners(ring)
+	if err := ring.UpdateWeight("server2", 0.5); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if n, _ := ring.VirtualNodes("server2"); n != 20 {
+		t.Errorf("expected 20 virtual nodes, got %d", n)
+	}
+	if w, _ := ring.Weight("server2"); w != 0.5 {
+		t.Errorf("expected weight 0.5, got %v", w)
+	}
+	for key, owner := range owners(ring) {
+		if owner != before[key] && before[key] != "server2" {
+			t.Errorf("%s moved from %s to %s", key, before[key], owner)
+		}
+	}
+
+	e := nextEvent(t, events)
+	if e.Type != NodeUpdated || e.Node != "server2" {
+		t.Fatalf("unexpected event %+v", e)
+	}
+	for _, m := range e.Moves {
+		if m.From != "server2" {
+			t.Errorf("unexpected move %+v", m)
+		}
+	}
+
+	// Same result as adding the node with that weight
+	direct := New(Config{Replicas: 40})
+	direct.AddNode("server1")
+	direct.AddNodeWithWeight("server2", 0.5)
+	direct.AddNode("server3")
+	want := owners(direct)
+	for key, owner := range owners(ring) {
+		if owner != want[key] {
+			t.Errorf("%s: expected %s, got %s", key, want[key], owner)
+		}
+	}
+}
+
+func TestUpdateWeightErrors(t *testing.T) {
+	ring := NewWithNodes(Config{}, []string{"server1"})
+
+	if err := ring.UpdateWeight("server1", 0); err != ErrInvalidWeight {
+		t.Errorf("expected ErrInvalidWeight, got %v", err)
+	}
+	if err := ring.UpdateWeight("missing", 2); err != ErrNodeNotFound {
+		t.Errorf("expected ErrNodeNotFound, got %v", err)
+	}
+
+	// An override keeps its count
+	ring.AddNodeWithVirtualNodes("server2", 7)
+	ring.UpdateWeight("server2", 3)
+	if n, _ := ring.VirtualNodes("server2"); n != 7 {
+		t.Errorf("expected the override to hold, got %d", n)
+	}
+}
+
+func TestUpdateWeightKetama(t *testing.T) {
+	ring := NewWithNodes(Config{Ketama: true}, []string{"10.0.0.1:11211", "10.0.0.2:11211"})
+
+	if err := ring.UpdateWeight("10.0.0.1:11211", 3); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	direct := New(Config{Ketama: true})
+	direct.AddNodeWithWeight("10.0.0.1:11211", 3)
+	direct.AddNode("10.0.0.2:11211")
+	want := owners(direct)
+	for key, owner := range owners(ring) {
+		if owner != want[key] {
+			t.Errorf("%s: expected %s, got %s", key, want[key], owner)
+		}
+	}
+}
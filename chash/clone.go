@@ -0,0 +1,126 @@
+package chash
+
+import (
+	"iter"
+	"maps"
+	"slices"
+)
+
+// Clone returns an independent copy of the ring: its configuration, nodes
+// with their weights, states, topology and metadata, affinity groups, pins,
+// tombstones and version
+// Subscribers are not copied and lookup statistics start from zero; node
+// metadata values are shared, not deep-copied
+func (r *Ring) Clone() *Ring {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	c := r.cloneLocked()
+	c.hashTags = r.hashTags
+	c.replication = r.replication
+	c.admissionChecks = slices.Clone(r.admissionChecks)
+	c.groups = make(map[string][]string, len(r.groups))
+	for name, prefixes := range r.groups {
+		c.groups[name] = slices.Clone(prefixes)
+	}
+	c.groupPrefixes = slices.Clone(r.groupPrefixes)
+	c.pins = maps.Clone(r.pins)
+	c.tombstones = maps.Clone(r.tombstones)
+	c.quarantine = r.quarantine
+	c.movementBudget = r.movementBudget
+	c.movementWindow = r.movementWindow
+	c.movements = slices.Clone(r.movements)
+	c.subscribers = make(map[int]*subscriber)
+	c.topologyChanges = r.topologyChanges
+	c.checkedVersion = r.checkedVersion
+	return c
+}
+
+// RingView is a read-only, point-in-time copy of a ring
+// Background jobs can iterate it at leisure while the live ring keeps
+// changing; it never observes later changes and cannot be modified
+type RingView struct {
+	ring *Ring
+}
+
+// Snapshot returns a read-only view of the ring as it is now
+// It copies the ring, so take one per job rather than per lookup
+func (r *Ring) Snapshot() *RingView {
+	return &RingView{ring: r.Clone()}
+}
+
+// Version returns the version of the ring when the view was taken
+func (v *RingView) Version() uint64 {
+	return v.ring.Version()
+}
+
+// GetNode is Ring.GetNode against the view
+func (v *RingView) GetNode(key string) (string, error) {
+	return v.ring.GetNode(key)
+}
+
+// GetNodeWithHash is Ring.GetNodeWithHash against the view
+func (v *RingView) GetNodeWithHash(key string) (string, uint64, error) {
+	return v.ring.GetNodeWithHash(key)
+}
+
+// GetNodeForHash is Ring.GetNodeForHash against the view
+func (v *RingView) GetNodeForHash(hash uint64) (string, error) {
+	return v.ring.GetNodeForHash(hash)
+}
+
+// GetNodes is Ring.GetNodes against the view
+func (v *RingView) GetNodes(key string, count int) ([]string, error) {
+	return v.ring.GetNodes(key, count)
+}
+
+// Lookup is Ring.Lookup against the view
+func (v *RingView) Lookup(keys iter.Seq[string]) iter.Seq2[string, string] {
+	return v.ring.Lookup(keys)
+}
+
+// Nodes returns the nodes of the view sorted by name
+func (v *RingView) Nodes() []string {
+	return v.ring.Nodes()
+}
+
+// IsEmpty returns true if the view has no nodes
+func (v *RingView) IsEmpty() bool {
+	return v.ring.IsEmpty()
+}
+
+// Weight is Ring.Weight against the view
+func (v *RingView) Weight(node string) (float64, error) {
+	return v.ring.Weight(node)
+}
+
+// NodeState is Ring.NodeState against the view
+func (v *RingView) NodeState(node string) (NodeState, error) {
+	return v.ring.NodeState(node)
+}
+
+// Topology is Ring.Topology against the view
+func (v *RingView) Topology(node string) (Topology, error) {
+	return v.ring.Topology(node)
+}
+
+// OwnedRanges is Ring.OwnedRanges against the view
+func (v *RingView) OwnedRanges(node string) []Range {
+	return v.ring.OwnedRanges(node)
+}
+
+// VirtualNodeCount returns the number of virtual nodes in the view
+func (v *RingView) VirtualNodeCount() int {
+	return v.ring.VirtualNodeCount()
+}
+
+// Fingerprint is Ring.Fingerprint of the view
+func (v *RingView) Fingerprint() uint64 {
+	return v.ring.Fingerprint()
+}
+
+// Diff returns the ranges whose owner differs between the view and other,
+// with From the owner in the view
+func (v *RingView) Diff(other *Ring) []RangeMove {
+	return v.ring.Diff(other)
+}
@@ -0,0 +1,98 @@
+package chash
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestCloneIsIndependent(t *testing.T) {
+	ring := NewWithNodes(Config{Replicas: 50}, []string{"server1", "server2", "server3"})
+	if err := ring.SetNodeState("server2", NodeDraining); err != nil {
+		t.Fatalf("SetNodeState failed: %v", err)
+	}
+	if _, err := ring.Pin(time.Hour, "user:1"); err != nil {
+		t.Fatalf("Pin failed: %v", err)
+	}
+
+	clone := ring.Clone()
+	if clone.Version() != ring.Version() || clone.Fingerprint() != ring.Fingerprint() {
+		t.Fatal("Clone does not match the ring")
+	}
+	if state, _ := clone.NodeState("server2"); state != NodeDraining {
+		t.Errorf("Expected cloned state draining, got %v", state)
+	}
+	if _, ok := clone.Pinned("user:1"); !ok {
+		t.Error("Expected pin to be cloned")
+	}
+
+	if err := ring.AddNode("server4"); err != nil {
+		t.Fatalf("AddNode failed: %v", err)
+	}
+	if err := ring.UpdateWeight("server1", 3); err != nil {
+		t.Fatalf("UpdateWeight failed: %v", err)
+	}
+	if err := clone.RemoveNode("server3"); err != nil {
+		t.Fatalf("RemoveNode on clone failed: %v", err)
+	}
+
+	if got := clone.Nodes(); !slices.Equal(got, []string{"server1", "server2"}) {
+		t.Errorf("Clone saw changes to the ring: %v", got)
+	}
+	if w, _ := clone.Weight("server1"); w != 1 {
+		t.Errorf("Expected cloned weight 1, got %v", w)
+	}
+	if got := ring.Nodes(); !slices.Equal(got, []string{"server1", "server2", "server3", "server4"}) {
+		t.Errorf("Ring saw changes to the clone: %v", got)
+	}
+
+	// The clone has subscribers of its own
+	events, cancel := clone.Subscribe()
+	defer cancel()
+	if err := clone.AddNode("server5"); err != nil {
+		t.Fatalf("AddNode on clone failed: %v", err)
+	}
+	if event := <-events; event.Node != "server5" {
+		t.Errorf("Expected event for server5, got %+v", event)
+	}
+}
+
+func TestSnapshotIsConsistent(t *testing.T) {
+	ring := NewWithNodes(Config{Replicas: 50}, []string{"server1", "server2", "server3"})
+
+	owners := make(map[string]string)
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("key%d", i)
+		owners[key], _ = ring.GetNode(key)
+	}
+
+	view := ring.Snapshot()
+	version := view.Version()
+
+	if err := ring.RemoveNode("server1"); err != nil {
+		t.Fatalf("RemoveNode failed: %v", err)
+	}
+	if err := ring.AddNode("server4"); err != nil {
+		t.Fatalf("AddNode failed: %v", err)
+	}
+
+	if view.Version() != version {
+		t.Errorf("View version changed from %d to %d", version, view.Version())
+	}
+	if got := view.Nodes(); !slices.Equal(got, []string{"server1", "server2", "server3"}) {
+		t.Errorf("Expected view nodes unchanged, got %v", got)
+	}
+	for key, want := range owners {
+		if got, err := view.GetNode(key); err != nil || got != want {
+			t.Fatalf("View routed %s to %s (%v), want %s", key, got, err, want)
+		}
+	}
+
+	if moves := view.Diff(ring); len(moves) == 0 {
+		t.Error("Expected view to differ from the changed ring")
+	}
+	if len(view.OwnedRanges("server1")) == 0 {
+		t.Error("Expected view to report ranges of a node since removed")
+	}
+}
@@ -0,0 +1,143 @@
+package chash
+
+import (
+	"sort"
+	"strconv"
+)
+
+// Collision is a ring position shared by several virtual nodes
+// Only the first node in name order owns keys there; the others' virtual
+// nodes are dead weight, which skews ownership with weak hash functions
+type Collision struct {
+	Hash  uint64
+	Nodes []string
+}
+
+// Collisions returns the positions shared by more than one virtual node, in
+// ring order
+// With Config.ResolveCollisions set it is empty: colliding virtual nodes are
+// moved instead, see DisplacedVirtualNodes
+func (r *Ring) Collisions() []Collision {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var collisions []Collision
+	for i := 1; i < len(r.ring); i++ {
+		if r.ring[i] != r.ring[i-1] {
+			continue
+		}
+		if n := len(collisions); n > 0 && collisions[n-1].Hash == r.ring[i] {
+			collisions[n-1].Nodes = append(collisions[n-1].Nodes, r.ownerLocked(i))
+			continue
+		}
+		collisions = append(collisions, Collision{
+			Hash:  r.ring[i],
+			Nodes: []string{r.ownerLocked(i - 1), r.ownerLocked(i)},
+		})
+	}
+	return collisions
+}
+
+// DisplacedVirtualNodes returns the number of virtual nodes moved off a
+// colliding position by Config.ResolveCollisions
+func (r *Ring) DisplacedVirtualNodes() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.displaced
+}
+
+// resolveCollisionsLocked lays the ring out again if it has colliding
+// positions or displaced virtual nodes, so that a displaced virtual node
+// returns home once its collision is gone
+// Caller must hold r.mu for writing and have sorted the ring
+func (r *Ring) resolveCollisionsLocked() {
+	if !r.resolveCollisions || r.ketama {
+		return
+	}
+	if r.displaced == 0 && !hasCollisions(r.ring) {
+		return
+	}
+	r.relayoutLocked()
+}
+
+// hasCollisions returns true if a sorted ring repeats a position
+func hasCollisions(ring []uint64) bool {
+	for i := 1; i < len(ring); i++ {
+		if ring[i] == ring[i-1] {
+			return true
+		}
+	}
+	return false
+}
+
+// maxRehashes bounds the search for a free position; a hash function with
+// too few outputs for the virtual nodes leaves the rest colliding
+const maxRehashes = 1000
+
+// relayoutLocked places every virtual node again, resolving collisions as a
+// function of membership alone, independent of the order nodes were added:
+// visiting virtual nodes by node name and then index, the first to claim a
+// position keeps it, and each later one is rehashed as "node#i#k" for
+// k = 1, 2, ... until it lands on a position that is free and no virtual
+// node's home
+// Caller must hold r.mu for writing
+func (r *Ring) relayoutLocked() {
+	type vnode struct {
+		index uint32
+		key   string
+		hash  uint64
+	}
+
+	names := make([]string, 0, len(r.nodeSet))
+	for name := range r.nodeSet {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var vnodes []vnode
+	homes := make(map[uint64]struct{}, len(r.ring))
+	for _, name := range names {
+		entry := r.nodeSet[name]
+		for i := 0; i < entry.vnodes; i++ {
+			key := name + "#" + strconv.Itoa(i)
+			hash := r.virtualNodeHashLocked(name, entry, i)
+			vnodes = append(vnodes, vnode{index: entry.index, key: key, hash: hash})
+			homes[hash] = struct{}{}
+		}
+	}
+
+	claimed := make(map[uint64]struct{}, len(vnodes))
+	var displaced []vnode
+	r.ring = r.ring[:0]
+	r.owners = r.owners[:0]
+	for _, v := range vnodes {
+		if _, taken := claimed[v.hash]; taken {
+			displaced = append(displaced, v)
+			continue
+		}
+		claimed[v.hash] = struct{}{}
+		r.ring = append(r.ring, v.hash)
+		r.owners = append(r.owners, v.index)
+	}
+
+	r.displaced = 0
+	for _, v := range displaced {
+		hash := v.hash
+		for k := 1; k <= maxRehashes; k++ {
+			candidate := r.hashFunc(v.key + "#" + strconv.Itoa(k))
+			_, home := homes[candidate]
+			_, taken := claimed[candidate]
+			if !home && !taken {
+				hash = candidate
+				r.displaced++
+				break
+			}
+		}
+		claimed[hash] = struct{}{}
+		r.ring = append(r.ring, hash)
+		r.owners = append(r.owners, v.index)
+	}
+
+	r.sortLocked()
+}
@@ -0,0 +1,78 @@
+package chash
+
+import (
+	"reflect"
+	"testing"
+)
+
+// weakHash has 4096 outputs, so a few dozen virtual nodes collide
+func weakHash(key string) uint64 {
+	return FNV1a(key) % 4096 << 52
+}
+
+func TestCollisionsDetected(t *testing.T) {
+	ring := NewWithNodes(Config{Replicas: 100, HashFunc: weakHash}, []string{"server1", "server2", "server3"})
+
+	collisions := ring.Collisions()
+	if len(collisions) == 0 {
+		t.Fatal("expected collisions with a weak hash function")
+	}
+	for _, c := range collisions {
+		if len(c.Nodes) < 2 {
+			t.Errorf("expected several virtual nodes at %d, got %v", c.Hash, c.Nodes)
+		}
+	}
+	if ring.DisplacedVirtualNodes() != 0 {
+		t.Error("expected nothing displaced without ResolveCollisions")
+	}
+}
+
+func TestCollisionsResolved(t *testing.T) {
+	config := Config{Replicas: 100, HashFunc: weakHash, ResolveCollisions: true}
+	ring := NewWithNodes(config, []string{"server1", "server2", "server3"})
+
+	if c := ring.Collisions(); len(c) != 0 {
+		t.Errorf("expected collisions resolved, got %d", len(c))
+	}
+	if ring.DisplacedVirtualNodes() == 0 {
+		t.Error("expected some virtual nodes displaced")
+	}
+	if n := ring.VirtualNodeCount(); n != 300 {
+		t.Errorf("expected 300 virtual nodes, got %d", n)
+	}
+
+	// The layout depends on membership, not on the order nodes were added
+	reordered := NewWithNodes(config, []string{"server3", "server1", "server2"})
+	if !reflect.DeepEqual(ring.points(), reordered.points()) {
+		t.Error("expected the same layout regardless of insertion order")
+	}
+
+	// Displaced virtual nodes return home once their collision is gone
+	ring.RemoveNode("server2")
+	fresh := NewWithNodes(config, []string{"server1", "server3"})
+	if !reflect.DeepEqual(ring.points(), fresh.points()) {
+		t.Error("expected removal to restore the layout of the remaining nodes")
+	}
+
+	ring.SetVirtualNodes("server1", 40)
+	fresh.SetVirtualNodes("server1", 40)
+	if !reflect.DeepEqual(ring.points(), fresh.points()) {
+		t.Error("expected shrinking to match a ring built at the smaller size")
+	}
+	if len(ring.Collisions()) != 0 {
+		t.Error("expected no collisions after resizing")
+	}
+}
+
+func TestCollisionsExhausted(t *testing.T) {
+	// Two outputs cannot hold three virtual nodes
+	tiny := func(key string) uint64 { return FNV1a(key) % 2 }
+	ring := NewWithNodes(Config{Replicas: 3, HashFunc: tiny, ResolveCollisions: true}, []string{"server1"})
+
+	if n := ring.VirtualNodeCount(); n != 3 {
+		t.Errorf("expected every virtual node kept, got %d", n)
+	}
+	if len(ring.Collisions()) == 0 {
+		t.Error("expected unresolvable collisions to remain visible")
+	}
+}
@@ -0,0 +1,132 @@
+// Package core is the allocation-free routing core of chash: hashing, ring
+// lookup and the replica walk over an immutable table. It has no dependencies
+// beyond crypto/sha256 and builds for WebAssembly and as a C shared library,
+// so edge proxies and non-Go sidecars can embed the same routing decisions.
+
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Table is an immutable routing table
+// Points holds the sorted virtual node positions and Owners the index of the
+// physical node owning each position, so lookups return small integers rather
+// than strings
+type Table struct {
+	Points []uint64
+	Owners []uint32
+}
+
+// Hash is the default chash hash: the first eight bytes of the key's SHA-256
+// digest, read big-endian
+func Hash(key []byte) uint64 {
+	h := sha256.Sum256(key)
+	return binary.BigEndian.Uint64(h[:8])
+}
+
+// AppendVirtualNode appends the name of a node's i-th virtual node ("node#i")
+// to dst
+func AppendVirtualNode(dst []byte, node string, i int) []byte {
+	dst = append(dst, node...)
+	dst = append(dst, '#')
+	return strconv.AppendInt(dst, int64(i), 10)
+}
+
+// Build places vnodes[i] virtual nodes for nodes[i] the way chash.Ring does
+// with the default hash function; owners are indices into nodes
+// Virtual nodes that collide on a position are ordered by node name, as in
+// chash.Ring, so the first of them owns the keys
+// Building allocates; lookups on the result do not
+func Build(nodes []string, vnodes []int) Table {
+	return build(nodes, vnodes, Hash)
+}
+
+// build is Build with the hash function of virtual node names as a parameter
+func build(nodes []string, vnodes []int, hash func([]byte) uint64) Table {
+	total := 0
+	for i := range nodes {
+		total += vnodes[i]
+	}
+
+	type point struct {
+		hash  uint64
+		owner uint32
+	}
+	points := make([]point, 0, total)
+
+	var name []byte
+	for i, node := range nodes {
+		for v := 0; v < vnodes[i]; v++ {
+			name = AppendVirtualNode(name[:0], node, v)
+			points = append(points, point{hash: hash(name), owner: uint32(i)})
+		}
+	}
+
+	slices.SortFunc(points, func(a, b point) int {
+		switch {
+		case a.hash < b.hash:
+			return -1
+		case a.hash > b.hash:
+			return 1
+		default:
+			return strings.Compare(nodes[a.owner], nodes[b.owner])
+		}
+	})
+
+	t := Table{Points: make([]uint64, len(points)), Owners: make([]uint32, len(points))}
+	for i, p := range points {
+		t.Points[i] = p.hash
+		t.Owners[i] = p.owner
+	}
+	return t
+}
+
+// Search returns the index of the first point clockwise from hash, wrapping
+// to zero past the last point; points must be sorted and non-empty
+func Search(points []uint64, hash uint64) int {
+	lo, hi := 0, len(points)
+	for lo < hi {
+		mid := int(uint(lo+hi) >> 1)
+		if points[mid] < hash {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+
+	if lo == len(points) {
+		return 0
+	}
+	return lo
+}
+
+// Len returns the number of virtual nodes in the table
+func (t Table) Len() int {
+	return len(t.Points)
+}
+
+// Lookup returns the owner of hash; the table must not be empty
+func (t Table) Lookup(hash uint64) uint32 {
+	return t.Owners[Search(t.Points, hash)]
+}
+
+// Replicas appends up to n distinct owners clockwise from hash to dst and
+// returns it; with enough capacity in dst it does not allocate
+// The table must not be empty
+func (t Table) Replicas(hash uint64, n int, dst []uint32) []uint32 {
+	start := len(dst)
+	idx := Search(t.Points, hash)
+
+	for i := 0; i < len(t.Points) && len(dst)-start < n; i++ {
+		owner := t.Owners[(idx+i)%len(t.Points)]
+		if !slices.Contains(dst[start:], owner) {
+			dst = append(dst, owner)
+		}
+	}
+	return dst
+}
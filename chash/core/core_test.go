@@ -0,0 +1,76 @@
+package core
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSearch(t *testing.T) {
+	points := []uint64{10, 20, 30}
+
+	tests := []struct {
+		hash     uint64
+		expected int
+	}{
+		{0, 0}, {10, 0}, {11, 1}, {20, 1}, {25, 2}, {30, 2}, {31, 0},
+	}
+
+	for _, tt := range tests {
+		if got := Search(points, tt.hash); got != tt.expected {
+			t.Errorf("Search(%d) = %d, expected %d", tt.hash, got, tt.expected)
+		}
+	}
+}
+
+func TestBuildAndReplicas(t *testing.T) {
+	nodes := []string{"server1", "server2", "server3"}
+	table := Build(nodes, []int{50, 50, 100})
+
+	if table.Len() != 200 {
+		t.Fatalf("expected 200 points, got %d", table.Len())
+	}
+	for i := 1; i < table.Len(); i++ {
+		if table.Points[i-1] > table.Points[i] {
+			t.Fatal("expected sorted points")
+		}
+	}
+
+	counts := make([]int, len(nodes))
+	for i := 0; i < 4000; i++ {
+		hash := Hash([]byte(fmt.Sprintf("key%d", i)))
+		owner := table.Lookup(hash)
+		counts[owner]++
+
+		replicas := table.Replicas(hash, 3, nil)
+		if len(replicas) != 3 || replicas[0] != owner {
+			t.Fatalf("unexpected replicas %v for owner %d", replicas, owner)
+		}
+		if replicas[0] == replicas[1] || replicas[1] == replicas[2] || replicas[0] == replicas[2] {
+			t.Fatalf("expected distinct replicas, got %v", replicas)
+		}
+	}
+
+	// The double-weight node owns about half the keys
+	if counts[2] < 1600 || counts[2] > 2400 {
+		t.Errorf("expected about 2000 keys on server3, got %d", counts[2])
+	}
+
+	if replicas := table.Replicas(0, 10, nil); len(replicas) != 3 {
+		t.Errorf("expected replicas capped at node count, got %v", replicas)
+	}
+}
+
+func TestLookupDoesNotAllocate(t *testing.T) {
+	table := Build([]string{"a", "b", "c"}, []int{100, 100, 100})
+	key := []byte("user:12345")
+	dst := make([]uint32, 0, 3)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		hash := Hash(key)
+		table.Lookup(hash)
+		dst = table.Replicas(hash, 3, dst[:0])
+	})
+	if allocs != 0 {
+		t.Errorf("expected no allocations, got %v", allocs)
+	}
+}
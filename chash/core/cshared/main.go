@@ -0,0 +1,101 @@
+//go:build cgo
+
+// Command cshared exposes the routing core as a C shared library
+//
+//	go build -buildmode=c-shared -o libdcore.so ./chash/core/cshared
+//
+// A table is loaded once, either from node names and virtual node counts or
+// from points and owners exported by chash.Ring.Table, and lookups return
+// owner indices into the caller's node list.
+package main
+
+/*
+#include <stddef.h>
+#include <stdint.h>
+*/
+import "C"
+
+import (
+	"slices"
+	"sync"
+	"unsafe"
+
+	"github.com/mohdrashid9678/dcore/chash/core"
+)
+
+var (
+	// mu guards table; lookups take the read lock
+	mu    sync.RWMutex
+	table core.Table
+)
+
+// dcore_build places vnodes[i] virtual nodes for each of the n names with
+// the default hash function and installs the result
+//
+//export dcore_build
+func dcore_build(names **C.char, vnodes *C.int, n C.size_t) {
+	cnames := unsafe.Slice(names, int(n))
+	counts := unsafe.Slice(vnodes, int(n))
+
+	nodes := make([]string, n)
+	perNode := make([]int, n)
+	for i := range nodes {
+		nodes[i] = C.GoString(cnames[i])
+		perNode[i] = int(counts[i])
+	}
+
+	t := core.Build(nodes, perNode)
+
+	mu.Lock()
+	table = t
+	mu.Unlock()
+}
+
+// dcore_load installs a table of n sorted points and their owner indices;
+// both arrays are copied
+//
+//export dcore_load
+func dcore_load(points *C.uint64_t, owners *C.uint32_t, n C.size_t) {
+	t := core.Table{
+		Points: slices.Clone(unsafe.Slice((*uint64)(unsafe.Pointer(points)), int(n))),
+		Owners: slices.Clone(unsafe.Slice((*uint32)(unsafe.Pointer(owners)), int(n))),
+	}
+
+	mu.Lock()
+	table = t
+	mu.Unlock()
+}
+
+// dcore_lookup returns the owner index of a key, or -1 if no table is loaded
+//
+//export dcore_lookup
+func dcore_lookup(key *C.char, keyLen C.size_t) C.int64_t {
+	k := unsafe.Slice((*byte)(unsafe.Pointer(key)), int(keyLen))
+
+	mu.RLock()
+	defer mu.RUnlock()
+
+	if table.Len() == 0 {
+		return -1
+	}
+	return C.int64_t(table.Lookup(core.Hash(k)))
+}
+
+// dcore_replicas writes up to n distinct owner indices for a key into out and
+// returns how many were written
+//
+//export dcore_replicas
+func dcore_replicas(key *C.char, keyLen C.size_t, out *C.uint32_t, n C.size_t) C.size_t {
+	k := unsafe.Slice((*byte)(unsafe.Pointer(key)), int(keyLen))
+	dst := unsafe.Slice((*uint32)(unsafe.Pointer(out)), int(n))
+
+	mu.RLock()
+	defer mu.RUnlock()
+
+	if table.Len() == 0 {
+		return 0
+	}
+	return C.size_t(len(table.Replicas(core.Hash(k), int(n), dst[:0])))
+}
+
+func main() {}
@@ -0,0 +1,4 @@
+package core
+
+// BuildWithHash exposes build to the external tests
+var BuildWithHash = build
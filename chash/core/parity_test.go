@@ -0,0 +1,43 @@
+package core_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mohdrashid9678/dcore/chash"
+	"github.com/mohdrashid9678/dcore/chash/core"
+)
+
+// weakHash has 256 outputs, so virtual nodes collide often
+func weakHash(key []byte) uint64 {
+	return core.Hash(key) % 256 << 56
+}
+
+func TestBuildMatchesRingOnCollisions(t *testing.T) {
+	config := chash.Config{Replicas: 60, HashFunc: func(key string) uint64 { return weakHash([]byte(key)) }}
+
+	// Insertion order must not decide which colliding virtual node wins
+	ring := chash.NewWithNodes(config, []string{"server3", "server1", "server2"})
+	if len(ring.Collisions()) == 0 {
+		t.Fatal("expected collisions with a weak hash function")
+	}
+
+	want, nodes := ring.Table()
+	for _, order := range [][]int{{0, 1, 2}, {2, 1, 0}, {1, 2, 0}} {
+		names := make([]string, len(order))
+		for i, j := range order {
+			names[i] = nodes[j]
+		}
+		got := core.BuildWithHash(names, []int{60, 60, 60}, weakHash)
+
+		if !reflect.DeepEqual(got.Points, want.Points) {
+			t.Fatalf("order %v: points differ from chash.Ring", order)
+		}
+		for i := range got.Owners {
+			if names[got.Owners[i]] != nodes[want.Owners[i]] {
+				t.Fatalf("order %v: position %d owned by %s, chash.Ring has %s",
+					order, got.Points[i], names[got.Owners[i]], nodes[want.Owners[i]])
+			}
+		}
+	}
+}
@@ -0,0 +1,62 @@
+//go:build js && wasm
+
+// Command wasm exposes the routing core to JavaScript
+//
+//	GOOS=js GOARCH=wasm go build -o dcore.wasm ./chash/core/wasm
+//
+// It registers three globals: dcoreBuild(names, vnodes) installs a table,
+// dcoreLookup(key) returns the owner index of a key (or -1 without a table),
+// and dcoreReplicas(key, n) returns up to n distinct owner indices.
+package main
+
+import (
+	"syscall/js"
+
+	"github.com/mohdrashid9678/dcore/chash/core"
+)
+
+// table is only touched from the JavaScript event loop
+var table core.Table
+
+func main() {
+	js.Global().Set("dcoreBuild", js.FuncOf(build))
+	js.Global().Set("dcoreLookup", js.FuncOf(lookup))
+	js.Global().Set("dcoreReplicas", js.FuncOf(replicas))
+
+	// Keep the exported functions alive
+	select {}
+}
+
+func build(this js.Value, args []js.Value) any {
+	names, counts := args[0], args[1]
+
+	nodes := make([]string, names.Length())
+	vnodes := make([]int, len(nodes))
+	for i := range nodes {
+		nodes[i] = names.Index(i).String()
+		vnodes[i] = counts.Index(i).Int()
+	}
+
+	table = core.Build(nodes, vnodes)
+	return nil
+}
+
+func lookup(this js.Value, args []js.Value) any {
+	if table.Len() == 0 {
+		return -1
+	}
+	return int(table.Lookup(core.Hash([]byte(args[0].String()))))
+}
+
+func replicas(this js.Value, args []js.Value) any {
+	if table.Len() == 0 {
+		return []any{}
+	}
+
+	owners := table.Replicas(core.Hash([]byte(args[0].String())), args[1].Int(), nil)
+	out := make([]any, len(owners))
+	for i, owner := range owners {
+		out[i] = int(owner)
+	}
+	return out
+}
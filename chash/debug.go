@@ -0,0 +1,62 @@
+package chash
+
+import "sort"
+
+// DebugState is a point-in-time view of a ring for debugging; the chashdebug
+// package serves it as JSON
+type DebugState struct {
+	Version      uint64      `json:"version"`
+	Replicas     int         `json:"replicas"`
+	VirtualNodes int         `json:"virtual_nodes"`
+	Lookups      uint64      `json:"lookups"`
+	Nodes        []DebugNode `json:"nodes"`
+}
+
+// DebugNode describes one physical node in a DebugState
+type DebugNode struct {
+	Name         string  `json:"name"`
+	Weight       float64 `json:"weight"`
+	VirtualNodes int     `json:"virtual_nodes"`
+	State        string  `json:"state"`
+	Role         string  `json:"role"`
+	Region       string  `json:"region,omitempty"`
+	Zone         string  `json:"zone,omitempty"`
+	Rack         string  `json:"rack,omitempty"`
+
+	// Ownership is the percentage of the hash space the node owns
+	Ownership float64 `json:"ownership_percent"`
+}
+
+// DebugState returns the current state of the ring, with nodes sorted by name
+// It walks every virtual node to measure ownership. Publish it with
+// expvar.Publish("ring", expvar.Func(func() any { return ring.DebugState() }))
+func (r *Ring) DebugState() DebugState {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	shares := ownership(r.pointsLocked())
+	state := DebugState{
+		Version:      r.topologyChanges,
+		Replicas:     r.replicas,
+		VirtualNodes: len(r.ring),
+		Lookups:      r.lookups.Load(),
+		Nodes:        make([]DebugNode, 0, len(r.nodeSet)),
+	}
+	for node, entry := range r.nodeSet {
+		state.Nodes = append(state.Nodes, DebugNode{
+			Name:         node,
+			Weight:       entry.weight,
+			VirtualNodes: entry.vnodes,
+			State:        entry.state.String(),
+			Role:         entry.role.String(),
+			Region:       entry.topology.Region,
+			Zone:         entry.topology.Zone,
+			Rack:         entry.topology.Rack,
+			Ownership:    shares[node] * 100,
+		})
+	}
+	sort.Slice(state.Nodes, func(i, j int) bool {
+		return state.Nodes[i].Name < state.Nodes[j].Name
+	})
+	return state
+}
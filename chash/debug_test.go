@@ -0,0 +1,41 @@
+package chash
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDebugState(t *testing.T) {
+	ring := New(Config{Replicas: 10})
+	if err := ring.AddNodeWithWeight("node2", 2); err != nil {
+		t.Fatalf("AddNodeWithWeight failed: %v", err)
+	}
+	if err := ring.AddNode("node1"); err != nil {
+		t.Fatalf("AddNode failed: %v", err)
+	}
+	if err := ring.SetTopology("node1", Topology{Zone: "a"}); err != nil {
+		t.Fatalf("SetTopology failed: %v", err)
+	}
+	if err := ring.SetNodeState("node2", NodeDraining); err != nil {
+		t.Fatalf("SetNodeState failed: %v", err)
+	}
+
+	state := ring.DebugState()
+	if state.Version != ring.Version() || state.VirtualNodes != 30 || state.Replicas != 10 {
+		t.Errorf("unexpected ring summary %+v", state)
+	}
+	if len(state.Nodes) != 2 || state.Nodes[0].Name != "node1" || state.Nodes[1].Name != "node2" {
+		t.Fatalf("expected nodes sorted by name, got %+v", state.Nodes)
+	}
+
+	n1, n2 := state.Nodes[0], state.Nodes[1]
+	if n1.Zone != "a" || n1.State != "active" || n1.VirtualNodes != 10 {
+		t.Errorf("unexpected node1 %+v", n1)
+	}
+	if n2.Weight != 2 || n2.State != "draining" || n2.VirtualNodes != 20 {
+		t.Errorf("unexpected node2 %+v", n2)
+	}
+	if total := n1.Ownership + n2.Ownership; math.Abs(total-100) > 1e-9 {
+		t.Errorf("expected ownership to sum to 100%%, got %v", total)
+	}
+}
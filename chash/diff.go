@@ -0,0 +1,28 @@
+package chash
+
+// RangeMove is a hash range whose primary owner differs between two ring states
+// It has the same shape as a Handoff from TakeoverPlan
+type RangeMove = Handoff
+
+// Diff returns the hash ranges whose owner changes when moving from this ring
+// to other, with From the owner here and To the owner in other
+// Both rings must use the same hash function; neither is modified
+func (r *Ring) Diff(other *Ring) []RangeMove {
+	var moves []RangeMove
+	for _, seg := range compareOwnership(r.points(), other.points()) {
+		moves = append(moves, RangeMove{Range: seg.rng, From: seg.ownerA, To: seg.ownerB})
+	}
+	return moves
+}
+
+// MovedFraction returns the share of the hash space covered by moves
+func MovedFraction(moves []RangeMove) float64 {
+	var fraction float64
+	for _, m := range moves {
+		fraction += m.Range.Fraction()
+	}
+	if fraction > 1 {
+		fraction = 1
+	}
+	return fraction
+}
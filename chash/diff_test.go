@@ -0,0 +1,67 @@
+package chash
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	current := NewWithNodes(Config{Replicas: 50}, []string{"server1", "server2", "server3"})
+	next := NewWithNodes(Config{Replicas: 50}, []string{"server1", "server2", "server3", "server4"})
+
+	moves := current.Diff(next)
+	if len(moves) == 0 {
+		t.Fatal("expected moves when adding a node")
+	}
+
+	// Brute force: every key that changed owner lies in a move with matching owners
+	moved := 0
+	for i := 0; i < 5000; i++ {
+		key := fmt.Sprintf("key%d", i)
+		hash := DefaultHashFunc(key)
+
+		before, _ := current.GetNode(key)
+		after, _ := next.GetNode(key)
+
+		var move *RangeMove
+		for j := range moves {
+			if moves[j].Range.Contains(hash) {
+				move = &moves[j]
+				break
+			}
+		}
+
+		if before == after {
+			if move != nil {
+				t.Errorf("key %s did not move but lies in %+v", key, *move)
+			}
+			continue
+		}
+
+		moved++
+		if move == nil || move.From != before || move.To != after {
+			t.Errorf("key %s moved %s -> %s, diff says %+v", key, before, after, move)
+		}
+	}
+
+	// About a quarter of the keys move to the new node
+	fraction := MovedFraction(moves)
+	if fraction < 0.1 || fraction > 0.4 {
+		t.Errorf("expected about 25%% of the keyspace to move, got %.2f", fraction)
+	}
+	if got := float64(moved) / 5000; got < fraction-0.05 || got > fraction+0.05 {
+		t.Errorf("moved keys %.2f disagree with moved fraction %.2f", got, fraction)
+	}
+}
+
+func TestDiffIdentical(t *testing.T) {
+	a := NewWithNodes(Config{Replicas: 20}, []string{"server1", "server2"})
+	b := NewWithNodes(Config{Replicas: 20}, []string{"server2", "server1"})
+
+	if moves := a.Diff(b); len(moves) != 0 {
+		t.Errorf("expected no moves between identical rings, got %d", len(moves))
+	}
+	if MovedFraction(nil) != 0 {
+		t.Error("expected zero fraction for no moves")
+	}
+}
@@ -0,0 +1,50 @@
+package chash
+
+// Disagreement is a hash range that two rings route to different nodes
+type Disagreement struct {
+	Range Range
+
+	// ClientOwner is the owner of the range in the client's ring ("" if empty)
+	ClientOwner string
+
+	// ServerOwner is the owner of the range in the server's ring ("" if empty)
+	ServerOwner string
+}
+
+// DivergenceReport describes how far a client's view of the ring has drifted
+// from the server's
+type DivergenceReport struct {
+	// Disagreements lists the maximal hash ranges routed differently
+	Disagreements []Disagreement
+
+	// MisrouteProbability is the probability that a uniformly hashed key is
+	// routed to a different primary node by the client than by the server
+	MisrouteProbability float64
+}
+
+// Exceeds returns true if the misroute probability is above threshold
+func (d DivergenceReport) Exceeds(threshold float64) bool {
+	return d.MisrouteProbability > threshold
+}
+
+// Divergence compares the primary ownership of two ring snapshots
+// Both rings must use the same hash function for the result to be meaningful
+func Divergence(client, server *Ring) DivergenceReport {
+	var report DivergenceReport
+
+	for _, seg := range compareOwnership(client.points(), server.points()) {
+		report.Disagreements = append(report.Disagreements, Disagreement{
+			Range:       seg.rng,
+			ClientOwner: seg.ownerA,
+			ServerOwner: seg.ownerB,
+		})
+		report.MisrouteProbability += seg.rng.Fraction()
+	}
+
+	// Guard against floating point drift past certainty
+	if report.MisrouteProbability > 1 {
+		report.MisrouteProbability = 1
+	}
+
+	return report
+}
@@ -0,0 +1,111 @@
+package chash
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestDivergenceIdenticalRings(t *testing.T) {
+	nodes := []string{"server1", "server2", "server3"}
+	client := NewWithNodes(Config{Replicas: 20}, nodes)
+	server := NewWithNodes(Config{Replicas: 20}, nodes)
+
+	report := Divergence(client, server)
+
+	if len(report.Disagreements) != 0 {
+		t.Errorf("expected no disagreements, got %d", len(report.Disagreements))
+	}
+	if report.MisrouteProbability != 0 {
+		t.Errorf("expected misroute probability 0, got %v", report.MisrouteProbability)
+	}
+}
+
+func TestDivergenceEmptyRing(t *testing.T) {
+	client := New(Config{Replicas: 5})
+	server := NewWithNodes(Config{Replicas: 5}, []string{"server1"})
+
+	report := Divergence(client, server)
+
+	if report.MisrouteProbability != 1 {
+		t.Errorf("expected misroute probability 1 against empty ring, got %v", report.MisrouteProbability)
+	}
+
+	if report := Divergence(New(Config{}), New(Config{})); len(report.Disagreements) != 0 {
+		t.Error("expected no disagreements between empty rings")
+	}
+}
+
+func TestDivergenceStaleClient(t *testing.T) {
+	client := NewWithNodes(Config{Replicas: 100}, []string{"server1", "server2", "server3", "server4"})
+	server := NewWithNodes(Config{Replicas: 100}, []string{"server1", "server2", "server3", "server4", "server5"})
+
+	report := Divergence(client, server)
+
+	// Roughly a fifth of the keyspace moved to server5
+	if math.Abs(report.MisrouteProbability-0.2) > 0.08 {
+		t.Errorf("expected misroute probability near 0.2, got %v", report.MisrouteProbability)
+	}
+
+	for _, d := range report.Disagreements {
+		if d.ServerOwner != "server5" {
+			t.Errorf("expected every moved range to belong to server5, got %s", d.ServerOwner)
+		}
+		if d.ClientOwner == d.ServerOwner {
+			t.Errorf("disagreement with equal owners: %+v", d)
+		}
+	}
+
+	if !report.Exceeds(0.1) {
+		t.Error("expected report to exceed 10% threshold")
+	}
+	if report.Exceeds(0.5) {
+		t.Error("expected report not to exceed 50% threshold")
+	}
+
+	// The reported probability matches sampled keys
+	misrouted := 0
+	samples := 20000
+	for i := 0; i < samples; i++ {
+		key := fmt.Sprintf("key%d", i)
+		a, _ := client.GetNode(key)
+		b, _ := server.GetNode(key)
+		if a != b {
+			misrouted++
+		}
+	}
+
+	sampled := float64(misrouted) / float64(samples)
+	if math.Abs(sampled-report.MisrouteProbability) > 0.02 {
+		t.Errorf("expected sampled rate %v close to reported %v", sampled, report.MisrouteProbability)
+	}
+}
+
+func TestDivergenceRangesContainMisroutedKeys(t *testing.T) {
+	client := NewWithNodes(Config{Replicas: 10}, []string{"server1", "server2", "server3"})
+	server := NewWithNodes(Config{Replicas: 10}, []string{"server1", "server3"})
+
+	report := Divergence(client, server)
+
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("key%d", i)
+		hash := DefaultHashFunc(key)
+		a, _ := client.GetNode(key)
+		b, _ := server.GetNode(key)
+
+		covered := false
+		for _, d := range report.Disagreements {
+			if d.Range.Contains(hash) {
+				covered = true
+				if d.ClientOwner != a || d.ServerOwner != b {
+					t.Fatalf("range %v owners (%s, %s) do not match key owners (%s, %s)",
+						d.Range, d.ClientOwner, d.ServerOwner, a, b)
+				}
+			}
+		}
+
+		if covered != (a != b) {
+			t.Fatalf("key %s misrouted=%v but covered=%v", key, a != b, covered)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package chash
+
+import "sync"
+
+// EventType identifies a topology change
+type EventType int
+
+const (
+	// NodeAdded is emitted after a node joins the ring
+	NodeAdded EventType = iota
+
+	// NodeRemoved is emitted after a node leaves the ring
+	NodeRemoved
+
+	// RingReloaded is emitted after the topology is replaced by a snapshot
+	RingReloaded
+
+	// NodeUpdated is emitted after a node's virtual nodes are added or removed
+	// in place, or its role changes
+	NodeUpdated
+)
+
+// String returns the event type name
+func (t EventType) String() string {
+	switch t {
+	case NodeAdded:
+		return "NodeAdded"
+	case NodeRemoved:
+		return "NodeRemoved"
+	case RingReloaded:
+		return "RingReloaded"
+	case NodeUpdated:
+		return "NodeUpdated"
+	default:
+		return "Unknown"
+	}
+}
+
+// TopologyEvent describes a change to the ring and the ranges it moved
+type TopologyEvent struct {
+	Type EventType
+
+	// Node is the added, removed or updated node; empty for batch changes and
+	// RingReloaded
+	Node string
+
+	// Nodes lists every node added or removed, including Node
+	Nodes []string
+
+	// Epoch is the ring's topology change count after the change
+	Epoch uint64
+
+	// Moves lists the hash ranges whose primary owner changed
+	Moves []Handoff
+}
+
+// subscriber queues events for one consumer so a slow reader never blocks
+// the ring or misses an event
+type subscriber struct {
+	mu     sync.Mutex
+	queue  []TopologyEvent
+	notify chan struct{}
+	done   chan struct{}
+	out    chan TopologyEvent
+}
+
+// Subscribe returns a channel of topology events and a function that ends the
+// subscription and closes the channel
+// Events are delivered in order and never dropped; they queue in memory until
+// read, so consumers must keep reading or unsubscribe
+func (r *Ring) Subscribe() (<-chan TopologyEvent, func()) {
+	s := &subscriber{
+		notify: make(chan struct{}, 1),
+		done:   make(chan struct{}),
+		out:    make(chan TopologyEvent),
+	}
+
+	r.mu.Lock()
+	id := r.nextSubscriberID
+	r.nextSubscriberID++
+	r.subscribers[id] = s
+	r.mu.Unlock()
+
+	go s.run()
+
+	var once sync.Once
+	return s.out, func() {
+		once.Do(func() {
+			r.mu.Lock()
+			delete(r.subscribers, id)
+			r.mu.Unlock()
+
+			close(s.done)
+		})
+	}
+}
+
+// run forwards queued events to the output channel until unsubscribed
+func (s *subscriber) run() {
+	defer close(s.out)
+
+	for {
+		s.mu.Lock()
+		if len(s.queue) == 0 {
+			s.mu.Unlock()
+			select {
+			case <-s.notify:
+				continue
+			case <-s.done:
+				return
+			}
+		}
+		event := s.queue[0]
+		s.queue = s.queue[1:]
+		s.mu.Unlock()
+
+		select {
+		case s.out <- event:
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// enqueue adds an event without blocking
+func (s *subscriber) enqueue(event TopologyEvent) {
+	s.mu.Lock()
+	s.queue = append(s.queue, event)
+	s.mu.Unlock()
+
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+// watchLocked captures the ring before a change if anyone is subscribed,
+// returning nil otherwise so unobserved changes cost nothing extra
+// Caller must hold r.mu for writing
+func (r *Ring) watchLocked() []ringPoint {
+	if len(r.subscribers) == 0 {
+		return nil
+	}
+	return r.pointsLocked()
+}
+
+// publishLocked emits an event describing the change since before
+// Every topology change ends here, so it also checks the ring's invariants
+// Caller must hold r.mu for writing
+func (r *Ring) publishLocked(typ EventType, nodes []string, before []ringPoint) {
+	r.checkInvariantsLocked()
+
+	if before == nil || len(r.subscribers) == 0 {
+		return
+	}
+
+	event := TopologyEvent{Type: typ, Nodes: nodes, Epoch: r.topologyChanges}
+	if len(nodes) == 1 {
+		event.Node = nodes[0]
+	}
+	for _, seg := range compareOwnership(before, r.pointsLocked()) {
+		event.Moves = append(event.Moves, Handoff{Range: seg.rng, From: seg.ownerA, To: seg.ownerB})
+	}
+
+	for _, s := range r.subscribers {
+		s.enqueue(event)
+	}
+}
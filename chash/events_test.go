@@ -0,0 +1,115 @@
+package chash
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// nextEvent reads one event or fails after a timeout
+func nextEvent(t *testing.T, events <-chan TopologyEvent) TopologyEvent {
+	t.Helper()
+
+	select {
+	case e := <-events:
+		return e
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for topology event")
+		return TopologyEvent{}
+	}
+}
+
+func TestSubscribeNodeEvents(t *testing.T) {
+	ring := NewWithNodes(Config{Replicas: 20}, []string{"server1", "server2"})
+
+	events, unsubscribe := ring.Subscribe()
+	defer unsubscribe()
+
+	ring.AddNode("server3")
+	added := nextEvent(t, events)
+	if added.Type != NodeAdded || added.Node != "server3" || len(added.Moves) == 0 {
+		t.Fatalf("unexpected event %+v", added)
+	}
+
+	// Every move hands a range to the new node, and keys in it now route there
+	for _, m := range added.Moves {
+		if m.To != "server3" || m.From == "server3" {
+			t.Errorf("unexpected move %+v", m)
+		}
+	}
+	for i := 0; i < 500; i++ {
+		key := fmt.Sprintf("key%d", i)
+		hash := DefaultHashFunc(key)
+		node, _ := ring.GetNode(key)
+		for _, m := range added.Moves {
+			if m.Range.Contains(hash) && node != "server3" {
+				t.Errorf("key %s in moved range routes to %s", key, node)
+			}
+		}
+	}
+
+	ring.RemoveNode("server1")
+	removed := nextEvent(t, events)
+	if removed.Type != NodeRemoved || removed.Node != "server1" || removed.Epoch <= added.Epoch {
+		t.Fatalf("unexpected event %+v", removed)
+	}
+	for _, m := range removed.Moves {
+		if m.From != "server1" {
+			t.Errorf("unexpected move %+v", m)
+		}
+	}
+}
+
+func TestSubscribeSlowReaderMissesNothing(t *testing.T) {
+	ring := New(Config{Replicas: 5})
+	events, unsubscribe := ring.Subscribe()
+	defer unsubscribe()
+
+	for i := 0; i < 50; i++ {
+		ring.AddNode(fmt.Sprintf("server%d", i))
+	}
+
+	for i := 0; i < 50; i++ {
+		e := nextEvent(t, events)
+		if e.Node != fmt.Sprintf("server%d", i) {
+			t.Fatalf("expected events in order, got %s at %d", e.Node, i)
+		}
+	}
+}
+
+func TestSubscribeReload(t *testing.T) {
+	ring := NewWithNodes(Config{Replicas: 20}, []string{"server1"})
+	events, unsubscribe := ring.Subscribe()
+	defer unsubscribe()
+
+	data, _ := newSnapshotRing().MarshalBinary()
+	ring.UnmarshalBinary(data)
+
+	e := nextEvent(t, events)
+	if e.Type != RingReloaded || len(e.Moves) == 0 {
+		t.Errorf("unexpected event %+v", e)
+	}
+}
+
+func TestUnsubscribe(t *testing.T) {
+	ring := New(Config{Replicas: 5})
+	events, unsubscribe := ring.Subscribe()
+
+	unsubscribe()
+	unsubscribe() // Safe to call twice
+
+	ring.AddNode("server1")
+
+	select {
+	case _, ok := <-events:
+		if ok {
+			t.Error("expected no events after unsubscribe")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected channel closed after unsubscribe")
+	}
+
+	if len(ring.subscribers) != 0 {
+		t.Error("expected subscriber removed")
+	}
+}
@@ -95,28 +95,19 @@ func loadDistributionDemo() {
 		ring.AddNode(server)
 	}
 
-	// Generate many keys and see distribution
+	// Route many synthetic keys and see distribution
 	numKeys := 10000
-	distribution := make(map[string]int)
-
-	for i := 0; i < numKeys; i++ {
-		key := fmt.Sprintf("key-%d", i)
-		server, err := ring.GetNode(key)
-		if err != nil {
-			continue
-		}
-		distribution[server]++
-	}
+	report := ring.AnalyzeDistribution(numKeys)
 
 	fmt.Printf("Distribution of %d keys across %d servers:\n", numKeys, numServers)
-	expectedPerServer := numKeys / numServers
-
-	for server, count := range distribution {
+	for _, server := range ring.Nodes() {
+		count := report.Counts[server]
 		percentage := float64(count) / float64(numKeys) * 100
-		deviation := float64(count-expectedPerServer) / float64(expectedPerServer) * 100
+		deviation := (report.Skew[server] - 1) * 100
 		fmt.Printf("  %s: %d keys (%.1f%%, %+.1f%% from expected)\n",
 			server, count, percentage, deviation)
 	}
+	fmt.Printf("Adding a server would move %.1f%% of keys\n", report.AddMovement*100)
 }
 
 func nodeChangesDemo() {
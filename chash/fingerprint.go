@@ -0,0 +1,38 @@
+package chash
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+)
+
+// fingerprintProbe is hashed with the ring's hash function so rings whose
+// custom hash functions were not given a HashName still fingerprint apart
+const fingerprintProbe = "dcore/chash fingerprint probe"
+
+// Fingerprint returns a stable 64-bit hash of the ring's routing inputs:
+// replicas, hashing mode, partitions, the hash function, hash tags, collision
+// resolution, the replication strategy, and every node with its weight,
+// virtual node override and topology labels, plus affinity groups
+// Rings that report the same fingerprint place every key the same way, so
+// processes can exchange fingerprints to detect divergence cheaply and fetch
+// a full snapshot only on a mismatch
+// Node states, roles and pins are process-local and left out, so lookups that
+// skip draining nodes or honor pins can still differ
+func (r *Ring) Fingerprint() uint64 {
+	// MarshalBinary is canonical: nodes and groups are sorted by name
+	encoded, _ := r.MarshalBinary()
+
+	var replication ReplicationStrategy = SimpleStrategy{}
+	if r.replication != nil {
+		replication = r.replication
+	}
+
+	h := fnv.New64a()
+	h.Write(encoded)
+	h.Write([]byte(r.hashName))
+	h.Write(binary.BigEndian.AppendUint64(nil, r.hashFunc(fingerprintProbe)))
+	fmt.Fprintf(h, "hashtags=%t collisions=%t replication=%T%+v",
+		r.hashTags, r.resolveCollisions, replication, replication)
+	return h.Sum64()
+}
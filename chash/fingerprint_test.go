@@ -0,0 +1,90 @@
+package chash
+
+import (
+	"hash/fnv"
+	"testing"
+)
+
+func TestFingerprintStable(t *testing.T) {
+	a := NewWithNodes(Config{Replicas: 20}, []string{"server1", "server2", "server3"})
+	b := NewWithNodes(Config{Replicas: 20}, []string{"server3", "server1", "server2"})
+
+	if a.Fingerprint() != b.Fingerprint() {
+		t.Error("expected the same fingerprint regardless of insertion order")
+	}
+	if a.Fingerprint() != a.Fingerprint() {
+		t.Error("expected repeated fingerprints to match")
+	}
+
+	// Node states are process-local
+	b.SetNodeState("server1", NodeDraining)
+	if a.Fingerprint() != b.Fingerprint() {
+		t.Error("expected node states to be left out")
+	}
+
+	// The default replication strategy is SimpleStrategy
+	c := NewWithNodes(Config{Replicas: 20, Replication: SimpleStrategy{}}, []string{"server1", "server2", "server3"})
+	if a.Fingerprint() != c.Fingerprint() {
+		t.Error("expected an explicit SimpleStrategy to match the default")
+	}
+}
+
+func TestFingerprintDetectsDivergence(t *testing.T) {
+	base := func() *Ring {
+		return NewWithNodes(Config{Replicas: 20}, []string{"server1", "server2"})
+	}
+	want := base().Fingerprint()
+
+	changes := map[string]func() *Ring{
+		"node added": func() *Ring {
+			r := base()
+			r.AddNode("server3")
+			return r
+		},
+		"weight changed": func() *Ring {
+			r := base()
+			r.UpdateWeight("server1", 2)
+			return r
+		},
+		"virtual nodes overridden": func() *Ring {
+			r := base()
+			r.SetVirtualNodes("server1", 5)
+			return r
+		},
+		"topology labelled": func() *Ring {
+			r := base()
+			r.SetTopology("server1", Topology{Zone: "a"})
+			return r
+		},
+		"replicas changed": func() *Ring {
+			return NewWithNodes(Config{Replicas: 30}, []string{"server1", "server2"})
+		},
+		"hash function changed": func() *Ring {
+			hashFunc := func(key string) uint64 {
+				h := fnv.New64a()
+				h.Write([]byte(key))
+				return h.Sum64()
+			}
+			return NewWithNodes(Config{Replicas: 20, HashFunc: hashFunc}, []string{"server1", "server2"})
+		},
+		"hash tags enabled": func() *Ring {
+			return NewWithNodes(Config{Replicas: 20, HashTags: true}, []string{"server1", "server2"})
+		},
+		"collisions resolved": func() *Ring {
+			return NewWithNodes(Config{Replicas: 20, ResolveCollisions: true}, []string{"server1", "server2"})
+		},
+		"replication strategy changed": func() *Ring {
+			config := Config{Replicas: 20, Replication: TopologyAwareStrategy{Spread: SpreadZone}}
+			return NewWithNodes(config, []string{"server1", "server2"})
+		},
+		"hash name changed": func() *Ring {
+			return NewWithNodes(Config{Replicas: 20, HashFunc: DefaultHashFunc, HashName: "custom"}, []string{"server1", "server2"})
+		},
+	}
+
+	for name, change := range changes {
+		if change().Fingerprint() == want {
+			t.Errorf("%s: expected a different fingerprint", name)
+		}
+	}
+}
@@ -0,0 +1,311 @@
+package chash
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+// Built-in hash function names accepted by Config.HashName
+const (
+	HashSHA256   = "sha256"
+	HashXXHash64 = "xxhash64"
+	HashFNV1a    = "fnv1a"
+	HashMurmur3  = "murmur3"
+	HashSipHash  = "siphash"
+)
+
+// builtinHashes maps Config.HashName values to constructors of their
+// implementations; only SipHash uses the seed
+var builtinHashes = map[string]func(seed [16]byte) (HashFunc, HashFuncBytes){
+	HashSHA256: func([16]byte) (HashFunc, HashFuncBytes) {
+		return DefaultHashFunc, DefaultHashFuncBytes
+	},
+	HashXXHash64: func([16]byte) (HashFunc, HashFuncBytes) {
+		return XXHash64, xxHash64[[]byte]
+	},
+	HashFNV1a: func([16]byte) (HashFunc, HashFuncBytes) {
+		return FNV1a, fnv1a[[]byte]
+	},
+	HashMurmur3: func([16]byte) (HashFunc, HashFuncBytes) {
+		return Murmur3, murmur3[[]byte]
+	},
+	HashSipHash: func(seed [16]byte) (HashFunc, HashFuncBytes) {
+		k0, k1 := u64(seed[:8]), u64(seed[8:])
+		return func(key string) uint64 { return sipHash(k0, k1, key) },
+			func(key []byte) uint64 { return sipHash(k0, k1, key) }
+	},
+}
+
+// builtinHash returns the name and hash functions of the built-in selected by
+// name and seed; an empty name selects SipHash if seed is set, SHA-256 otherwise
+// It panics on an unknown name: a misspelt hash function would silently
+// route every key differently from the rest of the cluster
+func builtinHash(name string, seed [16]byte) (string, HashFunc, HashFuncBytes) {
+	if name == "" {
+		name = HashSHA256
+		if seed != ([16]byte{}) {
+			name = HashSipHash
+		}
+	}
+
+	newHash, ok := builtinHashes[name]
+	if !ok {
+		panic(fmt.Sprintf("chash: unknown hash function %q", name))
+	}
+	hashFunc, hashFuncBytes := newHash(seed)
+	return name, hashFunc, hashFuncBytes
+}
+
+// XXHash64 is the 64-bit xxHash of key with seed 0
+// It is much faster than SHA-256 and well distributed, but not resistant to
+// keys chosen by an attacker
+func XXHash64(key string) uint64 {
+	return xxHash64(key)
+}
+
+// FNV1a is the 64-bit FNV-1a hash of key
+// It is simple to reproduce in other languages but the least well mixed
+// built-in; prefer XXHash64 unless a client already routes with FNV-1a
+func FNV1a(key string) uint64 {
+	return fnv1a(key)
+}
+
+// Murmur3 is the first 64 bits of MurmurHash3 x64_128 of key with seed 0, as
+// many client libraries compute it
+func Murmur3(key string) uint64 {
+	return murmur3(key)
+}
+
+// SipHash returns SipHash-2-4 keyed with seed
+// Unlike the other built-ins it resists hash flooding: without the seed an
+// attacker cannot choose keys that all land on one node. Processes that must
+// route identically share the seed, so keep it as secret as the topology
+func SipHash(seed [16]byte) HashFunc {
+	hashFunc, _ := builtinHashes[HashSipHash](seed)
+	return hashFunc
+}
+
+// byteString is the key types the hash functions accept without conversion
+type byteString interface{ ~string | ~[]byte }
+
+// u64 and u32 read little-endian words from the start of b
+func u64[T byteString](b T) uint64 {
+	_ = b[7]
+	return uint64(b[0]) | uint64(b[1])<<8 | uint64(b[2])<<16 | uint64(b[3])<<24 |
+		uint64(b[4])<<32 | uint64(b[5])<<40 | uint64(b[6])<<48 | uint64(b[7])<<56
+}
+
+func u32[T byteString](b T) uint64 {
+	_ = b[3]
+	return uint64(b[0]) | uint64(b[1])<<8 | uint64(b[2])<<16 | uint64(b[3])<<24
+}
+
+const (
+	xxPrime1 uint64 = 11400714785074694791
+	xxPrime2 uint64 = 14029467366897019727
+	xxPrime3 uint64 = 1609587929392839161
+	xxPrime4 uint64 = 9650029242287828579
+	xxPrime5 uint64 = 2870177450012600261
+)
+
+func xxRound(acc, input uint64) uint64 {
+	acc += input * xxPrime2
+	acc = bits.RotateLeft64(acc, 31)
+	return acc * xxPrime1
+}
+
+func xxMerge(acc, val uint64) uint64 {
+	acc ^= xxRound(0, val)
+	return acc*xxPrime1 + xxPrime4
+}
+
+func xxHash64[T byteString](b T) uint64 {
+	n := len(b)
+	var h uint64
+
+	if n >= 32 {
+		// The lane seeds wrap: xxPrime1 + xxPrime2 and -xxPrime1 mod 2^64
+		v1 := uint64(6983438078262162902)
+		v2 := xxPrime2
+		v3 := uint64(0)
+		v4 := uint64(7046029288634856825)
+		for ; len(b) >= 32; b = b[32:] {
+			v1 = xxRound(v1, u64(b[0:8]))
+			v2 = xxRound(v2, u64(b[8:16]))
+			v3 = xxRound(v3, u64(b[16:24]))
+			v4 = xxRound(v4, u64(b[24:32]))
+		}
+		h = bits.RotateLeft64(v1, 1) + bits.RotateLeft64(v2, 7) +
+			bits.RotateLeft64(v3, 12) + bits.RotateLeft64(v4, 18)
+		h = xxMerge(h, v1)
+		h = xxMerge(h, v2)
+		h = xxMerge(h, v3)
+		h = xxMerge(h, v4)
+	} else {
+		h = xxPrime5
+	}
+
+	h += uint64(n)
+
+	for ; len(b) >= 8; b = b[8:] {
+		h ^= xxRound(0, u64(b[:8]))
+		h = bits.RotateLeft64(h, 27)*xxPrime1 + xxPrime4
+	}
+	if len(b) >= 4 {
+		h ^= u32(b[:4]) * xxPrime1
+		h = bits.RotateLeft64(h, 23)*xxPrime2 + xxPrime3
+		b = b[4:]
+	}
+	for ; len(b) > 0; b = b[1:] {
+		h ^= uint64(b[0]) * xxPrime5
+		h = bits.RotateLeft64(h, 11) * xxPrime1
+	}
+
+	h ^= h >> 33
+	h *= xxPrime2
+	h ^= h >> 29
+	h *= xxPrime3
+	h ^= h >> 32
+	return h
+}
+
+func fnv1a[T byteString](b T) uint64 {
+	const (
+		offset64 = 14695981039346656037
+		prime64  = 1099511628211
+	)
+
+	h := uint64(offset64)
+	for i := 0; i < len(b); i++ {
+		h ^= uint64(b[i])
+		h *= prime64
+	}
+	return h
+}
+
+func murmur3[T byteString](b T) uint64 {
+	h1, _ := murmur3x128(b)
+	return h1
+}
+
+// murmur3x128 is MurmurHash3 x64_128 with seed 0, as its two 64-bit halves
+func murmur3x128[T byteString](b T) (uint64, uint64) {
+	const (
+		c1 uint64 = 0x87c37b91114253d5
+		c2 uint64 = 0x4cf5ad432745937f
+	)
+
+	n := len(b)
+	var h1, h2 uint64
+
+	for ; len(b) >= 16; b = b[16:] {
+		k1, k2 := u64(b[0:8]), u64(b[8:16])
+
+		k1 *= c1
+		k1 = bits.RotateLeft64(k1, 31)
+		k1 *= c2
+		h1 ^= k1
+		h1 = bits.RotateLeft64(h1, 27)
+		h1 += h2
+		h1 = h1*5 + 0x52dce729
+
+		k2 *= c2
+		k2 = bits.RotateLeft64(k2, 33)
+		k2 *= c1
+		h2 ^= k2
+		h2 = bits.RotateLeft64(h2, 31)
+		h2 += h1
+		h2 = h2*5 + 0x38495ab5
+	}
+
+	// Tail: bytes 8-15 feed k2 and bytes 0-7 feed k1
+	var k1, k2 uint64
+	for i := len(b) - 1; i >= 8; i-- {
+		k2 ^= uint64(b[i]) << (8 * (i - 8))
+	}
+	if len(b) > 8 {
+		k2 *= c2
+		k2 = bits.RotateLeft64(k2, 33)
+		k2 *= c1
+		h2 ^= k2
+	}
+	for i := min(len(b), 8) - 1; i >= 0; i-- {
+		k1 ^= uint64(b[i]) << (8 * i)
+	}
+	if len(b) > 0 {
+		k1 *= c1
+		k1 = bits.RotateLeft64(k1, 31)
+		k1 *= c2
+		h1 ^= k1
+	}
+
+	h1 ^= uint64(n)
+	h2 ^= uint64(n)
+	h1 += h2
+	h2 += h1
+	h1 = fmix64(h1)
+	h2 = fmix64(h2)
+	h1 += h2
+	h2 += h1
+	return h1, h2
+}
+
+func sipHash[T byteString](k0, k1 uint64, b T) uint64 {
+	v0 := k0 ^ 0x736f6d6570736575
+	v1 := k1 ^ 0x646f72616e646f6d
+	v2 := k0 ^ 0x6c7967656e657261
+	v3 := k1 ^ 0x7465646279746573
+
+	round := func() {
+		v0 += v1
+		v1 = bits.RotateLeft64(v1, 13)
+		v1 ^= v0
+		v0 = bits.RotateLeft64(v0, 32)
+		v2 += v3
+		v3 = bits.RotateLeft64(v3, 16)
+		v3 ^= v2
+		v0 += v3
+		v3 = bits.RotateLeft64(v3, 21)
+		v3 ^= v0
+		v2 += v1
+		v1 = bits.RotateLeft64(v1, 17)
+		v1 ^= v2
+		v2 = bits.RotateLeft64(v2, 32)
+	}
+
+	n := len(b)
+	for ; len(b) >= 8; b = b[8:] {
+		m := u64(b[:8])
+		v3 ^= m
+		round()
+		round()
+		v0 ^= m
+	}
+
+	// The last word holds the remaining bytes and the length in its top byte
+	m := uint64(n) << 56
+	for i := len(b) - 1; i >= 0; i-- {
+		m |= uint64(b[i]) << (8 * i)
+	}
+	v3 ^= m
+	round()
+	round()
+	v0 ^= m
+
+	v2 ^= 0xff
+	round()
+	round()
+	round()
+	round()
+	return v0 ^ v1 ^ v2 ^ v3
+}
+
+// fmix64 is MurmurHash3's finalizer
+func fmix64(k uint64) uint64 {
+	k ^= k >> 33
+	k *= 0xff51afd7ed558ccd
+	k ^= k >> 33
+	k *= 0xc4ceb9fe1a85ec53
+	k ^= k >> 33
+	return k
+}
@@ -0,0 +1,153 @@
+package chash
+
+import (
+	"fmt"
+	"hash/fnv"
+	"testing"
+)
+
+const quickFox = "The quick brown fox jumps over the lazy dog"
+
+// testSeed is the key of the SipHash reference vectors: bytes 0 through 15
+var testSeed = [16]byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+func TestBuiltinHashVectors(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   HashFunc
+		key  string
+		want uint64
+	}{
+		{"xxhash64 empty", XXHash64, "", 0xef46db3751d8e999},
+		{"xxhash64 short", XXHash64, "a", 0xd24ec4f1a98c6e5b},
+		{"xxhash64 abc", XXHash64, "abc", 0x44bc2cf5ad770999},
+		{"xxhash64 long", XXHash64, quickFox, 0x0b242d361fda71bc},
+		{"fnv1a empty", FNV1a, "", 0xcbf29ce484222325},
+		{"fnv1a short", FNV1a, "a", 0xaf63dc4c8601ec8c},
+		{"murmur3 empty", Murmur3, "", 0},
+		{"murmur3 hello", Murmur3, "hello", 0xcbd8a7b341bd9b02},
+		{"murmur3 long", Murmur3, quickFox, 0xe34bbc7bbc071b6c},
+	}
+
+	for _, tt := range tests {
+		if got := tt.fn(tt.key); got != tt.want {
+			t.Errorf("%s: expected %#x, got %#x", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestSipHashVectors(t *testing.T) {
+	// From the SipHash paper: messages of bytes 0, 1, 2, ... of each length
+	want := map[int]uint64{
+		0:  0x726fdb47dd0e0e31,
+		7:  0xab0200f58b01d137,
+		8:  0x93f5f5799a932462,
+		15: 0xa129ca6149be45e5,
+	}
+
+	fn := SipHash(testSeed)
+	for n, w := range want {
+		msg := make([]byte, n)
+		for i := range msg {
+			msg[i] = byte(i)
+		}
+		if got := fn(string(msg)); got != w {
+			t.Errorf("length %d: expected %#x, got %#x", n, w, got)
+		}
+	}
+}
+
+func TestConfigSeed(t *testing.T) {
+	nodes := []string{"server1", "server2", "server3"}
+	seed := [16]byte{42}
+
+	a := NewWithNodes(Config{Replicas: 20, Seed: seed}, nodes)
+	b := NewWithNodes(Config{Replicas: 20, Seed: seed}, nodes)
+	other := NewWithNodes(Config{Replicas: 20, Seed: [16]byte{43}}, nodes)
+
+	if a.hashName != HashSipHash {
+		t.Errorf("expected a seed to select SipHash, got %q", a.hashName)
+	}
+	if a.Fingerprint() != b.Fingerprint() {
+		t.Error("expected rings sharing a seed to route identically")
+	}
+	if a.Fingerprint() == other.Fingerprint() {
+		t.Error("expected rings with different seeds to fingerprint apart")
+	}
+
+	want, _ := a.GetNodeForHash(SipHash(seed)("user:123"))
+	if got, _ := a.GetNode("user:123"); got != want {
+		t.Error("expected keys hashed with the seeded SipHash")
+	}
+
+	// An explicit HashName wins over the seed
+	if ring := New(Config{Seed: seed, HashName: HashXXHash64}); ring.hashName != HashXXHash64 {
+		t.Errorf("expected explicit hash name to be kept, got %q", ring.hashName)
+	}
+}
+
+func TestFNV1aMatchesStdlib(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		h := fnv.New64a()
+		h.Write([]byte(key))
+		if got := FNV1a(key); got != h.Sum64() {
+			t.Fatalf("%s: expected %#x, got %#x", key, h.Sum64(), got)
+		}
+	}
+}
+
+func TestBuiltinHashBytesAgree(t *testing.T) {
+	// Cover every tail length and both block loops
+	key := ""
+	for i := 0; i < 80; i++ {
+		for name, newHash := range builtinHashes {
+			hashFunc, hashFuncBytes := newHash(testSeed)
+			if got, want := hashFuncBytes([]byte(key)), hashFunc(key); got != want {
+				t.Fatalf("%s(%q): bytes %#x, string %#x", name, key, got, want)
+			}
+		}
+		key += string(rune('a' + i%26))
+	}
+}
+
+func TestConfigHashName(t *testing.T) {
+	for _, name := range []string{HashSHA256, HashXXHash64, HashFNV1a, HashMurmur3, HashSipHash} {
+		ring := NewWithNodes(Config{Replicas: 20, HashName: name}, []string{"server1", "server2", "server3"})
+		_, fn, _ := builtinHash(name, [16]byte{})
+
+		want, _ := ring.GetNodeForHash(fn("user:123"))
+		if got, _ := ring.GetNode("user:123"); got != want {
+			t.Errorf("%s: expected keys hashed with the named function", name)
+		}
+		if got, _ := ring.GetNodeBytes([]byte("user:123")); got != want {
+			t.Errorf("%s: expected byte keys hashed with the named function", name)
+		}
+	}
+
+	// Named rings fingerprint apart
+	a := NewWithNodes(Config{Replicas: 20, HashName: HashXXHash64}, []string{"server1"})
+	b := NewWithNodes(Config{Replicas: 20, HashName: HashMurmur3}, []string{"server1"})
+	if a.Fingerprint() == b.Fingerprint() {
+		t.Error("expected different fingerprints for different hash functions")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic for an unknown hash function")
+		}
+	}()
+	New(Config{HashName: "md4"})
+}
+
+func BenchmarkBuiltinHashes(b *testing.B) {
+	key := "user:1234567890:profile"
+	for name, newHash := range builtinHashes {
+		hashFunc, _ := newHash(testSeed)
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				hashFunc(key)
+			}
+		})
+	}
+}
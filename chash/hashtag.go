@@ -0,0 +1,35 @@
+package chash
+
+import (
+	"bytes"
+	"strings"
+)
+
+// HashTag returns the hash tag of key: the text between the first '{' and the
+// first '}' after it, as in Redis Cluster
+// Keys without a tag, or with an empty one like "a{}b", have no tag and are
+// hashed whole
+func HashTag(key string) (string, bool) {
+	open := strings.IndexByte(key, '{')
+	if open < 0 {
+		return "", false
+	}
+	end := strings.IndexByte(key[open+1:], '}')
+	if end <= 0 {
+		return "", false
+	}
+	return key[open+1 : open+1+end], true
+}
+
+// hashTagBytes is HashTag for a byte-slice key; it returns a sub-slice of key
+func hashTagBytes(key []byte) ([]byte, bool) {
+	open := bytes.IndexByte(key, '{')
+	if open < 0 {
+		return nil, false
+	}
+	end := bytes.IndexByte(key[open+1:], '}')
+	if end <= 0 {
+		return nil, false
+	}
+	return key[open+1 : open+1+end], true
+}
@@ -0,0 +1,78 @@
+package chash
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestHashTag(t *testing.T) {
+	tests := []struct {
+		key string
+		tag string
+		ok  bool
+	}{
+		{"user:{42}:profile", "42", true},
+		{"{user1000}.following", "user1000", true},
+		{"foo{}{bar}", "", false},
+		{"foo{{bar}}zap", "{bar", true},
+		{"foo{bar}{zap}", "bar", true},
+		{"no-tag", "", false},
+		{"open{only", "", false},
+		{"close}{first", "", false},
+	}
+
+	for _, tt := range tests {
+		tag, ok := HashTag(tt.key)
+		if tag != tt.tag || ok != tt.ok {
+			t.Errorf("HashTag(%q) = %q, %v; expected %q, %v", tt.key, tag, ok, tt.tag, tt.ok)
+		}
+
+		btag, bok := hashTagBytes([]byte(tt.key))
+		if string(btag) != tt.tag || bok != tt.ok {
+			t.Errorf("hashTagBytes(%q) = %q, %v; expected %q, %v", tt.key, btag, bok, tt.tag, tt.ok)
+		}
+	}
+}
+
+func TestHashTagsColocateKeys(t *testing.T) {
+	nodes := []string{"server1", "server2", "server3", "server4"}
+	ring := NewWithNodes(Config{Replicas: 20, HashTags: true}, nodes)
+
+	for i := 0; i < 100; i++ {
+		tag := fmt.Sprintf("%d", i)
+		want, _ := ring.GetNode(tag)
+
+		for _, key := range []string{"user:{" + tag + "}:profile", "user:{" + tag + "}:cart"} {
+			if node, _ := ring.GetNode(key); node != want {
+				t.Errorf("%s: expected %s, got %s", key, want, node)
+			}
+			if node, _ := ring.GetNodeBytes([]byte(key)); node != want {
+				t.Errorf("%s: expected %s for byte key, got %s", key, want, node)
+			}
+		}
+	}
+
+	// Without the option tags are ordinary characters
+	plain := NewWithNodes(Config{Replicas: 20}, nodes)
+	spread := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		node, _ := plain.GetNode(fmt.Sprintf("user:{42}:item%d", i))
+		spread[node] = true
+	}
+	if len(spread) == 1 {
+		t.Error("expected tagged keys to spread when hash tags are disabled")
+	}
+}
+
+func TestAffinityGroupBeforeHashTag(t *testing.T) {
+	ring := NewWithNodes(Config{Replicas: 20, HashTags: true}, []string{"server1", "server2", "server3"})
+	ring.AddAffinityGroup("tenant-a", "tenant-a:")
+
+	want, _ := ring.GetNode("tenant-a")
+	for i := 0; i < 50; i++ {
+		key := fmt.Sprintf("tenant-a:{%d}", i)
+		if node, _ := ring.GetNode(key); node != want {
+			t.Errorf("%s: expected the group owner %s, got %s", key, want, node)
+		}
+	}
+}
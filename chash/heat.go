@@ -0,0 +1,255 @@
+package chash
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mohdrashid9678/dcore/clock"
+)
+
+// HeatConfig holds configuration options for creating a new HeatTracker
+type HeatConfig struct {
+	// HalfLife is how long it takes a range's recorded heat to halve
+	// Default: 1 minute
+	HalfLife time.Duration
+
+	// SplitFactor flags ranges at least this many times hotter than the
+	// average range as split candidates
+	// Default: 4
+	SplitFactor float64
+
+	// Tolerance is how far a node's share of the heat may stray from its
+	// share of the weight before a virtual node count is suggested
+	// Default: 0.2 (20%)
+	Tolerance float64
+
+	// Clock is the time source
+	// Default: the wall clock
+	Clock clock.Clock
+}
+
+// Heat is an exponentially decayed count of requests and bytes
+type Heat struct {
+	Requests float64
+	Bytes    float64
+}
+
+// RangeHeat is the heat of the hash range ending at one virtual node
+type RangeHeat struct {
+	Range Range
+	Node  string
+	Heat  Heat
+}
+
+// HeatReport summarises where load lands on the ring
+type HeatReport struct {
+	// Ranges lists every range that received load, hottest first
+	Ranges []RangeHeat
+
+	// Nodes sums the heat of each node's ranges
+	Nodes map[string]Heat
+
+	// Split lists the ranges hot enough that they should be split, such as by
+	// adding a virtual node inside them, hottest first
+	Split []RangeHeat
+
+	// VirtualNodes suggests new virtual node counts for nodes whose share of
+	// the requests strays from their share of the weight; apply them with
+	// SetVirtualNodes
+	VirtualNodes map[string]int
+}
+
+// HeatTracker records the load each owned range of a ring receives and
+// recommends how to rebalance it
+// Heat decays with HalfLife, so the report follows shifting hot spots
+type HeatTracker struct {
+	ring   *Ring
+	config HeatConfig
+
+	// mu protects ranges
+	mu sync.Mutex
+
+	// ranges maps the position of a virtual node to the heat of the range
+	// ending there; positions removed from the ring are dropped on Report
+	ranges map[uint64]*decayingHeat
+}
+
+// decayingHeat is a Heat last brought up to date at a point in time
+type decayingHeat struct {
+	heat Heat
+	at   time.Time
+}
+
+// NewHeatTracker creates a tracker for the ranges of ring
+func NewHeatTracker(ring *Ring, config HeatConfig) *HeatTracker {
+	if config.HalfLife <= 0 {
+		config.HalfLife = time.Minute
+	}
+	if config.SplitFactor <= 0 {
+		config.SplitFactor = 4
+	}
+	if config.Tolerance <= 0 {
+		config.Tolerance = 0.2
+	}
+	config.Clock = clock.OrReal(config.Clock)
+
+	return &HeatTracker{
+		ring:   ring,
+		config: config,
+		ranges: make(map[uint64]*decayingHeat),
+	}
+}
+
+// Record adds one request of size bytes for key to the heat of its range
+// Keys are hashed like GetNode; records on an empty ring are dropped
+func (t *HeatTracker) Record(key string, bytes int) {
+	if key == "" {
+		return
+	}
+
+	t.ring.mu.RLock()
+	if len(t.ring.ring) == 0 {
+		t.ring.mu.RUnlock()
+		return
+	}
+	position := t.ring.ring[t.ring.searchLocked(t.ring.hashKeyLocked(key))]
+	t.ring.mu.RUnlock()
+
+	now := t.config.Clock.Now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	d, exists := t.ranges[position]
+	if !exists {
+		d = &decayingHeat{at: now}
+		t.ranges[position] = d
+	}
+	d.decay(now, t.config.HalfLife)
+	d.heat.Requests++
+	d.heat.Bytes += float64(bytes)
+}
+
+// decay brings the heat forward to now
+func (d *decayingHeat) decay(now time.Time, halfLife time.Duration) {
+	if elapsed := now.Sub(d.at); elapsed > 0 {
+		factor := math.Exp2(-elapsed.Seconds() / halfLife.Seconds())
+		d.heat.Requests *= factor
+		d.heat.Bytes *= factor
+	}
+	d.at = now
+}
+
+// Reset forgets all recorded heat
+func (t *HeatTracker) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	clear(t.ranges)
+}
+
+// Report returns the current heat of the ring's ranges and the resulting
+// recommendations
+func (t *HeatTracker) Report() HeatReport {
+	now := t.config.Clock.Now()
+
+	t.ring.mu.RLock()
+	defer t.ring.mu.RUnlock()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	report := HeatReport{
+		Nodes:        make(map[string]Heat),
+		VirtualNodes: make(map[string]int),
+	}
+
+	// Walk the ring so ranges follow the current topology
+	live := make(map[uint64]struct{}, len(t.ranges))
+	var total Heat
+	n := len(t.ring.ring)
+	for i, position := range t.ring.ring {
+		d, exists := t.ranges[position]
+		if !exists {
+			continue
+		}
+		if _, seen := live[position]; seen {
+			continue // A colliding position only owns keys once
+		}
+		live[position] = struct{}{}
+		d.decay(now, t.config.HalfLife)
+
+		node := t.ring.ownerLocked(i)
+		rh := RangeHeat{
+			Range: Range{Start: t.ring.ring[(i+n-1)%n], End: position},
+			Node:  node,
+			Heat:  d.heat,
+		}
+		report.Ranges = append(report.Ranges, rh)
+
+		nh := report.Nodes[node]
+		nh.Requests += d.heat.Requests
+		nh.Bytes += d.heat.Bytes
+		report.Nodes[node] = nh
+		total.Requests += d.heat.Requests
+		total.Bytes += d.heat.Bytes
+	}
+
+	// Ranges of removed virtual nodes no longer exist
+	for position := range t.ranges {
+		if _, ok := live[position]; !ok {
+			delete(t.ranges, position)
+		}
+	}
+
+	sort.Slice(report.Ranges, func(i, j int) bool {
+		return report.Ranges[i].Heat.Requests > report.Ranges[j].Heat.Requests
+	})
+
+	if total.Requests == 0 {
+		return report
+	}
+
+	// Ranges the ring has but that saw no load still count toward the average
+	mean := total.Requests / float64(n)
+	for _, rh := range report.Ranges {
+		if rh.Heat.Requests < t.config.SplitFactor*mean {
+			break
+		}
+		report.Split = append(report.Split, rh)
+	}
+
+	t.suggestVirtualNodesLocked(report, total.Requests)
+	return report
+}
+
+// suggestVirtualNodesLocked scales the virtual node count of every node whose
+// share of the requests strays from its share of the weight by more than the
+// tolerance; the factor is capped at 2 either way so repeated tuning converges
+// rather than oscillates
+// Caller must hold t.mu and t.ring.mu
+func (t *HeatTracker) suggestVirtualNodesLocked(report HeatReport, total float64) {
+	var weights float64
+	for _, entry := range t.ring.nodeSet {
+		weights += entry.weight
+	}
+
+	for node, entry := range t.ring.nodeSet {
+		fair := total * entry.weight / weights
+		actual := report.Nodes[node].Requests
+
+		if math.Abs(actual-fair) <= t.config.Tolerance*fair {
+			continue
+		}
+
+		factor := 2.0
+		if actual > 0 {
+			factor = min(max(fair/actual, 0.5), 2)
+		}
+		if suggested := max(int(math.Round(float64(entry.vnodes)*factor)), 1); suggested != entry.vnodes {
+			report.VirtualNodes[node] = suggested
+		}
+	}
+}
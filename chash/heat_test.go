@@ -0,0 +1,99 @@
+package chash
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/mohdrashid9678/dcore/clock"
+)
+
+func TestHeatTrackerReport(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	ring := NewWithNodes(Config{Replicas: 10}, []string{"server1", "server2", "server3"})
+	tracker := NewHeatTracker(ring, HeatConfig{Clock: clk})
+
+	for i := 0; i < 3000; i++ {
+		tracker.Record(fmt.Sprintf("key-%d", i), 100)
+	}
+	for i := 0; i < 3000; i++ {
+		tracker.Record("hot", 10)
+	}
+
+	report := tracker.Report()
+
+	hottest := report.Ranges[0]
+	if !hottest.Range.Contains(ring.hashFunc("hot")) {
+		t.Errorf("expected the hot key's range first, got %v", hottest.Range)
+	}
+	if owner, _ := ring.GetNode("hot"); hottest.Node != owner {
+		t.Errorf("expected range owned by %s, got %s", owner, hottest.Node)
+	}
+	if len(report.Split) == 0 || report.Split[0].Range != hottest.Range {
+		t.Errorf("expected the hot range to be a split candidate, got %+v", report.Split)
+	}
+
+	var requests, bytes float64
+	for _, h := range report.Nodes {
+		requests += h.Requests
+		bytes += h.Bytes
+	}
+	if requests != 6000 || bytes != 330000 {
+		t.Errorf("expected node heat to add up, got %v requests and %v bytes", requests, bytes)
+	}
+
+	// The hot node should shed virtual nodes
+	hot := hottest.Node
+	vnodes, _ := ring.VirtualNodes(hot)
+	if suggested, ok := report.VirtualNodes[hot]; !ok || suggested >= vnodes {
+		t.Errorf("expected fewer than %d virtual nodes suggested for %s, got %v", vnodes, hot, report.VirtualNodes)
+	}
+}
+
+func TestHeatTrackerDecay(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	ring := NewWithNodes(Config{Replicas: 10}, []string{"server1", "server2"})
+	tracker := NewHeatTracker(ring, HeatConfig{HalfLife: time.Minute, Clock: clk})
+
+	for i := 0; i < 100; i++ {
+		tracker.Record("key", 1)
+	}
+	clk.Advance(time.Minute)
+
+	report := tracker.Report()
+	if got := report.Ranges[0].Heat.Requests; math.Abs(got-50) > 1e-9 {
+		t.Errorf("expected heat halved after one half-life, got %v", got)
+	}
+
+	tracker.Reset()
+	if report := tracker.Report(); len(report.Ranges) != 0 {
+		t.Errorf("expected no heat after reset, got %+v", report.Ranges)
+	}
+}
+
+func TestHeatTrackerFollowsTopology(t *testing.T) {
+	ring := NewWithNodes(Config{Replicas: 10}, []string{"server1", "server2"})
+	tracker := NewHeatTracker(ring, HeatConfig{})
+
+	for i := 0; i < 500; i++ {
+		tracker.Record(fmt.Sprintf("key-%d", i), 0)
+	}
+	ring.RemoveNode("server1")
+
+	report := tracker.Report()
+	for _, rh := range report.Ranges {
+		if rh.Node != "server2" {
+			t.Fatalf("expected ranges of removed nodes to be dropped, got %s", rh.Node)
+		}
+	}
+	if _, ok := report.Nodes["server1"]; ok {
+		t.Error("expected no heat for a removed node")
+	}
+
+	// Nothing to recommend without load
+	empty := NewHeatTracker(ring, HeatConfig{}).Report()
+	if len(empty.Split) != 0 || len(empty.VirtualNodes) != 0 {
+		t.Errorf("expected no recommendations without load, got %+v", empty)
+	}
+}
@@ -0,0 +1,167 @@
+package chash
+
+import (
+	"sync"
+	"time"
+)
+
+// Sample is a point-in-time snapshot of ring health
+type Sample struct {
+	// Time is when the sample was taken
+	Time time.Time
+
+	// PhysicalNodes and VirtualNodes are the ring sizes at sample time
+	PhysicalNodes int
+	VirtualNodes  int
+
+	// Imbalance is the largest node's keyspace share divided by the fair share
+	// 1.0 means perfectly balanced; 0 for an empty ring
+	Imbalance float64
+
+	// LookupRate is the number of lookups per second since the previous sample
+	LookupRate float64
+
+	// TopologyChanges is the number of node additions and removals since the previous sample
+	TopologyChanges uint64
+}
+
+// StatsHistory keeps a fixed-size in-memory history of ring samples so trends
+// can be inspected without external monitoring
+type StatsHistory struct {
+	// mu protects all fields below
+	mu sync.Mutex
+
+	ring *Ring
+
+	// samples is a circular buffer of the most recent samples
+	samples []Sample
+	next    int
+	full    bool
+
+	// last holds the counters of the previous sample for computing deltas
+	last     Stats
+	lastTime time.Time
+
+	stop chan struct{}
+}
+
+// NewStatsHistory creates a history that retains up to capacity samples of ring
+// Default capacity: 360 (one hour at 10s intervals)
+func NewStatsHistory(ring *Ring, capacity int) *StatsHistory {
+	if capacity <= 0 {
+		capacity = 360 // Default history length
+	}
+
+	return &StatsHistory{
+		ring:    ring,
+		samples: make([]Sample, capacity),
+	}
+}
+
+// Record takes a sample now and appends it to the history
+func (h *StatsHistory) Record() Sample {
+	return h.recordAt(h.ring.clock.Now())
+}
+
+// recordAt takes a sample stamped with the given time
+func (h *StatsHistory) recordAt(now time.Time) Sample {
+	stats := h.ring.GetStats()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	sample := Sample{
+		Time:          now,
+		PhysicalNodes: stats.PhysicalNodes,
+		VirtualNodes:  stats.VirtualNodes,
+		Imbalance:     imbalance(stats.Keyspace),
+	}
+
+	// Rates and deltas need a previous sample
+	if !h.lastTime.IsZero() {
+		if elapsed := now.Sub(h.lastTime).Seconds(); elapsed > 0 {
+			sample.LookupRate = float64(stats.Lookups-h.last.Lookups) / elapsed
+		}
+		sample.TopologyChanges = stats.TopologyChanges - h.last.TopologyChanges
+	}
+
+	h.last = stats
+	h.lastTime = now
+
+	h.samples[h.next] = sample
+	h.next = (h.next + 1) % len(h.samples)
+	if h.next == 0 {
+		h.full = true
+	}
+
+	return sample
+}
+
+// Samples returns the retained samples from oldest to newest
+func (h *StatsHistory) Samples() []Sample {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !h.full {
+		return append([]Sample(nil), h.samples[:h.next]...)
+	}
+
+	out := make([]Sample, 0, len(h.samples))
+	out = append(out, h.samples[h.next:]...)
+	out = append(out, h.samples[:h.next]...)
+	return out
+}
+
+// Start records a sample every interval in a background goroutine
+// Calling Start on a running history has no effect; call Stop to end sampling
+func (h *StatsHistory) Start(interval time.Duration) {
+	h.mu.Lock()
+	if h.stop != nil {
+		h.mu.Unlock()
+		return
+	}
+	stop := make(chan struct{})
+	h.stop = stop
+	h.mu.Unlock()
+
+	go func() {
+		ticker := h.ring.clock.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case now := <-ticker.C():
+				h.recordAt(now)
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends background sampling started by Start
+func (h *StatsHistory) Stop() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.stop != nil {
+		close(h.stop)
+		h.stop = nil
+	}
+}
+
+// imbalance returns the largest share divided by the fair share
+func imbalance(shares map[string]float64) float64 {
+	if len(shares) == 0 {
+		return 0
+	}
+
+	var largest float64
+	for _, share := range shares {
+		if share > largest {
+			largest = share
+		}
+	}
+
+	return largest * float64(len(shares))
+}
@@ -0,0 +1,103 @@
+package chash
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestStatsCounters(t *testing.T) {
+	ring := New(Config{Replicas: 5})
+	ring.AddNode("server1")
+	ring.AddNode("server2")
+	ring.RemoveNode("server2")
+
+	ring.GetNode("key1")
+	ring.GetNodes("key2", 1)
+	ring.GetNodeForHash(42)
+
+	stats := ring.GetStats()
+	if stats.Lookups != 3 {
+		t.Errorf("expected 3 lookups, got %d", stats.Lookups)
+	}
+	if stats.TopologyChanges != 3 {
+		t.Errorf("expected 3 topology changes, got %d", stats.TopologyChanges)
+	}
+}
+
+func TestStatsHistoryRecord(t *testing.T) {
+	ring := NewWithNodes(Config{Replicas: 50}, []string{"server1", "server2"})
+	history := NewStatsHistory(ring, 3)
+
+	start := time.Now()
+	first := history.recordAt(start)
+
+	if first.PhysicalNodes != 2 || first.VirtualNodes != 100 {
+		t.Errorf("unexpected ring size in sample: %+v", first)
+	}
+	if first.Imbalance < 1 {
+		t.Errorf("expected imbalance >= 1, got %v", first.Imbalance)
+	}
+	if first.LookupRate != 0 || first.TopologyChanges != 0 {
+		t.Errorf("expected no deltas on first sample, got %+v", first)
+	}
+
+	for i := 0; i < 100; i++ {
+		ring.GetNode(fmt.Sprintf("key%d", i))
+	}
+	ring.AddNode("server3")
+
+	second := history.recordAt(start.Add(10 * time.Second))
+	if second.LookupRate != 10 {
+		t.Errorf("expected 10 lookups/s, got %v", second.LookupRate)
+	}
+	if second.TopologyChanges != 1 {
+		t.Errorf("expected 1 topology change, got %d", second.TopologyChanges)
+	}
+}
+
+func TestStatsHistoryWrapsAround(t *testing.T) {
+	ring := NewWithNodes(Config{Replicas: 5}, []string{"server1"})
+	history := NewStatsHistory(ring, 3)
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		history.recordAt(start.Add(time.Duration(i) * time.Second))
+	}
+
+	samples := history.Samples()
+	if len(samples) != 3 {
+		t.Fatalf("expected 3 retained samples, got %d", len(samples))
+	}
+
+	// Oldest retained sample is the third one recorded
+	for i, sample := range samples {
+		expected := start.Add(time.Duration(i+2) * time.Second)
+		if !sample.Time.Equal(expected) {
+			t.Errorf("sample %d: expected time %v, got %v", i, expected, sample.Time)
+		}
+	}
+}
+
+func TestStatsHistoryStartStop(t *testing.T) {
+	ring := NewWithNodes(Config{Replicas: 5}, []string{"server1"})
+	history := NewStatsHistory(ring, 10)
+
+	history.Start(5 * time.Millisecond)
+	history.Start(5 * time.Millisecond) // No effect while running
+
+	deadline := time.Now().Add(time.Second)
+	for len(history.Samples()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	history.Stop()
+
+	if len(history.Samples()) < 2 {
+		t.Error("expected background samples to be recorded")
+	}
+
+	// Single node owns the whole ring
+	if imb := history.Samples()[0].Imbalance; imb != 1 {
+		t.Errorf("expected imbalance 1 for single node, got %v", imb)
+	}
+}
@@ -0,0 +1,40 @@
+package chash
+
+import "github.com/mohdrashid9678/dcore/invariant"
+
+// checkInvariantsLocked verifies the ring's internal consistency after a
+// change when invariant checks are enabled
+// Caller must hold r.mu for writing
+func (r *Ring) checkInvariantsLocked() {
+	if !invariant.Enabled() {
+		return
+	}
+
+	invariant.Check(len(r.owners) == len(r.ring), "chash/owners-length",
+		"%d owners for %d virtual nodes", len(r.owners), len(r.ring))
+
+	for i := 1; i < len(r.ring); i++ {
+		if r.ring[i] < r.ring[i-1] {
+			invariant.Check(false, "chash/ring-sorted", "position %d is out of order", i)
+			break
+		}
+	}
+
+	vnodes, inactive := 0, 0
+	for node, entry := range r.nodeSet {
+		invariant.Check(int(entry.index) < len(r.names) && r.names[entry.index] == node, "chash/node-index",
+			"node %s has table index %d", node, entry.index)
+		vnodes += entry.vnodes
+		if entry.state != NodeActive {
+			inactive++
+		}
+	}
+	invariant.Check(vnodes == len(r.ring), "chash/vnode-count",
+		"nodes account for %d virtual nodes, ring has %d", vnodes, len(r.ring))
+	invariant.Check(inactive == r.inactive, "chash/inactive-count",
+		"%d inactive nodes, counted %d", inactive, r.inactive)
+
+	invariant.Check(r.topologyChanges >= r.checkedVersion, "chash/monotonic-version",
+		"version went from %d back to %d", r.checkedVersion, r.topologyChanges)
+	r.checkedVersion = r.topologyChanges
+}
@@ -0,0 +1,53 @@
+package chash
+
+import (
+	"os"
+	"testing"
+
+	"github.com/mohdrashid9678/dcore/invariant"
+)
+
+func TestMain(m *testing.M) {
+	invariant.SetMode(invariant.Panic)
+	os.Exit(m.Run())
+}
+
+func TestInvariantsHoldThroughChanges(t *testing.T) {
+	invariant.Reset()
+	defer invariant.Reset()
+
+	// Any violation panics in the Panic mode TestMain sets
+	ring := NewWithNodes(Config{Replicas: 20}, []string{"server1", "server2", "server3"})
+	ring.AddNodeWithWeight("server4", 2)
+	ring.UpdateWeight("server1", 0.5)
+	ring.SetVirtualNodes("server2", 3)
+	ring.SetNodeState("server3", NodeDraining)
+	ring.RemoveNode("server4")
+
+	data, _ := ring.MarshalBinary()
+	ring.UnmarshalBinary(data)
+
+	if n := len(invariant.Violations()); n != 0 {
+		t.Errorf("expected no violations, got %v", invariant.Violations())
+	}
+}
+
+func TestInvariantsDetectCorruption(t *testing.T) {
+	invariant.Reset()
+	defer invariant.Reset()
+	defer invariant.SetMode(invariant.SetMode(invariant.Log))
+	defer invariant.SetHandler(invariant.SetHandler(nil))
+
+	ring := NewWithNodes(Config{Replicas: 20}, []string{"server1", "server2"})
+
+	ring.mu.Lock()
+	ring.ring[0], ring.ring[1] = ring.ring[1], ring.ring[0]
+	ring.inactive = 1
+	ring.checkInvariantsLocked()
+	ring.mu.Unlock()
+
+	violations := invariant.Violations()
+	if violations["chash/ring-sorted"] != 1 || violations["chash/inactive-count"] != 1 {
+		t.Errorf("expected sorting and state violations, got %v", violations)
+	}
+}
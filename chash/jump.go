@@ -0,0 +1,152 @@
+package chash
+
+import (
+	"fmt"
+	"sync"
+)
+
+// JumpHash maps key to a bucket in [0, buckets) using Lamping and Veach's
+// jump consistent hash; it returns -1 if buckets is not positive
+// Growing from n to n+1 buckets moves only 1/(n+1) of the keys, all into the new bucket
+func JumpHash(key uint64, buckets int) int {
+	if buckets <= 0 {
+		return -1
+	}
+
+	var b, j int64 = -1, 0
+	for j < int64(buckets) {
+		b = j
+		key = key*2862933555777941757 + 1
+		j = int64(float64(b+1) * (float64(int64(1)<<31) / float64((key>>33)+1)))
+	}
+
+	return int(b)
+}
+
+// JumpRing maps keys to numbered shards with jump consistent hashing
+// It needs no virtual nodes, so memory is O(nodes), but nodes can only be
+// added or removed at the end of the list
+type JumpRing struct {
+	// mu protects nodes
+	mu sync.RWMutex
+
+	// hashFunc turns keys into jump hash inputs
+	hashFunc HashFunc
+
+	// nodes maps bucket indices to node names
+	nodes []string
+}
+
+// NewJumpRing creates a jump hash ring over nodes, which become buckets 0..n-1
+// hashFunc defaults to DefaultHashFunc
+func NewJumpRing(hashFunc HashFunc, nodes ...string) *JumpRing {
+	if hashFunc == nil {
+		hashFunc = DefaultHashFunc
+	}
+
+	return &JumpRing{
+		hashFunc: hashFunc,
+		nodes:    append([]string(nil), nodes...),
+	}
+}
+
+// GetNode returns the node responsible for the given key
+func (j *JumpRing) GetNode(key string) (string, error) {
+	if key == "" {
+		return "", ErrEmptyKey
+	}
+
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	if len(j.nodes) == 0 {
+		return "", ErrNoNodes
+	}
+
+	return j.nodes[JumpHash(j.hashFunc(key), len(j.nodes))], nil
+}
+
+// Bucket returns the bucket index of key, or -1 if the ring is empty
+func (j *JumpRing) Bucket(key string) int {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	return JumpHash(j.hashFunc(key), len(j.nodes))
+}
+
+// AddNode appends node as the next bucket
+func (j *JumpRing) AddNode(node string) error {
+	if node == "" {
+		return ErrEmptyKey
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	for _, existing := range j.nodes {
+		if existing == node {
+			return fmt.Errorf("node %s already exists", node)
+		}
+	}
+
+	j.nodes = append(j.nodes, node)
+	return nil
+}
+
+// RemoveLastNode removes the highest-numbered bucket and returns its node
+// Jump hash cannot remove arbitrary buckets without remapping most keys
+func (j *JumpRing) RemoveLastNode() (string, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if len(j.nodes) == 0 {
+		return "", ErrNoNodes
+	}
+
+	last := j.nodes[len(j.nodes)-1]
+	j.nodes = j.nodes[:len(j.nodes)-1]
+	return last, nil
+}
+
+// ReplaceNode swaps the node serving a bucket, keeping its keys in place
+// Like AddNode it rejects an empty name or one already serving a bucket
+func (j *JumpRing) ReplaceNode(old, node string) error {
+	if node == "" {
+		return ErrEmptyKey
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	bucket := -1
+	for i, existing := range j.nodes {
+		switch existing {
+		case old:
+			bucket = i
+		case node:
+			return fmt.Errorf("node %s already exists", node)
+		}
+	}
+	if bucket < 0 {
+		return ErrNodeNotFound
+	}
+
+	j.nodes[bucket] = node
+	return nil
+}
+
+// Nodes returns the nodes in bucket order
+func (j *JumpRing) Nodes() []string {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	return append([]string(nil), j.nodes...)
+}
+
+// Size returns the number of buckets
+func (j *JumpRing) Size() int {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	return len(j.nodes)
+}
@@ -0,0 +1,115 @@
+package chash
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestJumpHashEdgeCases(t *testing.T) {
+	// A single bucket always wins; no buckets is invalid
+	tests := []struct {
+		key      uint64
+		buckets  int
+		expected int
+	}{
+		{0, 1, 0},
+		{1, 1, 0},
+		{0xdeadbeef, 1, 0},
+		{1, 0, -1},
+	}
+
+	for _, tt := range tests {
+		if got := JumpHash(tt.key, tt.buckets); got != tt.expected {
+			t.Errorf("JumpHash(%d, %d) = %d, expected %d", tt.key, tt.buckets, got, tt.expected)
+		}
+	}
+}
+
+func TestJumpHashMinimalMovement(t *testing.T) {
+	const keys = 10000
+
+	moved := 0
+	for i := uint64(0); i < keys; i++ {
+		key := DefaultHashFunc(fmt.Sprintf("key%d", i))
+		before := JumpHash(key, 10)
+		after := JumpHash(key, 11)
+
+		if before != after {
+			moved++
+			if after != 10 {
+				t.Fatalf("key moved from %d to existing bucket %d", before, after)
+			}
+		}
+	}
+
+	// Expect roughly 1/11 of keys to move
+	if moved < keys/11/2 || moved > keys/11*2 {
+		t.Errorf("expected about %d keys to move, got %d", keys/11, moved)
+	}
+}
+
+func TestJumpRing(t *testing.T) {
+	ring := NewJumpRing(nil, "shard0", "shard1", "shard2")
+
+	if _, err := NewJumpRing(nil).GetNode("key1"); err != ErrNoNodes {
+		t.Errorf("expected ErrNoNodes, got %v", err)
+	}
+	if _, err := ring.GetNode(""); err != ErrEmptyKey {
+		t.Errorf("expected ErrEmptyKey, got %v", err)
+	}
+
+	counts := make(map[string]int)
+	for i := 0; i < 3000; i++ {
+		key := fmt.Sprintf("key%d", i)
+		node, err := ring.GetNode(key)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if ring.Nodes()[ring.Bucket(key)] != node {
+			t.Errorf("bucket and node disagree for %s", key)
+		}
+		counts[node]++
+	}
+
+	for node, count := range counts {
+		if count < 800 || count > 1200 {
+			t.Errorf("node %s got %d keys, expected about 1000", node, count)
+		}
+	}
+
+	if err := ring.AddNode("shard1"); err == nil {
+		t.Error("expected error adding duplicate node")
+	}
+	ring.AddNode("shard3")
+	if ring.Size() != 4 {
+		t.Errorf("expected 4 buckets, got %d", ring.Size())
+	}
+
+	last, _ := ring.RemoveLastNode()
+	if last != "shard3" {
+		t.Errorf("expected shard3 removed, got %s", last)
+	}
+
+	// Replacing a node keeps its bucket
+	before := ring.Bucket("key1")
+	ring.ReplaceNode(ring.Nodes()[before], "replacement")
+	if node, _ := ring.GetNode("key1"); node != "replacement" {
+		t.Errorf("expected replacement to serve key1, got %s", node)
+	}
+	if err := ring.ReplaceNode("missing", "x"); err != ErrNodeNotFound {
+		t.Errorf("expected ErrNodeNotFound, got %v", err)
+	}
+	if err := ring.ReplaceNode("replacement", ""); err != ErrEmptyKey {
+		t.Errorf("expected ErrEmptyKey, got %v", err)
+	}
+
+	// A node serves one bucket at most
+	nodes := ring.Nodes()
+	if err := ring.ReplaceNode("replacement", nodes[(before+1)%len(nodes)]); err == nil {
+		t.Error("expected error replacing with an existing node")
+	}
+	if !slices.Equal(ring.Nodes(), nodes) {
+		t.Errorf("expected a failed replace to leave %v, got %v", nodes, ring.Nodes())
+	}
+}
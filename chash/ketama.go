@@ -0,0 +1,58 @@
+package chash
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+	"math"
+	"strconv"
+)
+
+// KetamaHashFunc hashes keys the way libketama does: the first four bytes of
+// the key's MD5 digest, read little-endian
+// The 32-bit value is shifted into the high bits of the result so ordering is
+// preserved and range fractions stay correct on the 64-bit ring
+func KetamaHashFunc(key string) uint64 {
+	return ketamaHashFuncBytes([]byte(key))
+}
+
+// ketamaHashFuncBytes is KetamaHashFunc over a byte slice
+func ketamaHashFuncBytes(key []byte) uint64 {
+	digest := md5.Sum(key)
+	return uint64(binary.LittleEndian.Uint32(digest[:4])) << 32
+}
+
+// rebuildKetamaLocked recomputes the whole continuum with libketama's layout:
+// each node gets floor(share × 40 × nodes) MD5 digests of "node-idx", and each
+// digest yields four points
+// Caller must hold r.mu for writing
+func (r *Ring) rebuildKetamaLocked() {
+	var total float64
+	for _, entry := range r.nodeSet {
+		total += entry.weight
+	}
+
+	r.ring = r.ring[:0]
+	r.owners = r.owners[:0]
+	r.names = r.names[:0]
+
+	servers := float64(float32(len(r.nodeSet)))
+	for node, entry := range r.nodeSet {
+		// libketama computes the share in single precision
+		share := float64(float32(entry.weight) / float32(total))
+		digests := int(math.Floor(share * 40 * servers))
+
+		r.indexLocked(node, entry)
+		entry.vnodes = digests * 4
+		for k := 0; k < digests; k++ {
+			digest := md5.Sum([]byte(node + "-" + strconv.Itoa(k)))
+			for h := 0; h < 4; h++ {
+				point := uint64(binary.LittleEndian.Uint32(digest[h*4:])) << 32
+
+				r.ring = append(r.ring, point)
+				r.owners = append(r.owners, entry.index)
+			}
+		}
+	}
+
+	r.sortLocked()
+}
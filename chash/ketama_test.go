@@ -0,0 +1,86 @@
+package chash
+
+import (
+	"crypto/md5"
+	"fmt"
+	"sort"
+	"testing"
+)
+
+// ketamaPoint is a point of a reference 32-bit continuum
+type ketamaPoint struct {
+	value  uint32
+	server string
+}
+
+// referenceKetama builds a continuum the way libketama's C code does for
+// equally weighted servers, using 32-bit points throughout
+func referenceKetama(servers []string) []ketamaPoint {
+	var continuum []ketamaPoint
+	for _, server := range servers {
+		for k := 0; k < 40; k++ {
+			d := md5.Sum([]byte(fmt.Sprintf("%s-%d", server, k)))
+			for h := 0; h < 4; h++ {
+				value := uint32(d[3+h*4])<<24 | uint32(d[2+h*4])<<16 |
+					uint32(d[1+h*4])<<8 | uint32(d[h*4])
+				continuum = append(continuum, ketamaPoint{value, server})
+			}
+		}
+	}
+
+	sort.Slice(continuum, func(i, j int) bool { return continuum[i].value < continuum[j].value })
+	return continuum
+}
+
+// referenceLookup mirrors ketama_get_server
+func referenceLookup(continuum []ketamaPoint, key string) string {
+	d := md5.Sum([]byte(key))
+	h := uint32(d[3])<<24 | uint32(d[2])<<16 | uint32(d[1])<<8 | uint32(d[0])
+
+	idx := sort.Search(len(continuum), func(i int) bool { return continuum[i].value >= h })
+	if idx == len(continuum) {
+		idx = 0
+	}
+	return continuum[idx].server
+}
+
+func TestKetamaMatchesReference(t *testing.T) {
+	servers := []string{"10.0.1.1:11211", "10.0.1.2:11211", "10.0.1.3:11211", "10.0.1.4:11211"}
+	ring := NewWithNodes(Config{Ketama: true}, servers)
+
+	if ring.VirtualNodeCount() != 160*len(servers) {
+		t.Errorf("expected 160 points per server, got %d", ring.VirtualNodeCount())
+	}
+
+	continuum := referenceKetama(servers)
+	for i := 0; i < 5000; i++ {
+		key := fmt.Sprintf("key%d", i)
+		node, _ := ring.GetNode(key)
+		if expected := referenceLookup(continuum, key); node != expected {
+			t.Fatalf("key %s: expected %s, got %s", key, expected, node)
+		}
+	}
+
+	// Removing a server rebuilds the continuum to match a client without it
+	ring.RemoveNode(servers[1])
+	continuum = referenceKetama([]string{servers[0], servers[2], servers[3]})
+	for i := 0; i < 5000; i++ {
+		key := fmt.Sprintf("key%d", i)
+		node, _ := ring.GetNode(key)
+		if expected := referenceLookup(continuum, key); node != expected {
+			t.Fatalf("after removal, key %s: expected %s, got %s", key, expected, node)
+		}
+	}
+}
+
+func TestKetamaWeights(t *testing.T) {
+	ring := New(Config{Ketama: true})
+	ring.AddNodeWithWeight("10.0.1.1:11211", 1)
+	ring.AddNodeWithWeight("10.0.1.2:11211", 3)
+
+	// share × 40 × servers digests, four points each
+	stats := ring.GetStats()
+	if stats.VirtualNodes != (20+60)*4 {
+		t.Errorf("expected 320 points, got %d", stats.VirtualNodes)
+	}
+}
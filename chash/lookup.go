@@ -0,0 +1,19 @@
+package chash
+
+import "iter"
+
+// Lookup routes every key of keys and yields each key with its node, without
+// collecting either into a slice
+// Keys are routed one at a time like GetNode, so the loop body may modify the
+// ring; later keys see the change. A key that cannot be routed, because it is
+// empty or the ring has no serving node, is yielded with an empty node
+func (r *Ring) Lookup(keys iter.Seq[string]) iter.Seq2[string, string] {
+	return func(yield func(string, string) bool) {
+		for key := range keys {
+			node, _ := r.GetNode(key)
+			if !yield(key, node) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package chash
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestLookup(t *testing.T) {
+	ring := NewWithNodes(Config{Replicas: 10}, []string{"server1", "server2", "server3"})
+
+	keys := []string{"user:1", "", "user:2", "user:3"}
+	i := 0
+	for key, node := range ring.Lookup(slices.Values(keys)) {
+		if key != keys[i] {
+			t.Fatalf("expected keys in order, got %q at %d", key, i)
+		}
+		want, _ := ring.GetNode(key)
+		if node != want {
+			t.Errorf("%q: expected %q, got %q", key, want, node)
+		}
+		i++
+	}
+	if i != len(keys) {
+		t.Errorf("expected %d pairs, got %d", len(keys), i)
+	}
+
+	// Stopping early stops pulling keys
+	pulled := 0
+	source := func(yield func(string) bool) {
+		for n := 0; ; n++ {
+			pulled++
+			if !yield(fmt.Sprintf("key-%d", n)) {
+				return
+			}
+		}
+	}
+	for range ring.Lookup(source) {
+		if pulled == 5 {
+			break
+		}
+	}
+	if pulled != 5 {
+		t.Errorf("expected 5 keys pulled, got %d", pulled)
+	}
+
+	// The loop body may change the ring
+	for _, node := range ring.Lookup(slices.Values([]string{"a", "b"})) {
+		ring.RemoveNode(node)
+	}
+	if len(ring.Nodes()) == 3 {
+		t.Error("expected removal inside the loop to take effect")
+	}
+
+	empty := New(Config{})
+	for _, node := range empty.Lookup(slices.Values([]string{"a"})) {
+		if node != "" {
+			t.Errorf("expected empty node on an empty ring, got %q", node)
+		}
+	}
+}
+
+func BenchmarkLookup(b *testing.B) {
+	ring := NewWithNodes(Config{}, []string{"server1", "server2", "server3"})
+	keys := make([]string, 1000)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key-%d", i)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for range ring.Lookup(slices.Values(keys)) {
+		}
+	}
+}
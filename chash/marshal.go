@@ -0,0 +1,443 @@
+package chash
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+)
+
+var (
+	// ErrInvalidSnapshot is returned when decoding malformed ring state
+	ErrInvalidSnapshot = errors.New("invalid ring snapshot")
+
+	// ErrSnapshotMismatch is returned when a snapshot's hashing mode differs
+	// from the ring it is loaded into
+	ErrSnapshotMismatch = errors.New("ring snapshot uses a different hashing mode")
+)
+
+// snapshotMagic and the snapshot version prefix the binary encoding
+// Version 2 adds the partition count, version 3 per-node virtual node
+// overrides and version 4 manual tokens; snapshots use the oldest version that
+// can hold them so older readers can load them
+const (
+	snapshotMagic = "CHR"
+
+	snapshotVersion             = 1
+	snapshotVersionPartitions   = 2
+	snapshotVersionVirtualNodes = 3
+	snapshotVersionTokens       = 4
+
+	// snapshotVersionLatest is the newest version this package reads
+	snapshotVersionLatest = snapshotVersionTokens
+)
+
+// maxSnapshotVirtualNodes bounds the virtual nodes a snapshot may place, so a
+// crafted snapshot cannot force a huge allocation or a long rebuild
+const maxSnapshotVirtualNodes = 1 << 24
+
+// versionFor returns the oldest snapshot version that can encode s
+func (s snapshot) versionFor() int {
+	for _, n := range s.Nodes {
+		if len(n.Tokens) > 0 {
+			return snapshotVersionTokens
+		}
+	}
+	for _, n := range s.Nodes {
+		if n.VirtualNodes > 0 {
+			return snapshotVersionVirtualNodes
+		}
+	}
+	if s.Partitions > 0 {
+		return snapshotVersionPartitions
+	}
+	return snapshotVersion
+}
+
+// snapshot is the serializable topology of a ring
+// Virtual node positions are derived from node names, so only the inputs to
+// placement are stored; metadata, node states and roles, TTLs, pins and
+// tombstones are process-local
+type snapshot struct {
+	Version    int             `json:"version"`
+	Replicas   int             `json:"replicas"`
+	Ketama     bool            `json:"ketama,omitempty"`
+	Partitions int             `json:"partitions,omitempty"`
+	Nodes      []snapshotNode  `json:"nodes"`
+	Groups     []AffinityGroup `json:"groups,omitempty"`
+}
+
+// snapshotNode is a node's placement inputs
+type snapshotNode struct {
+	Name         string    `json:"name"`
+	Weight       float64   `json:"weight"`
+	VirtualNodes int       `json:"virtual_nodes,omitempty"`
+	Topology     *Topology `json:"topology,omitempty"`
+	Tokens       []uint64  `json:"tokens,omitempty"`
+}
+
+// MarshalBinary encodes the ring topology: replicas, hashing mode, nodes with
+// their weights, virtual node overrides, manual tokens and topology labels,
+// and affinity groups
+// The hash function is not encoded; the loading ring must use the same one
+func (r *Ring) MarshalBinary() ([]byte, error) {
+	s := r.snapshot()
+
+	buf := append([]byte(snapshotMagic), byte(s.Version))
+	buf = binary.AppendUvarint(buf, uint64(s.Replicas))
+	if s.Ketama {
+		buf = append(buf, 1)
+	} else {
+		buf = append(buf, 0)
+	}
+	if s.Version >= snapshotVersionPartitions {
+		buf = binary.AppendUvarint(buf, uint64(s.Partitions))
+	}
+
+	buf = binary.AppendUvarint(buf, uint64(len(s.Nodes)))
+	for _, n := range s.Nodes {
+		var topo Topology
+		if n.Topology != nil {
+			topo = *n.Topology
+		}
+
+		buf = appendString(buf, n.Name)
+		buf = binary.BigEndian.AppendUint64(buf, math.Float64bits(n.Weight))
+		buf = appendString(buf, topo.Region)
+		buf = appendString(buf, topo.Zone)
+		buf = appendString(buf, topo.Rack)
+		if s.Version >= snapshotVersionVirtualNodes {
+			buf = binary.AppendUvarint(buf, uint64(n.VirtualNodes))
+		}
+		if s.Version >= snapshotVersionTokens {
+			buf = binary.AppendUvarint(buf, uint64(len(n.Tokens)))
+			for _, token := range n.Tokens {
+				buf = binary.BigEndian.AppendUint64(buf, token)
+			}
+		}
+	}
+
+	buf = binary.AppendUvarint(buf, uint64(len(s.Groups)))
+	for _, g := range s.Groups {
+		buf = appendString(buf, g.Name)
+		buf = binary.AppendUvarint(buf, uint64(len(g.Prefixes)))
+		for _, prefix := range g.Prefixes {
+			buf = appendString(buf, prefix)
+		}
+	}
+
+	return buf, nil
+}
+
+// UnmarshalBinary replaces the ring topology with one encoded by MarshalBinary
+// The ring must have been created with New; it keeps its hash function
+func (r *Ring) UnmarshalBinary(data []byte) error {
+	d := decoder{data: data}
+
+	if string(d.bytes(len(snapshotMagic))) != snapshotMagic {
+		return fmt.Errorf("%w: bad magic", ErrInvalidSnapshot)
+	}
+	s := snapshot{Version: int(d.byte())}
+	if d.err == nil && (s.Version < snapshotVersion || s.Version > snapshotVersionLatest) {
+		return fmt.Errorf("%w: unsupported version %d", ErrInvalidSnapshot, s.Version)
+	}
+
+	s.Replicas = int(d.uvarint())
+	s.Ketama = d.byte() == 1
+	if s.Version >= snapshotVersionPartitions {
+		s.Partitions = int(d.uvarint())
+	}
+
+	// Every node takes at least a name, a weight and three topology labels
+	nodes := d.count(12)
+	for i := uint64(0); i < nodes && d.err == nil; i++ {
+		n := snapshotNode{Name: d.string()}
+		n.Weight = math.Float64frombits(d.uint64())
+
+		topo := Topology{Region: d.string(), Zone: d.string(), Rack: d.string()}
+		if topo != (Topology{}) {
+			n.Topology = &topo
+		}
+		if s.Version >= snapshotVersionVirtualNodes {
+			n.VirtualNodes = int(d.uvarint())
+		}
+		if s.Version >= snapshotVersionTokens {
+			tokens := d.count(8)
+			for j := uint64(0); j < tokens && d.err == nil; j++ {
+				n.Tokens = append(n.Tokens, d.uint64())
+			}
+		}
+		s.Nodes = append(s.Nodes, n)
+	}
+
+	groups := d.count(2)
+	for i := uint64(0); i < groups && d.err == nil; i++ {
+		g := AffinityGroup{Name: d.string()}
+		prefixes := d.count(1)
+		for j := uint64(0); j < prefixes && d.err == nil; j++ {
+			g.Prefixes = append(g.Prefixes, d.string())
+		}
+		s.Groups = append(s.Groups, g)
+	}
+
+	if d.err != nil {
+		return d.err
+	}
+	if len(d.data) != 0 {
+		return fmt.Errorf("%w: %d trailing bytes", ErrInvalidSnapshot, len(d.data))
+	}
+
+	return r.restore(s)
+}
+
+// MarshalJSON encodes the ring topology as JSON; see MarshalBinary
+func (r *Ring) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.snapshot())
+}
+
+// UnmarshalJSON replaces the ring topology with one encoded by MarshalJSON
+func (r *Ring) UnmarshalJSON(data []byte) error {
+	var s snapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSnapshot, err)
+	}
+	return r.restore(s)
+}
+
+// snapshot captures the ring topology with nodes sorted by name
+func (r *Ring) snapshot() snapshot {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	s := snapshot{
+		Replicas:   r.replicas,
+		Ketama:     r.ketama,
+		Partitions: len(r.partitions),
+		Nodes:      make([]snapshotNode, 0, len(r.nodeSet)),
+	}
+
+	for name, entry := range r.nodeSet {
+		n := snapshotNode{Name: name, Weight: entry.weight, VirtualNodes: entry.fixed, Tokens: slices.Clone(entry.tokens)}
+		if entry.topology != (Topology{}) {
+			topo := entry.topology
+			n.Topology = &topo
+		}
+		s.Nodes = append(s.Nodes, n)
+	}
+	sort.Slice(s.Nodes, func(i, j int) bool { return s.Nodes[i].Name < s.Nodes[j].Name })
+
+	for name, prefixes := range r.groups {
+		s.Groups = append(s.Groups, AffinityGroup{Name: name, Prefixes: append([]string(nil), prefixes...)})
+	}
+	sort.Slice(s.Groups, func(i, j int) bool { return s.Groups[i].Name < s.Groups[j].Name })
+
+	s.Version = s.versionFor()
+	return s
+}
+
+// restore validates a snapshot and swaps it in as the ring topology
+// Any known version that can hold the snapshot's contents is accepted, so
+// snapshots written at an older or newer known version than needed still load
+// Admission checks and quarantine do not apply: the snapshot is authoritative
+// Nodes that survive the reload keep their metadata, state, role and TTL
+func (r *Ring) restore(s snapshot) error {
+	if s.Partitions < 0 || s.Version < s.versionFor() || s.Version > snapshotVersionLatest {
+		return fmt.Errorf("%w: unsupported version %d", ErrInvalidSnapshot, s.Version)
+	}
+	if s.Replicas <= 0 || s.Replicas > maxSnapshotVirtualNodes {
+		return fmt.Errorf("%w: replicas must be in [1, %d]", ErrInvalidSnapshot, maxSnapshotVirtualNodes)
+	}
+
+	seen := make(map[string]struct{}, len(s.Nodes))
+	for _, n := range s.Nodes {
+		if n.Name == "" {
+			return fmt.Errorf("%w: empty node name", ErrInvalidSnapshot)
+		}
+		if _, dup := seen[n.Name]; dup {
+			return fmt.Errorf("%w: duplicate node %s", ErrInvalidSnapshot, n.Name)
+		}
+		seen[n.Name] = struct{}{}
+
+		if n.Weight <= 0 || math.IsNaN(n.Weight) || math.IsInf(n.Weight, 0) {
+			return fmt.Errorf("%w: node %s: %v", ErrInvalidSnapshot, n.Name, ErrInvalidWeight)
+		}
+		if n.VirtualNodes < 0 || n.VirtualNodes > maxSnapshotVirtualNodes || (n.VirtualNodes > 0 && s.Ketama) {
+			return fmt.Errorf("%w: node %s: invalid virtual node count", ErrInvalidSnapshot, n.Name)
+		}
+		if n.Tokens != nil {
+			if err := checkTokens(n.Tokens); err != nil || s.Ketama || n.VirtualNodes > 0 {
+				return fmt.Errorf("%w: node %s: invalid tokens", ErrInvalidSnapshot, n.Name)
+			}
+		}
+	}
+
+	// Count in floating point: a huge weight times replicas overflows int
+	if !s.Ketama {
+		var vnodes float64
+		for _, n := range s.Nodes {
+			switch {
+			case n.Tokens != nil:
+				vnodes += float64(len(n.Tokens))
+			case n.VirtualNodes > 0:
+				vnodes += float64(n.VirtualNodes)
+			default:
+				vnodes += max(math.Round(n.Weight*float64(s.Replicas)), 1)
+			}
+		}
+		if vnodes > maxSnapshotVirtualNodes {
+			return fmt.Errorf("%w: %.0f virtual nodes exceed the limit of %d", ErrInvalidSnapshot, vnodes, maxSnapshotVirtualNodes)
+		}
+	}
+
+	var prefixes []groupPrefix
+	groups := make(map[string][]string, len(s.Groups))
+	for _, g := range s.Groups {
+		if g.Name == "" || len(g.Prefixes) == 0 {
+			return fmt.Errorf("%w: malformed affinity group", ErrInvalidSnapshot)
+		}
+		groups[g.Name] = append([]string(nil), g.Prefixes...)
+		for _, prefix := range g.Prefixes {
+			prefixes = append(prefixes, groupPrefix{prefix: prefix, group: g.Name})
+		}
+	}
+	sort.SliceStable(prefixes, func(i, j int) bool {
+		return len(prefixes[i].prefix) > len(prefixes[j].prefix)
+	})
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if s.Ketama != r.ketama || s.Partitions != len(r.partitions) {
+		return ErrSnapshotMismatch
+	}
+
+	before := r.watchLocked()
+	defer r.publishLocked(RingReloaded, nil, before)
+
+	// Keep metadata, state, role and TTL of nodes that survive the reload
+	old := r.nodeSet
+
+	r.replicas = s.Replicas
+	r.ring = r.ring[:0]
+	r.owners = r.owners[:0]
+	r.names = r.names[:0]
+	r.nodeSet = make(map[string]*nodeEntry, len(s.Nodes))
+	r.inactive = 0
+
+	for _, n := range s.Nodes {
+		entry := &nodeEntry{weight: n.Weight, fixed: n.VirtualNodes}
+		if n.Tokens != nil {
+			entry.tokens = slices.Sorted(slices.Values(n.Tokens))
+		}
+		if n.Topology != nil {
+			entry.topology = *n.Topology
+		}
+		if prev, ok := old[n.Name]; ok {
+			entry.meta = prev.meta
+			entry.state = prev.state
+			entry.role = prev.role
+			entry.ttl, entry.expires = prev.ttl, prev.expires
+		}
+		r.nodeSet[n.Name] = entry
+		if entry.state != NodeActive {
+			r.inactive++
+		}
+
+		if !r.ketama {
+			r.placeLocked(n.Name, entry)
+		}
+	}
+
+	if r.ketama {
+		r.rebuildKetamaLocked()
+	} else {
+		r.sortLocked()
+		r.resolveCollisionsLocked()
+		r.retuneLocked()
+	}
+
+	r.groups = groups
+	r.groupPrefixes = prefixes
+	r.topologyChanges++
+
+	return nil
+}
+
+// appendString appends a length-prefixed string
+func appendString(buf []byte, s string) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(s)))
+	return append(buf, s...)
+}
+
+// decoder reads a binary snapshot, remembering the first error
+type decoder struct {
+	data []byte
+	err  error
+}
+
+func (d *decoder) fail() {
+	if d.err == nil {
+		d.err = fmt.Errorf("%w: truncated data", ErrInvalidSnapshot)
+	}
+	d.data = nil
+}
+
+func (d *decoder) bytes(n int) []byte {
+	if d.err != nil || n < 0 || len(d.data) < n {
+		d.fail()
+		return nil
+	}
+	b := d.data[:n]
+	d.data = d.data[n:]
+	return b
+}
+
+func (d *decoder) byte() byte {
+	if b := d.bytes(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (d *decoder) uint64() uint64 {
+	if b := d.bytes(8); b != nil {
+		return binary.BigEndian.Uint64(b)
+	}
+	return 0
+}
+
+func (d *decoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(d.data)
+	if n <= 0 {
+		d.fail()
+		return 0
+	}
+	d.data = d.data[n:]
+	return v
+}
+
+// count reads an element count, failing if the remaining data cannot hold
+// that many elements of at least size bytes each
+func (d *decoder) count(size int) uint64 {
+	n := d.uvarint()
+	if n > uint64(len(d.data)/size) {
+		d.fail()
+		return 0
+	}
+	return n
+}
+
+func (d *decoder) string() string {
+	n := d.uvarint()
+	if n > uint64(len(d.data)) {
+		d.fail()
+		return ""
+	}
+	return string(d.bytes(int(n)))
+}
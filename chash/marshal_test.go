@@ -0,0 +1,190 @@
+package chash
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+)
+
+// newSnapshotRing builds a ring exercising every serialized field
+func newSnapshotRing() *Ring {
+	ring := New(Config{Replicas: 30})
+	ring.AddNode("server1")
+	ring.AddNodeWithWeight("server2", 2.5)
+	ring.AddNode("server3")
+	ring.SetTopology("server1", Topology{Region: "eu", Zone: "eu-1a", Rack: "r1"})
+	ring.AddAffinityGroup("user:42", "user:42:", "cart:42:")
+	return ring
+}
+
+// assertSameRouting fails if the rings route any sample key differently
+func assertSameRouting(t *testing.T, a, b *Ring) {
+	t.Helper()
+
+	if a.VirtualNodeCount() != b.VirtualNodeCount() {
+		t.Fatalf("expected %d virtual nodes, got %d", a.VirtualNodeCount(), b.VirtualNodeCount())
+	}
+
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("key%d", i)
+		if i%10 == 0 {
+			key = fmt.Sprintf("cart:42:%d", i)
+		}
+
+		expected, _ := a.GetNode(key)
+		if node, _ := b.GetNode(key); node != expected {
+			t.Fatalf("key %s: expected %s, got %s", key, expected, node)
+		}
+	}
+}
+
+func TestMarshalBinaryRoundTrip(t *testing.T) {
+	ring := newSnapshotRing()
+
+	data, err := ring.MarshalBinary()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// Replicas come from the snapshot, not the loading ring
+	loaded := New(Config{Replicas: 5})
+	loaded.AddNode("stale")
+	if err := loaded.UnmarshalBinary(data); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	assertSameRouting(t, ring, loaded)
+
+	if weight, _ := loaded.Weight("server2"); weight != 2.5 {
+		t.Errorf("expected weight 2.5, got %v", weight)
+	}
+	if topo, _ := loaded.Topology("server1"); topo.Rack != "r1" {
+		t.Errorf("expected topology restored, got %+v", topo)
+	}
+	if _, err := loaded.Weight("stale"); err != ErrNodeNotFound {
+		t.Error("expected stale node replaced by snapshot")
+	}
+
+	// Encoding is deterministic
+	again, _ := loaded.MarshalBinary()
+	if string(again) != string(data) {
+		t.Error("expected identical encoding of identical topology")
+	}
+}
+
+func TestMarshalJSONRoundTrip(t *testing.T) {
+	ring := newSnapshotRing()
+
+	data, err := json.Marshal(ring)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	loaded := New(Config{})
+	if err := json.Unmarshal(data, loaded); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	assertSameRouting(t, ring, loaded)
+	if len(loaded.AffinityGroups()) != 1 {
+		t.Errorf("expected affinity group restored, got %+v", loaded.AffinityGroups())
+	}
+}
+
+func TestUnmarshalKeepsMetadata(t *testing.T) {
+	ring := New(Config{Replicas: 10})
+	ring.AddNodeWithMeta("server1", "conn1")
+
+	data, _ := newSnapshotRing().MarshalBinary()
+	ring.UnmarshalBinary(data)
+
+	if meta, _ := ring.Meta("server1"); meta != "conn1" {
+		t.Errorf("expected metadata kept for surviving node, got %v", meta)
+	}
+}
+
+func TestUnmarshalInvalid(t *testing.T) {
+	data, _ := newSnapshotRing().MarshalBinary()
+	ring := New(Config{})
+
+	tests := map[string][]byte{
+		"empty":     nil,
+		"bad magic": append([]byte("XYZ"), data[3:]...),
+		"version":   append([]byte("CHR\x09"), data[4:]...),
+		"truncated": data[:len(data)-3],
+		"trailing":  append(append([]byte(nil), data...), 0),
+	}
+
+	for name, input := range tests {
+		if err := ring.UnmarshalBinary(input); !errors.Is(err, ErrInvalidSnapshot) {
+			t.Errorf("%s: expected ErrInvalidSnapshot, got %v", name, err)
+		}
+	}
+
+	for _, input := range []string{
+		`{"version":1,"replicas":0,"nodes":[]}`,
+		`{"version":1,"replicas":5,"nodes":[{"name":"a","weight":1},{"name":"a","weight":1}]}`,
+		`{"version":1,"replicas":5,"nodes":[{"name":"a","weight":-1}]}`,
+		`{"version":9,"replicas":5,"nodes":[]}`,
+		`{"version":2,"replicas":5,"nodes":[{"name":"a","weight":1,"virtual_nodes":3}]}`,
+		`{"version":1,"replicas":1000000000,"nodes":[]}`,
+		`{"version":1,"replicas":5,"nodes":[{"name":"a","weight":1e300}]}`,
+		`{"version":3,"replicas":5,"nodes":[{"name":"a","weight":1,"virtual_nodes":1000000000}]}`,
+		`not json`,
+	} {
+		if err := ring.UnmarshalJSON([]byte(input)); !errors.Is(err, ErrInvalidSnapshot) {
+			t.Errorf("%s: expected ErrInvalidSnapshot, got %v", input, err)
+		}
+	}
+
+	// Crafted counts are rejected before anything is allocated for them
+	huge := binary.AppendUvarint(nil, 1<<62)
+	for name, input := range map[string][]byte{
+		"replicas": append(append([]byte("CHR\x01"), huge...), 0, 0, 0),
+		"nodes":    append(append([]byte("CHR\x01\x05\x00"), huge...), 0),
+		"tokens": append(append(append([]byte("CHR\x04\x05\x00\x00\x01\x01a"),
+			make([]byte, 8+3+1)...), huge...), 0),
+	} {
+		if err := ring.UnmarshalBinary(input); !errors.Is(err, ErrInvalidSnapshot) {
+			t.Errorf("%s: expected ErrInvalidSnapshot, got %v", name, err)
+		}
+	}
+
+	ketama := New(Config{Ketama: true})
+	if err := ketama.UnmarshalBinary(data); err != ErrSnapshotMismatch {
+		t.Errorf("expected ErrSnapshotMismatch, got %v", err)
+	}
+}
+
+func TestUnmarshalKnownVersions(t *testing.T) {
+	// A snapshot may use any known version that can hold its contents, such
+	// as one written by a newer encoder that always uses the latest version
+	var data []byte
+	data = append(data, "CHR"...)
+	data = append(data, snapshotVersionLatest)
+	data = binary.AppendUvarint(data, 10) // replicas
+	data = append(data, 0)                // not ketama
+	data = binary.AppendUvarint(data, 0)  // partitions
+	data = binary.AppendUvarint(data, 1)  // nodes
+	data = appendString(data, "server1")
+	data = binary.BigEndian.AppendUint64(data, math.Float64bits(2))
+	data = appendString(appendString(appendString(data, ""), ""), "")
+	data = binary.AppendUvarint(data, 0) // virtual node override
+	data = binary.AppendUvarint(data, 0) // tokens
+	data = binary.AppendUvarint(data, 0) // groups
+
+	ring := New(Config{})
+	if err := ring.UnmarshalBinary(data); err != nil {
+		t.Fatalf("expected a latest-version snapshot to load, got %v", err)
+	}
+	if weight, _ := ring.Weight("server1"); weight != 2 || ring.VirtualNodeCount() != 20 {
+		t.Errorf("unexpected ring: weight %v, %d virtual nodes", weight, ring.VirtualNodeCount())
+	}
+
+	if err := ring.UnmarshalJSON([]byte(`{"version":3,"replicas":5,"nodes":[{"name":"a","weight":1}]}`)); err != nil {
+		t.Errorf("expected a version 3 snapshot without overrides to load, got %v", err)
+	}
+}
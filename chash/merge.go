@@ -0,0 +1,84 @@
+package chash
+
+import "sort"
+
+// Merge returns a new ring with the configuration and nodes of a plus the
+// nodes of b that a lacks, such as to combine the views of two discovery
+// sources during a datacenter merge
+// Nodes from b keep their weight, virtual node count, topology, metadata,
+// state, role and TTL; a node in both rings keeps its settings from a.
+// Neither ring is modified
+func Merge(a, b *Ring) *Ring {
+	merged := a.Clone()
+
+	b.mu.RLock()
+	entries := make(map[string]nodeEntry)
+	for node, entry := range b.nodeSet {
+		if !merged.hasNode(node) {
+			entries[node] = *entry
+		}
+	}
+	b.mu.RUnlock()
+
+	if len(entries) == 0 {
+		return merged
+	}
+
+	merged.mu.Lock()
+	defer merged.mu.Unlock()
+
+	// Place in name order so the layout does not depend on map iteration
+	nodes := make([]string, 0, len(entries))
+	for node := range entries {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+
+	for _, node := range nodes {
+		from := entries[node]
+		entry := &nodeEntry{
+			weight:   from.weight,
+			fixed:    from.fixed,
+			topology: from.topology,
+			meta:     from.meta,
+			state:    from.state,
+			role:     from.role,
+			ttl:      from.ttl,
+			expires:  from.expires,
+			tokens:   from.tokens,
+		}
+		if entry.state != NodeActive {
+			merged.inactive++
+		}
+		merged.nodeSet[node] = entry
+		delete(merged.tombstones, node)
+		merged.topologyChanges++
+
+		if !merged.ketama {
+			merged.placeLocked(node, entry)
+		}
+	}
+
+	if merged.ketama {
+		merged.rebuildKetamaLocked()
+	} else {
+		merged.sortLocked()
+		merged.resolveCollisionsLocked()
+		merged.retuneLocked()
+	}
+	merged.checkInvariantsLocked()
+	return merged
+}
+
+// Difference returns the nodes of a that are not in b, sorted by name
+// Call it both ways round to find the nodes only one of two rings knows
+func Difference(a, b *Ring) []string {
+	nodes := a.Nodes()
+	missing := nodes[:0]
+	for _, node := range nodes {
+		if !b.hasNode(node) {
+			missing = append(missing, node)
+		}
+	}
+	return missing
+}
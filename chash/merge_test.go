@@ -0,0 +1,72 @@
+package chash
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	a := NewWithNodes(Config{Replicas: 50}, []string{"server1", "server2"})
+	b := NewWithNodes(Config{Replicas: 50}, []string{"server2", "server3"})
+	if err := a.UpdateWeight("server2", 2); err != nil {
+		t.Fatalf("UpdateWeight failed: %v", err)
+	}
+	if err := b.AddNodeWithWeight("server4", 3); err != nil {
+		t.Fatalf("AddNodeWithWeight failed: %v", err)
+	}
+	if err := b.SetNodeState("server3", NodeDraining); err != nil {
+		t.Fatalf("SetNodeState failed: %v", err)
+	}
+	if err := b.SetMeta("server3", "east"); err != nil {
+		t.Fatalf("SetMeta failed: %v", err)
+	}
+
+	merged := Merge(a, b)
+
+	if got := merged.Nodes(); !slices.Equal(got, []string{"server1", "server2", "server3", "server4"}) {
+		t.Fatalf("Unexpected merged nodes: %v", got)
+	}
+	if w, _ := merged.Weight("server2"); w != 2 {
+		t.Errorf("Expected server2 to keep its weight from a, got %v", w)
+	}
+	if w, _ := merged.Weight("server4"); w != 3 {
+		t.Errorf("Expected server4 to keep its weight from b, got %v", w)
+	}
+	if state, _ := merged.NodeState("server3"); state != NodeDraining {
+		t.Errorf("Expected server3 draining, got %v", state)
+	}
+	if meta, _ := merged.Meta("server3"); meta != "east" {
+		t.Errorf("Expected server3 metadata, got %v", meta)
+	}
+
+	// The merged layout matches adding the same nodes to a directly
+	want := a.Clone()
+	if err := want.AddNode("server3"); err != nil {
+		t.Fatalf("AddNode failed: %v", err)
+	}
+	if err := want.AddNodeWithWeight("server4", 3); err != nil {
+		t.Fatalf("AddNodeWithWeight failed: %v", err)
+	}
+	if moves := want.Diff(merged); len(moves) != 0 {
+		t.Errorf("Expected merged ownership to match, got %d moved ranges", len(moves))
+	}
+
+	if got := a.Nodes(); !slices.Equal(got, []string{"server1", "server2"}) {
+		t.Errorf("Merge modified a: %v", got)
+	}
+}
+
+func TestDifference(t *testing.T) {
+	a := NewWithNodes(Config{}, []string{"server1", "server2", "server3"})
+	b := NewWithNodes(Config{}, []string{"server2", "server4"})
+
+	if got := Difference(a, b); !slices.Equal(got, []string{"server1", "server3"}) {
+		t.Errorf("Expected [server1 server3], got %v", got)
+	}
+	if got := Difference(b, a); !slices.Equal(got, []string{"server4"}) {
+		t.Errorf("Expected [server4], got %v", got)
+	}
+	if got := Difference(a, a); len(got) != 0 {
+		t.Errorf("Expected no difference with itself, got %v", got)
+	}
+}
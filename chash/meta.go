@@ -0,0 +1,111 @@
+package chash
+
+// NodeInfo describes a physical node and the data attached to it
+type NodeInfo struct {
+	// Name is the node name on the ring
+	Name string
+
+	// Weight is the weight the node was added with
+	Weight float64
+
+	// Topology holds the node's failure domain labels
+	Topology Topology
+
+	// Meta is the metadata attached with AddNodeWithMeta or SetMeta
+	Meta any
+
+	// State is the node's lookup state, see SetNodeState
+	State NodeState
+
+	// Role is the node's role, see SetNodeRole
+	Role NodeRole
+}
+
+// AddNodeWithMeta adds a node and attaches arbitrary metadata to it, such as a
+// connection pool or address, so lookups can return it via GetNodeInfo
+func (r *Ring) AddNodeWithMeta(node string, meta any) error {
+	return r.addNode(node, &nodeEntry{weight: 1, meta: meta}, false)
+}
+
+// SetMeta replaces the metadata attached to a node
+func (r *Ring) SetMeta(node string, meta any) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, exists := r.nodeSet[node]
+	if !exists {
+		return ErrNodeNotFound
+	}
+
+	entry.meta = meta
+	return nil
+}
+
+// Meta returns the metadata attached to a node
+func (r *Ring) Meta(node string) (any, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entry, exists := r.nodeSet[node]
+	if !exists {
+		return nil, ErrNodeNotFound
+	}
+
+	return entry.meta, nil
+}
+
+// GetNodeInfo returns the node responsible for key along with its metadata
+// It routes exactly like GetNode
+func (r *Ring) GetNodeInfo(key string) (NodeInfo, error) {
+	if key == "" {
+		return NodeInfo{}, ErrEmptyKey
+	}
+
+	r.lookups.Add(1)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(r.ring) == 0 {
+		return NodeInfo{}, ErrNoNodes
+	}
+
+	node, ok := "", false
+	if len(r.pins) > 0 {
+		node, ok = r.pinnedLocked(key, r.clock.Now())
+	}
+	if !ok {
+		node = r.primaryLocked(r.searchLocked(r.hashKeyLocked(key)))
+	}
+	if node == "" {
+		return NodeInfo{}, ErrNoNodes
+	}
+
+	return r.infoLocked(node), nil
+}
+
+// NodeInfo returns the description of a node
+func (r *Ring) NodeInfo(node string) (NodeInfo, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, exists := r.nodeSet[node]; !exists {
+		return NodeInfo{}, ErrNodeNotFound
+	}
+
+	return r.infoLocked(node), nil
+}
+
+// infoLocked builds the NodeInfo of an existing node
+// Caller must hold r.mu
+func (r *Ring) infoLocked(node string) NodeInfo {
+	entry := r.nodeSet[node]
+	return NodeInfo{
+		Name:     node,
+		Weight:   entry.weight,
+		Topology: entry.topology,
+		Meta:     entry.meta,
+		State:    entry.state,
+		Role:     entry.role,
+	}
+}
@@ -0,0 +1,76 @@
+package chash
+
+import (
+	"fmt"
+	"testing"
+)
+
+type testConn struct {
+	addr string
+}
+
+func TestNodeMetadata(t *testing.T) {
+	ring := New(Config{Replicas: 20})
+
+	conns := make(map[string]*testConn)
+	for i := 1; i <= 3; i++ {
+		node := fmt.Sprintf("server%d", i)
+		conns[node] = &testConn{addr: node + ":8080"}
+		if err := ring.AddNodeWithMeta(node, conns[node]); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("key%d", i)
+
+		info, err := ring.GetNodeInfo(key)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		node, _ := ring.GetNode(key)
+		if info.Name != node || info.Weight != 1 {
+			t.Errorf("expected %s with weight 1, got %+v", node, info)
+		}
+		if conn, ok := info.Meta.(*testConn); !ok || conn != conns[node] {
+			t.Errorf("expected metadata of %s, got %v", node, info.Meta)
+		}
+	}
+
+	ring.SetMeta("server1", "replaced")
+	if meta, _ := ring.Meta("server1"); meta != "replaced" {
+		t.Errorf("expected replaced metadata, got %v", meta)
+	}
+
+	ring.SetTopology("server2", Topology{Zone: "z1"})
+	if info, _ := ring.NodeInfo("server2"); info.Topology.Zone != "z1" {
+		t.Errorf("expected topology in info, got %+v", info)
+	}
+}
+
+func TestNodeMetadataErrors(t *testing.T) {
+	ring := New(Config{Replicas: 5})
+
+	if _, err := ring.GetNodeInfo(""); err != ErrEmptyKey {
+		t.Errorf("expected ErrEmptyKey, got %v", err)
+	}
+	if _, err := ring.GetNodeInfo("key"); err != ErrNoNodes {
+		t.Errorf("expected ErrNoNodes, got %v", err)
+	}
+	if err := ring.SetMeta("missing", 1); err != ErrNodeNotFound {
+		t.Errorf("expected ErrNodeNotFound, got %v", err)
+	}
+	if _, err := ring.Meta("missing"); err != ErrNodeNotFound {
+		t.Errorf("expected ErrNodeNotFound, got %v", err)
+	}
+	if _, err := ring.NodeInfo("missing"); err != ErrNodeNotFound {
+		t.Errorf("expected ErrNodeNotFound, got %v", err)
+	}
+
+	// Plain nodes have no metadata
+	ring.AddNode("server1")
+	if meta, err := ring.Meta("server1"); err != nil || meta != nil {
+		t.Errorf("expected nil metadata, got %v (%v)", meta, err)
+	}
+}
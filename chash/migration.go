@@ -0,0 +1,140 @@
+package chash
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+// ErrNotFound is returned by a MigrationFetch when a node does not hold the key
+var ErrNotFound = errors.New("key not found on node")
+
+// HashMigration routes keys across two rings with different hash functions
+// while data moves from the old placement to the new one
+// Reads go to the new placement and fall back to the old; writes go to both,
+// so the hash function can be switched without a big-bang rehash
+type HashMigration struct {
+	old  *Ring
+	next *Ring
+
+	// Counters for progress tracking
+	reads     atomic.Uint64
+	moved     atomic.Uint64
+	fallbacks atomic.Uint64
+	migrated  atomic.Uint64
+}
+
+// MigrationProgress summarises how far a hash migration has come
+type MigrationProgress struct {
+	// Reads is the number of read lookups
+	Reads uint64
+
+	// Moved is the number of reads whose placement differs between the rings
+	Moved uint64
+
+	// Fallbacks is the number of reads served by the old placement
+	Fallbacks uint64
+
+	// Migrated is the number of keys reported as copied to their new placement
+	Migrated uint64
+}
+
+// FallbackRate returns the share of reads that had to fall back to the old placement
+// It approaches zero as the migration completes
+func (p MigrationProgress) FallbackRate() float64 {
+	if p.Reads == 0 {
+		return 0
+	}
+	return float64(p.Fallbacks) / float64(p.Reads)
+}
+
+// NewHashMigration creates a migration from the old ring to the next ring
+// Both rings should hold the same nodes; only their hash functions differ
+func NewHashMigration(old, next *Ring) *HashMigration {
+	return &HashMigration{old: old, next: next}
+}
+
+// ReadNodes returns the node to read key from and, if the placement moved,
+// the old node to fall back to; fallback is empty when both agree
+func (m *HashMigration) ReadNodes(key string) (primary, fallback string, err error) {
+	primary, err = m.next.GetNode(key)
+	if err != nil {
+		return "", "", err
+	}
+
+	old, err := m.old.GetNode(key)
+	if err != nil {
+		return "", "", err
+	}
+
+	m.reads.Add(1)
+	if old == primary {
+		return primary, "", nil
+	}
+
+	m.moved.Add(1)
+	return primary, old, nil
+}
+
+// WriteNodes returns every node a write of key must go to: the new placement
+// first, followed by the old one if it differs
+func (m *HashMigration) WriteNodes(key string) ([]string, error) {
+	primary, err := m.next.GetNode(key)
+	if err != nil {
+		return nil, err
+	}
+
+	old, err := m.old.GetNode(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if old == primary {
+		return []string{primary}, nil
+	}
+	return []string{primary, old}, nil
+}
+
+// MigrationFetch reads a key from a node, returning ErrNotFound if it is absent
+type MigrationFetch[T any] func(node string) (T, error)
+
+// ReadThrough reads key from its new placement and, on ErrNotFound, from its
+// old placement, recording the fallback in the migration's progress
+func ReadThrough[T any](m *HashMigration, key string, fetch MigrationFetch[T]) (T, error) {
+	var zero T
+
+	primary, fallback, err := m.ReadNodes(key)
+	if err != nil {
+		return zero, err
+	}
+
+	value, err := fetch(primary)
+	if err == nil || !errors.Is(err, ErrNotFound) || fallback == "" {
+		return value, err
+	}
+
+	m.fallbacks.Add(1)
+	return fetch(fallback)
+}
+
+// RecordFallback counts a read served by the old placement
+// Use it when reads are issued manually via ReadNodes rather than ReadThrough
+func (m *HashMigration) RecordFallback() {
+	m.fallbacks.Add(1)
+}
+
+// RecordMigrated counts n keys copied to their new placement
+func (m *HashMigration) RecordMigrated(n int) {
+	if n > 0 {
+		m.migrated.Add(uint64(n))
+	}
+}
+
+// Progress returns the migration counters
+func (m *HashMigration) Progress() MigrationProgress {
+	return MigrationProgress{
+		Reads:     m.reads.Load(),
+		Moved:     m.moved.Load(),
+		Fallbacks: m.fallbacks.Load(),
+		Migrated:  m.migrated.Load(),
+	}
+}
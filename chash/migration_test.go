@@ -0,0 +1,124 @@
+package chash
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"testing"
+)
+
+func fnvHash(key string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	return h.Sum64()
+}
+
+func newTestMigration() *HashMigration {
+	nodes := []string{"server1", "server2", "server3"}
+	old := NewWithNodes(Config{Replicas: 20}, nodes)
+	next := NewWithNodes(Config{Replicas: 20, HashFunc: fnvHash}, nodes)
+	return NewHashMigration(old, next)
+}
+
+func TestHashMigrationPlacements(t *testing.T) {
+	m := newTestMigration()
+
+	moved := 0
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("key%d", i)
+
+		primary, fallback, err := m.ReadNodes(key)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		expected, _ := m.next.GetNode(key)
+		if primary != expected {
+			t.Errorf("expected primary %s, got %s", expected, primary)
+		}
+
+		writes, _ := m.WriteNodes(key)
+		if fallback == "" {
+			if len(writes) != 1 {
+				t.Errorf("expected single write for unmoved key, got %v", writes)
+			}
+			continue
+		}
+
+		moved++
+		if len(writes) != 2 || writes[0] != primary || writes[1] != fallback {
+			t.Errorf("expected writes [%s %s], got %v", primary, fallback, writes)
+		}
+	}
+
+	progress := m.Progress()
+	if progress.Reads != 100 || progress.Moved != uint64(moved) {
+		t.Errorf("unexpected progress: %+v (moved %d)", progress, moved)
+	}
+	if moved == 0 {
+		t.Error("expected some keys to move between hash functions")
+	}
+}
+
+func TestReadThroughFallsBack(t *testing.T) {
+	m := newTestMigration()
+
+	// Data has not been copied yet: every key lives only on its old node
+	store := make(map[string]string)
+	var keys []string
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("key%d", i)
+		old, _ := m.old.GetNode(key)
+		store[old+"/"+key] = "value-" + key
+		keys = append(keys, key)
+	}
+
+	var current string
+	fetch := func(node string) (string, error) {
+		value, ok := store[node+"/"+current]
+		if !ok {
+			return "", ErrNotFound
+		}
+		return value, nil
+	}
+
+	for _, key := range keys {
+		current = key
+		value, err := ReadThrough(m, key, fetch)
+		if err != nil || value != "value-"+key {
+			t.Errorf("expected value for %s, got %q (%v)", key, value, err)
+		}
+	}
+
+	progress := m.Progress()
+	if progress.Fallbacks != progress.Moved {
+		t.Errorf("expected a fallback for every moved key, got %+v", progress)
+	}
+	if progress.FallbackRate() == 0 {
+		t.Error("expected non-zero fallback rate")
+	}
+}
+
+func TestReadThroughPropagatesErrors(t *testing.T) {
+	m := newTestMigration()
+	boom := errors.New("boom")
+
+	calls := 0
+	_, err := ReadThrough(m, "key1", func(node string) (int, error) {
+		calls++
+		return 0, boom
+	})
+
+	if err != boom || calls != 1 {
+		t.Errorf("expected error without fallback, got %v after %d calls", err, calls)
+	}
+	if m.Progress().Fallbacks != 0 {
+		t.Error("expected no fallbacks recorded")
+	}
+
+	m.RecordMigrated(5)
+	m.RecordFallback()
+	if p := m.Progress(); p.Migrated != 5 || p.Fallbacks != 1 {
+		t.Errorf("unexpected progress: %+v", p)
+	}
+}
@@ -0,0 +1,107 @@
+package chash
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrMovementBudgetExceeded is returned when a topology change would move more
+// of the keyspace than Config.MovementBudget allows
+var ErrMovementBudgetExceeded = errors.New("key movement budget exceeded")
+
+// movement is the share of the keyspace moved by one topology change
+type movement struct {
+	at       time.Time
+	fraction float64
+}
+
+// MovementSpent returns the share of the keyspace moved by topology changes
+// within the current movement window, forced changes included
+func (r *Ring) MovementSpent() float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.movementSpentLocked(r.clock.Now())
+}
+
+// movementSpentLocked drops movements older than the window and sums the rest
+// Caller must hold r.mu for writing
+func (r *Ring) movementSpentLocked(now time.Time) float64 {
+	cutoff := now.Add(-r.movementWindow)
+	i := 0
+	for i < len(r.movements) && !r.movements[i].at.After(cutoff) {
+		i++
+	}
+	r.movements = r.movements[i:]
+
+	var spent float64
+	for _, m := range r.movements {
+		spent += m.fraction
+	}
+	return spent
+}
+
+// spendMovementLocked measures the keyspace mutate moves by applying it to a
+// copy of the ring, and records it if it fits the budget or force is set
+// Returns ErrMovementBudgetExceeded otherwise; the ring itself is never
+// modified, so the caller applies the change only after a nil error
+// Caller must hold r.mu for writing
+func (r *Ring) spendMovementLocked(now time.Time, force bool, mutate func(probe *Ring)) error {
+	if r.movementBudget <= 0 {
+		return nil
+	}
+
+	probe := r.cloneLocked()
+	mutate(probe)
+
+	var fraction float64
+	for _, seg := range compareOwnership(r.pointsLocked(), probe.pointsLocked()) {
+		if seg.ownerA != "" { // Populating an empty ring moves no data
+			fraction += seg.rng.Fraction()
+		}
+	}
+	fraction = min(fraction, 1)
+
+	spent := r.movementSpentLocked(now)
+	if !force && spent+fraction > r.movementBudget {
+		return fmt.Errorf("%w: change moves %.2f%% of the keyspace, %.2f%% of %.2f%% left in window",
+			ErrMovementBudgetExceeded, fraction*100, max(r.movementBudget-spent, 0)*100, r.movementBudget*100)
+	}
+
+	r.movements = append(r.movements, movement{at: now, fraction: fraction})
+	return nil
+}
+
+// cloneLocked copies the placement state of the ring into a detached ring
+// that topology changes can be rehearsed on; it has no subscribers, pins or
+// budget of its own
+// Caller must hold r.mu
+func (r *Ring) cloneLocked() *Ring {
+	nodeSet := make(map[string]*nodeEntry, len(r.nodeSet))
+	for name, entry := range r.nodeSet {
+		copied := *entry
+		nodeSet[name] = &copied
+	}
+
+	return &Ring{
+		hashFunc:           r.hashFunc,
+		hashFuncBytes:      r.hashFuncBytes,
+		hashName:           r.hashName,
+		replicas:           r.replicas,
+		targetStdDev:       r.targetStdDev,
+		maxVirtualNodes:    r.maxVirtualNodes,
+		ketama:             r.ketama,
+		partitions:         r.partitions,
+		clock:              r.clock,
+		ring:               append([]uint64(nil), r.ring...),
+		owners:             append([]uint32(nil), r.owners...),
+		names:              append([]string(nil), r.names...),
+		nodeSet:            nodeSet,
+		inactive:           r.inactive,
+		tombstones:         make(map[string]Tombstone),
+		tombstoneRetention: r.tombstoneRetention,
+		resolveCollisions:  r.resolveCollisions,
+		displaced:          r.displaced,
+	}
+}
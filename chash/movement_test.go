@@ -0,0 +1,149 @@
+package chash
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/mohdrashid9678/dcore/clock"
+)
+
+func newBudgetRing(budget float64, clk clock.Clock) *Ring {
+	return NewWithNodes(Config{
+		Replicas:       100,
+		MovementBudget: budget,
+		MovementWindow: time.Hour,
+		Clock:          clk,
+	}, []string{"server1", "server2", "server3", "server4"})
+}
+
+func TestMovementMatchesDiff(t *testing.T) {
+	ring := newBudgetRing(1, nil)
+	before := NewWithNodes(Config{Replicas: 100}, ring.Nodes())
+
+	if err := ring.AddNode("server5"); err != nil {
+		t.Fatalf("AddNode failed: %v", err)
+	}
+
+	want := MovedFraction(before.Diff(ring))
+	if got := ring.MovementSpent(); math.Abs(got-want) > 1e-9 {
+		t.Errorf("expected movement %.4f to match diff %.4f", got, want)
+	}
+}
+
+func TestMovementBudgetRejectsAndForces(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	ring := newBudgetRing(0.3, clk)
+
+	if err := ring.AddNode("server5"); err != nil {
+		t.Fatalf("expected first addition within budget, got %v", err)
+	}
+	spent := ring.MovementSpent()
+	version := ring.Version()
+
+	if err := ring.AddNode("server6"); !errors.Is(err, ErrMovementBudgetExceeded) {
+		t.Fatalf("expected ErrMovementBudgetExceeded, got %v", err)
+	}
+	if err := ring.RemoveNode("server1"); !errors.Is(err, ErrMovementBudgetExceeded) {
+		t.Fatalf("expected removal to exceed budget, got %v", err)
+	}
+	if ring.Version() != version || ring.MovementSpent() != spent {
+		t.Error("expected rejected changes to leave the ring and budget untouched")
+	}
+
+	if err := ring.ForceAddNode("server6"); err != nil {
+		t.Fatalf("expected forced addition to succeed, got %v", err)
+	}
+	if ring.MovementSpent() <= spent {
+		t.Error("expected forced addition to count against the budget")
+	}
+
+	// The window slides past the earlier changes
+	clk.Advance(time.Hour)
+	if spent := ring.MovementSpent(); spent != 0 {
+		t.Errorf("expected empty window, got %.4f", spent)
+	}
+	if err := ring.RemoveNode("server1"); err != nil {
+		t.Errorf("expected removal after window to succeed, got %v", err)
+	}
+}
+
+func TestMovementBudgetBatches(t *testing.T) {
+	ring := newBudgetRing(0.3, nil)
+
+	if err := ring.AddNodes([]string{"server5", "server6"}); !errors.Is(err, ErrMovementBudgetExceeded) {
+		t.Fatalf("expected batch to exceed budget, got %v", err)
+	}
+	if len(ring.Nodes()) != 4 {
+		t.Error("expected no node of a rejected batch to be added")
+	}
+
+	if err := ring.RemoveNodes([]string{"server1", "server2"}); !errors.Is(err, ErrMovementBudgetExceeded) {
+		t.Fatalf("expected batch to exceed budget, got %v", err)
+	}
+
+	err := ring.SetNodes([]string{"server3", "server4", "server5", "server6"})
+	if !errors.Is(err, ErrMovementBudgetExceeded) {
+		t.Fatalf("expected membership replacement to exceed budget, got %v", err)
+	}
+	if len(ring.Nodes()) != 4 || ring.MovementSpent() != 0 {
+		t.Error("expected rejected batches to leave the ring and budget untouched")
+	}
+
+	if err := ring.RemoveNodes([]string{"server1"}); err != nil {
+		t.Errorf("expected small batch within budget, got %v", err)
+	}
+}
+
+func TestMovementBudgetResizes(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	ring := newBudgetRing(0.05, clk)
+	version := ring.Version()
+
+	if err := ring.UpdateWeight("server1", 20); !errors.Is(err, ErrMovementBudgetExceeded) {
+		t.Fatalf("expected weight change to exceed budget, got %v", err)
+	}
+	if weight, _ := ring.Weight("server1"); weight != 1 {
+		t.Errorf("expected rejected weight change to keep weight 1, got %v", weight)
+	}
+	if err := ring.SetVirtualNodes("server2", 2000); !errors.Is(err, ErrMovementBudgetExceeded) {
+		t.Fatalf("expected virtual node change to exceed budget, got %v", err)
+	}
+	if vnodes, _ := ring.VirtualNodes("server2"); vnodes != 100 {
+		t.Errorf("expected rejected change to keep 100 virtual nodes, got %d", vnodes)
+	}
+	if ring.Version() != version || ring.MovementSpent() != 0 {
+		t.Error("expected rejected resizes to leave the ring and budget untouched")
+	}
+
+	// A small resize fits and is charged like a diff of the two layouts
+	before := ring.Clone()
+	if err := ring.SetVirtualNodes("server2", 105); err != nil {
+		t.Fatalf("expected small resize within budget, got %v", err)
+	}
+	want := MovedFraction(before.Diff(ring))
+	if got := ring.MovementSpent(); want == 0 || math.Abs(got-want) > 1e-9 {
+		t.Errorf("expected movement %.4f to match diff %.4f", got, want)
+	}
+
+	// The override is kept, so clearing it is a resize of its own
+	clk.Advance(time.Hour)
+	if err := ring.UpdateWeight("server2", 1.02); err != nil {
+		t.Fatalf("UpdateWeight failed: %v", err)
+	}
+	if ring.MovementSpent() != 0 {
+		t.Error("expected a weight change under an override to move nothing")
+	}
+}
+
+func TestMovementBudgetDisabled(t *testing.T) {
+	ring := newBudgetRing(0, nil)
+
+	if err := ring.AddNodes([]string{"server5", "server6", "server7"}); err != nil {
+		t.Fatalf("expected no budget by default, got %v", err)
+	}
+	if spent := ring.MovementSpent(); spent != 0 {
+		t.Errorf("expected no movement tracked without a budget, got %.4f", spent)
+	}
+}
@@ -0,0 +1,82 @@
+package chash
+
+import "errors"
+
+// Namespace is a view of a ring for one keyspace; it shares the ring's nodes
+// but hashes keys with the namespace mixed in, so tenants that reuse key
+// names are spread independently instead of landing on the same nodes
+// Affinity groups, hash tags, node states and partitions apply as on the
+// ring; pins do not, since they are keyed by plain key
+type Namespace struct {
+	ring *Ring
+	name string
+	salt uint64
+}
+
+// Namespace returns the view of the ring for namespace name
+// The empty name is the ring's own keyspace and routes exactly like the ring
+func (r *Ring) Namespace(name string) *Namespace {
+	ns := &Namespace{ring: r, name: name}
+	if name != "" {
+		ns.salt = r.hashFunc("namespace#" + name)
+	}
+	return ns
+}
+
+// Name returns the namespace name
+func (ns *Namespace) Name() string {
+	return ns.name
+}
+
+// hashKeyLocked is Ring.hashKeyLocked with the namespace mixed in
+// Caller must hold ns.ring.mu
+func (ns *Namespace) hashKeyLocked(key string) uint64 {
+	r := ns.ring
+	hash := r.hashFunc(r.groupKeyLocked(key))
+	if ns.name != "" {
+		hash = mix64(hash ^ ns.salt)
+	}
+	return r.partitionHash(hash)
+}
+
+// GetNode returns the node responsible for key in the namespace
+func (ns *Namespace) GetNode(key string) (string, error) {
+	if key == "" {
+		return "", ErrEmptyKey
+	}
+
+	r := ns.ring
+	r.lookups.Add(1)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(r.ring) == 0 {
+		return "", ErrNoNodes
+	}
+
+	return servingNode(r.primaryLocked(r.searchLocked(ns.hashKeyLocked(key))))
+}
+
+// GetNodes returns the top count nodes responsible for key in the namespace
+func (ns *Namespace) GetNodes(key string, count int) ([]string, error) {
+	if key == "" {
+		return nil, ErrEmptyKey
+	}
+
+	if count <= 0 {
+		return nil, errors.New("count must be positive")
+	}
+
+	r := ns.ring
+	r.lookups.Add(1)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(r.ring) == 0 {
+		return nil, ErrNoNodes
+	}
+
+	return nodesOrErr(r.walkLocked(r.searchLocked(ns.hashKeyLocked(key)), count))
+}
@@ -0,0 +1,83 @@
+package chash
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestNamespaceIsolatesTenants(t *testing.T) {
+	ring := NewWithNodes(Config{Replicas: 50}, []string{"server1", "server2", "server3", "server4"})
+	a := ring.Namespace("tenant-a")
+	b := ring.Namespace("tenant-b")
+
+	if a.Name() != "tenant-a" {
+		t.Errorf("expected tenant-a, got %s", a.Name())
+	}
+
+	differ := 0
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("user:%d", i)
+
+		na, err := a.GetNode(key)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		nb, _ := b.GetNode(key)
+		if na != nb {
+			differ++
+		}
+
+		// Lookups are stable and replicas start with the primary
+		if again, _ := a.GetNode(key); again != na {
+			t.Fatalf("%s: expected stable routing, got %s then %s", key, na, again)
+		}
+		if nodes, _ := a.GetNodes(key, 2); len(nodes) != 2 || nodes[0] != na {
+			t.Errorf("%s: expected 2 replicas led by %s, got %v", key, na, nodes)
+		}
+	}
+
+	// With four nodes, independent placement puts about 3/4 of keys elsewhere
+	if differ < 600 {
+		t.Errorf("expected namespaces to place keys independently, only %d of 1000 differ", differ)
+	}
+}
+
+func TestNamespaceDefaultMatchesRing(t *testing.T) {
+	ring := NewWithNodes(Config{Replicas: 50}, []string{"server1", "server2", "server3"})
+	ns := ring.Namespace("")
+
+	for i := 0; i < 200; i++ {
+		key := fmt.Sprintf("key%d", i)
+		want, _ := ring.GetNode(key)
+		if got, _ := ns.GetNode(key); got != want {
+			t.Errorf("%s: expected %s, got %s", key, want, got)
+		}
+	}
+}
+
+func TestNamespaceKeepsHashTags(t *testing.T) {
+	ring := NewWithNodes(Config{Replicas: 50, HashTags: true}, []string{"server1", "server2", "server3"})
+	ns := ring.Namespace("tenant-a")
+
+	for i := 0; i < 50; i++ {
+		n1, _ := ns.GetNode(fmt.Sprintf("user:{%d}:profile", i))
+		n2, _ := ns.GetNode(fmt.Sprintf("user:{%d}:cart", i))
+		if n1 != n2 {
+			t.Errorf("expected tagged keys to share a node, got %s and %s", n1, n2)
+		}
+	}
+}
+
+func TestNamespaceErrors(t *testing.T) {
+	ns := New(Config{}).Namespace("tenant-a")
+
+	if _, err := ns.GetNode("key"); err != ErrNoNodes {
+		t.Errorf("expected ErrNoNodes, got %v", err)
+	}
+	if _, err := ns.GetNode(""); err != ErrEmptyKey {
+		t.Errorf("expected ErrEmptyKey, got %v", err)
+	}
+	if _, err := ns.GetNodes("key", 0); err == nil {
+		t.Error("expected an error for a non-positive count")
+	}
+}
@@ -0,0 +1,145 @@
+package chash
+
+import (
+	"errors"
+	"strconv"
+)
+
+var (
+	// ErrPartitionsDisabled is returned by partition APIs on a ring without Config.Partitions
+	ErrPartitionsDisabled = errors.New("ring is not in fixed partition mode")
+
+	// ErrInvalidPartition is returned for a partition number outside [0, Partitions)
+	ErrInvalidPartition = errors.New("partition out of range")
+)
+
+// partitionPositions returns the ring position of each of n partitions
+func partitionPositions(hashFunc HashFunc, n int) []uint64 {
+	if n <= 0 {
+		return nil
+	}
+
+	positions := make([]uint64, n)
+	for p := range positions {
+		positions[p] = hashFunc("partition#" + strconv.Itoa(p))
+	}
+	return positions
+}
+
+// partitionHash maps a key hash to its partition's ring position
+// It returns hash unchanged when partition mode is disabled
+// partitions is immutable, so no lock is needed
+func (r *Ring) partitionHash(hash uint64) uint64 {
+	if len(r.partitions) == 0 {
+		return hash
+	}
+	return r.partitions[hash%uint64(len(r.partitions))]
+}
+
+// PartitionCount returns the number of fixed partitions, or 0 if partition
+// mode is disabled
+func (r *Ring) PartitionCount() int {
+	return len(r.partitions)
+}
+
+// GetPartition returns the partition key belongs to
+// A key's partition never changes; only the partition's owner does. Affinity
+// groups apply, so grouped keys share a partition
+func (r *Ring) GetPartition(key string) (int, error) {
+	if key == "" {
+		return 0, ErrEmptyKey
+	}
+	if len(r.partitions) == 0 {
+		return 0, ErrPartitionsDisabled
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return int(r.hashFunc(r.groupKeyLocked(key)) % uint64(len(r.partitions))), nil
+}
+
+// PartitionOwner returns the node responsible for partition p
+func (r *Ring) PartitionOwner(p int) (string, error) {
+	if len(r.partitions) == 0 {
+		return "", ErrPartitionsDisabled
+	}
+	if p < 0 || p >= len(r.partitions) {
+		return "", ErrInvalidPartition
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(r.ring) == 0 {
+		return "", ErrNoNodes
+	}
+
+	return servingNode(r.primaryLocked(r.searchLocked(r.partitions[p])))
+}
+
+// PartitionReplicas returns count distinct nodes for partition p, primary first
+func (r *Ring) PartitionReplicas(p, count int) ([]string, error) {
+	if len(r.partitions) == 0 {
+		return nil, ErrPartitionsDisabled
+	}
+	if p < 0 || p >= len(r.partitions) {
+		return nil, ErrInvalidPartition
+	}
+	if count <= 0 {
+		return nil, errors.New("count must be positive")
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(r.ring) == 0 {
+		return nil, ErrNoNodes
+	}
+
+	return nodesOrErr(r.walkLocked(r.searchLocked(r.partitions[p]), count))
+}
+
+// PartitionTable returns the owner of every partition, indexed by partition number
+// Comparing tables taken before and after a topology change yields the
+// partitions to move
+func (r *Ring) PartitionTable() ([]string, error) {
+	if len(r.partitions) == 0 {
+		return nil, ErrPartitionsDisabled
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(r.ring) == 0 {
+		return nil, ErrNoNodes
+	}
+
+	owners := make([]string, len(r.partitions))
+	for p, hash := range r.partitions {
+		owners[p] = r.primaryLocked(r.searchLocked(hash))
+	}
+	return owners, nil
+}
+
+// PartitionsOwnedBy returns the partitions node is the primary owner of, ascending
+func (r *Ring) PartitionsOwnedBy(node string) ([]int, error) {
+	if len(r.partitions) == 0 {
+		return nil, ErrPartitionsDisabled
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, exists := r.nodeSet[node]; !exists {
+		return nil, ErrNodeNotFound
+	}
+
+	var owned []int
+	for p, hash := range r.partitions {
+		if r.primaryLocked(r.searchLocked(hash)) == node {
+			owned = append(owned, p)
+		}
+	}
+	return owned, nil
+}
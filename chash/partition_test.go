@@ -0,0 +1,159 @@
+package chash
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestPartitionRouting(t *testing.T) {
+	ring := NewWithNodes(Config{Replicas: 20, Partitions: 64}, []string{"server1", "server2", "server3"})
+
+	if ring.PartitionCount() != 64 {
+		t.Errorf("expected 64 partitions, got %d", ring.PartitionCount())
+	}
+
+	for i := 0; i < 500; i++ {
+		key := fmt.Sprintf("key%d", i)
+
+		p, err := ring.GetPartition(key)
+		if err != nil || p < 0 || p >= 64 {
+			t.Fatalf("expected partition in range, got %d (%v)", p, err)
+		}
+
+		owner, _ := ring.PartitionOwner(p)
+		node, _ := ring.GetNode(key)
+		if node != owner {
+			t.Errorf("expected %s to route to partition owner %s, got %s", key, owner, node)
+		}
+
+		replicas, _ := ring.GetNodes(key, 2)
+		partitionReplicas, _ := ring.PartitionReplicas(p, 2)
+		if fmt.Sprint(replicas) != fmt.Sprint(partitionReplicas) {
+			t.Errorf("expected replicas %v, got %v", partitionReplicas, replicas)
+		}
+
+		nodeBytes, _ := ring.GetNodeBytes([]byte(key))
+		if nodeBytes != owner {
+			t.Errorf("expected byte key to route to %s, got %s", owner, nodeBytes)
+		}
+	}
+}
+
+func TestPartitionsMoveWhole(t *testing.T) {
+	ring := NewWithNodes(Config{Replicas: 20, Partitions: 128}, []string{"server1", "server2", "server3"})
+
+	before, err := ring.PartitionTable()
+	if err != nil || len(before) != 128 {
+		t.Fatalf("expected 128 owners, got %d (%v)", len(before), err)
+	}
+
+	ring.AddNode("server4")
+	after, _ := ring.PartitionTable()
+
+	moved := make(map[int]bool)
+	for p := range before {
+		if before[p] != after[p] {
+			if after[p] != "server4" {
+				t.Errorf("partition %d moved to %s, expected server4", p, after[p])
+			}
+			moved[p] = true
+		}
+	}
+
+	owned, _ := ring.PartitionsOwnedBy("server4")
+	if len(owned) != len(moved) || len(owned) == 0 {
+		t.Errorf("expected server4 to own the %d moved partitions, got %v", len(moved), owned)
+	}
+
+	// Every key in an unmoved partition keeps its owner
+	for i := 0; i < 500; i++ {
+		key := fmt.Sprintf("key%d", i)
+		p, _ := ring.GetPartition(key)
+		node, _ := ring.GetNode(key)
+		if !moved[p] && node != before[p] {
+			t.Errorf("key %s in unmoved partition %d changed owner", key, p)
+		}
+	}
+}
+
+func TestPartitionAffinityGroups(t *testing.T) {
+	ring := NewWithNodes(Config{Replicas: 20, Partitions: 256}, []string{"server1", "server2"})
+	ring.AddAffinityGroup("user:42", "user:42:")
+
+	p1, _ := ring.GetPartition("user:42:profile")
+	p2, _ := ring.GetPartition("user:42:cart")
+	if p1 != p2 {
+		t.Errorf("expected grouped keys to share a partition, got %d and %d", p1, p2)
+	}
+}
+
+func TestPartitionErrors(t *testing.T) {
+	plain := NewWithNodes(Config{Replicas: 5}, []string{"server1"})
+	if _, err := plain.GetPartition("key1"); err != ErrPartitionsDisabled {
+		t.Errorf("expected ErrPartitionsDisabled, got %v", err)
+	}
+	if _, err := plain.PartitionOwner(0); err != ErrPartitionsDisabled {
+		t.Errorf("expected ErrPartitionsDisabled, got %v", err)
+	}
+
+	ring := New(Config{Replicas: 5, Partitions: 8})
+	if _, err := ring.PartitionOwner(0); err != ErrNoNodes {
+		t.Errorf("expected ErrNoNodes, got %v", err)
+	}
+	if _, err := ring.PartitionTable(); err != ErrNoNodes {
+		t.Errorf("expected ErrNoNodes, got %v", err)
+	}
+
+	ring.AddNode("server1")
+	if _, err := ring.PartitionOwner(8); err != ErrInvalidPartition {
+		t.Errorf("expected ErrInvalidPartition, got %v", err)
+	}
+	if _, err := ring.PartitionReplicas(-1, 1); err != ErrInvalidPartition {
+		t.Errorf("expected ErrInvalidPartition, got %v", err)
+	}
+	if _, err := ring.GetPartition(""); err != ErrEmptyKey {
+		t.Errorf("expected ErrEmptyKey, got %v", err)
+	}
+	if _, err := ring.PartitionsOwnedBy("server9"); err != ErrNodeNotFound {
+		t.Errorf("expected ErrNodeNotFound, got %v", err)
+	}
+}
+
+func TestPartitionSnapshot(t *testing.T) {
+	ring := NewWithNodes(Config{Replicas: 10, Partitions: 32}, []string{"server1", "server2"})
+
+	data, err := ring.MarshalBinary()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	restored := New(Config{Replicas: 10, Partitions: 32})
+	if err := restored.UnmarshalBinary(data); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	before, _ := ring.PartitionTable()
+	after, _ := restored.PartitionTable()
+	if fmt.Sprint(before) != fmt.Sprint(after) {
+		t.Error("expected restored ring to own partitions identically")
+	}
+
+	// A different partition count would route every key differently
+	if err := New(Config{Partitions: 16}).UnmarshalBinary(data); err != ErrSnapshotMismatch {
+		t.Errorf("expected ErrSnapshotMismatch, got %v", err)
+	}
+	if err := New(Config{}).UnmarshalBinary(data); err != ErrSnapshotMismatch {
+		t.Errorf("expected ErrSnapshotMismatch, got %v", err)
+	}
+
+	js, _ := ring.MarshalJSON()
+	if err := New(Config{Partitions: 32}).UnmarshalJSON(js); err != nil {
+		t.Errorf("expected JSON round trip, got %v", err)
+	}
+
+	// Partitions require version 2
+	err = ring.UnmarshalJSON([]byte(`{"version":1,"replicas":5,"partitions":32,"nodes":[]}`))
+	if !errors.Is(err, ErrInvalidSnapshot) {
+		t.Errorf("expected ErrInvalidSnapshot, got %v", err)
+	}
+}
@@ -0,0 +1,122 @@
+package chash
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+// ErrInvalidTTL is returned when a pin is requested with a non-positive TTL
+var ErrInvalidTTL = errors.New("ttl must be positive")
+
+// pin fixes a key to an owner until it expires
+type pin struct {
+	node    string
+	expires time.Time
+}
+
+// Pin fixes each key to its current owner for ttl, even across topology changes,
+// and returns the owners it pinned
+// Batch jobs that pre-compute per-node work lists use it so ownership cannot
+// shift under them mid-run. A pin is ignored once its node leaves the ring,
+// since requests cannot be routed to a removed node. Pinning an already pinned
+// key keeps its owner and extends the TTL
+func (r *Ring) Pin(ttl time.Duration, keys ...string) (map[string]string, error) {
+	if ttl <= 0 {
+		return nil, ErrInvalidTTL
+	}
+	for _, key := range keys {
+		if key == "" {
+			return nil, ErrEmptyKey
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Every node disabled leaves nothing to pin to
+	if len(r.ring) == 0 || r.primaryLocked(0) == "" {
+		return nil, ErrNoNodes
+	}
+
+	now := r.clock.Now()
+	r.purgePinsLocked(now)
+
+	expires := now.Add(ttl)
+	owners := make(map[string]string, len(keys))
+	for _, key := range keys {
+		node, ok := r.pinnedLocked(key, now)
+		if !ok {
+			node = r.primaryLocked(r.searchLocked(r.hashKeyLocked(key)))
+		}
+
+		r.pins[key] = pin{node: node, expires: expires}
+		owners[key] = node
+	}
+
+	return owners, nil
+}
+
+// Unpin releases the given keys so they follow the ring again
+func (r *Ring) Unpin(keys ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, key := range keys {
+		delete(r.pins, key)
+	}
+}
+
+// Pinned returns the node a key is pinned to, if it has a live pin
+func (r *Ring) Pinned(key string) (string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.pinnedLocked(key, r.clock.Now())
+}
+
+// PinnedKeys returns the keys with live pins, sorted
+func (r *Ring) PinnedKeys() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := r.clock.Now()
+	var keys []string
+	for key := range r.pins {
+		if _, ok := r.pinnedLocked(key, now); ok {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+	return keys
+}
+
+// pinnedLocked returns the pinned owner of key if the pin is live and its node
+// is still in the ring
+// Caller must hold r.mu
+func (r *Ring) pinnedLocked(key string, now time.Time) (string, bool) {
+	if len(r.pins) == 0 {
+		return "", false
+	}
+
+	p, ok := r.pins[key]
+	if !ok || !now.Before(p.expires) {
+		return "", false
+	}
+	if _, exists := r.nodeSet[p.node]; !exists {
+		return "", false
+	}
+
+	return p.node, true
+}
+
+// purgePinsLocked drops expired pins
+// Caller must hold r.mu for writing
+func (r *Ring) purgePinsLocked(now time.Time) {
+	for key, p := range r.pins {
+		if !now.Before(p.expires) {
+			delete(r.pins, key)
+		}
+	}
+}
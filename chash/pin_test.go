@@ -0,0 +1,117 @@
+package chash
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/mohdrashid9678/dcore/clock"
+)
+
+func TestPinSurvivesTopologyChange(t *testing.T) {
+	ring := NewWithNodes(Config{Replicas: 20}, []string{"server1", "server2"})
+
+	keys := make([]string, 200)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key%d", i)
+	}
+
+	owners, err := ring.Pin(time.Hour, keys...)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	ring.AddNode("server3")
+	ring.AddNode("server4")
+
+	for _, key := range keys {
+		node, _ := ring.GetNode(key)
+		if node != owners[key] {
+			t.Errorf("expected %s to stay on %s, got %s", key, owners[key], node)
+		}
+	}
+
+	if len(ring.PinnedKeys()) != len(keys) {
+		t.Errorf("expected %d pinned keys, got %d", len(keys), len(ring.PinnedKeys()))
+	}
+
+	// Unpinned keys follow the ring again
+	ring.Unpin(keys...)
+	moved := 0
+	for _, key := range keys {
+		node, _ := ring.GetNode(key)
+		if node != owners[key] {
+			moved++
+		}
+	}
+	if moved == 0 {
+		t.Error("expected some keys to move after unpinning")
+	}
+}
+
+func TestPinExpiry(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	ring := NewWithNodes(Config{Replicas: 20, Clock: clk}, []string{"server1"})
+
+	ring.Pin(time.Minute, "key1")
+	clk.Advance(59 * time.Second)
+	if _, ok := ring.Pinned("key1"); !ok {
+		t.Error("expected pin to hold until it expires")
+	}
+
+	clk.Advance(time.Second)
+	if _, ok := ring.Pinned("key1"); ok {
+		t.Error("expected pin to expire")
+	}
+
+	// Expired pins are purged on the next Pin call
+	ring.Pin(time.Hour, "key2")
+	if len(ring.pins) != 1 {
+		t.Errorf("expected expired pin to be purged, got %d pins", len(ring.pins))
+	}
+}
+
+func TestPinIgnoredForRemovedNode(t *testing.T) {
+	ring := NewWithNodes(Config{Replicas: 20}, []string{"server1", "server2"})
+
+	owners, _ := ring.Pin(time.Hour, "key1")
+	ring.RemoveNode(owners["key1"])
+
+	if _, ok := ring.Pinned("key1"); ok {
+		t.Error("expected pin to removed node to be ignored")
+	}
+
+	node, err := ring.GetNode("key1")
+	if err != nil || node == owners["key1"] {
+		t.Errorf("expected key routed to remaining node, got %s (%v)", node, err)
+	}
+}
+
+func TestPinRepinKeepsOwner(t *testing.T) {
+	ring := NewWithNodes(Config{Replicas: 20}, []string{"server1"})
+
+	first, _ := ring.Pin(time.Hour, "key1")
+	ring.AddNode("server2")
+	ring.AddNode("server3")
+
+	second, _ := ring.Pin(time.Hour, "key1")
+	if second["key1"] != first["key1"] {
+		t.Errorf("expected re-pin to keep %s, got %s", first["key1"], second["key1"])
+	}
+}
+
+func TestPinValidation(t *testing.T) {
+	ring := New(Config{Replicas: 5})
+
+	if _, err := ring.Pin(time.Hour, "key1"); err != ErrNoNodes {
+		t.Errorf("expected ErrNoNodes, got %v", err)
+	}
+
+	ring.AddNode("server1")
+	if _, err := ring.Pin(0, "key1"); err != ErrInvalidTTL {
+		t.Errorf("expected ErrInvalidTTL, got %v", err)
+	}
+	if _, err := ring.Pin(time.Hour, ""); err != ErrEmptyKey {
+		t.Errorf("expected ErrEmptyKey, got %v", err)
+	}
+}
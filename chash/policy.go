@@ -0,0 +1,205 @@
+package chash
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+var (
+	// ErrInvalidPolicy is returned for a write policy without rules or with a
+	// rule that asks for no replicas
+	ErrInvalidPolicy = errors.New("invalid write policy")
+
+	// ErrPolicyUnsatisfiable is returned when the ring does not have enough
+	// matching nodes in distinct domains to satisfy a write policy
+	ErrPolicyUnsatisfiable = errors.New("write policy cannot be satisfied")
+)
+
+// PlacementRule asks for Count replicas on nodes selected by topology labels
+// Empty fields of Match and Exclude are ignored, so the zero rule matches
+// every node
+type PlacementRule struct {
+	// Match selects nodes whose labels equal every non-empty field
+	Match Topology `json:"match,omitempty"`
+
+	// Exclude rejects nodes whose labels equal any non-empty field
+	Exclude Topology `json:"exclude,omitempty"`
+
+	// Count is the number of replicas to place with this rule
+	Count int `json:"count"`
+
+	// Spread places this rule's replicas in distinct failure domains
+	// Default: SpreadNode
+	Spread SpreadConstraint `json:"spread,omitempty"`
+}
+
+// WritePolicy declares where the replicas of a key are placed, such as two
+// replicas in the local region on distinct zones plus one in any other region
+// Rules are filled in order and a node serves at most one rule
+type WritePolicy struct {
+	Rules []PlacementRule `json:"rules"`
+}
+
+// Replicas returns the total number of replicas the policy places
+func (p WritePolicy) Replicas() int {
+	total := 0
+	for _, rule := range p.Rules {
+		total += rule.Count
+	}
+	return total
+}
+
+// validate checks that every rule asks for at least one replica
+func (p WritePolicy) validate() error {
+	if len(p.Rules) == 0 {
+		return fmt.Errorf("%w: no rules", ErrInvalidPolicy)
+	}
+	for i, rule := range p.Rules {
+		if rule.Count <= 0 {
+			return fmt.Errorf("%w: rule %d: count must be positive", ErrInvalidPolicy, i)
+		}
+	}
+	return nil
+}
+
+// matches returns true if a node with topology t is eligible for the rule
+func (rule PlacementRule) matches(t Topology) bool {
+	m, x := rule.Match, rule.Exclude
+	if (m.Region != "" && t.Region != m.Region) ||
+		(m.Zone != "" && t.Zone != m.Zone) ||
+		(m.Rack != "" && t.Rack != m.Rack) {
+		return false
+	}
+	return (x.Region == "" || t.Region != x.Region) &&
+		(x.Zone == "" || t.Zone != x.Zone) &&
+		(x.Rack == "" || t.Rack != x.Rack)
+}
+
+// policyPlacement assigns candidate nodes to the rules of a policy
+type policyPlacement struct {
+	policy  WritePolicy
+	nodes   [][]string
+	domains []map[string]struct{}
+	placed  int
+}
+
+func newPolicyPlacement(policy WritePolicy) *policyPlacement {
+	p := &policyPlacement{
+		policy:  policy,
+		nodes:   make([][]string, len(policy.Rules)),
+		domains: make([]map[string]struct{}, len(policy.Rules)),
+	}
+	for i := range p.domains {
+		p.domains[i] = make(map[string]struct{})
+	}
+	return p
+}
+
+// offer assigns node to the first rule it matches that still needs replicas
+// and has not used the node's domain
+func (p *policyPlacement) offer(node string, t Topology) {
+	for i, rule := range p.policy.Rules {
+		if len(p.nodes[i]) == rule.Count || !rule.matches(t) {
+			continue
+		}
+		domain := rule.Spread.domain(node, t)
+		if _, used := p.domains[i][domain]; used {
+			continue
+		}
+
+		p.domains[i][domain] = struct{}{}
+		p.nodes[i] = append(p.nodes[i], node)
+		p.placed++
+		return
+	}
+}
+
+// done returns true once every rule has its replicas
+func (p *policyPlacement) done() bool {
+	return p.placed == p.policy.Replicas()
+}
+
+// err describes the first rule left short of replicas
+func (p *policyPlacement) err() error {
+	for i, rule := range p.policy.Rules {
+		if have := len(p.nodes[i]); have < rule.Count {
+			return fmt.Errorf("%w: rule %d needs %d %s domains, have %d",
+				ErrPolicyUnsatisfiable, i, rule.Count, rule.Spread, have)
+		}
+	}
+	return nil
+}
+
+// GetNodesPolicy returns the replicas of key placed by a write policy
+// It walks the ring clockwise like GetNodes and offers each node to the rules
+// in order; the result lists the replicas of the first rule, then the second,
+// and so on, each in ring order
+func (r *Ring) GetNodesPolicy(key string, policy WritePolicy) ([]string, error) {
+	if key == "" {
+		return nil, ErrEmptyKey
+	}
+	if err := policy.validate(); err != nil {
+		return nil, err
+	}
+
+	r.lookups.Add(1)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(r.ring) == 0 {
+		return nil, ErrNoNodes
+	}
+
+	idx := r.searchLocked(r.hashKeyLocked(key))
+
+	placement := newPolicyPlacement(policy)
+	r.visitLocked(idx, make([]bool, len(r.names)), func(owner uint32) bool {
+		node := r.names[owner]
+		placement.offer(node, r.nodeSet[node].topology)
+		return !placement.done()
+	})
+
+	if err := placement.err(); err != nil {
+		return nil, err
+	}
+
+	result := make([]string, 0, policy.Replicas())
+	for _, nodes := range placement.nodes {
+		result = append(result, nodes...)
+	}
+	return result, nil
+}
+
+// CheckPolicy reports whether the ring can satisfy a write policy for every
+// key, and if failed is not nil, whether it still can after losing every node
+// failed returns true for; use it to simulate the loss of a zone or region
+// before rolling a policy out
+// Nodes are offered in name order, so with overlapping rules a key may still
+// fail where the check passes; rules that select disjoint nodes are exact
+func (r *Ring) CheckPolicy(policy WritePolicy, failed func(node string, t Topology) bool) error {
+	if err := policy.validate(); err != nil {
+		return err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	nodes := make([]string, 0, len(r.nodeSet))
+	for node := range r.nodeSet {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+
+	placement := newPolicyPlacement(policy)
+	for _, node := range nodes {
+		t := r.nodeSet[node].topology
+		if failed != nil && failed(node, t) {
+			continue
+		}
+		placement.offer(node, t)
+	}
+
+	return placement.err()
+}
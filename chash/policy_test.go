@@ -0,0 +1,117 @@
+package chash
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// newMultiRegionRing builds a ring with two regions of three zones, each zone holding two nodes
+func newMultiRegionRing() *Ring {
+	ring := New(Config{Replicas: 20})
+	for _, region := range []string{"us", "eu"} {
+		for zone := 1; zone <= 3; zone++ {
+			for n := 1; n <= 2; n++ {
+				node := fmt.Sprintf("%s-z%d-n%d", region, zone, n)
+				ring.AddNode(node)
+				ring.SetTopology(node, Topology{Region: region, Zone: fmt.Sprintf("%s-%d", region, zone)})
+			}
+		}
+	}
+	return ring
+}
+
+// localPlusRemote is two replicas in us on distinct zones plus one outside us
+var localPlusRemote = WritePolicy{Rules: []PlacementRule{
+	{Match: Topology{Region: "us"}, Count: 2, Spread: SpreadZone},
+	{Exclude: Topology{Region: "us"}, Count: 1},
+}}
+
+func TestGetNodesPolicy(t *testing.T) {
+	ring := newMultiRegionRing()
+
+	for i := 0; i < 200; i++ {
+		key := fmt.Sprintf("key%d", i)
+
+		nodes, err := ring.GetNodesPolicy(key, localPlusRemote)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(nodes) != 3 {
+			t.Fatalf("expected 3 replicas, got %v", nodes)
+		}
+
+		a, _ := ring.Topology(nodes[0])
+		b, _ := ring.Topology(nodes[1])
+		c, _ := ring.Topology(nodes[2])
+		if a.Region != "us" || b.Region != "us" || a.Zone == b.Zone {
+			t.Errorf("%s: expected two local replicas in distinct zones, got %v", key, nodes)
+		}
+		if c.Region == "us" {
+			t.Errorf("%s: expected a remote replica, got %v", key, nodes)
+		}
+	}
+}
+
+func TestGetNodesPolicyFollowsRing(t *testing.T) {
+	ring := newMultiRegionRing()
+	everywhere := WritePolicy{Rules: []PlacementRule{{Count: 3}}}
+
+	for i := 0; i < 50; i++ {
+		key := fmt.Sprintf("key%d", i)
+
+		got, err := ring.GetNodesPolicy(key, everywhere)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		want, _ := ring.GetNodes(key, 3)
+		for j := range want {
+			if got[j] != want[j] {
+				t.Fatalf("%s: expected %v, got %v", key, want, got)
+			}
+		}
+	}
+}
+
+func TestGetNodesPolicyErrors(t *testing.T) {
+	ring := newMultiRegionRing()
+
+	if _, err := ring.GetNodesPolicy("key", WritePolicy{}); !errors.Is(err, ErrInvalidPolicy) {
+		t.Errorf("expected ErrInvalidPolicy, got %v", err)
+	}
+	if _, err := ring.GetNodesPolicy("key", WritePolicy{Rules: []PlacementRule{{Count: 0}}}); !errors.Is(err, ErrInvalidPolicy) {
+		t.Errorf("expected ErrInvalidPolicy, got %v", err)
+	}
+	if _, err := ring.GetNodesPolicy("", localPlusRemote); err != ErrEmptyKey {
+		t.Errorf("expected ErrEmptyKey, got %v", err)
+	}
+
+	fourZones := WritePolicy{Rules: []PlacementRule{{Match: Topology{Region: "us"}, Count: 4, Spread: SpreadZone}}}
+	if _, err := ring.GetNodesPolicy("key", fourZones); !errors.Is(err, ErrPolicyUnsatisfiable) {
+		t.Errorf("expected ErrPolicyUnsatisfiable, got %v", err)
+	}
+}
+
+func TestCheckPolicy(t *testing.T) {
+	ring := newMultiRegionRing()
+
+	if err := ring.CheckPolicy(localPlusRemote, nil); err != nil {
+		t.Errorf("expected policy to be satisfiable, got %v", err)
+	}
+
+	// Losing one local zone still leaves two
+	zoneDown := func(node string, t Topology) bool { return t.Zone == "us-1" }
+	if err := ring.CheckPolicy(localPlusRemote, zoneDown); err != nil {
+		t.Errorf("expected policy to survive a zone loss, got %v", err)
+	}
+
+	// Losing the remote region leaves nowhere for the remote replica
+	regionDown := func(node string, t Topology) bool { return t.Region == "eu" }
+	if err := ring.CheckPolicy(localPlusRemote, regionDown); !errors.Is(err, ErrPolicyUnsatisfiable) {
+		t.Errorf("expected ErrPolicyUnsatisfiable, got %v", err)
+	}
+
+	if err := ring.CheckPolicy(WritePolicy{}, nil); !errors.Is(err, ErrInvalidPolicy) {
+		t.Errorf("expected ErrInvalidPolicy, got %v", err)
+	}
+}
@@ -0,0 +1,264 @@
+package chash
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/mohdrashid9678/dcore/chash/core"
+)
+
+// Range is an interval of the hash space covering hashes h with Start < h <= End
+// A range with Start > End wraps around zero, and Start == End covers the whole ring
+type Range struct {
+	Start uint64
+	End   uint64
+}
+
+// Contains returns true if hash falls within the range
+func (rg Range) Contains(hash uint64) bool {
+	switch {
+	case rg.Start < rg.End:
+		return hash > rg.Start && hash <= rg.End
+	case rg.Start > rg.End:
+		return hash > rg.Start || hash <= rg.End
+	default:
+		return true
+	}
+}
+
+// Fraction returns the share of the hash space covered by the range, in (0, 1]
+func (rg Range) Fraction() float64 {
+	if rg.Start == rg.End {
+		return 1
+	}
+	// Unsigned subtraction handles wrapping ranges
+	return float64(rg.End-rg.Start) / math.Pow(2, 64)
+}
+
+// String returns the range in interval notation
+func (rg Range) String() string {
+	return fmt.Sprintf("(%d, %d]", rg.Start, rg.End)
+}
+
+// ringPoint is a virtual node position and the physical node that owns it
+type ringPoint struct {
+	hash uint64
+	node string
+}
+
+// points returns a sorted copy of the ring's virtual node positions
+// Taking a copy lets callers compare rings without holding two locks at once
+func (r *Ring) points() []ringPoint {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.pointsLocked()
+}
+
+// pointsLocked is points for callers already holding r.mu
+func (r *Ring) pointsLocked() []ringPoint {
+	points := make([]ringPoint, len(r.ring))
+	for i, hash := range r.ring {
+		points[i] = ringPoint{hash: hash, node: r.ownerLocked(i)}
+	}
+	return points
+}
+
+// ownerAt returns the owner of hash in a sorted point list, or "" if it is empty
+func ownerAt(points []ringPoint, hash uint64) string {
+	if len(points) == 0 {
+		return ""
+	}
+
+	idx := sort.Search(len(points), func(i int) bool {
+		return points[i].hash >= hash
+	})
+	if idx == len(points) {
+		idx = 0
+	}
+
+	return points[idx].node
+}
+
+// boundaries returns the sorted, de-duplicated positions of both point lists
+func boundaries(a, b []ringPoint) []uint64 {
+	merged := make([]uint64, 0, len(a)+len(b))
+	for _, p := range a {
+		merged = append(merged, p.hash)
+	}
+	for _, p := range b {
+		merged = append(merged, p.hash)
+	}
+
+	sort.Slice(merged, func(i, j int) bool { return merged[i] < merged[j] })
+
+	unique := merged[:0]
+	for i, h := range merged {
+		if i == 0 || h != merged[i-1] {
+			unique = append(unique, h)
+		}
+	}
+	return unique
+}
+
+// ownershipSegment is a range with a constant owner in each of two rings
+type ownershipSegment struct {
+	rng    Range
+	ownerA string
+	ownerB string
+}
+
+// compareOwnership splits the hash space at the virtual nodes of both rings
+// and returns the maximal ranges whose owners differ between a and b
+func compareOwnership(a, b []ringPoint) []ownershipSegment {
+	bounds := boundaries(a, b)
+	if len(bounds) == 0 {
+		return nil
+	}
+
+	// Walk the elementary segments clockwise starting with the wrapping one
+	var segments []ownershipSegment
+	for i, end := range bounds {
+		start := bounds[(i+len(bounds)-1)%len(bounds)]
+
+		ownerA := ownerAt(a, end)
+		ownerB := ownerAt(b, end)
+
+		if n := len(segments); n > 0 && segments[n-1].rng.End == start &&
+			segments[n-1].ownerA == ownerA && segments[n-1].ownerB == ownerB {
+			segments[n-1].rng.End = end
+			continue
+		}
+
+		segments = append(segments, ownershipSegment{
+			rng:    Range{Start: start, End: end},
+			ownerA: ownerA,
+			ownerB: ownerB,
+		})
+	}
+
+	// The last segment ends where the first (wrapping) one starts
+	if n := len(segments); n > 1 && segments[n-1].ownerA == segments[0].ownerA &&
+		segments[n-1].ownerB == segments[0].ownerB {
+		segments[0].rng.Start = segments[n-1].rng.Start
+		segments = segments[:n-1]
+	}
+
+	// Keep only the ranges whose owners differ
+	differing := segments[:0]
+	for _, seg := range segments {
+		if seg.ownerA != seg.ownerB {
+			differing = append(differing, seg)
+		}
+	}
+	return differing
+}
+
+// ownership returns the share of the hash space owned by each node
+func ownership(points []ringPoint) map[string]float64 {
+	shares := make(map[string]float64)
+
+	if len(points) == 1 {
+		shares[points[0].node] = 1
+		return shares
+	}
+
+	for i, p := range points {
+		prev := points[(i+len(points)-1)%len(points)]
+		if prev.hash == p.hash {
+			// A colliding position owns nothing but its node still counts
+			shares[p.node] += 0
+			continue
+		}
+		shares[p.node] += Range{Start: prev.hash, End: p.hash}.Fraction()
+	}
+
+	return shares
+}
+
+// Table exports the ring as an immutable core.Table for embedding the routing
+// decision elsewhere, such as a WebAssembly module or a C sidecar; owners are
+// indices into the returned node names, which are sorted
+// Keys must be hashed with the ring's hash function; affinity groups, hash
+// tags, pins and fixed partitions are not part of the table
+func (r *Ring) Table() (core.Table, []string) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	nodes := make([]string, 0, len(r.nodeSet))
+	for node := range r.nodeSet {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+
+	// Map node table indices to positions in the sorted name list
+	remap := make([]uint32, len(r.names))
+	for i, node := range nodes {
+		remap[r.nodeSet[node].index] = uint32(i)
+	}
+
+	table := core.Table{
+		Points: append([]uint64(nil), r.ring...),
+		Owners: make([]uint32, len(r.owners)),
+	}
+	for i, owner := range r.owners {
+		table.Owners[i] = remap[owner]
+	}
+
+	return table, nodes
+}
+
+// OwnedRanges returns the hash ranges node is the primary owner of, in ring
+// order starting after zero; adjacent virtual nodes are merged into one range
+// A node owning the whole ring gets a single range with Start == End, and an
+// unknown node gets nil
+func (r *Ring) OwnedRanges(node string) []Range {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entry, exists := r.nodeSet[node]
+	if !exists {
+		return nil
+	}
+
+	n := len(r.ring)
+
+	// Start walking just after a point owned by someone else so that a run of
+	// points crossing zero is not split in two
+	first := -1
+	for i, owner := range r.owners {
+		if owner != entry.index {
+			first = i
+			break
+		}
+	}
+	if first == -1 {
+		return []Range{{Start: r.ring[0], End: r.ring[0]}}
+	}
+
+	var ranges []Range
+	for k := 1; k <= n; k++ {
+		i := (first + k) % n
+		if r.owners[i] != entry.index {
+			continue
+		}
+		// Only the first of colliding virtual nodes owns keys
+		if i > 0 && r.ring[i] == r.ring[i-1] {
+			continue
+		}
+
+		prev := r.ring[(i+n-1)%n]
+		if len(ranges) > 0 && ranges[len(ranges)-1].End == prev {
+			ranges[len(ranges)-1].End = r.ring[i]
+			continue
+		}
+		ranges = append(ranges, Range{Start: prev, End: r.ring[i]})
+	}
+
+	// Report in ring order: the range containing zero, if any, goes first
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].End < ranges[j].End
+	})
+	return ranges
+}
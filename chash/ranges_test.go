@@ -0,0 +1,174 @@
+package chash
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/mohdrashid9678/dcore/chash/core"
+)
+
+func TestRangeContains(t *testing.T) {
+	tests := []struct {
+		name     string
+		rng      Range
+		hash     uint64
+		expected bool
+	}{
+		{"inside", Range{10, 20}, 15, true},
+		{"end is inclusive", Range{10, 20}, 20, true},
+		{"start is exclusive", Range{10, 20}, 10, false},
+		{"outside", Range{10, 20}, 25, false},
+		{"wrapping high side", Range{100, 5}, 200, true},
+		{"wrapping low side", Range{100, 5}, 0, true},
+		{"wrapping gap", Range{100, 5}, 50, false},
+		{"full ring", Range{7, 7}, 12345, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.rng.Contains(tt.hash) != tt.expected {
+				t.Errorf("expected Contains(%d)=%v for %v", tt.hash, tt.expected, tt.rng)
+			}
+		})
+	}
+}
+
+func TestRangeFraction(t *testing.T) {
+	half := uint64(1) << 63
+
+	if f := (Range{0, half}).Fraction(); f != 0.5 {
+		t.Errorf("expected 0.5, got %v", f)
+	}
+
+	// Wrapping range covering the other half
+	if f := (Range{half, 0}).Fraction(); f != 0.5 {
+		t.Errorf("expected 0.5 for wrapping range, got %v", f)
+	}
+
+	if f := (Range{42, 42}).Fraction(); f != 1 {
+		t.Errorf("expected full ring fraction 1, got %v", f)
+	}
+
+	if f := (Range{0, 1}).Fraction(); f != 1/math.Pow(2, 64) {
+		t.Errorf("expected single hash fraction, got %v", f)
+	}
+}
+
+func TestTableMatchesRing(t *testing.T) {
+	ring := New(Config{Replicas: 40})
+	ring.AddNode("server1")
+	ring.AddNodeWithWeight("server2", 2)
+	ring.AddNode("server3")
+
+	table, nodes := ring.Table()
+	if table.Len() != ring.VirtualNodeCount() {
+		t.Fatalf("expected %d points, got %d", ring.VirtualNodeCount(), table.Len())
+	}
+
+	// A table rebuilt from names and virtual node counts is identical
+	rebuilt := core.Build(nodes, []int{40, 80, 40})
+
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("key%d", i)
+		hash := DefaultHashFunc(key)
+
+		expected, _ := ring.GetNodes(key, 2)
+		replicas := table.Replicas(hash, 2, nil)
+		if nodes[replicas[0]] != expected[0] || nodes[replicas[1]] != expected[1] {
+			t.Fatalf("key %s: expected %v, got %v", key, expected, replicas)
+		}
+		if rebuilt.Lookup(hash) != replicas[0] {
+			t.Fatalf("key %s: rebuilt table disagrees", key)
+		}
+	}
+}
+
+func TestOwnedRanges(t *testing.T) {
+	nodes := []string{"server1", "server2", "server3"}
+	ring := NewWithNodes(Config{Replicas: 20}, nodes)
+
+	owned := make(map[string][]Range)
+	total := 0.0
+	for _, node := range nodes {
+		owned[node] = ring.OwnedRanges(node)
+		for i, rg := range owned[node] {
+			total += rg.Fraction()
+			if i > 0 && owned[node][i-1].End >= rg.End {
+				t.Errorf("expected %s ranges in ring order, got %v", node, owned[node])
+			}
+		}
+	}
+	if math.Abs(total-1) > 1e-9 {
+		t.Errorf("expected ranges to cover the ring once, got %v", total)
+	}
+
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("key%d", i)
+		owner, _ := ring.GetNode(key)
+		hash := ring.hashFunc(key)
+
+		for _, node := range nodes {
+			contained := false
+			for _, rg := range owned[node] {
+				if rg.Contains(hash) {
+					contained = true
+				}
+			}
+			if contained != (node == owner) {
+				t.Errorf("key %s owned by %s but %s ranges contain=%v", key, owner, node, contained)
+			}
+		}
+	}
+}
+
+func TestOwnedRangesEdgeCases(t *testing.T) {
+	ring := New(Config{Replicas: 10})
+
+	if ranges := ring.OwnedRanges("server1"); ranges != nil {
+		t.Errorf("expected nil for unknown node, got %v", ranges)
+	}
+
+	ring.AddNode("server1")
+	ranges := ring.OwnedRanges("server1")
+	if len(ranges) != 1 || ranges[0].Fraction() != 1 {
+		t.Errorf("expected a single full-ring range, got %v", ranges)
+	}
+}
+
+func TestOwnedRangesCollisions(t *testing.T) {
+	// Every node's virtual nodes land on the same three positions, which b
+	// and c lose to a
+	sameForAll := func(key string) uint64 {
+		return uint64(key[len(key)-1]-'0'+1) << 62
+	}
+	ring := NewWithNodes(Config{Replicas: 3, HashFunc: sameForAll}, []string{"a", "b", "c"})
+
+	if ranges := ring.OwnedRanges("a"); len(ranges) != 1 || ranges[0].Fraction() != 1 {
+		t.Errorf("expected a to own the whole ring, got %v", ranges)
+	}
+	for _, node := range []string{"b", "c"} {
+		if ranges := ring.OwnedRanges(node); len(ranges) != 0 {
+			t.Errorf("expected %s to own nothing, got %v", node, ranges)
+		}
+	}
+
+	// With partial collisions ranges still match the keyspace shares
+	ring = NewWithNodes(Config{Replicas: 100, HashFunc: weakHash}, []string{"server1", "server2", "server3"})
+	if len(ring.Collisions()) == 0 {
+		t.Fatal("expected collisions with a weak hash function")
+	}
+	keyspace := ring.GetStats().Keyspace
+	for _, node := range ring.Nodes() {
+		var owned float64
+		for _, rg := range ring.OwnedRanges(node) {
+			if rg.Start == rg.End {
+				t.Fatalf("%s: unexpected whole-ring range %v", node, rg)
+			}
+			owned += rg.Fraction()
+		}
+		if math.Abs(owned-keyspace[node]) > 1e-9 {
+			t.Errorf("%s: owned ranges cover %v, keyspace share is %v", node, owned, keyspace[node])
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package chash
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"sync"
+)
+
+// WeightedRendezvous selects nodes by weighted rendezvous (highest random
+// weight) hashing: each node scores every key, and a node wins a share of keys
+// proportional to its weight
+// Changing one node's weight only moves keys to or from that node, so it suits
+// sticky canaries: a canary at weight 5 next to a baseline at 95 gets a stable
+// 5% of keys, and raising it to 10 adds keys without reshuffling the rest
+type WeightedRendezvous struct {
+	// mu protects nodes
+	mu sync.RWMutex
+
+	// hashFunc hashes keys and node names
+	hashFunc HashFunc
+
+	// nodes are sorted by name so ties resolve deterministically
+	nodes []rendezvousNode
+}
+
+// rendezvousNode is a node with its weight and precomputed name hash
+type rendezvousNode struct {
+	name   string
+	weight float64
+	hash   uint64
+}
+
+// NewWeightedRendezvous creates an empty selector
+// hashFunc defaults to DefaultHashFunc
+func NewWeightedRendezvous(hashFunc HashFunc) *WeightedRendezvous {
+	if hashFunc == nil {
+		hashFunc = DefaultHashFunc
+	}
+
+	return &WeightedRendezvous{hashFunc: hashFunc}
+}
+
+// SetWeight adds node with the given weight, or updates its weight
+func (w *WeightedRendezvous) SetWeight(node string, weight float64) error {
+	if node == "" {
+		return ErrEmptyKey
+	}
+	if weight <= 0 || math.IsNaN(weight) || math.IsInf(weight, 0) {
+		return ErrInvalidWeight
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	i := sort.Search(len(w.nodes), func(i int) bool { return w.nodes[i].name >= node })
+	if i < len(w.nodes) && w.nodes[i].name == node {
+		w.nodes[i].weight = weight
+		return nil
+	}
+
+	w.nodes = append(w.nodes, rendezvousNode{})
+	copy(w.nodes[i+1:], w.nodes[i:])
+	w.nodes[i] = rendezvousNode{name: node, weight: weight, hash: w.hashFunc(node)}
+	return nil
+}
+
+// RemoveNode removes node
+func (w *WeightedRendezvous) RemoveNode(node string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	i := sort.Search(len(w.nodes), func(i int) bool { return w.nodes[i].name >= node })
+	if i == len(w.nodes) || w.nodes[i].name != node {
+		return ErrNodeNotFound
+	}
+
+	w.nodes = append(w.nodes[:i], w.nodes[i+1:]...)
+	return nil
+}
+
+// Weight returns the weight of node
+func (w *WeightedRendezvous) Weight(node string) (float64, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	for _, n := range w.nodes {
+		if n.name == node {
+			return n.weight, nil
+		}
+	}
+	return 0, ErrNodeNotFound
+}
+
+// Nodes returns the node names, sorted
+func (w *WeightedRendezvous) Nodes() []string {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	names := make([]string, len(w.nodes))
+	for i, n := range w.nodes {
+		names[i] = n.name
+	}
+	return names
+}
+
+// GetNode returns the node with the highest weighted score for key
+func (w *WeightedRendezvous) GetNode(key string) (string, error) {
+	if key == "" {
+		return "", ErrEmptyKey
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if len(w.nodes) == 0 {
+		return "", ErrNoNodes
+	}
+
+	keyHash := w.hashFunc(key)
+	best, bestScore := 0, math.Inf(-1)
+	for i, n := range w.nodes {
+		// Strictly greater keeps the first node by name on a tie
+		if score := rendezvousScore(keyHash, n); score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+
+	return w.nodes[best].name, nil
+}
+
+// GetNodes returns up to count distinct nodes for key, highest score first
+// Removing the first node promotes the second, so the list doubles as a
+// failover order
+func (w *WeightedRendezvous) GetNodes(key string, count int) ([]string, error) {
+	if key == "" {
+		return nil, ErrEmptyKey
+	}
+	if count <= 0 {
+		return nil, errors.New("count must be positive")
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if len(w.nodes) == 0 {
+		return nil, ErrNoNodes
+	}
+
+	type scored struct {
+		name  string
+		score float64
+	}
+
+	keyHash := w.hashFunc(key)
+	scores := make([]scored, len(w.nodes))
+	for i, n := range w.nodes {
+		scores[i] = scored{name: n.name, score: rendezvousScore(keyHash, n)}
+	}
+	// Stable sort keeps name order on ties, matching GetNode
+	sort.SliceStable(scores, func(i, j int) bool {
+		return scores[i].score > scores[j].score
+	})
+
+	if count > len(scores) {
+		count = len(scores)
+	}
+	result := make([]string, count)
+	for i := range result {
+		result[i] = scores[i].name
+	}
+	return result, nil
+}
+
+// rendezvousScore is the logarithmic weighted score -weight / ln(u), where u
+// is uniform in (0, 1) for each (key, node) pair
+// The highest score wins with probability weight / total weight
+func rendezvousScore(keyHash uint64, n rendezvousNode) float64 {
+	u := (float64(mix64(keyHash^n.hash)>>11) + 0.5) / (1 << 53)
+	return -n.weight / math.Log(u)
+}
+
+// mix64 is the splitmix64 finalizer; it spreads the combined key and node
+// hashes so every pair scores independently
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
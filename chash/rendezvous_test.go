@@ -0,0 +1,118 @@
+package chash
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func canaryShare(w *WeightedRendezvous, keys int) (map[string]string, float64) {
+	owners := make(map[string]string, keys)
+	canary := 0
+	for i := 0; i < keys; i++ {
+		key := fmt.Sprintf("key%d", i)
+		node, _ := w.GetNode(key)
+		owners[key] = node
+		if node == "canary" {
+			canary++
+		}
+	}
+	return owners, float64(canary) / float64(keys)
+}
+
+func TestRendezvousWeightedShare(t *testing.T) {
+	w := NewWeightedRendezvous(nil)
+	w.SetWeight("baseline", 95)
+	w.SetWeight("canary", 5)
+
+	_, share := canaryShare(w, 20000)
+	if math.Abs(share-0.05) > 0.01 {
+		t.Errorf("expected ~5%% of keys on canary, got %.2f%%", share*100)
+	}
+}
+
+func TestRendezvousWeightChangeIsMinimal(t *testing.T) {
+	w := NewWeightedRendezvous(nil)
+	w.SetWeight("baseline-1", 50)
+	w.SetWeight("baseline-2", 45)
+	w.SetWeight("canary", 5)
+
+	before, _ := canaryShare(w, 5000)
+
+	// Raising the canary only pulls keys onto it
+	w.SetWeight("canary", 20)
+	after, share := canaryShare(w, 5000)
+	for key, node := range after {
+		if node != before[key] && node != "canary" {
+			t.Errorf("key %s moved %s -> %s, expected moves only to canary", key, before[key], node)
+		}
+	}
+	if math.Abs(share-20.0/115) > 0.02 {
+		t.Errorf("unexpected canary share %.3f", share)
+	}
+
+	// Removing the canary only moves its own keys
+	w.RemoveNode("canary")
+	removed, _ := canaryShare(w, 5000)
+	for key, node := range removed {
+		if after[key] != "canary" && node != after[key] {
+			t.Errorf("key %s moved %s -> %s, expected only canary keys to move", key, after[key], node)
+		}
+	}
+}
+
+func TestRendezvousGetNodes(t *testing.T) {
+	w := NewWeightedRendezvous(nil)
+	for i := 1; i <= 4; i++ {
+		w.SetWeight(fmt.Sprintf("server%d", i), float64(i))
+	}
+
+	for i := 0; i < 200; i++ {
+		key := fmt.Sprintf("key%d", i)
+		nodes, err := w.GetNodes(key, 10)
+		if err != nil || len(nodes) != 4 {
+			t.Fatalf("expected 4 nodes, got %v (%v)", nodes, err)
+		}
+
+		primary, _ := w.GetNode(key)
+		if nodes[0] != primary {
+			t.Errorf("expected %s first, got %v", primary, nodes)
+		}
+
+		// Failover order: without the primary, the second node wins
+		clone := NewWeightedRendezvous(nil)
+		for _, n := range w.Nodes() {
+			weight, _ := w.Weight(n)
+			clone.SetWeight(n, weight)
+		}
+		clone.RemoveNode(primary)
+		if next, _ := clone.GetNode(key); next != nodes[1] {
+			t.Errorf("expected failover to %s, got %s", nodes[1], next)
+		}
+	}
+}
+
+func TestRendezvousErrors(t *testing.T) {
+	w := NewWeightedRendezvous(nil)
+
+	if _, err := w.GetNode("key1"); err != ErrNoNodes {
+		t.Errorf("expected ErrNoNodes, got %v", err)
+	}
+	if err := w.SetWeight("server1", 0); err != ErrInvalidWeight {
+		t.Errorf("expected ErrInvalidWeight, got %v", err)
+	}
+	if err := w.SetWeight("", 1); err != ErrEmptyKey {
+		t.Errorf("expected ErrEmptyKey, got %v", err)
+	}
+	if err := w.RemoveNode("server1"); err != ErrNodeNotFound {
+		t.Errorf("expected ErrNodeNotFound, got %v", err)
+	}
+
+	w.SetWeight("server1", 1)
+	if _, err := w.GetNodes("key1", 0); err == nil {
+		t.Error("expected error for non-positive count")
+	}
+	if _, err := w.Weight("server2"); err != ErrNodeNotFound {
+		t.Errorf("expected ErrNodeNotFound, got %v", err)
+	}
+}
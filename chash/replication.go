@@ -0,0 +1,86 @@
+package chash
+
+import "iter"
+
+// Candidate is a node offered to a replication strategy
+type Candidate struct {
+	Node     string
+	Topology Topology
+}
+
+// ReplicationStrategy chooses a key's replicas for GetNodes and the lookups
+// built on it
+// Select receives the nodes available for the key in ring order, starting with
+// the key's primary, and returns up to count of them, primary first so the
+// ring keeps choosing primaries; it may stop reading candidates early
+// Select runs under the ring's read lock and must not call back into the ring
+type ReplicationStrategy interface {
+	Select(candidates iter.Seq[Candidate], count int) []string
+}
+
+// SimpleStrategy takes the first count distinct nodes clockwise; it is the
+// default strategy
+type SimpleStrategy struct{}
+
+// Select returns the first count candidates
+func (SimpleStrategy) Select(candidates iter.Seq[Candidate], count int) []string {
+	result := make([]string, 0, count)
+	for c := range candidates {
+		result = append(result, c.Node)
+		if len(result) == count {
+			break
+		}
+	}
+	return result
+}
+
+// TopologyAwareStrategy keeps the ring's primary and places the secondaries in
+// failure domains not used yet, like Cassandra's NetworkTopologyStrategy
+// When there are fewer domains than replicas, the remaining replicas are
+// filled from the skipped nodes in ring order rather than failing; use
+// GetNodesSpread where strict spreading is required
+type TopologyAwareStrategy struct {
+	// Spread is the failure domain secondaries are spread across
+	Spread SpreadConstraint
+}
+
+// Select returns the primary followed by nodes in new domains, then skipped nodes
+func (s TopologyAwareStrategy) Select(candidates iter.Seq[Candidate], count int) []string {
+	result := make([]string, 0, count)
+	seen := make(map[string]struct{})
+	var skipped []string
+
+	for c := range candidates {
+		domain := s.Spread.domain(c.Node, c.Topology)
+		if _, used := seen[domain]; used {
+			skipped = append(skipped, c.Node)
+			continue
+		}
+		seen[domain] = struct{}{}
+
+		result = append(result, c.Node)
+		if len(result) == count {
+			return result
+		}
+	}
+
+	for _, node := range skipped {
+		if len(result) == count {
+			break
+		}
+		result = append(result, node)
+	}
+	return result
+}
+
+// candidatesLocked yields the nodes available clockwise from ring index idx
+// in replica order, skipping the nodes marked in seen
+// Caller must hold r.mu
+func (r *Ring) candidatesLocked(idx int, seen []bool) iter.Seq[Candidate] {
+	return func(yield func(Candidate) bool) {
+		r.visitLocked(idx, seen, func(owner uint32) bool {
+			node := r.names[owner]
+			return yield(Candidate{Node: node, Topology: r.nodeSet[node].topology})
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package chash
+
+import (
+	"fmt"
+	"iter"
+	"testing"
+)
+
+func TestSimpleStrategyMatchesDefault(t *testing.T) {
+	nodes := []string{"server1", "server2", "server3", "server4"}
+	ring := NewWithNodes(Config{Replicas: 20}, nodes)
+	simple := NewWithNodes(Config{Replicas: 20, Replication: SimpleStrategy{}}, nodes)
+
+	for i := 0; i < 200; i++ {
+		key := fmt.Sprintf("key%d", i)
+		want, _ := ring.GetNodes(key, 3)
+		got, _ := simple.GetNodes(key, 3)
+		for j := range want {
+			if got[j] != want[j] {
+				t.Fatalf("%s: expected %v, got %v", key, want, got)
+			}
+		}
+	}
+}
+
+func TestTopologyAwareStrategy(t *testing.T) {
+	ring := newZonedRing()
+	ring.replication = TopologyAwareStrategy{Spread: SpreadRack}
+
+	for i := 0; i < 200; i++ {
+		key := fmt.Sprintf("key%d", i)
+
+		nodes, err := ring.GetNodes(key, 3)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		// The ring still chooses the primary
+		if primary, _ := ring.GetNode(key); nodes[0] != primary {
+			t.Errorf("%s: expected primary %s, got %v", key, primary, nodes)
+		}
+
+		racks := make(map[Topology]bool)
+		for _, node := range nodes {
+			topo, _ := ring.Topology(node)
+			racks[Topology{Zone: topo.Zone, Rack: topo.Rack}] = true
+		}
+		if len(racks) != 3 {
+			t.Errorf("%s: expected 3 distinct racks, got %v", key, nodes)
+		}
+	}
+}
+
+func TestTopologyAwareStrategyFillsWhenShort(t *testing.T) {
+	ring := NewWithNodes(Config{Replicas: 20, Replication: TopologyAwareStrategy{Spread: SpreadZone}},
+		[]string{"server1", "server2", "server3"})
+	ring.SetTopology("server1", Topology{Zone: "a"})
+	ring.SetTopology("server2", Topology{Zone: "a"})
+	ring.SetTopology("server3", Topology{Zone: "b"})
+
+	for i := 0; i < 100; i++ {
+		nodes, err := ring.GetNodes(fmt.Sprintf("key%d", i), 3)
+		if err != nil || len(nodes) != 3 {
+			t.Fatalf("expected 3 nodes from 2 zones, got %v, %v", nodes, err)
+		}
+	}
+}
+
+// lastStrategy keeps the primary and then prefers the nodes furthest along the ring
+type lastStrategy struct{}
+
+func (lastStrategy) Select(candidates iter.Seq[Candidate], count int) []string {
+	var all []string
+	for c := range candidates {
+		all = append(all, c.Node)
+	}
+	result := []string{all[0]}
+	for i := len(all) - 1; i > 0 && len(result) < count; i-- {
+		result = append(result, all[i])
+	}
+	return result
+}
+
+func TestCustomStrategy(t *testing.T) {
+	ring := NewWithNodes(Config{Replicas: 20, Replication: lastStrategy{}}, []string{"server1", "server2", "server3", "server4"})
+	ring.SetNodeState("server4", NodeDisabled)
+
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("key%d", i)
+		nodes, _ := ring.GetNodes(key, 2)
+		if len(nodes) != 2 {
+			t.Fatalf("%s: expected 2 nodes, got %v", key, nodes)
+		}
+		for _, node := range nodes {
+			if node == "server4" {
+				t.Errorf("%s: expected disabled node to be withheld from the strategy, got %v", key, nodes)
+			}
+		}
+
+		// Excluded nodes are never offered either
+		excluded, _ := ring.GetNodesExcluding(key, 3, []string{nodes[1]})
+		for _, node := range excluded {
+			if node == nodes[1] {
+				t.Errorf("%s: expected %s excluded, got %v", key, nodes[1], excluded)
+			}
+		}
+	}
+}
@@ -0,0 +1,360 @@
+package chash
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// Hash128 is a position in a 128-bit hash space
+type Hash128 struct {
+	Hi uint64
+	Lo uint64
+}
+
+// Compare returns -1, 0 or +1 as h is less than, equal to or greater than o
+func (h Hash128) Compare(o Hash128) int {
+	switch {
+	case h.Hi < o.Hi || (h.Hi == o.Hi && h.Lo < o.Lo):
+		return -1
+	case h == o:
+		return 0
+	default:
+		return 1
+	}
+}
+
+// sub returns h - o modulo 2^128
+func (h Hash128) sub(o Hash128) Hash128 {
+	lo := h.Lo - o.Lo
+	borrow := uint64(0)
+	if h.Lo < o.Lo {
+		borrow = 1
+	}
+	return Hash128{Hi: h.Hi - o.Hi - borrow, Lo: lo}
+}
+
+// Big returns the hash as an unsigned integer, the form most token-based
+// stores print and parse tokens in
+func (h Hash128) Big() *big.Int {
+	b := make([]byte, 16)
+	binary.BigEndian.PutUint64(b, h.Hi)
+	binary.BigEndian.PutUint64(b[8:], h.Lo)
+	return new(big.Int).SetBytes(b)
+}
+
+// String returns the hash as a decimal integer
+func (h Hash128) String() string {
+	return h.Big().String()
+}
+
+// HashFunc128 hashes a key into the 128-bit hash space
+type HashFunc128 func(string) Hash128
+
+// DefaultHashFunc128 returns the first 128 bits of the SHA-256 of key
+func DefaultHashFunc128(key string) Hash128 {
+	sum := sha256.Sum256([]byte(key))
+	return Hash128{Hi: binary.BigEndian.Uint64(sum[:8]), Lo: binary.BigEndian.Uint64(sum[8:16])}
+}
+
+// Murmur3x128 is MurmurHash3 x64_128 of key with seed 0; Hi holds the first
+// 64 bits, which equal Murmur3
+func Murmur3x128(key string) Hash128 {
+	h1, h2 := murmur3x128(key)
+	return Hash128{Hi: h1, Lo: h2}
+}
+
+// Range128 is an interval of the 128-bit hash space covering hashes h with
+// Start < h <= End; like Range it wraps when Start > End and covers the whole
+// space when Start == End
+type Range128 struct {
+	Start Hash128
+	End   Hash128
+}
+
+// Contains returns true if hash falls within the range
+func (rg Range128) Contains(hash Hash128) bool {
+	switch rg.Start.Compare(rg.End) {
+	case -1:
+		return hash.Compare(rg.Start) > 0 && hash.Compare(rg.End) <= 0
+	case 1:
+		return hash.Compare(rg.Start) > 0 || hash.Compare(rg.End) <= 0
+	default:
+		return true
+	}
+}
+
+// Fraction returns the share of the hash space covered by the range, in (0, 1]
+func (rg Range128) Fraction() float64 {
+	if rg.Start == rg.End {
+		return 1
+	}
+	// Modular subtraction handles wrapping ranges
+	width := rg.End.sub(rg.Start)
+	return (float64(width.Hi) + float64(width.Lo)/math.Pow(2, 64)) / math.Pow(2, 64)
+}
+
+// String returns the range in interval notation
+func (rg Range128) String() string {
+	return fmt.Sprintf("(%s, %s]", rg.Start, rg.End)
+}
+
+// Config128 holds configuration options for creating a new Ring128
+type Config128 struct {
+	// Replicas specifies the number of virtual nodes per physical node
+	// Default: 150
+	Replicas int
+
+	// HashFunc specifies the hash function to use
+	// Default: DefaultHashFunc128
+	HashFunc HashFunc128
+}
+
+// Ring128 is a consistent hash ring over a 128-bit hash space
+// It suits stores whose token ranges are 128 bits wide and rings so large
+// that 64-bit virtual node positions risk colliding. It covers the core of
+// Ring: weighted nodes, lookups, replica walks and owned ranges
+type Ring128 struct {
+	// mu protects all fields below for concurrent access
+	mu sync.RWMutex
+
+	// hashFunc is the hash function used for generating hashes
+	hashFunc HashFunc128
+
+	// replicas is the number of virtual nodes per physical node
+	replicas int
+
+	// points holds the virtual nodes sorted by hash
+	points []point128
+
+	// weights maps each physical node to its weight
+	weights map[string]float64
+}
+
+// point128 is a virtual node position and the physical node that owns it
+type point128 struct {
+	hash Hash128
+	node string
+}
+
+// NewRing128 creates a new 128-bit consistent hash ring with the given configuration
+func NewRing128(config Config128) *Ring128 {
+	if config.Replicas <= 0 {
+		config.Replicas = 150 // Default number of replicas
+	}
+	if config.HashFunc == nil {
+		config.HashFunc = DefaultHashFunc128
+	}
+
+	return &Ring128{
+		hashFunc: config.HashFunc,
+		replicas: config.Replicas,
+		weights:  make(map[string]float64),
+	}
+}
+
+// AddNode adds a physical node with weight 1
+// Returns an error if the node already exists
+func (r *Ring128) AddNode(node string) error {
+	return r.AddNodeWithWeight(node, 1)
+}
+
+// AddNodeWithWeight adds a physical node with round(weight × Replicas)
+// virtual nodes, at least one
+func (r *Ring128) AddNodeWithWeight(node string, weight float64) error {
+	if node == "" {
+		return ErrEmptyKey
+	}
+	if weight <= 0 || math.IsNaN(weight) || math.IsInf(weight, 0) {
+		return ErrInvalidWeight
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.weights[node]; exists {
+		return fmt.Errorf("node %s already exists", node)
+	}
+	r.weights[node] = weight
+
+	n := max(int(math.Round(weight*float64(r.replicas))), 1)
+	for i := 0; i < n; i++ {
+		r.points = append(r.points, point128{hash: r.hashFunc(node + "#" + strconv.Itoa(i)), node: node})
+	}
+
+	// Colliding positions are ordered by node name, as in Ring
+	sort.Slice(r.points, func(i, j int) bool {
+		if c := r.points[i].hash.Compare(r.points[j].hash); c != 0 {
+			return c < 0
+		}
+		return r.points[i].node < r.points[j].node
+	})
+	return nil
+}
+
+// RemoveNode removes a physical node and all its virtual nodes
+// Returns ErrNodeNotFound if the node doesn't exist
+func (r *Ring128) RemoveNode(node string) error {
+	if node == "" {
+		return ErrEmptyKey
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.weights[node]; !exists {
+		return ErrNodeNotFound
+	}
+	delete(r.weights, node)
+
+	// Filtering keeps the remaining positions sorted
+	points := r.points[:0]
+	for _, p := range r.points {
+		if p.node != node {
+			points = append(points, p)
+		}
+	}
+	clear(r.points[len(points):])
+	r.points = points
+	return nil
+}
+
+// GetNode returns the node responsible for key
+func (r *Ring128) GetNode(key string) (string, error) {
+	if key == "" {
+		return "", ErrEmptyKey
+	}
+	return r.GetNodeForHash(r.hashFunc(key))
+}
+
+// GetNodeForHash returns the node owning a position of the hash space, such
+// as a token read from a store
+func (r *Ring128) GetNodeForHash(hash Hash128) (string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(r.points) == 0 {
+		return "", ErrNoNodes
+	}
+	return r.points[r.searchLocked(hash)].node, nil
+}
+
+// GetNodes returns up to count distinct nodes for key, walking clockwise
+// from its position; the first is the node GetNode returns
+func (r *Ring128) GetNodes(key string, count int) ([]string, error) {
+	if key == "" {
+		return nil, ErrEmptyKey
+	}
+	if count <= 0 {
+		return nil, errors.New("count must be positive")
+	}
+
+	hash := r.hashFunc(key)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(r.points) == 0 {
+		return nil, ErrNoNodes
+	}
+
+	count = min(count, len(r.weights))
+	nodes := make([]string, 0, count)
+	seen := make(map[string]struct{}, count)
+	start := r.searchLocked(hash)
+	for i := 0; i < len(r.points) && len(nodes) < count; i++ {
+		node := r.points[(start+i)%len(r.points)].node
+		if _, dup := seen[node]; !dup {
+			seen[node] = struct{}{}
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes, nil
+}
+
+// searchLocked returns the index of the first virtual node at or after hash,
+// wrapping to 0
+// Caller must hold r.mu and ensure the ring is not empty
+func (r *Ring128) searchLocked(hash Hash128) int {
+	idx := sort.Search(len(r.points), func(i int) bool {
+		return r.points[i].hash.Compare(hash) >= 0
+	})
+	if idx == len(r.points) {
+		idx = 0
+	}
+	return idx
+}
+
+// OwnedRanges returns the hash ranges whose keys node owns, merged and in
+// ring order like Ring.OwnedRanges; an unknown node gets nil
+func (r *Ring128) OwnedRanges(node string) []Range128 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, exists := r.weights[node]; !exists {
+		return nil
+	}
+
+	n := len(r.points)
+
+	// Start walking just after a point owned by someone else so that a run of
+	// points crossing zero is not split in two
+	first := -1
+	for i, p := range r.points {
+		if p.node != node {
+			first = i
+			break
+		}
+	}
+	if first == -1 {
+		return []Range128{{Start: r.points[0].hash, End: r.points[0].hash}}
+	}
+
+	var ranges []Range128
+	for k := 1; k <= n; k++ {
+		i := (first + k) % n
+		if r.points[i].node != node {
+			continue
+		}
+
+		prev := r.points[(i+n-1)%n].hash
+		if len(ranges) > 0 && ranges[len(ranges)-1].End == prev {
+			ranges[len(ranges)-1].End = r.points[i].hash
+			continue
+		}
+		ranges = append(ranges, Range128{Start: prev, End: r.points[i].hash})
+	}
+
+	// Report in ring order: the range containing zero, if any, goes first
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].End.Compare(ranges[j].End) < 0
+	})
+	return ranges
+}
+
+// Nodes returns the physical nodes sorted by name
+func (r *Ring128) Nodes() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	nodes := make([]string, 0, len(r.weights))
+	for node := range r.weights {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+	return nodes
+}
+
+// VirtualNodeCount returns the total number of virtual nodes
+func (r *Ring128) VirtualNodeCount() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return len(r.points)
+}
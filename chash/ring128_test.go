@@ -0,0 +1,149 @@
+package chash
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestHash128(t *testing.T) {
+	a := Hash128{Hi: 1, Lo: 0}
+	b := Hash128{Hi: 0, Lo: math.MaxUint64}
+
+	if a.Compare(b) != 1 || b.Compare(a) != -1 || a.Compare(a) != 0 {
+		t.Error("expected Hi to dominate the comparison")
+	}
+	if d := a.sub(b); d != (Hash128{Lo: 1}) {
+		t.Errorf("expected subtraction to borrow, got %+v", d)
+	}
+	if s := a.String(); s != "18446744073709551616" {
+		t.Errorf("expected 2^64, got %s", s)
+	}
+
+	// Hi is the 64-bit Murmur3
+	if h := Murmur3x128("hello"); h != (Hash128{Hi: 0xcbd8a7b341bd9b02, Lo: 0x5b1e906a48ae1d19}) {
+		t.Errorf("unexpected Murmur3x128: %#x %#x", h.Hi, h.Lo)
+	}
+}
+
+func TestRange128(t *testing.T) {
+	half := Hash128{Hi: 1 << 63}
+	rg := Range128{Start: Hash128{}, End: half}
+
+	if !rg.Contains(half) || rg.Contains(Hash128{}) || rg.Contains(Hash128{Hi: 1<<63 + 1}) {
+		t.Error("expected (0, 2^127] bounds")
+	}
+	if f := rg.Fraction(); f != 0.5 {
+		t.Errorf("expected half the space, got %f", f)
+	}
+
+	// A wrapping range covers the other half
+	wrap := Range128{Start: half, End: Hash128{}}
+	if !wrap.Contains(Hash128{}) || wrap.Contains(Hash128{Lo: 1}) {
+		t.Error("expected wrapping range to contain zero only at its end")
+	}
+	if f := wrap.Fraction(); f != 0.5 {
+		t.Errorf("expected half the space, got %f", f)
+	}
+}
+
+func TestRing128Lookups(t *testing.T) {
+	ring := NewRing128(Config128{Replicas: 50})
+	for _, node := range []string{"server1", "server2", "server3"} {
+		if err := ring.AddNode(node); err != nil {
+			t.Fatalf("AddNode failed: %v", err)
+		}
+	}
+
+	if err := ring.AddNode("server1"); err == nil {
+		t.Error("expected duplicate node to fail")
+	}
+	if ring.VirtualNodeCount() != 150 {
+		t.Errorf("expected 150 virtual nodes, got %d", ring.VirtualNodeCount())
+	}
+
+	counts := make(map[string]int)
+	for i := 0; i < 3000; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		node, err := ring.GetNode(key)
+		if err != nil {
+			t.Fatalf("GetNode failed: %v", err)
+		}
+		counts[node]++
+
+		nodes, err := ring.GetNodes(key, 5)
+		if err != nil || len(nodes) != 3 || nodes[0] != node {
+			t.Fatalf("expected 3 distinct nodes led by %s, got %v (%v)", node, nodes, err)
+		}
+	}
+	for node, n := range counts {
+		if n < 600 {
+			t.Errorf("%s received only %d of 3000 keys", node, n)
+		}
+	}
+
+	// Removing a node only moves its own keys
+	before := make(map[string]string)
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		before[key], _ = ring.GetNode(key)
+	}
+	if err := ring.RemoveNode("server2"); err != nil {
+		t.Fatalf("RemoveNode failed: %v", err)
+	}
+	for key, owner := range before {
+		if got, _ := ring.GetNode(key); owner != "server2" && got != owner {
+			t.Fatalf("%s moved from %s to %s", key, owner, got)
+		}
+	}
+
+	if err := ring.RemoveNode("server2"); err != ErrNodeNotFound {
+		t.Errorf("expected ErrNodeNotFound, got %v", err)
+	}
+	if _, err := NewRing128(Config128{}).GetNode("key"); err != ErrNoNodes {
+		t.Errorf("expected ErrNoNodes, got %v", err)
+	}
+}
+
+func TestRing128OwnedRanges(t *testing.T) {
+	ring := NewRing128(Config128{Replicas: 20, HashFunc: Murmur3x128})
+	ring.AddNode("server1")
+	ring.AddNodeWithWeight("server2", 2)
+
+	var total float64
+	for _, node := range ring.Nodes() {
+		ranges := ring.OwnedRanges(node)
+		for i, rg := range ranges {
+			total += rg.Fraction()
+			if i > 0 && ranges[i-1].End.Compare(rg.End) >= 0 {
+				t.Errorf("%s: ranges out of order", node)
+			}
+		}
+
+		for i := 0; i < 200; i++ {
+			key := fmt.Sprintf("key-%d", i)
+			if owner, _ := ring.GetNode(key); owner != node {
+				continue
+			}
+			hash := Murmur3x128(key)
+			found := false
+			for _, rg := range ranges {
+				found = found || rg.Contains(hash)
+			}
+			if !found {
+				t.Fatalf("%s: no owned range contains %s", node, key)
+			}
+		}
+	}
+	if math.Abs(total-1) > 1e-9 {
+		t.Errorf("expected ranges to cover the space once, got %f", total)
+	}
+
+	ring.RemoveNode("server1")
+	if ranges := ring.OwnedRanges("server2"); len(ranges) != 1 || ranges[0].Fraction() != 1 {
+		t.Errorf("expected a single full range, got %v", ranges)
+	}
+	if ring.OwnedRanges("server1") != nil {
+		t.Error("expected nil ranges for an unknown node")
+	}
+}
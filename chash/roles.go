@@ -0,0 +1,125 @@
+package chash
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidNodeRole is returned when setting a role that is not defined
+var ErrInvalidNodeRole = errors.New("invalid node role")
+
+// NodeRole splits the nodes of a ring into writable primaries and read-only
+// replicas, so one ring serves both write and read routing
+// Roles only affect GetWriteNode and GetReadNodes; every other lookup sees
+// all nodes
+type NodeRole int
+
+const (
+	// RolePrimary nodes take writes; nodes are primaries unless set otherwise
+	RolePrimary NodeRole = iota
+
+	// RoleReplica nodes are read-only followers
+	RoleReplica
+)
+
+// String returns the role name
+func (role NodeRole) String() string {
+	switch role {
+	case RolePrimary:
+		return "primary"
+	case RoleReplica:
+		return "replica"
+	default:
+		return fmt.Sprintf("NodeRole(%d)", int(role))
+	}
+}
+
+// SetNodeRole changes the role of a node, bumps the ring version and emits
+// a NodeUpdated event without moves
+func (r *Ring) SetNodeRole(node string, role NodeRole) error {
+	if role < RolePrimary || role > RoleReplica {
+		return fmt.Errorf("%w: %d", ErrInvalidNodeRole, int(role))
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, exists := r.nodeSet[node]
+	if !exists {
+		return ErrNodeNotFound
+	}
+
+	if entry.role == role {
+		return nil
+	}
+
+	before := r.watchLocked()
+	defer r.publishLocked(NodeUpdated, []string{node}, before)
+
+	entry.role = role
+	r.topologyChanges++
+	return nil
+}
+
+// NodeRole returns the role of a node
+func (r *Ring) NodeRole(node string) (NodeRole, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entry, exists := r.nodeSet[node]
+	if !exists {
+		return RolePrimary, ErrNodeNotFound
+	}
+	return entry.role, nil
+}
+
+// GetWriteNode returns the primary responsible for writes to key: the first
+// primary clockwise from the key's position
+// Node states apply as in GetNode; returns ErrNoNodes if no primary can serve
+func (r *Ring) GetWriteNode(key string) (string, error) {
+	nodes, err := r.getRoleNodes(key, 1, RolePrimary)
+	if err != nil {
+		return "", err
+	}
+	return nodes[0], nil
+}
+
+// GetReadNodes returns up to count distinct replicas to read key from,
+// walking clockwise from the key's position like GetNodes
+// Returns ErrNoNodes if no replica can serve
+func (r *Ring) GetReadNodes(key string, count int) ([]string, error) {
+	return r.getRoleNodes(key, count, RoleReplica)
+}
+
+// getRoleNodes returns up to count distinct nodes with role clockwise from
+// the position of key
+func (r *Ring) getRoleNodes(key string, count int, role NodeRole) ([]string, error) {
+	if key == "" {
+		return nil, ErrEmptyKey
+	}
+	if count <= 0 {
+		return nil, errors.New("count must be positive")
+	}
+
+	r.lookups.Add(1)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(r.ring) == 0 {
+		return nil, ErrNoNodes
+	}
+
+	// Nodes of the other role count as already seen
+	seen := make([]bool, len(r.names))
+	for i, name := range r.names {
+		seen[i] = r.nodeSet[name].role != role
+	}
+
+	var nodes []string
+	r.visitLocked(r.searchLocked(r.hashKeyLocked(key)), seen, func(owner uint32) bool {
+		nodes = append(nodes, r.names[owner])
+		return len(nodes) < count
+	})
+	return nodesOrErr(nodes)
+}
@@ -0,0 +1,135 @@
+package chash
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func newRolesRing(t *testing.T) *Ring {
+	t.Helper()
+	ring := NewWithNodes(Config{Replicas: 50}, []string{"primary1", "primary2", "replica1", "replica2", "replica3"})
+	for _, node := range []string{"replica1", "replica2", "replica3"} {
+		if err := ring.SetNodeRole(node, RoleReplica); err != nil {
+			t.Fatalf("SetNodeRole failed: %v", err)
+		}
+	}
+	return ring
+}
+
+func TestReadWriteSplit(t *testing.T) {
+	ring := newRolesRing(t)
+
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("key%d", i)
+
+		writer, err := ring.GetWriteNode(key)
+		if err != nil {
+			t.Fatalf("GetWriteNode failed: %v", err)
+		}
+		if role, _ := ring.NodeRole(writer); role != RolePrimary {
+			t.Fatalf("Key %s writes to %s, a %v", key, writer, role)
+		}
+
+		readers, err := ring.GetReadNodes(key, 5)
+		if err != nil {
+			t.Fatalf("GetReadNodes failed: %v", err)
+		}
+		if len(readers) != 3 {
+			t.Fatalf("Expected all 3 replicas, got %v", readers)
+		}
+		for _, reader := range readers {
+			if role, _ := ring.NodeRole(reader); role != RoleReplica {
+				t.Fatalf("Key %s reads from %s, a %v", key, reader, role)
+			}
+		}
+	}
+}
+
+func TestReadWriteSplitFollowsRing(t *testing.T) {
+	ring := newRolesRing(t)
+
+	// The write node is the first primary clockwise from the key, so it is
+	// the first primary GetNodes returns
+	for i := 0; i < 200; i++ {
+		key := fmt.Sprintf("key%d", i)
+		writer, _ := ring.GetWriteNode(key)
+		readers, _ := ring.GetReadNodes(key, 2)
+
+		all, _ := ring.GetNodes(key, 5)
+		var primaries, replicas []string
+		for _, node := range all {
+			if role, _ := ring.NodeRole(node); role == RolePrimary {
+				primaries = append(primaries, node)
+			} else {
+				replicas = append(replicas, node)
+			}
+		}
+		if writer != primaries[0] {
+			t.Errorf("Key %s: write node %s, first primary %s", key, writer, primaries[0])
+		}
+		if fmt.Sprint(readers) != fmt.Sprint(replicas[:2]) {
+			t.Errorf("Key %s: read nodes %v, first replicas %v", key, readers, replicas[:2])
+		}
+	}
+}
+
+func TestReadWriteSplitStates(t *testing.T) {
+	ring := newRolesRing(t)
+	ring.SetNodeState("primary1", NodeDisabled)
+
+	for i := 0; i < 100; i++ {
+		if writer, _ := ring.GetWriteNode(fmt.Sprintf("key%d", i)); writer != "primary2" {
+			t.Fatalf("Expected writes to move to primary2, got %s", writer)
+		}
+	}
+
+	ring.SetNodeState("primary2", NodeDisabled)
+	if _, err := ring.GetWriteNode("key"); !errors.Is(err, ErrNoNodes) {
+		t.Errorf("Expected ErrNoNodes with every primary disabled, got %v", err)
+	}
+	if readers, err := ring.GetReadNodes("key", 1); err != nil || len(readers) != 1 {
+		t.Errorf("Expected reads unaffected, got %v, %v", readers, err)
+	}
+}
+
+func TestNodeRoleErrors(t *testing.T) {
+	ring := NewWithNodes(Config{}, []string{"server1"})
+
+	if _, err := ring.GetReadNodes("key", 1); !errors.Is(err, ErrNoNodes) {
+		t.Errorf("Expected ErrNoNodes without replicas, got %v", err)
+	}
+	if err := ring.SetNodeRole("server1", NodeRole(7)); !errors.Is(err, ErrInvalidNodeRole) {
+		t.Errorf("Expected ErrInvalidNodeRole, got %v", err)
+	}
+	if err := ring.SetNodeRole("missing", RoleReplica); !errors.Is(err, ErrNodeNotFound) {
+		t.Errorf("Expected ErrNodeNotFound, got %v", err)
+	}
+
+	version := ring.Version()
+	ring.SetNodeRole("server1", RoleReplica)
+	if ring.Version() == version {
+		t.Error("Expected SetNodeRole to bump the version")
+	}
+	if role, _ := ring.NodeRole("server1"); role != RoleReplica || role.String() != "replica" {
+		t.Errorf("Expected replica, got %v", role)
+	}
+}
+
+func TestSetNodeRoleEvent(t *testing.T) {
+	ring := NewWithNodes(Config{Replicas: 20}, []string{"server1", "server2"})
+
+	events, unsubscribe := ring.Subscribe()
+	defer unsubscribe()
+
+	if err := ring.SetNodeRole("server2", RoleReplica); err != nil {
+		t.Fatalf("SetNodeRole failed: %v", err)
+	}
+	event := nextEvent(t, events)
+	if event.Type != NodeUpdated || event.Node != "server2" || event.Epoch != ring.Version() {
+		t.Errorf("Unexpected event %+v", event)
+	}
+	if len(event.Moves) != 0 {
+		t.Errorf("Expected a role change to move no ranges, got %d moves", len(event.Moves))
+	}
+}
@@ -0,0 +1,97 @@
+package chash
+
+import "sort"
+
+// VirtualNode is a virtual node position and the physical node that owns it
+type VirtualNode struct {
+	Hash uint64
+	Node string
+}
+
+// VirtualNodesBetween returns the virtual nodes positioned in the range
+// (start, end] in clockwise order, wrapping around zero when start > end;
+// start == end covers the whole ring
+// Colliding virtual nodes are all listed, ordered by node name
+func (r *Ring) VirtualNodesBetween(start, end uint64) []VirtualNode {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var vnodes []VirtualNode
+	r.scanLocked(start, end, func(i int) {
+		vnodes = append(vnodes, VirtualNode{Hash: r.ring[i], Node: r.ownerLocked(i)})
+	})
+	return vnodes
+}
+
+// NodesInRange returns the distinct nodes owning keys that hash into the range
+// (start, end], in clockwise order of their first owned position; the range
+// wraps around zero when start > end and covers the whole ring when
+// start == end
+// Anti-entropy and repair tools use it to find the owners of a token range.
+// Like OwnedRanges it reports ownership, so node states do not apply
+func (r *Ring) NodesInRange(start, end uint64) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	n := len(r.ring)
+	if n == 0 {
+		return nil
+	}
+
+	var nodes []string
+	seen := make(map[string]struct{})
+	add := func(i int) {
+		// Only the first of colliding virtual nodes owns keys
+		if i > 0 && r.ring[i] == r.ring[i-1] {
+			return
+		}
+		node := r.ownerLocked(i)
+		if _, dup := seen[node]; !dup {
+			seen[node] = struct{}{}
+			nodes = append(nodes, node)
+		}
+	}
+
+	last, count := r.scanLocked(start, end, add)
+
+	// Keys after the last virtual node in the range, up to end, belong to
+	// the next virtual node clockwise
+	if start != end && (count == 0 || r.ring[last] != end) {
+		add(r.firstAfterLocked(end))
+	}
+	return nodes
+}
+
+// scanLocked calls visit with the index of each virtual node positioned in
+// (start, end] in clockwise order and returns the last index visited and the
+// number visited
+// Caller must hold r.mu
+func (r *Ring) scanLocked(start, end uint64, visit func(i int)) (last, count int) {
+	n := len(r.ring)
+	if n == 0 {
+		return 0, 0
+	}
+
+	rg := Range{Start: start, End: end}
+	first := r.firstAfterLocked(start)
+	for k := 0; k < n; k++ {
+		i := (first + k) % n
+		if !rg.Contains(r.ring[i]) {
+			break
+		}
+		visit(i)
+		last, count = i, count+1
+	}
+	return last, count
+}
+
+// firstAfterLocked returns the index of the first virtual node positioned
+// after hash, wrapping to 0
+// Caller must hold r.mu and ensure the ring is not empty
+func (r *Ring) firstAfterLocked(hash uint64) int {
+	i := sort.Search(len(r.ring), func(i int) bool { return r.ring[i] > hash })
+	if i == len(r.ring) {
+		i = 0
+	}
+	return i
+}
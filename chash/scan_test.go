@@ -0,0 +1,80 @@
+package chash
+
+import (
+	"math"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func TestNodesInRange(t *testing.T) {
+	ring := NewWithNodes(Config{Replicas: 10}, []string{"server1", "server2", "server3", "server4", "server5"})
+	rng := rand.New(rand.NewPCG(1, 2))
+
+	owned := make(map[string][]Range)
+	for _, node := range ring.Nodes() {
+		owned[node] = ring.OwnedRanges(node)
+	}
+
+	for trial := 0; trial < 2000; trial++ {
+		start := rng.Uint64()
+		end := start + rng.Uint64N(math.MaxUint64/20) // Mostly narrow ranges
+		if trial%10 == 0 {
+			end = rng.Uint64() // Some wide and wrapping ones
+		}
+		rg := Range{Start: start, End: end}
+
+		got := ring.NodesInRange(start, end)
+
+		// Two ranges overlap iff one contains the other's end
+		var want []string
+		for node, ranges := range owned {
+			for _, o := range ranges {
+				if o.Contains(end) || rg.Contains(o.End) {
+					want = append(want, node)
+					break
+				}
+			}
+		}
+		sorted := slices.Sorted(slices.Values(got))
+		slices.Sort(want)
+		if !slices.Equal(sorted, want) {
+			t.Fatalf("Range %v: got %v, want %v", rg, got, want)
+		}
+
+		if first, _ := ring.GetNodeForHash(start + 1); got[0] != first {
+			t.Fatalf("Range %v: expected %s first, got %v", rg, first, got)
+		}
+	}
+
+	if got := ring.NodesInRange(42, 42); len(got) != 5 {
+		t.Errorf("Expected the whole ring to cover every node, got %v", got)
+	}
+	if got := New(Config{}).NodesInRange(0, 1); got != nil {
+		t.Errorf("Expected nil for an empty ring, got %v", got)
+	}
+}
+
+func TestVirtualNodesBetween(t *testing.T) {
+	ring := NewWithNodes(Config{Replicas: 20}, []string{"server1", "server2", "server3"})
+	all := ring.VirtualNodesBetween(0, 0)
+	if len(all) != ring.VirtualNodeCount() {
+		t.Fatalf("Expected all %d virtual nodes, got %d", ring.VirtualNodeCount(), len(all))
+	}
+
+	a, b := all[10].Hash, all[20].Hash
+	if got := ring.VirtualNodesBetween(a, b); !slices.Equal(got, all[11:21]) {
+		t.Errorf("Expected virtual nodes 11-20, got %v", got)
+	}
+
+	// Wrapping ranges continue past zero
+	wrapped := ring.VirtualNodesBetween(b, a)
+	want := append(slices.Clone(all[21:]), all[:11]...)
+	if !slices.Equal(wrapped, want) {
+		t.Errorf("Expected %d wrapped virtual nodes, got %d", len(want), len(wrapped))
+	}
+
+	if got := ring.VirtualNodesBetween(a, a+1); len(got) != 0 {
+		t.Errorf("Expected no virtual nodes in a narrow gap, got %v", got)
+	}
+}
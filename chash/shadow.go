@@ -0,0 +1,150 @@
+package chash
+
+import (
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+)
+
+// ShadowConfig holds configuration options for creating a new ShadowRing
+type ShadowConfig struct {
+	// SampleRate is the fraction of lookups also routed against the
+	// candidate, in (0, 1]
+	// Default: 1 (compare every lookup)
+	SampleRate float64
+
+	// OnDivergence is called for every compared key the candidate routes to
+	// a different node; candidate is empty if the candidate could not route
+	// it. It runs on the lookup path and must be fast
+	// Default: nil
+	OnDivergence func(key, primary, candidate string)
+}
+
+// ShadowMove is a pair of nodes a key routes to on the primary and candidate
+type ShadowMove struct {
+	From string
+	To   string
+}
+
+// ShadowStats summarises how the candidate's answers differ from the primary's
+type ShadowStats struct {
+	// Compared counts the lookups routed against both rings
+	Compared uint64
+
+	// Divergent counts the compared lookups the rings disagree on
+	Divergent uint64
+
+	// Moves counts divergent lookups by primary and candidate node
+	Moves map[ShadowMove]uint64
+}
+
+// DivergentFraction returns the share of compared lookups the rings disagree on
+func (s ShadowStats) DivergentFraction() float64 {
+	if s.Compared == 0 {
+		return 0
+	}
+	return float64(s.Divergent) / float64(s.Compared)
+}
+
+// ShadowRing routes lookups against a primary ring and, in the shadow, also
+// against a candidate ring with a proposed topology, counting where the two
+// disagree
+// Unlike Diff, which measures the keyspace a change moves, the stats weigh the
+// change by real traffic, so it can be validated before cutover. Callers only
+// ever see the primary's answers
+type ShadowRing struct {
+	primary   *Ring
+	candidate *Ring
+	config    ShadowConfig
+
+	compared  atomic.Uint64
+	divergent atomic.Uint64
+
+	// mu protects moves
+	mu    sync.Mutex
+	moves map[ShadowMove]uint64
+}
+
+// NewShadowRing creates a shadow ring comparing candidate against primary
+func NewShadowRing(primary, candidate *Ring, config ShadowConfig) *ShadowRing {
+	if config.SampleRate <= 0 || config.SampleRate > 1 {
+		config.SampleRate = 1 // Default: compare every lookup
+	}
+
+	return &ShadowRing{
+		primary:   primary,
+		candidate: candidate,
+		config:    config,
+		moves:     make(map[ShadowMove]uint64),
+	}
+}
+
+// Primary returns the ring lookups are answered from
+func (s *ShadowRing) Primary() *Ring {
+	return s.primary
+}
+
+// Candidate returns the ring lookups are compared against
+func (s *ShadowRing) Candidate() *Ring {
+	return s.candidate
+}
+
+// GetNode returns the primary's node for key and compares it with the
+// candidate's
+// Keys the primary cannot route are not compared
+func (s *ShadowRing) GetNode(key string) (string, error) {
+	node, err := s.primary.GetNode(key)
+	if err != nil {
+		return "", err
+	}
+
+	if s.config.SampleRate >= 1 || rand.Float64() < s.config.SampleRate {
+		s.compare(key, node)
+	}
+	return node, nil
+}
+
+// compare routes key against the candidate and records any divergence
+func (s *ShadowRing) compare(key, node string) {
+	s.compared.Add(1)
+
+	shadow, _ := s.candidate.GetNode(key)
+	if shadow == node {
+		return
+	}
+	s.divergent.Add(1)
+
+	s.mu.Lock()
+	s.moves[ShadowMove{From: node, To: shadow}]++
+	s.mu.Unlock()
+
+	if s.config.OnDivergence != nil {
+		s.config.OnDivergence(key, node, shadow)
+	}
+}
+
+// Stats returns the divergence recorded since creation or the last Reset
+func (s *ShadowRing) Stats() ShadowStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	moves := make(map[ShadowMove]uint64, len(s.moves))
+	for move, n := range s.moves {
+		moves[move] = n
+	}
+	return ShadowStats{
+		Compared:  s.compared.Load(),
+		Divergent: s.divergent.Load(),
+		Moves:     moves,
+	}
+}
+
+// Reset clears the recorded divergence, such as after changing the candidate
+func (s *ShadowRing) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.compared.Store(0)
+	s.divergent.Store(0)
+	clear(s.moves)
+}
@@ -0,0 +1,97 @@
+package chash
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestShadowRing(t *testing.T) {
+	primary := NewWithNodes(Config{Replicas: 100}, []string{"server1", "server2", "server3"})
+	candidate := primary.Clone()
+	if err := candidate.AddNode("server4"); err != nil {
+		t.Fatalf("AddNode failed: %v", err)
+	}
+
+	var reported int
+	shadow := NewShadowRing(primary, candidate, ShadowConfig{
+		OnDivergence: func(key, from, to string) {
+			reported++
+			if to != "server4" {
+				t.Errorf("Key %s diverged to %s; only server4 should take keys", key, to)
+			}
+		},
+	})
+
+	const keys = 10000
+	for i := 0; i < keys; i++ {
+		key := fmt.Sprintf("key%d", i)
+		got, err := shadow.GetNode(key)
+		if err != nil {
+			t.Fatalf("GetNode failed: %v", err)
+		}
+		if want, _ := primary.GetNode(key); got != want {
+			t.Fatalf("Shadow answered %s for %s, primary %s", got, key, want)
+		}
+	}
+
+	stats := shadow.Stats()
+	if stats.Compared != keys {
+		t.Errorf("Expected %d compared lookups, got %d", keys, stats.Compared)
+	}
+	if stats.Divergent != uint64(reported) {
+		t.Errorf("Divergent %d but %d reported", stats.Divergent, reported)
+	}
+
+	var moved uint64
+	for move, n := range stats.Moves {
+		if move.To != "server4" {
+			t.Errorf("Unexpected move %+v", move)
+		}
+		moved += n
+	}
+	if moved != stats.Divergent {
+		t.Errorf("Moves sum to %d, want %d", moved, stats.Divergent)
+	}
+
+	// Uniform keys diverge in line with the keyspace the change moves
+	want := MovedFraction(primary.Diff(candidate))
+	if got := stats.DivergentFraction(); math.Abs(got-want) > 0.03 {
+		t.Errorf("Divergent fraction %.3f, keyspace moved %.3f", got, want)
+	}
+
+	shadow.Reset()
+	if stats := shadow.Stats(); stats.Compared != 0 || stats.Divergent != 0 || len(stats.Moves) != 0 {
+		t.Errorf("Expected empty stats after Reset, got %+v", stats)
+	}
+}
+
+func TestShadowRingUnroutableCandidate(t *testing.T) {
+	primary := NewWithNodes(Config{}, []string{"server1"})
+	shadow := NewShadowRing(primary, New(Config{}), ShadowConfig{})
+
+	if node, err := shadow.GetNode("key"); err != nil || node != "server1" {
+		t.Fatalf("Expected server1, got %s, %v", node, err)
+	}
+	stats := shadow.Stats()
+	if stats.Divergent != 1 || stats.Moves[ShadowMove{From: "server1"}] != 1 {
+		t.Errorf("Expected divergence to an empty candidate, got %+v", stats)
+	}
+
+	// Keys the primary cannot route are not compared
+	if _, err := NewShadowRing(New(Config{}), primary, ShadowConfig{}).GetNode("key"); err == nil {
+		t.Error("Expected error from an empty primary")
+	}
+}
+
+func TestShadowRingSampling(t *testing.T) {
+	ring := NewWithNodes(Config{}, []string{"server1", "server2"})
+	shadow := NewShadowRing(ring, ring, ShadowConfig{SampleRate: 0.1})
+
+	for i := 0; i < 10000; i++ {
+		shadow.GetNode(fmt.Sprintf("key%d", i))
+	}
+	if got := shadow.Stats().Compared; got < 700 || got > 1300 {
+		t.Errorf("Expected about 1000 sampled comparisons, got %d", got)
+	}
+}
@@ -0,0 +1,188 @@
+package chash
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidNodeState is returned when setting a state that is not defined
+var ErrInvalidNodeState = errors.New("invalid node state")
+
+// NodeState controls how lookups treat a node without removing it, so a
+// rolling deploy does not churn key ownership
+// States only affect lookups: virtual nodes, owned ranges, diffs and
+// snapshots still describe every node
+type NodeState int
+
+const (
+	// NodeActive nodes serve lookups normally
+	NodeActive NodeState = iota
+
+	// NodeDraining nodes receive no primary assignments but still serve as
+	// replicas; a key whose primary is draining moves to the next active node
+	NodeDraining
+
+	// NodeDisabled nodes are skipped by every lookup until re-enabled
+	NodeDisabled
+)
+
+// String returns the state name
+func (s NodeState) String() string {
+	switch s {
+	case NodeActive:
+		return "active"
+	case NodeDraining:
+		return "draining"
+	case NodeDisabled:
+		return "disabled"
+	default:
+		return fmt.Sprintf("NodeState(%d)", int(s))
+	}
+}
+
+// SetNodeState changes the lookup state of a node and bumps the ring version
+// If every node is draining, lookups fall back to draining nodes; if every
+// node is disabled, lookups return ErrNoNodes
+func (r *Ring) SetNodeState(node string, state NodeState) error {
+	if state < NodeActive || state > NodeDisabled {
+		return fmt.Errorf("%w: %d", ErrInvalidNodeState, int(state))
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, exists := r.nodeSet[node]
+	if !exists {
+		return ErrNodeNotFound
+	}
+
+	if entry.state == state {
+		return nil
+	}
+
+	if entry.state == NodeActive && state != NodeActive {
+		r.inactive++
+	} else if entry.state != NodeActive && state == NodeActive {
+		r.inactive--
+	}
+	entry.state = state
+	r.topologyChanges++
+	r.checkInvariantsLocked()
+	return nil
+}
+
+// NodeState returns the lookup state of a node
+func (r *Ring) NodeState(node string) (NodeState, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entry, exists := r.nodeSet[node]
+	if !exists {
+		return NodeActive, ErrNodeNotFound
+	}
+	return entry.state, nil
+}
+
+// stateLocked returns the state of the node at node table index owner
+// Caller must hold r.mu
+func (r *Ring) stateLocked(owner uint32) NodeState {
+	if r.inactive == 0 {
+		return NodeActive
+	}
+	return r.nodeSet[r.names[owner]].state
+}
+
+// primaryLocked returns the node serving ring index idx: its owner, or the
+// next active node clockwise when the owner is draining or disabled
+// Returns "" if every node is disabled
+// Caller must hold r.mu and ensure the ring is not empty
+func (r *Ring) primaryLocked(idx int) string {
+	if r.inactive == 0 {
+		return r.ownerLocked(idx)
+	}
+
+	draining := ""
+	for i := 0; i < len(r.ring); i++ {
+		owner := r.owners[(idx+i)%len(r.ring)]
+		switch r.stateLocked(owner) {
+		case NodeActive:
+			return r.names[owner]
+		case NodeDraining:
+			if draining == "" {
+				draining = r.names[owner]
+			}
+		}
+	}
+	return draining
+}
+
+// servingNode returns node, or ErrNoNodes if primaryLocked found none
+func servingNode(node string) (string, error) {
+	if node == "" {
+		return "", ErrNoNodes
+	}
+	return node, nil
+}
+
+// nodesOrErr returns nodes, or ErrNoNodes if a walk found none
+func nodesOrErr(nodes []string) ([]string, error) {
+	if len(nodes) == 0 {
+		return nil, ErrNoNodes
+	}
+	return nodes, nil
+}
+
+// visitLocked calls visit with each distinct node clockwise from ring index
+// idx in replica order, until visit returns false
+// Nodes marked in seen are skipped and every visited node is marked; disabled
+// nodes are skipped, and draining nodes met before the first active node are
+// visited right after it, so a draining node is only first when no active
+// node is left
+// Caller must hold r.mu
+func (r *Ring) visitLocked(idx int, seen []bool, visit func(owner uint32) bool) {
+	var deferred []uint32
+	primary := false
+
+	for i := 0; i < len(r.ring); i++ {
+		owner := r.owners[(idx+i)%len(r.ring)]
+		if seen[owner] {
+			continue
+		}
+		seen[owner] = true
+
+		switch r.stateLocked(owner) {
+		case NodeDisabled:
+			continue
+		case NodeDraining:
+			if !primary {
+				deferred = append(deferred, owner)
+				continue
+			}
+		default:
+			if !primary {
+				primary = true
+				if !visit(owner) {
+					return
+				}
+				for _, d := range deferred {
+					if !visit(d) {
+						return
+					}
+				}
+				deferred = nil
+				continue
+			}
+		}
+
+		if !visit(owner) {
+			return
+		}
+	}
+
+	// No active node: fall back to the draining ones
+	for _, d := range deferred {
+		if !visit(d) {
+			return
+		}
+	}
+}
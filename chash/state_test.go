@@ -0,0 +1,142 @@
+package chash
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestNodeStateDraining(t *testing.T) {
+	ring := NewWithNodes(Config{Replicas: 20}, []string{"server1", "server2", "server3"})
+
+	before := make(map[string][]string)
+	for i := 0; i < 300; i++ {
+		key := fmt.Sprintf("key%d", i)
+		before[key], _ = ring.GetNodes(key, 3)
+	}
+
+	if err := ring.SetNodeState("server2", NodeDraining); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if state, _ := ring.NodeState("server2"); state != NodeDraining {
+		t.Errorf("expected draining, got %v", state)
+	}
+
+	for key, old := range before {
+		node, err := ring.GetNode(key)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if node == "server2" {
+			t.Fatalf("%s: expected no primary on a draining node", key)
+		}
+		if old[0] != "server2" && node != old[0] {
+			t.Errorf("%s: expected %s to keep its primary, got %s", key, old[0], node)
+		}
+
+		// The draining node stays a replica, just not the first one
+		nodes, _ := ring.GetNodes(key, 3)
+		if len(nodes) != 3 || nodes[0] != node {
+			t.Errorf("%s: expected 3 replicas led by %s, got %v", key, node, nodes)
+		}
+	}
+
+	if ring.NodeCount() != 3 || ring.VirtualNodeCount() != 60 {
+		t.Error("expected draining to leave the ring layout unchanged")
+	}
+
+	ring.SetNodeState("server2", NodeActive)
+	for key, old := range before {
+		if node, _ := ring.GetNode(key); node != old[0] {
+			t.Errorf("%s: expected %s after reactivation, got %s", key, old[0], node)
+		}
+	}
+}
+
+func TestNodeStateDisabled(t *testing.T) {
+	ring := NewWithNodes(Config{Replicas: 20}, []string{"server1", "server2", "server3"})
+	ring.SetNodeState("server3", NodeDisabled)
+
+	for i := 0; i < 300; i++ {
+		key := fmt.Sprintf("key%d", i)
+
+		nodes, err := ring.GetNodes(key, 3)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(nodes) != 2 {
+			t.Fatalf("%s: expected the 2 enabled nodes, got %v", key, nodes)
+		}
+		for _, node := range nodes {
+			if node == "server3" {
+				t.Fatalf("%s: expected disabled node to be skipped, got %v", key, nodes)
+			}
+		}
+	}
+
+	info, _ := ring.NodeInfo("server3")
+	if info.State != NodeDisabled {
+		t.Errorf("expected NodeInfo to report disabled, got %v", info.State)
+	}
+}
+
+func TestNodeStateFallbacks(t *testing.T) {
+	ring := NewWithNodes(Config{Replicas: 10}, []string{"server1", "server2"})
+
+	// With every node draining, lookups still succeed
+	ring.SetNodeState("server1", NodeDraining)
+	ring.SetNodeState("server2", NodeDraining)
+	if _, err := ring.GetNode("key"); err != nil {
+		t.Errorf("expected draining fallback, got %v", err)
+	}
+
+	ring.SetNodeState("server1", NodeDisabled)
+	ring.SetNodeState("server2", NodeDisabled)
+	if _, err := ring.GetNode("key"); err != ErrNoNodes {
+		t.Errorf("expected ErrNoNodes, got %v", err)
+	}
+	if _, err := ring.GetNodes("key", 2); err != ErrNoNodes {
+		t.Errorf("expected ErrNoNodes, got %v", err)
+	}
+
+	// Removing an inactive node keeps the fast path accounting right
+	ring.RemoveNode("server1")
+	ring.SetNodeState("server2", NodeActive)
+	if ring.inactive != 0 {
+		t.Errorf("expected no inactive nodes, got %d", ring.inactive)
+	}
+}
+
+func TestSetNodeStateErrors(t *testing.T) {
+	ring := NewWithNodes(Config{}, []string{"server1"})
+
+	if err := ring.SetNodeState("missing", NodeDraining); err != ErrNodeNotFound {
+		t.Errorf("expected ErrNodeNotFound, got %v", err)
+	}
+	if err := ring.SetNodeState("server1", NodeState(7)); !errors.Is(err, ErrInvalidNodeState) {
+		t.Errorf("expected ErrInvalidNodeState, got %v", err)
+	}
+	if _, err := ring.NodeState("missing"); err != ErrNodeNotFound {
+		t.Errorf("expected ErrNodeNotFound, got %v", err)
+	}
+}
+
+func TestNodeStateSurvivesReload(t *testing.T) {
+	ring := NewWithNodes(Config{Replicas: 10}, []string{"server1", "server2"})
+	ring.SetNodeState("server1", NodeDisabled)
+
+	data, err := ring.MarshalBinary()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := ring.UnmarshalBinary(data); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if state, _ := ring.NodeState("server1"); state != NodeDisabled {
+		t.Errorf("expected state to survive the reload, got %v", state)
+	}
+	if node, _ := ring.GetNode("key"); node != "server2" {
+		t.Errorf("expected server2, got %s", node)
+	}
+}
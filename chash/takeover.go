@@ -0,0 +1,36 @@
+package chash
+
+// Handoff is a hash range that moves to a new owner when nodes leave the ring
+type Handoff struct {
+	Range Range
+
+	// From is the node that owns the range today
+	From string
+
+	// To is the node that takes the range over ("" if no nodes would remain)
+	To string
+}
+
+// TakeoverPlan returns the ranges that would change owner if nodes were
+// removed, and who would take each one over
+// The ring is not modified, so the plan can be used to pre-warm takeover owners
+func (r *Ring) TakeoverPlan(nodes ...string) []Handoff {
+	leaving := make(map[string]struct{}, len(nodes))
+	for _, node := range nodes {
+		leaving[node] = struct{}{}
+	}
+
+	current := r.points()
+	remaining := make([]ringPoint, 0, len(current))
+	for _, p := range current {
+		if _, ok := leaving[p.node]; !ok {
+			remaining = append(remaining, p)
+		}
+	}
+
+	var plan []Handoff
+	for _, seg := range compareOwnership(current, remaining) {
+		plan = append(plan, Handoff{Range: seg.rng, From: seg.ownerA, To: seg.ownerB})
+	}
+	return plan
+}
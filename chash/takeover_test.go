@@ -0,0 +1,66 @@
+package chash
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestTakeoverPlan(t *testing.T) {
+	ring := NewWithNodes(Config{Replicas: 20}, []string{"server1", "server2", "server3"})
+
+	plan := ring.TakeoverPlan("server2")
+	if len(plan) == 0 {
+		t.Fatal("expected handoffs for a removed node")
+	}
+
+	var share float64
+	for _, h := range plan {
+		if h.From != "server2" {
+			t.Errorf("expected only server2 ranges to move, got %+v", h)
+		}
+		if h.To == "server2" || h.To == "" {
+			t.Errorf("unexpected takeover owner in %+v", h)
+		}
+		share += h.Range.Fraction()
+	}
+
+	// The plan matches the ring after the removal
+	after := NewWithNodes(Config{Replicas: 20}, []string{"server1", "server3"})
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("key%d", i)
+		hash := DefaultHashFunc(key)
+
+		before, _ := ring.GetNode(key)
+		owner, _ := after.GetNode(key)
+		for _, h := range plan {
+			if h.Range.Contains(hash) && h.To != owner {
+				t.Errorf("plan says %s goes to %s, ring says %s", key, h.To, owner)
+			}
+		}
+		if before != "server2" && before != owner {
+			t.Errorf("key %s moved from surviving node %s", key, before)
+		}
+	}
+
+	if share <= 0 || share >= 1 {
+		t.Errorf("expected a partial share to move, got %v", share)
+	}
+
+	// The ring itself is untouched
+	if ring.NodeCount() != 3 {
+		t.Errorf("expected ring unchanged, got %d nodes", ring.NodeCount())
+	}
+}
+
+func TestTakeoverPlanAllNodes(t *testing.T) {
+	ring := NewWithNodes(Config{Replicas: 5}, []string{"server1"})
+
+	plan := ring.TakeoverPlan("server1")
+	if len(plan) != 1 || plan[0].To != "" {
+		t.Errorf("expected whole ring to have no takeover owner, got %+v", plan)
+	}
+
+	if plan := ring.TakeoverPlan("missing"); len(plan) != 0 {
+		t.Errorf("expected no handoffs for unknown node, got %+v", plan)
+	}
+}
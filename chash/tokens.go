@@ -0,0 +1,71 @@
+package chash
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+)
+
+var (
+	// ErrInvalidTokens is returned when a token list is empty or repeats a token
+	ErrInvalidTokens = errors.New("tokens must be a non-empty list of distinct positions")
+
+	// ErrManualTokens is returned when changing the virtual node count of a
+	// node whose tokens were assigned manually
+	ErrManualTokens = errors.New("node has manually assigned tokens")
+)
+
+// AddNodeWithTokens adds a node whose virtual nodes sit exactly at tokens
+// instead of at positions hashed from the node name, Cassandra-style, so the
+// ring can mirror the token map of an existing cluster
+// The node's weight is 1 but its share of the keys follows from its tokens;
+// its virtual node count cannot be changed. Not supported on ketama rings
+func (r *Ring) AddNodeWithTokens(node string, tokens []uint64) error {
+	if err := checkTokens(tokens); err != nil {
+		return err
+	}
+	if r.ketama {
+		return ErrKetamaVirtualNodes
+	}
+	return r.addNode(node, &nodeEntry{weight: 1, tokens: slices.Sorted(slices.Values(tokens))}, false)
+}
+
+// Tokens returns the manually assigned tokens of node in ascending order, or
+// nil if its positions are hashed from its name
+func (r *Ring) Tokens(node string) ([]uint64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entry, exists := r.nodeSet[node]
+	if !exists {
+		return nil, ErrNodeNotFound
+	}
+	return slices.Clone(entry.tokens), nil
+}
+
+// checkTokens returns ErrInvalidTokens unless tokens is non-empty and distinct
+func checkTokens(tokens []uint64) error {
+	if len(tokens) == 0 {
+		return ErrInvalidTokens
+	}
+
+	seen := make(map[uint64]struct{}, len(tokens))
+	for _, token := range tokens {
+		if _, dup := seen[token]; dup {
+			return fmt.Errorf("%w: %d repeats", ErrInvalidTokens, token)
+		}
+		seen[token] = struct{}{}
+	}
+	return nil
+}
+
+// virtualNodeHashLocked returns the home position of virtual node i of node:
+// its i-th manual token, or the hash of "node#i"
+// Caller must hold r.mu
+func (r *Ring) virtualNodeHashLocked(node string, entry *nodeEntry, i int) uint64 {
+	if entry.tokens != nil {
+		return entry.tokens[i]
+	}
+	return r.hashFunc(node + "#" + strconv.Itoa(i))
+}
@@ -0,0 +1,124 @@
+package chash
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestAddNodeWithTokens(t *testing.T) {
+	const third = math.MaxUint64 / 3
+	ring := New(Config{})
+	tokens := map[string][]uint64{
+		"cass1": {third},
+		"cass2": {2 * third},
+		"cass3": {math.MaxUint64, 100},
+	}
+	for node, ts := range tokens {
+		if err := ring.AddNodeWithTokens(node, ts); err != nil {
+			t.Fatalf("AddNodeWithTokens failed: %v", err)
+		}
+	}
+
+	want := []VirtualNode{{100, "cass3"}, {third, "cass1"}, {2 * third, "cass2"}, {math.MaxUint64, "cass3"}}
+	if got := ring.VirtualNodesBetween(0, 0); !slices.Equal(got, want) {
+		t.Fatalf("Expected virtual nodes exactly at the tokens, got %v", got)
+	}
+
+	// Each node owns the range ending at its token, as in Cassandra
+	if got := ring.OwnedRanges("cass1"); !slices.Equal(got, []Range{{Start: 100, End: third}}) {
+		t.Errorf("Unexpected ranges for cass1: %v", got)
+	}
+	for hash, node := range map[uint64]string{50: "cass3", 101: "cass1", third + 1: "cass2", 2*third + 1: "cass3"} {
+		if got, _ := ring.GetNodeForHash(hash); got != node {
+			t.Errorf("Hash %d: expected %s, got %s", hash, node, got)
+		}
+	}
+
+	if got, _ := ring.Tokens("cass3"); !slices.Equal(got, []uint64{100, math.MaxUint64}) {
+		t.Errorf("Expected sorted tokens, got %v", got)
+	}
+
+	// Manual and hashed nodes mix; the count of a token node is fixed
+	ring.AddNode("hashed")
+	if got, _ := ring.Tokens("hashed"); got != nil {
+		t.Errorf("Expected no tokens for a hashed node, got %v", got)
+	}
+	if err := ring.SetVirtualNodes("cass1", 10); !errors.Is(err, ErrManualTokens) {
+		t.Errorf("Expected ErrManualTokens, got %v", err)
+	}
+	if err := ring.UpdateWeight("cass1", 5); err != nil {
+		t.Fatalf("UpdateWeight failed: %v", err)
+	}
+	if n, _ := ring.VirtualNodes("cass1"); n != 1 {
+		t.Errorf("Expected a token node to keep 1 virtual node, got %d", n)
+	}
+}
+
+func TestAddNodeWithTokensErrors(t *testing.T) {
+	ring := New(Config{})
+
+	if err := ring.AddNodeWithTokens("node", nil); !errors.Is(err, ErrInvalidTokens) {
+		t.Errorf("Expected ErrInvalidTokens for no tokens, got %v", err)
+	}
+	if err := ring.AddNodeWithTokens("node", []uint64{1, 2, 1}); !errors.Is(err, ErrInvalidTokens) {
+		t.Errorf("Expected ErrInvalidTokens for a repeated token, got %v", err)
+	}
+	if err := New(Config{Ketama: true}).AddNodeWithTokens("node", []uint64{1}); !errors.Is(err, ErrKetamaVirtualNodes) {
+		t.Errorf("Expected ErrKetamaVirtualNodes, got %v", err)
+	}
+}
+
+func TestTokensSurviveSerialization(t *testing.T) {
+	ring := NewWithNodes(Config{Replicas: 20}, []string{"hashed"})
+	ring.AddNodeWithTokens("manual", []uint64{7, 1 << 40, 1 << 63})
+
+	binary, err := ring.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary failed: %v", err)
+	}
+	if binary[len(snapshotMagic)] != snapshotVersionTokens {
+		t.Errorf("Expected snapshot version %d, got %d", snapshotVersionTokens, binary[len(snapshotMagic)])
+	}
+	encoded, err := ring.MarshalJSON()
+	if err != nil {
+		t.Fatalf("MarshalJSON failed: %v", err)
+	}
+
+	for name, load := range map[string]func(*Ring) error{
+		"binary": func(r *Ring) error { return r.UnmarshalBinary(binary) },
+		"json":   func(r *Ring) error { return r.UnmarshalJSON(encoded) },
+	} {
+		restored := New(Config{})
+		if err := load(restored); err != nil {
+			t.Fatalf("%s: load failed: %v", name, err)
+		}
+		if got, _ := restored.Tokens("manual"); !slices.Equal(got, []uint64{7, 1 << 40, 1 << 63}) {
+			t.Errorf("%s: expected tokens restored, got %v", name, got)
+		}
+		if moves := ring.Diff(restored); len(moves) != 0 {
+			t.Errorf("%s: expected identical ownership, got %d moved ranges", name, len(moves))
+		}
+	}
+
+	if err := New(Config{}).UnmarshalJSON([]byte(`{"version":4,"replicas":1,"nodes":[{"name":"a","weight":1,"tokens":[5,5]}]}`)); !errors.Is(err, ErrInvalidSnapshot) {
+		t.Errorf("Expected ErrInvalidSnapshot for repeated tokens, got %v", err)
+	}
+}
+
+func TestTokensWithCollisionResolution(t *testing.T) {
+	ring := New(Config{ResolveCollisions: true})
+	ring.AddNodeWithTokens("a", []uint64{10, 20})
+	ring.AddNodeWithTokens("b", []uint64{20, 30})
+
+	if got := ring.Collisions(); len(got) != 0 {
+		t.Errorf("Expected the collision resolved, got %v", got)
+	}
+	if got, _ := ring.GetNodeForHash(20); got != "a" {
+		t.Errorf("Expected a to keep token 20, got %s", got)
+	}
+	if n := ring.DisplacedVirtualNodes(); n != 1 {
+		t.Errorf("Expected one displaced virtual node, got %d", n)
+	}
+}
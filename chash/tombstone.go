@@ -0,0 +1,86 @@
+package chash
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ErrQuarantined is returned when re-adding a node within its quarantine period
+var ErrQuarantined = errors.New("node is quarantined after recent removal")
+
+// Tombstone records a node removed from the ring
+type Tombstone struct {
+	// Node is the removed node name
+	Node string
+
+	// RemovedAt is when the node was removed
+	RemovedAt time.Time
+
+	// Epoch is the ring's topology change count after the removal
+	Epoch uint64
+
+	// Reason is the caller-supplied reason, if any
+	Reason string
+}
+
+// Tombstones returns the retained tombstones, most recent first
+func (r *Ring) Tombstones() []Tombstone {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	cutoff := r.clock.Now().Add(-r.tombstoneRetention)
+	tombstones := make([]Tombstone, 0, len(r.tombstones))
+	for _, ts := range r.tombstones {
+		if ts.RemovedAt.After(cutoff) {
+			tombstones = append(tombstones, ts)
+		}
+	}
+
+	sort.Slice(tombstones, func(i, j int) bool {
+		return tombstones[i].Epoch > tombstones[j].Epoch
+	})
+	return tombstones
+}
+
+// TombstoneFor returns the tombstone of a recently removed node
+func (r *Ring) TombstoneFor(node string) (Tombstone, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ts, ok := r.tombstones[node]
+	if !ok || !ts.RemovedAt.After(r.clock.Now().Add(-r.tombstoneRetention)) {
+		return Tombstone{}, false
+	}
+	return ts, true
+}
+
+// checkQuarantineLocked returns ErrQuarantined if node was removed too recently
+// Caller must hold r.mu
+func (r *Ring) checkQuarantineLocked(node string, now time.Time) error {
+	if r.quarantine <= 0 {
+		return nil
+	}
+
+	ts, ok := r.tombstones[node]
+	if !ok {
+		return nil
+	}
+
+	if until := ts.RemovedAt.Add(r.quarantine); now.Before(until) {
+		return fmt.Errorf("%w: %s until %s", ErrQuarantined, node, until.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// pruneTombstonesLocked drops tombstones older than the retention period
+// Caller must hold r.mu for writing
+func (r *Ring) pruneTombstonesLocked(now time.Time) {
+	cutoff := now.Add(-r.tombstoneRetention)
+	for node, ts := range r.tombstones {
+		if !ts.RemovedAt.After(cutoff) {
+			delete(r.tombstones, node)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package chash
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mohdrashid9678/dcore/clock"
+)
+
+func TestTombstonesRecorded(t *testing.T) {
+	ring := NewWithNodes(Config{Replicas: 5}, []string{"server1", "server2", "server3"})
+
+	ring.RemoveNodeWithReason("server1", "disk failure")
+	ring.RemoveNode("server2")
+
+	tombstones := ring.Tombstones()
+	if len(tombstones) != 2 {
+		t.Fatalf("expected 2 tombstones, got %d", len(tombstones))
+	}
+
+	// Most recent first
+	if tombstones[0].Node != "server2" || tombstones[1].Node != "server1" {
+		t.Errorf("unexpected tombstone order: %+v", tombstones)
+	}
+	if tombstones[0].Epoch <= tombstones[1].Epoch {
+		t.Errorf("expected increasing epochs, got %+v", tombstones)
+	}
+
+	ts, ok := ring.TombstoneFor("server1")
+	if !ok || ts.Reason != "disk failure" {
+		t.Errorf("expected tombstone with reason, got %+v", ts)
+	}
+
+	// Re-adding clears the tombstone
+	ring.AddNode("server1")
+	if _, ok := ring.TombstoneFor("server1"); ok {
+		t.Error("expected tombstone cleared after re-add")
+	}
+}
+
+func TestQuarantineBlocksReAdd(t *testing.T) {
+	ring := NewWithNodes(Config{Replicas: 5, Quarantine: time.Hour}, []string{"server1", "server2"})
+
+	ring.RemoveNode("server1")
+
+	if err := ring.AddNode("server1"); !errors.Is(err, ErrQuarantined) {
+		t.Errorf("expected ErrQuarantined, got %v", err)
+	}
+	if _, err := ring.Weight("server1"); err != ErrNodeNotFound {
+		t.Error("expected quarantined node not to be added")
+	}
+
+	if err := ring.ForceAddNode("server1"); err != nil {
+		t.Errorf("expected forced add to succeed, got %v", err)
+	}
+	if _, err := ring.Weight("server1"); err != nil {
+		t.Error("expected forced node to be added")
+	}
+}
+
+func TestQuarantineExpires(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	ring := NewWithNodes(Config{Replicas: 5, Quarantine: time.Minute, Clock: clk}, []string{"server1"})
+
+	ring.RemoveNode("server1")
+	clk.Advance(time.Minute)
+
+	if err := ring.AddNode("server1"); err != nil {
+		t.Errorf("expected add after quarantine, got %v", err)
+	}
+
+	// Without a quarantine, re-adding is always allowed
+	open := NewWithNodes(Config{Replicas: 5}, []string{"server1"})
+	open.RemoveNode("server1")
+	if err := open.AddNode("server1"); err != nil {
+		t.Errorf("expected no quarantine by default, got %v", err)
+	}
+}
+
+func TestQuarantineOnFakeClock(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	ring := NewWithNodes(Config{Replicas: 5, Quarantine: time.Hour, Clock: clk}, []string{"server1"})
+
+	ring.RemoveNode("server1")
+	if ts, _ := ring.TombstoneFor("server1"); !ts.RemovedAt.Equal(clk.Now()) {
+		t.Errorf("expected tombstone stamped with the ring clock, got %v", ts.RemovedAt)
+	}
+
+	clk.Advance(59 * time.Minute)
+	if err := ring.AddNode("server1"); !errors.Is(err, ErrQuarantined) {
+		t.Errorf("expected ErrQuarantined, got %v", err)
+	}
+
+	clk.Advance(time.Minute)
+	if err := ring.AddNode("server1"); err != nil {
+		t.Errorf("expected add after quarantine, got %v", err)
+	}
+}
@@ -0,0 +1,141 @@
+package chash
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInsufficientSpread is returned when the ring does not have enough distinct
+// failure domains to place the requested replicas
+var ErrInsufficientSpread = errors.New("not enough distinct failure domains for replicas")
+
+// Topology labels the failure domains a node lives in
+// Racks are scoped to their zone and zones to their region
+type Topology struct {
+	Region string
+	Zone   string
+	Rack   string
+}
+
+// SpreadConstraint selects the failure domain replicas must be spread across
+type SpreadConstraint int
+
+const (
+	// SpreadNode only requires distinct nodes, like GetNodes
+	SpreadNode SpreadConstraint = iota
+
+	// SpreadRack places every replica in a distinct rack
+	SpreadRack
+
+	// SpreadZone places every replica in a distinct zone
+	SpreadZone
+
+	// SpreadRegion places every replica in a distinct region
+	SpreadRegion
+)
+
+// String returns the constraint name
+func (c SpreadConstraint) String() string {
+	switch c {
+	case SpreadNode:
+		return "node"
+	case SpreadRack:
+		return "rack"
+	case SpreadZone:
+		return "zone"
+	case SpreadRegion:
+		return "region"
+	default:
+		return fmt.Sprintf("SpreadConstraint(%d)", int(c))
+	}
+}
+
+// domain returns the failure domain of node under constraint
+// Nodes without labels at a level share a single unlabeled domain, so an
+// unlabeled node never counts as spread from another unlabeled node
+func (c SpreadConstraint) domain(node string, t Topology) string {
+	switch c {
+	case SpreadRack:
+		return t.Region + "/" + t.Zone + "/" + t.Rack
+	case SpreadZone:
+		return t.Region + "/" + t.Zone
+	case SpreadRegion:
+		return t.Region
+	default:
+		return node
+	}
+}
+
+// SetTopology sets the topology labels of a node
+func (r *Ring) SetTopology(node string, topology Topology) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, exists := r.nodeSet[node]
+	if !exists {
+		return ErrNodeNotFound
+	}
+
+	entry.topology = topology
+	return nil
+}
+
+// Topology returns the topology labels of a node
+func (r *Ring) Topology(node string) (Topology, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entry, exists := r.nodeSet[node]
+	if !exists {
+		return Topology{}, ErrNodeNotFound
+	}
+
+	return entry.topology, nil
+}
+
+// GetNodesSpread returns count nodes for key, each in a distinct failure domain
+// It walks the ring clockwise like GetNodes, skipping nodes whose domain already
+// holds a replica; ErrInsufficientSpread is returned if the ring does not have
+// count distinct domains
+func (r *Ring) GetNodesSpread(key string, count int, constraint SpreadConstraint) ([]string, error) {
+	if key == "" {
+		return nil, ErrEmptyKey
+	}
+
+	if count <= 0 {
+		return nil, errors.New("count must be positive")
+	}
+
+	r.lookups.Add(1)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(r.ring) == 0 {
+		return nil, ErrNoNodes
+	}
+
+	idx := r.searchLocked(r.hashKeyLocked(key))
+
+	result := make([]string, 0, count)
+	seenDomains := make(map[string]struct{})
+
+	r.visitLocked(idx, make([]bool, len(r.names)), func(owner uint32) bool {
+		node := r.names[owner]
+
+		domain := constraint.domain(node, r.nodeSet[node].topology)
+		if _, exists := seenDomains[domain]; exists {
+			return true
+		}
+		seenDomains[domain] = struct{}{}
+
+		result = append(result, node)
+		return len(result) < count
+	})
+
+	if len(result) < count {
+		return nil, fmt.Errorf("%w: need %d %s domains, have %d", ErrInsufficientSpread, count, constraint, len(result))
+	}
+
+	return result, nil
+}
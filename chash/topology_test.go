@@ -0,0 +1,105 @@
+package chash
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// newZonedRing builds a ring with three zones of two racks, each rack holding two nodes
+func newZonedRing() *Ring {
+	ring := New(Config{Replicas: 20})
+	for _, zone := range []string{"a", "b", "c"} {
+		for rack := 1; rack <= 2; rack++ {
+			for n := 1; n <= 2; n++ {
+				node := fmt.Sprintf("%s-r%d-n%d", zone, rack, n)
+				ring.AddNode(node)
+				ring.SetTopology(node, Topology{
+					Region: "eu",
+					Zone:   "eu-" + zone,
+					Rack:   fmt.Sprintf("r%d", rack),
+				})
+			}
+		}
+	}
+	return ring
+}
+
+func TestGetNodesSpreadZones(t *testing.T) {
+	ring := newZonedRing()
+
+	for i := 0; i < 200; i++ {
+		key := fmt.Sprintf("key%d", i)
+
+		nodes, err := ring.GetNodesSpread(key, 3, SpreadZone)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		zones := make(map[string]bool)
+		for _, node := range nodes {
+			topo, _ := ring.Topology(node)
+			if zones[topo.Zone] {
+				t.Fatalf("key %s has two replicas in zone %s: %v", key, topo.Zone, nodes)
+			}
+			zones[topo.Zone] = true
+		}
+
+		// The primary matches GetNode
+		primary, _ := ring.GetNode(key)
+		if nodes[0] != primary {
+			t.Errorf("expected primary %s, got %s", primary, nodes[0])
+		}
+	}
+}
+
+func TestGetNodesSpreadRacks(t *testing.T) {
+	ring := newZonedRing()
+
+	nodes, err := ring.GetNodesSpread("key1", 6, SpreadRack)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// Rack names repeat across zones but are scoped to them
+	racks := make(map[Topology]bool)
+	for _, node := range nodes {
+		topo, _ := ring.Topology(node)
+		if racks[topo] {
+			t.Errorf("duplicate rack %+v in %v", topo, nodes)
+		}
+		racks[topo] = true
+	}
+
+	if _, err := ring.GetNodesSpread("key1", 7, SpreadRack); !errors.Is(err, ErrInsufficientSpread) {
+		t.Errorf("expected ErrInsufficientSpread, got %v", err)
+	}
+	if _, err := ring.GetNodesSpread("key1", 2, SpreadRegion); !errors.Is(err, ErrInsufficientSpread) {
+		t.Errorf("expected ErrInsufficientSpread for single region, got %v", err)
+	}
+
+	nodes, _ = ring.GetNodesSpread("key1", 12, SpreadNode)
+	if len(nodes) != 12 {
+		t.Errorf("expected 12 distinct nodes, got %d", len(nodes))
+	}
+}
+
+func TestUnlabeledNodesShareDomain(t *testing.T) {
+	ring := NewWithNodes(Config{Replicas: 20}, []string{"server1", "server2", "server3"})
+
+	if _, err := ring.GetNodesSpread("key1", 2, SpreadZone); !errors.Is(err, ErrInsufficientSpread) {
+		t.Errorf("expected unlabeled nodes to count as one zone, got %v", err)
+	}
+
+	ring.SetTopology("server3", Topology{Zone: "z2"})
+	if nodes, err := ring.GetNodesSpread("key1", 2, SpreadZone); err != nil || len(nodes) != 2 {
+		t.Errorf("expected two zones, got %v (%v)", nodes, err)
+	}
+
+	if err := ring.SetTopology("missing", Topology{}); err != ErrNodeNotFound {
+		t.Errorf("expected ErrNodeNotFound, got %v", err)
+	}
+	if _, err := ring.Topology("missing"); err != ErrNodeNotFound {
+		t.Errorf("expected ErrNodeNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,146 @@
+package chash
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+var (
+	// ErrNoTTL is returned when touching a node that was added without a TTL
+	ErrNoTTL = errors.New("node has no ttl")
+
+	// ErrInvalidInterval is returned when starting the expiry sweeper with an
+	// interval that is not positive
+	ErrInvalidInterval = errors.New("interval must be positive")
+)
+
+// AddNodeTTL adds a node with weight 1 that is removed once ttl passes
+// without a Touch, turning the ring into a self-cleaning registry of workers
+// that heartbeat while alive
+// Expired nodes keep receiving keys until ExpireNodes runs, either called
+// directly or by the sweeper started with StartExpiry
+func (r *Ring) AddNodeTTL(node string, ttl time.Duration) error {
+	if ttl <= 0 {
+		return ErrInvalidTTL
+	}
+	return r.addNode(node, &nodeEntry{weight: 1, ttl: ttl, expires: r.clock.Now().Add(ttl)}, false)
+}
+
+// Touch extends a node's life by its TTL from now
+// Returns ErrNodeNotFound if the node is not in the ring, such as after it
+// expired, and ErrNoTTL if it was added without a TTL
+func (r *Ring) Touch(node string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, exists := r.nodeSet[node]
+	if !exists {
+		return ErrNodeNotFound
+	}
+	if entry.ttl == 0 {
+		return ErrNoTTL
+	}
+	entry.expires = r.clock.Now().Add(entry.ttl)
+	return nil
+}
+
+// Expires returns when a node added with AddNodeTTL expires unless touched
+// ok is false if the node is not in the ring or has no TTL
+func (r *Ring) Expires(node string) (expires time.Time, ok bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entry, exists := r.nodeSet[node]
+	if !exists || entry.ttl == 0 {
+		return time.Time{}, false
+	}
+	return entry.expires, true
+}
+
+// ExpireNodes removes every node whose TTL has passed since its last Touch
+// and returns them sorted by name
+// Expired nodes are gone whether or not the ring can afford the movement, so
+// removal ignores the movement budget
+func (r *Ring) ExpireNodes() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.expireLocked(r.clock.Now())
+}
+
+// expireLocked removes the nodes expired at now
+// Caller must hold r.mu for writing
+func (r *Ring) expireLocked(now time.Time) []string {
+	expired := make(map[string]struct{})
+	var nodes []string
+	vnodes := 0
+	for node, entry := range r.nodeSet {
+		if entry.ttl > 0 && !now.Before(entry.expires) {
+			expired[node] = struct{}{}
+			nodes = append(nodes, node)
+			vnodes += entry.vnodes
+		}
+	}
+	if len(nodes) == 0 {
+		return nil
+	}
+	sort.Strings(nodes)
+
+	// Forced changes still count toward the budget of later ones
+	r.spendMovementLocked(now, true, func(p *Ring) {
+		p.removeNodesLocked(expired, vnodes, "ttl expired")
+	})
+
+	before := r.watchLocked()
+	r.removeNodesLocked(expired, vnodes, "ttl expired")
+	r.publishLocked(NodeRemoved, nodes, before)
+	return nodes
+}
+
+// StartExpiry runs ExpireNodes every interval in a background goroutine
+// Calling StartExpiry while the sweeper runs has no effect; call StopExpiry to
+// end it
+// Returns ErrInvalidInterval if interval is not positive
+func (r *Ring) StartExpiry(interval time.Duration) error {
+	if interval <= 0 {
+		return ErrInvalidInterval
+	}
+
+	r.mu.Lock()
+	if r.stopExpiry != nil {
+		r.mu.Unlock()
+		return nil
+	}
+	stop := make(chan struct{})
+	r.stopExpiry = stop
+	r.mu.Unlock()
+
+	go func() {
+		ticker := r.clock.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case now := <-ticker.C():
+				r.mu.Lock()
+				r.expireLocked(now)
+				r.mu.Unlock()
+			case <-stop:
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// StopExpiry ends the sweeper started by StartExpiry
+func (r *Ring) StopExpiry() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.stopExpiry != nil {
+		close(r.stopExpiry)
+		r.stopExpiry = nil
+	}
+}
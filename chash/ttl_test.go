@@ -0,0 +1,105 @@
+package chash
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/mohdrashid9678/dcore/clock"
+)
+
+func TestNodeTTL(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	ring := New(Config{Clock: clk})
+	ring.AddNode("static")
+
+	if err := ring.AddNodeTTL("worker1", time.Minute); err != nil {
+		t.Fatalf("AddNodeTTL failed: %v", err)
+	}
+	if err := ring.AddNodeTTL("worker2", time.Minute); err != nil {
+		t.Fatalf("AddNodeTTL failed: %v", err)
+	}
+	if expires, ok := ring.Expires("worker1"); !ok || !expires.Equal(clk.Now().Add(time.Minute)) {
+		t.Errorf("Unexpected expiry %v, %v", expires, ok)
+	}
+	if _, ok := ring.Expires("static"); ok {
+		t.Error("Expected no expiry for a node without a TTL")
+	}
+
+	clk.Advance(45 * time.Second)
+	if err := ring.Touch("worker1"); err != nil {
+		t.Fatalf("Touch failed: %v", err)
+	}
+	if expired := ring.ExpireNodes(); expired != nil {
+		t.Errorf("Expected nothing expired yet, got %v", expired)
+	}
+
+	clk.Advance(15 * time.Second)
+	if expired := ring.ExpireNodes(); !slices.Equal(expired, []string{"worker2"}) {
+		t.Errorf("Expected worker2 to expire, got %v", expired)
+	}
+	if got := ring.Nodes(); !slices.Equal(got, []string{"static", "worker1"}) {
+		t.Errorf("Unexpected nodes after expiry: %v", got)
+	}
+	if ts, ok := ring.TombstoneFor("worker2"); !ok || ts.Reason != "ttl expired" {
+		t.Errorf("Expected ttl tombstone, got %+v, %v", ts, ok)
+	}
+
+	clk.Advance(time.Hour)
+	if expired := ring.ExpireNodes(); !slices.Equal(expired, []string{"worker1"}) {
+		t.Errorf("Expected worker1 to expire, got %v", expired)
+	}
+	if got := ring.Nodes(); !slices.Equal(got, []string{"static"}) {
+		t.Errorf("Expected only static to remain, got %v", got)
+	}
+}
+
+func TestTouchErrors(t *testing.T) {
+	ring := New(Config{})
+	ring.AddNode("static")
+
+	if err := ring.AddNodeTTL("worker", 0); !errors.Is(err, ErrInvalidTTL) {
+		t.Errorf("Expected ErrInvalidTTL, got %v", err)
+	}
+	if err := ring.Touch("static"); !errors.Is(err, ErrNoTTL) {
+		t.Errorf("Expected ErrNoTTL, got %v", err)
+	}
+	if err := ring.Touch("missing"); !errors.Is(err, ErrNodeNotFound) {
+		t.Errorf("Expected ErrNodeNotFound, got %v", err)
+	}
+}
+
+func TestExpirySweeper(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	ring := New(Config{Clock: clk})
+	ring.AddNodeTTL("worker", time.Minute)
+
+	events, cancel := ring.Subscribe()
+	defer cancel()
+
+	for _, interval := range []time.Duration{0, -time.Second} {
+		if err := ring.StartExpiry(interval); !errors.Is(err, ErrInvalidInterval) {
+			t.Errorf("Expected ErrInvalidInterval for %v, got %v", interval, err)
+		}
+	}
+
+	if err := ring.StartExpiry(10 * time.Second); err != nil {
+		t.Fatalf("StartExpiry failed: %v", err)
+	}
+	defer ring.StopExpiry()
+	clk.BlockUntil(1)
+
+	clk.Advance(70 * time.Second)
+	select {
+	case e := <-events:
+		if e.Type != NodeRemoved || e.Node != "worker" {
+			t.Errorf("Unexpected event %+v", e)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the sweeper to remove the expired node")
+	}
+	if !ring.IsEmpty() {
+		t.Errorf("Expected empty ring, got %v", ring.Nodes())
+	}
+}
@@ -0,0 +1,134 @@
+package chash
+
+import (
+	"fmt"
+	"sync"
+)
+
+// TypedRing is a ring whose nodes are values of any comparable type, such as a
+// struct holding an address and a connection, instead of bare strings
+// Each node is placed on an underlying Ring under the name returned by its ID function
+type TypedRing[T comparable] struct {
+	// mu keeps byID in step with the underlying ring
+	mu sync.RWMutex
+
+	ring *Ring
+	id   func(T) string
+
+	// byID maps node names on the ring back to node values
+	byID map[string]T
+}
+
+// NewTypedRing creates a typed ring with the given configuration
+// id must return a unique, stable name per node; nil uses fmt.Sprint
+func NewTypedRing[T comparable](config Config, id func(T) string) *TypedRing[T] {
+	if id == nil {
+		id = func(node T) string { return fmt.Sprint(node) }
+	}
+
+	return &TypedRing[T]{
+		ring: New(config),
+		id:   id,
+		byID: make(map[string]T),
+	}
+}
+
+// AddNode adds a node to the ring
+func (t *TypedRing[T]) AddNode(node T) error {
+	return t.AddNodeWithWeight(node, 1)
+}
+
+// AddNodeWithWeight adds a node owning a share of the keyspace proportional to weight
+func (t *TypedRing[T]) AddNodeWithWeight(node T, weight float64) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	name := t.id(node)
+	if err := t.ring.AddNodeWithWeight(name, weight); err != nil {
+		return err
+	}
+
+	t.byID[name] = node
+	return nil
+}
+
+// RemoveNode removes a node from the ring
+func (t *TypedRing[T]) RemoveNode(node T) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	name := t.id(node)
+	if err := t.ring.RemoveNode(name); err != nil {
+		return err
+	}
+
+	delete(t.byID, name)
+	return nil
+}
+
+// GetNode returns the node responsible for the given key
+func (t *TypedRing[T]) GetNode(key string) (T, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	var zero T
+
+	name, err := t.ring.GetNode(key)
+	if err != nil {
+		return zero, err
+	}
+
+	return t.lookupLocked(name)
+}
+
+// GetNodes returns the top count nodes responsible for the given key
+func (t *TypedRing[T]) GetNodes(key string, count int) ([]T, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	names, err := t.ring.GetNodes(key, count)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make([]T, len(names))
+	for i, name := range names {
+		if nodes[i], err = t.lookupLocked(name); err != nil {
+			return nil, err
+		}
+	}
+	return nodes, nil
+}
+
+// Nodes returns all nodes, ordered by name
+func (t *TypedRing[T]) Nodes() []T {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	names := t.ring.Nodes()
+	nodes := make([]T, 0, len(names))
+	for _, name := range names {
+		if node, ok := t.byID[name]; ok {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+// Ring returns the underlying string ring for read-only use such as statistics
+// and ownership queries; adding or removing nodes on it directly leaves the
+// typed ring unable to resolve them
+func (t *TypedRing[T]) Ring() *Ring {
+	return t.ring
+}
+
+// lookupLocked maps a ring node name back to its value
+// Caller must hold t.mu
+func (t *TypedRing[T]) lookupLocked(name string) (T, error) {
+	node, ok := t.byID[name]
+	if !ok {
+		var zero T
+		return zero, fmt.Errorf("%w: %s", ErrNodeNotFound, name)
+	}
+	return node, nil
+}
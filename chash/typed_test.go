@@ -0,0 +1,78 @@
+package chash
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+type testBackend struct {
+	Addr string
+	ID   int
+}
+
+func TestTypedRing(t *testing.T) {
+	ring := NewTypedRing(Config{Replicas: 20}, func(b testBackend) string { return b.Addr })
+
+	backends := []testBackend{{"10.0.0.1:80", 1}, {"10.0.0.2:80", 2}, {"10.0.0.3:80", 3}}
+	for _, b := range backends {
+		if err := ring.AddNode(b); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+
+	if err := ring.AddNode(backends[0]); err == nil {
+		t.Error("expected error adding duplicate node")
+	}
+
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("key%d", i)
+
+		backend, err := ring.GetNode(key)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		name, _ := ring.Ring().GetNode(key)
+		if backend.Addr != name {
+			t.Errorf("expected %s, got %+v", name, backend)
+		}
+
+		replicas, _ := ring.GetNodes(key, 3)
+		if len(replicas) != 3 || replicas[0] != backend {
+			t.Errorf("unexpected replicas %+v", replicas)
+		}
+	}
+
+	if nodes := ring.Nodes(); len(nodes) != 3 || nodes[0] != backends[0] {
+		t.Errorf("unexpected nodes %+v", nodes)
+	}
+
+	ring.RemoveNode(backends[1])
+	if len(ring.Nodes()) != 2 {
+		t.Errorf("expected 2 nodes, got %d", len(ring.Nodes()))
+	}
+	if err := ring.RemoveNode(backends[1]); err != ErrNodeNotFound {
+		t.Errorf("expected ErrNodeNotFound, got %v", err)
+	}
+}
+
+func TestTypedRingDefaultID(t *testing.T) {
+	ring := NewTypedRing[int](Config{Replicas: 5}, nil)
+
+	if _, err := ring.GetNode("key"); err != ErrNoNodes {
+		t.Errorf("expected ErrNoNodes, got %v", err)
+	}
+
+	ring.AddNode(7)
+	if node, _ := ring.GetNode("key"); node != 7 {
+		t.Errorf("expected 7, got %d", node)
+	}
+
+	// Nodes added behind the typed ring's back cannot be resolved
+	ring.Ring().RemoveNode("7")
+	ring.Ring().AddNode("stray")
+	if _, err := ring.GetNode("key"); !errors.Is(err, ErrNodeNotFound) {
+		t.Errorf("expected ErrNodeNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,165 @@
+package chash
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+)
+
+// Vector is the expected routing of one key, for checking other
+// implementations of the ring against this one
+type Vector struct {
+	Key string `json:"key"`
+
+	// Hash is the ring position the key routes on, encoded as a decimal
+	// string so languages without unsigned 64-bit JSON numbers read it exactly
+	Hash uint64 `json:"hash,string"`
+
+	// Owner is the primary node
+	Owner string `json:"owner"`
+
+	// Replicas is the replica list in preference order
+	Replicas []string `json:"replicas"`
+}
+
+// VectorSet bundles test vectors with the ring snapshot they were generated
+// from, as produced by MarshalJSON
+type VectorSet struct {
+	Ring         json.RawMessage `json:"ring"`
+	ReplicaCount int             `json:"replica_count"`
+	Vectors      []Vector        `json:"vectors"`
+}
+
+// VectorMismatch describes a vector another implementation disagrees with
+type VectorMismatch struct {
+	Key string
+
+	// Field is "hash", "owner", "replicas" or "missing"
+	Field string
+
+	Expected string
+	Actual   string
+}
+
+// String returns a one-line description of the mismatch
+func (m VectorMismatch) String() string {
+	return fmt.Sprintf("%s: %s expected %s, got %s", m.Key, m.Field, m.Expected, m.Actual)
+}
+
+// SampleKeys returns n deterministic keys mixing short, long, numeric and
+// non-ASCII keys, a reasonable default input for GenerateVectors
+func SampleKeys(n int) []string {
+	keys := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		switch i % 5 {
+		case 0:
+			keys = append(keys, "key"+strconv.Itoa(i))
+		case 1:
+			keys = append(keys, strconv.Itoa(i))
+		case 2:
+			keys = append(keys, "user:"+strconv.Itoa(i)+":profile")
+		case 3:
+			keys = append(keys, "ключ-"+strconv.Itoa(i)+"-键")
+		default:
+			keys = append(keys, fmt.Sprintf("%064d", i))
+		}
+	}
+	return keys
+}
+
+// GenerateVectors returns test vectors for keys, each with up to replicaCount
+// replicas, together with the ring snapshot
+func (r *Ring) GenerateVectors(keys []string, replicaCount int) (VectorSet, error) {
+	snapshot, err := r.MarshalJSON()
+	if err != nil {
+		return VectorSet{}, err
+	}
+
+	set := VectorSet{Ring: snapshot, ReplicaCount: replicaCount}
+	for _, key := range keys {
+		v, err := r.vector(key, replicaCount)
+		if err != nil {
+			return VectorSet{}, fmt.Errorf("key %q: %w", key, err)
+		}
+		set.Vectors = append(set.Vectors, v)
+	}
+
+	return set, nil
+}
+
+// VerifyVectors checks this ring against a vector set and returns every disagreement
+func (r *Ring) VerifyVectors(set VectorSet) ([]VectorMismatch, error) {
+	actual := make([]Vector, 0, len(set.Vectors))
+	for _, expected := range set.Vectors {
+		v, err := r.vector(expected.Key, set.ReplicaCount)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", expected.Key, err)
+		}
+		actual = append(actual, v)
+	}
+
+	return CompareVectors(set.Vectors, actual), nil
+}
+
+// CompareVectors compares vectors produced by another implementation against
+// the expected ones, matching them by key
+func CompareVectors(expected, actual []Vector) []VectorMismatch {
+	byKey := make(map[string]Vector, len(actual))
+	for _, v := range actual {
+		byKey[v.Key] = v
+	}
+
+	var mismatches []VectorMismatch
+	for _, want := range expected {
+		got, ok := byKey[want.Key]
+		if !ok {
+			mismatches = append(mismatches, VectorMismatch{Key: want.Key, Field: "missing", Expected: "vector", Actual: "none"})
+			continue
+		}
+
+		if got.Hash != want.Hash {
+			mismatches = append(mismatches, VectorMismatch{
+				Key: want.Key, Field: "hash",
+				Expected: strconv.FormatUint(want.Hash, 10), Actual: strconv.FormatUint(got.Hash, 10),
+			})
+		}
+		if got.Owner != want.Owner {
+			mismatches = append(mismatches, VectorMismatch{Key: want.Key, Field: "owner", Expected: want.Owner, Actual: got.Owner})
+		}
+		if !slices.Equal(got.Replicas, want.Replicas) {
+			mismatches = append(mismatches, VectorMismatch{
+				Key: want.Key, Field: "replicas",
+				Expected: fmt.Sprint(want.Replicas), Actual: fmt.Sprint(got.Replicas),
+			})
+		}
+	}
+
+	return mismatches
+}
+
+// vector computes the routing of a single key
+// Pins are deliberately ignored: they are process-local and not part of the snapshot
+func (r *Ring) vector(key string, replicaCount int) (Vector, error) {
+	if key == "" {
+		return Vector{}, ErrEmptyKey
+	}
+	if replicaCount <= 0 {
+		replicaCount = 1
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(r.ring) == 0 {
+		return Vector{}, ErrNoNodes
+	}
+
+	hash := r.hashKeyLocked(key)
+	replicas := r.walkLocked(r.searchLocked(hash), replicaCount)
+	if len(replicas) == 0 {
+		return Vector{}, ErrNoNodes
+	}
+
+	return Vector{Key: key, Hash: hash, Owner: replicas[0], Replicas: replicas}, nil
+}
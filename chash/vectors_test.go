@@ -0,0 +1,91 @@
+package chash
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestGenerateAndVerifyVectors(t *testing.T) {
+	ring := newSnapshotRing()
+
+	set, err := ring.GenerateVectors(SampleKeys(100), 2)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(set.Vectors) != 100 || set.ReplicaCount != 2 {
+		t.Fatalf("unexpected vector set: %d vectors, %d replicas", len(set.Vectors), set.ReplicaCount)
+	}
+
+	for _, v := range set.Vectors {
+		node, _ := ring.GetNode(v.Key)
+		if v.Owner != node || v.Replicas[0] != node || len(v.Replicas) != 2 {
+			t.Errorf("unexpected vector %+v", v)
+		}
+	}
+
+	// The vectors round-trip through JSON and verify against the snapshot
+	data, _ := json.Marshal(set)
+	var decoded VectorSet
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	loaded := New(Config{})
+	if err := loaded.UnmarshalJSON(decoded.Ring); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	mismatches, err := loaded.VerifyVectors(decoded)
+	if err != nil || len(mismatches) != 0 {
+		t.Errorf("expected loaded snapshot to agree, got %v (%v)", mismatches, err)
+	}
+}
+
+func TestVerifyVectorsDetectsDisagreement(t *testing.T) {
+	ring := newSnapshotRing()
+	set, _ := ring.GenerateVectors(SampleKeys(200), 1)
+
+	ring.RemoveNode("server2")
+	mismatches, _ := ring.VerifyVectors(set)
+	if len(mismatches) == 0 {
+		t.Fatal("expected mismatches after topology change")
+	}
+
+	for _, m := range mismatches {
+		if m.Field != "owner" && m.Field != "replicas" {
+			t.Errorf("expected only ownership mismatches, got %s", m)
+		}
+	}
+}
+
+func TestCompareVectors(t *testing.T) {
+	expected := []Vector{
+		{Key: "a", Hash: 1, Owner: "n1", Replicas: []string{"n1", "n2"}},
+		{Key: "b", Hash: 2, Owner: "n2", Replicas: []string{"n2", "n1"}},
+	}
+	actual := []Vector{
+		{Key: "a", Hash: 9, Owner: "n1", Replicas: []string{"n1", "n3"}},
+	}
+
+	mismatches := CompareVectors(expected, actual)
+	fields := make(map[string]bool)
+	for _, m := range mismatches {
+		fields[m.Key+"/"+m.Field] = true
+	}
+
+	for _, want := range []string{"a/hash", "a/replicas", "b/missing"} {
+		if !fields[want] {
+			t.Errorf("expected mismatch %s, got %v", want, mismatches)
+		}
+	}
+	if len(mismatches) != 3 {
+		t.Errorf("expected 3 mismatches, got %v", mismatches)
+	}
+}
+
+func TestVectorHashEncodedAsString(t *testing.T) {
+	data, _ := json.Marshal(Vector{Key: "k", Hash: 18446744073709551615, Owner: "n", Replicas: []string{"n"}})
+	if string(data) != `{"key":"k","hash":"18446744073709551615","owner":"n","replicas":["n"]}` {
+		t.Errorf("unexpected encoding %s", data)
+	}
+}
@@ -0,0 +1,53 @@
+package chash
+
+// VersionedNode is a lookup result tagged with the ring version it was made at
+type VersionedNode struct {
+	// Node is the node responsible for the key
+	Node string
+
+	// Version is the ring version the lookup was made at
+	Version uint64
+}
+
+// Version returns the ring version, which increases on every node addition,
+// removal, resize, state change and reload
+// It equals TopologyChanges in GetStats and the Epoch of the latest topology
+// event
+func (r *Ring) Version() uint64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.topologyChanges
+}
+
+// GetNodeVersioned routes key like GetNode and returns the node together with
+// the ring version the decision was made at
+// A request that compares the version with Version before committing can
+// detect that the topology changed while it was in flight
+func (r *Ring) GetNodeVersioned(key string) (VersionedNode, error) {
+	if key == "" {
+		return VersionedNode{}, ErrEmptyKey
+	}
+
+	r.lookups.Add(1)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(r.ring) == 0 {
+		return VersionedNode{}, ErrNoNodes
+	}
+
+	node, ok := "", false
+	if len(r.pins) > 0 {
+		node, ok = r.pinnedLocked(key, r.clock.Now())
+	}
+	if !ok {
+		node = r.primaryLocked(r.searchLocked(r.hashKeyLocked(key)))
+	}
+	if node == "" {
+		return VersionedNode{}, ErrNoNodes
+	}
+
+	return VersionedNode{Node: node, Version: r.topologyChanges}, nil
+}
@@ -0,0 +1,63 @@
+package chash
+
+import "testing"
+
+func TestVersion(t *testing.T) {
+	ring := New(Config{Replicas: 20})
+	if v := ring.Version(); v != 0 {
+		t.Fatalf("expected version 0, got %d", v)
+	}
+
+	ring.AddNode("server1")
+	ring.AddNode("server2")
+	if v := ring.Version(); v != 2 {
+		t.Errorf("expected version 2, got %d", v)
+	}
+
+	ring.UpdateWeight("server1", 2)
+	ring.SetNodeState("server2", NodeDraining)
+	if v := ring.Version(); v != 4 {
+		t.Errorf("expected version 4, got %d", v)
+	}
+
+	// Setting the same state again changes nothing
+	ring.SetNodeState("server2", NodeDraining)
+	if v := ring.Version(); v != 4 {
+		t.Errorf("expected version 4, got %d", v)
+	}
+
+	ring.RemoveNode("server1")
+	if v := ring.Version(); v != 5 {
+		t.Errorf("expected version 5, got %d", v)
+	}
+	if v := ring.Version(); v != ring.GetStats().TopologyChanges {
+		t.Errorf("expected version to match TopologyChanges, got %d", v)
+	}
+}
+
+func TestGetNodeVersioned(t *testing.T) {
+	ring := NewWithNodes(Config{Replicas: 20}, []string{"server1", "server2", "server3"})
+
+	result, err := ring.GetNodeVersioned("user:123")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if node, _ := ring.GetNode("user:123"); result.Node != node {
+		t.Errorf("expected node %s, got %s", node, result.Node)
+	}
+	if result.Version != ring.Version() {
+		t.Errorf("expected version %d, got %d", ring.Version(), result.Version)
+	}
+
+	ring.AddNode("server4")
+	if result.Version == ring.Version() {
+		t.Error("expected the topology change to make the result stale")
+	}
+
+	if _, err := ring.GetNodeVersioned(""); err != ErrEmptyKey {
+		t.Errorf("expected ErrEmptyKey, got %v", err)
+	}
+	if _, err := New(Config{}).GetNodeVersioned("key"); err != ErrNoNodes {
+		t.Errorf("expected ErrNoNodes, got %v", err)
+	}
+}
@@ -0,0 +1,179 @@
+package chash
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrInvalidVirtualNodes is returned when a virtual node count is not positive
+	ErrInvalidVirtualNodes = errors.New("virtual node count must be positive")
+
+	// ErrKetamaVirtualNodes is returned when overriding virtual node counts on a
+	// ketama ring, whose layout fixes them
+	ErrKetamaVirtualNodes = errors.New("ketama rings do not support virtual node overrides")
+)
+
+// AddNodeWithVirtualNodes adds a node with exactly vnodes virtual nodes,
+// regardless of Replicas; the node's weight is 1
+// Combined with SetVirtualNodes or WarmUp it lets a new node start with a
+// fraction of its share and ramp up
+func (r *Ring) AddNodeWithVirtualNodes(node string, vnodes int) error {
+	if vnodes <= 0 {
+		return ErrInvalidVirtualNodes
+	}
+	if r.ketama {
+		return ErrKetamaVirtualNodes
+	}
+	return r.addNode(node, &nodeEntry{weight: 1, fixed: vnodes}, false)
+}
+
+// VirtualNodes returns the number of virtual nodes placed for node
+func (r *Ring) VirtualNodes(node string) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entry, exists := r.nodeSet[node]
+	if !exists {
+		return 0, ErrNodeNotFound
+	}
+	return entry.vnodes, nil
+}
+
+// SetVirtualNodes changes the number of virtual nodes of node in place and
+// publishes a NodeUpdated event; a count of 0 clears the override so the
+// count follows the node's weight again
+// Virtual nodes are numbered, so growing adds the next positions and
+// shrinking drops the last ones: keys only move between this node and its
+// neighbours, never between other nodes
+// Returns ErrMovementBudgetExceeded if the keys moved do not fit the movement
+// budget
+func (r *Ring) SetVirtualNodes(node string, vnodes int) error {
+	if vnodes < 0 {
+		return ErrInvalidVirtualNodes
+	}
+	if r.ketama {
+		return ErrKetamaVirtualNodes
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, exists := r.nodeSet[node]
+	if !exists {
+		return ErrNodeNotFound
+	}
+	if entry.tokens != nil {
+		return ErrManualTokens
+	}
+
+	previous := entry.fixed
+	entry.fixed = vnodes
+	if err := r.resizeLocked(node, entry); err != nil {
+		entry.fixed = previous
+		return err
+	}
+	return nil
+}
+
+// resizeLocked adds or removes virtual nodes of node until it has the count
+// vnodeCountLocked asks for, publishing a NodeUpdated event if any changed
+// Returns ErrMovementBudgetExceeded, leaving the ring unchanged, if the keys
+// moved do not fit the movement budget
+// Caller must hold r.mu for writing
+func (r *Ring) resizeLocked(node string, entry *nodeEntry) error {
+	n := r.vnodeCountLocked(entry)
+	if n == entry.vnodes {
+		return nil
+	}
+
+	err := r.spendMovementLocked(r.clock.Now(), false, func(p *Ring) {
+		p.resizeLocked(node, p.nodeSet[node])
+	})
+	if err != nil {
+		return err
+	}
+
+	before := r.watchLocked()
+	defer r.publishLocked(NodeUpdated, []string{node}, before)
+	r.topologyChanges++
+
+	if n > entry.vnodes {
+		r.appendVirtualNodesLocked(node, entry, n)
+		r.sortLocked()
+		r.resolveCollisionsLocked()
+		return nil
+	}
+
+	// Count the positions of the dropped virtual nodes; a multiset keeps the
+	// node's other virtual nodes if two of them collide
+	drop := make(map[uint64]int, entry.vnodes-n)
+	for i := n; i < entry.vnodes; i++ {
+		drop[r.virtualNodeHashLocked(node, entry, i)]++
+	}
+
+	// Filtering keeps the remaining positions sorted
+	ring := r.ring[:0]
+	owners := r.owners[:0]
+	for i, hash := range r.ring {
+		if r.owners[i] == entry.index && drop[hash] > 0 {
+			drop[hash]--
+			continue
+		}
+		ring = append(ring, hash)
+		owners = append(owners, r.owners[i])
+	}
+	r.ring = ring
+	r.owners = owners
+	entry.vnodes = n
+	r.resolveCollisionsLocked()
+	return nil
+}
+
+// WarmUp ramps node from its current virtual node count to the count its
+// weight gives, in steps evenly spaced by interval, then clears the override
+// Add the node with AddNodeWithVirtualNodes at a fraction of its share first,
+// so a cold cache or fresh replica takes load gradually
+// If ctx is cancelled, or a step does not fit the movement budget, the node
+// keeps the count reached so far
+func (r *Ring) WarmUp(ctx context.Context, node string, steps int, interval time.Duration) error {
+	if steps <= 0 {
+		return errors.New("steps must be positive")
+	}
+	if r.ketama {
+		return ErrKetamaVirtualNodes
+	}
+
+	r.mu.RLock()
+	entry, exists := r.nodeSet[node]
+	var start, target int
+	if exists {
+		start = entry.vnodes
+		target = r.vnodeCountLocked(&nodeEntry{weight: entry.weight})
+	}
+	r.mu.RUnlock()
+
+	if !exists {
+		return ErrNodeNotFound
+	}
+
+	for step := 1; step <= steps; step++ {
+		timer := r.clock.NewTimer(interval)
+		select {
+		case <-timer.C():
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+
+		vnodes := start + (target-start)*step/steps
+		if step == steps {
+			vnodes = 0 // Follow the weight from now on
+		}
+		if err := r.SetVirtualNodes(node, vnodes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,254 @@
+package chash
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/mohdrashid9678/dcore/clock"
+)
+
+// owners returns the primary owner of a fixed set of keys
+func owners(ring *Ring) map[string]string {
+	result := make(map[string]string)
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("key%d", i)
+		result[key], _ = ring.GetNode(key)
+	}
+	return result
+}
+
+func TestAddNodeWithVirtualNodes(t *testing.T) {
+	ring := NewWithNodes(Config{Replicas: 50}, []string{"server1", "server2"})
+
+	if err := ring.AddNodeWithVirtualNodes("server3", 5); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if n, _ := ring.VirtualNodes("server3"); n != 5 {
+		t.Errorf("expected 5 virtual nodes, got %d", n)
+	}
+	if ring.VirtualNodeCount() != 105 {
+		t.Errorf("expected 105 virtual nodes in the ring, got %d", ring.VirtualNodeCount())
+	}
+
+	if err := ring.AddNodeWithVirtualNodes("server4", 0); err != ErrInvalidVirtualNodes {
+		t.Errorf("expected ErrInvalidVirtualNodes, got %v", err)
+	}
+	if _, err := ring.VirtualNodes("missing"); err != ErrNodeNotFound {
+		t.Errorf("expected ErrNodeNotFound, got %v", err)
+	}
+}
+
+func TestSetVirtualNodesMovesOnlyOwnKeys(t *testing.T) {
+	ring := NewWithNodes(Config{Replicas: 50}, []string{"server1", "server2", "server3"})
+	ring.AddNodeWithVirtualNodes("server4", 5)
+
+	for _, n := range []int{25, 10, 50} {
+		before := owners(ring)
+		if err := ring.SetVirtualNodes("server4", n); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if got, _ := ring.VirtualNodes("server4"); got != n {
+			t.Fatalf("expected %d virtual nodes, got %d", n, got)
+		}
+
+		for key, owner := range owners(ring) {
+			if owner != before[key] && owner != "server4" && before[key] != "server4" {
+				t.Errorf("%s moved from %s to %s", key, before[key], owner)
+			}
+		}
+	}
+
+	// The ring matches one built directly at the final count
+	direct := NewWithNodes(Config{Replicas: 50}, []string{"server1", "server2", "server3"})
+	direct.AddNodeWithVirtualNodes("server4", 50)
+	want := owners(direct)
+	for key, owner := range owners(ring) {
+		if owner != want[key] {
+			t.Errorf("%s: expected %s, got %s", key, want[key], owner)
+		}
+	}
+}
+
+func TestSetVirtualNodesClearsOverride(t *testing.T) {
+	ring := NewWithNodes(Config{Replicas: 40}, []string{"server1"})
+	ring.AddNodeWithVirtualNodes("server2", 4)
+
+	if err := ring.SetVirtualNodes("server2", 0); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if n, _ := ring.VirtualNodes("server2"); n != 40 {
+		t.Errorf("expected the count to follow the weight, got %d", n)
+	}
+}
+
+func TestSetVirtualNodesPublishesEvent(t *testing.T) {
+	ring := NewWithNodes(Config{Replicas: 20}, []string{"server1", "server2"})
+
+	events, unsubscribe := ring.Subscribe()
+	defer unsubscribe()
+
+	ring.SetVirtualNodes("server2", 40)
+	e := nextEvent(t, events)
+	if e.Type != NodeUpdated || e.Node != "server2" || len(e.Moves) == 0 {
+		t.Fatalf("unexpected event %+v", e)
+	}
+	for _, m := range e.Moves {
+		if m.To != "server2" {
+			t.Errorf("unexpected move %+v", m)
+		}
+	}
+}
+
+func TestVirtualNodesErrors(t *testing.T) {
+	ring := NewWithNodes(Config{}, []string{"server1"})
+
+	if err := ring.SetVirtualNodes("missing", 5); err != ErrNodeNotFound {
+		t.Errorf("expected ErrNodeNotFound, got %v", err)
+	}
+	if err := ring.SetVirtualNodes("server1", -1); err != ErrInvalidVirtualNodes {
+		t.Errorf("expected ErrInvalidVirtualNodes, got %v", err)
+	}
+
+	ketama := NewWithNodes(Config{Ketama: true}, []string{"10.0.0.1:11211"})
+	if err := ketama.AddNodeWithVirtualNodes("10.0.0.2:11211", 10); err != ErrKetamaVirtualNodes {
+		t.Errorf("expected ErrKetamaVirtualNodes, got %v", err)
+	}
+	if err := ketama.SetVirtualNodes("10.0.0.1:11211", 10); err != ErrKetamaVirtualNodes {
+		t.Errorf("expected ErrKetamaVirtualNodes, got %v", err)
+	}
+}
+
+func TestVirtualNodesSurviveSnapshot(t *testing.T) {
+	ring := NewWithNodes(Config{Replicas: 30}, []string{"server1", "server2"})
+	ring.AddNodeWithVirtualNodes("server3", 3)
+
+	data, err := ring.MarshalBinary()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if data[len(snapshotMagic)] != snapshotVersionVirtualNodes {
+		t.Errorf("expected version %d, got %d", snapshotVersionVirtualNodes, data[len(snapshotMagic)])
+	}
+
+	loaded := New(Config{Replicas: 30})
+	if err := loaded.UnmarshalBinary(data); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if n, _ := loaded.VirtualNodes("server3"); n != 3 {
+		t.Errorf("expected 3 virtual nodes after binary reload, got %d", n)
+	}
+
+	js, err := ring.MarshalJSON()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	loaded = New(Config{Replicas: 30})
+	if err := loaded.UnmarshalJSON(js); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if n, _ := loaded.VirtualNodes("server3"); n != 3 {
+		t.Errorf("expected 3 virtual nodes after JSON reload, got %d", n)
+	}
+
+	// Without overrides the older version is still written
+	ring.SetVirtualNodes("server3", 0)
+	data, _ = ring.MarshalBinary()
+	if data[len(snapshotMagic)] != snapshotVersion {
+		t.Errorf("expected version %d, got %d", snapshotVersion, data[len(snapshotMagic)])
+	}
+}
+
+func TestWarmUp(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	ring := NewWithNodes(Config{Replicas: 100, Clock: clk}, []string{"server1", "server2"})
+	ring.AddNodeWithVirtualNodes("server3", 10)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- ring.WarmUp(context.Background(), "server3", 3, time.Minute)
+	}()
+
+	for _, want := range []int{40, 70, 100} {
+		clk.BlockUntil(1)
+		clk.Advance(time.Minute)
+		if want == 100 {
+			break
+		}
+		waitFor(t, func() bool {
+			n, _ := ring.VirtualNodes("server3")
+			return n == want
+		})
+	}
+
+	if err := <-done; err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if n, _ := ring.VirtualNodes("server3"); n != 100 {
+		t.Errorf("expected full weight, got %d", n)
+	}
+	if _, err := ring.MarshalBinary(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestWarmUpCancelled(t *testing.T) {
+	ring := NewWithNodes(Config{Replicas: 100}, []string{"server1"})
+	ring.AddNodeWithVirtualNodes("server2", 10)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := ring.WarmUp(ctx, "server2", 5, time.Hour); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if n, _ := ring.VirtualNodes("server2"); n != 10 {
+		t.Errorf("expected the count to stay at 10, got %d", n)
+	}
+	if err := ring.WarmUp(ctx, "missing", 5, time.Hour); err != ErrNodeNotFound {
+		t.Errorf("expected ErrNodeNotFound, got %v", err)
+	}
+}
+
+func TestWarmUpMovementBudget(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	config := Config{Replicas: 100, Clock: clk, MovementBudget: 0.2, MovementWindow: time.Hour}
+	ring := NewWithNodes(config, []string{"server1", "server2"})
+	if err := ring.AddNodeWithVirtualNodes("server3", 10); err != nil {
+		t.Fatalf("AddNodeWithVirtualNodes failed: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- ring.WarmUp(context.Background(), "server3", 1, time.Minute)
+	}()
+	clk.BlockUntil(1)
+	clk.Advance(time.Minute)
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrMovementBudgetExceeded) {
+			t.Fatalf("expected ErrMovementBudgetExceeded, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for WarmUp")
+	}
+	if n, _ := ring.VirtualNodes("server3"); n != 10 {
+		t.Errorf("expected the count to stay at 10, got %d", n)
+	}
+}
+
+// waitFor polls cond until it holds or fails after a timeout
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for condition")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
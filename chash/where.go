@@ -0,0 +1,43 @@
+package chash
+
+// GetNodeWhere returns the first node clockwise from the position of key that
+// satisfies pred, such as the first node with free disk or in the caller's
+// region
+// A pinned key's node is returned if it satisfies pred. Node states apply as
+// in GetNodes: disabled nodes are skipped and draining nodes come after the
+// first active one. pred runs with the ring locked and must not call the
+// ring. Returns ErrNoNodes if no node satisfies pred
+func (r *Ring) GetNodeWhere(key string, pred func(NodeInfo) bool) (string, error) {
+	if key == "" {
+		return "", ErrEmptyKey
+	}
+
+	r.lookups.Add(1)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(r.ring) == 0 {
+		return "", ErrNoNodes
+	}
+
+	if len(r.pins) > 0 {
+		if node, ok := r.pinnedLocked(key, r.clock.Now()); ok && pred(r.infoLocked(node)) {
+			return node, nil
+		}
+	}
+
+	node := ""
+	seen := make([]bool, len(r.names))
+	r.visitLocked(r.searchLocked(r.hashKeyLocked(key)), seen, func(owner uint32) bool {
+		if pred(r.infoLocked(r.names[owner])) {
+			node = r.names[owner]
+			return false
+		}
+		return true
+	})
+	if node == "" {
+		return "", ErrNoNodes
+	}
+	return node, nil
+}
@@ -0,0 +1,79 @@
+package chash
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestGetNodeWhere(t *testing.T) {
+	ring := New(Config{Replicas: 50})
+	for i, region := range []string{"us", "eu", "us", "eu", "ap"} {
+		node := fmt.Sprintf("server%d", i)
+		ring.AddNodeWithMeta(node, i*100) // Free disk in GB
+		ring.SetTopology(node, Topology{Region: region})
+	}
+
+	inEU := func(info NodeInfo) bool { return info.Topology.Region == "eu" }
+	for i := 0; i < 500; i++ {
+		key := fmt.Sprintf("key%d", i)
+		got, err := ring.GetNodeWhere(key, inEU)
+		if err != nil {
+			t.Fatalf("GetNodeWhere failed: %v", err)
+		}
+
+		// The first node in replica order that matches
+		all, _ := ring.GetNodes(key, 5)
+		var want string
+		for _, node := range all {
+			if info, _ := ring.NodeInfo(node); inEU(info) {
+				want = node
+				break
+			}
+		}
+		if got != want {
+			t.Fatalf("Key %s: got %s, want %s", key, got, want)
+		}
+	}
+
+	// Every key goes to its own node when pred accepts anything
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("key%d", i)
+		got, _ := ring.GetNodeWhere(key, func(NodeInfo) bool { return true })
+		if want, _ := ring.GetNode(key); got != want {
+			t.Fatalf("Key %s: got %s, GetNode %s", key, got, want)
+		}
+	}
+
+	freeDisk := func(info NodeInfo) bool { return info.Meta.(int) >= 400 }
+	if got, _ := ring.GetNodeWhere("key", freeDisk); got != "server4" {
+		t.Errorf("Expected server4, the only node with free disk, got %s", got)
+	}
+
+	ring.SetNodeState("server4", NodeDisabled)
+	if _, err := ring.GetNodeWhere("key", freeDisk); !errors.Is(err, ErrNoNodes) {
+		t.Errorf("Expected ErrNoNodes when the only match is disabled, got %v", err)
+	}
+}
+
+func TestGetNodeWherePinned(t *testing.T) {
+	ring := NewWithNodes(Config{Replicas: 50}, []string{"server1", "server2", "server3"})
+	owner, hash, _ := ring.GetNodeWithHash("key")
+	ring.Pin(time.Hour, "key")
+
+	// Add nodes until one takes over the key's position
+	for i := 4; ; i++ {
+		ring.AddNode(fmt.Sprintf("server%d", i))
+		if node, _ := ring.GetNodeForHash(hash); node != owner {
+			break
+		}
+	}
+
+	if got, _ := ring.GetNodeWhere("key", func(NodeInfo) bool { return true }); got != owner {
+		t.Errorf("Expected pinned node %s, got %s", owner, got)
+	}
+	if got, _ := ring.GetNodeWhere("key", func(info NodeInfo) bool { return info.Name != owner }); got == owner || got == "" {
+		t.Errorf("Expected a node other than the pinned %s, got %q", owner, got)
+	}
+}
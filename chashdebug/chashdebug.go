@@ -0,0 +1,36 @@
+// Package chashdebug serves the state of a chash ring as JSON for debugging,
+// keeping net/http out of the chash package.
+
+package chashdebug
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/mohdrashid9678/dcore/chash"
+)
+
+// Handler serves the state of a ring
+type Handler struct {
+	ring *chash.Ring
+}
+
+// NewHandler creates a handler serving ring's DebugState as JSON, to mount
+// under a debug mux such as /debug/ring
+func NewHandler(ring *chash.Ring) *Handler {
+	return &Handler{ring: ring}
+}
+
+// ServeHTTP writes the ring's current DebugState
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(h.ring.DebugState())
+}
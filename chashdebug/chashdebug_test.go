@@ -0,0 +1,41 @@
+package chashdebug
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mohdrashid9678/dcore/chash"
+)
+
+func TestHandler(t *testing.T) {
+	ring := chash.New(chash.Config{})
+	if err := ring.AddNode("node1"); err != nil {
+		t.Fatalf("AddNode failed: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/debug/ring", NewHandler(ring))
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/ring", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("unexpected response %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+
+	var state chash.DebugState
+	if err := json.Unmarshal(rec.Body.Bytes(), &state); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(state.Nodes) != 1 || state.Nodes[0].Name != "node1" || math.Abs(state.Nodes[0].Ownership-100) > 1e-9 {
+		t.Errorf("unexpected state %+v", state)
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/debug/ring", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405 for POST, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,221 @@
+// Package chashprom exposes the state and lookup latency of a chash ring as
+// Prometheus metrics, written in the Prometheus text exposition format so
+// no client library is required.
+
+package chashprom
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/mohdrashid9678/dcore/chash"
+	"github.com/mohdrashid9678/dcore/clock"
+)
+
+// contentType is the media type of the text exposition format
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultBuckets are the lookup latency histogram bounds in seconds, from
+// 100ns to 1ms; in-memory lookups take around a microsecond
+var DefaultBuckets = []float64{1e-7, 2.5e-7, 5e-7, 1e-6, 2.5e-6, 5e-6, 1e-5, 2.5e-5, 1e-4, 1e-3}
+
+// Config holds configuration options for creating a new Exporter
+type Config struct {
+	// Namespace prefixes every metric name, as in dcore_chash_nodes
+	// Default: "dcore"
+	Namespace string
+
+	// ConstLabels are added to every metric, such as a ring name when a
+	// process exposes several rings
+	// Default: none
+	ConstLabels map[string]string
+
+	// Buckets are the upper bounds of the lookup latency histogram in seconds,
+	// in increasing order
+	// Default: DefaultBuckets
+	Buckets []float64
+
+	// Clock times lookups made through GetNode
+	// Default: the wall clock
+	Clock clock.Clock
+}
+
+// Exporter exposes the metrics of a ring
+// It is not a prometheus.Collector: serve it on its own endpoint, or append
+// WriteMetrics to an existing one
+// Ring metrics are read from the ring on every scrape. Lookup latency is
+// measured for lookups made through the exporter's GetNode, or reported with
+// Observe
+type Exporter struct {
+	ring   *chash.Ring
+	config Config
+	prefix string
+	labels string
+
+	// counts holds the non-cumulative count of each bucket plus +Inf
+	counts []atomic.Uint64
+	sum    atomic.Int64
+}
+
+// NewExporter creates an exporter for ring
+func NewExporter(ring *chash.Ring, config Config) *Exporter {
+	if config.Namespace == "" {
+		config.Namespace = "dcore"
+	}
+	if len(config.Buckets) == 0 {
+		config.Buckets = DefaultBuckets
+	}
+	config.Clock = clock.OrReal(config.Clock)
+
+	return &Exporter{
+		ring:   ring,
+		config: config,
+		prefix: config.Namespace + "_chash_",
+		labels: formatLabels(config.ConstLabels),
+		counts: make([]atomic.Uint64, len(config.Buckets)+1),
+	}
+}
+
+// GetNode routes key like chash.Ring.GetNode and records the lookup latency
+func (e *Exporter) GetNode(key string) (string, error) {
+	start := e.config.Clock.Now()
+	node, err := e.ring.GetNode(key)
+	e.Observe(e.config.Clock.Since(start))
+	return node, err
+}
+
+// Observe records the latency of a lookup made elsewhere
+func (e *Exporter) Observe(d time.Duration) {
+	seconds := d.Seconds()
+	i := sort.SearchFloat64s(e.config.Buckets, seconds)
+	e.counts[i].Add(1)
+	e.sum.Add(int64(d))
+}
+
+// ServeHTTP serves the metrics for a Prometheus scrape
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", contentType)
+	e.WriteMetrics(w)
+}
+
+// WriteMetrics writes the metrics in the text exposition format, such as to
+// append them to an existing metrics endpoint
+func (e *Exporter) WriteMetrics(w io.Writer) error {
+	stats := e.ring.GetStats()
+
+	states := make(map[chash.NodeState]int)
+	for _, node := range e.ring.Nodes() {
+		if state, err := e.ring.NodeState(node); err == nil {
+			states[state]++
+		}
+	}
+
+	b := bufio.NewWriter(w)
+
+	e.header(b, "nodes", "gauge", "Physical nodes in the ring by lookup state")
+	for _, state := range []chash.NodeState{chash.NodeActive, chash.NodeDraining, chash.NodeDisabled} {
+		e.sample(b, "nodes", `state="`+state.String()+`"`, float64(states[state]))
+	}
+
+	e.header(b, "virtual_nodes", "gauge", "Virtual nodes in the ring")
+	e.sample(b, "virtual_nodes", "", float64(stats.VirtualNodes))
+
+	e.header(b, "replicas", "gauge", "Virtual nodes per physical node of weight 1")
+	e.sample(b, "replicas", "", float64(stats.Replicas))
+
+	e.header(b, "topology_version", "gauge", "Ring version, bumped by every topology change")
+	e.sample(b, "topology_version", "", float64(e.ring.Version()))
+
+	e.header(b, "lookups_total", "counter", "Key lookups served by the ring")
+	e.sample(b, "lookups_total", "", float64(stats.Lookups))
+
+	e.header(b, "keyspace_share", "gauge", "Fraction of the hash space owned by each node")
+	nodes := make([]string, 0, len(stats.Keyspace))
+	for node := range stats.Keyspace {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+	for _, node := range nodes {
+		e.sample(b, "keyspace_share", `node="`+escape(node)+`"`, stats.Keyspace[node])
+	}
+
+	e.header(b, "lookup_duration_seconds", "histogram", "Latency of lookups made through the exporter")
+	var cumulative uint64
+	for i, bound := range e.config.Buckets {
+		cumulative += e.counts[i].Load()
+		e.sample(b, "lookup_duration_seconds_bucket", `le="`+formatFloat(bound)+`"`, float64(cumulative))
+	}
+	cumulative += e.counts[len(e.config.Buckets)].Load()
+	e.sample(b, "lookup_duration_seconds_bucket", `le="+Inf"`, float64(cumulative))
+	e.sample(b, "lookup_duration_seconds_sum", "", time.Duration(e.sum.Load()).Seconds())
+	e.sample(b, "lookup_duration_seconds_count", "", float64(cumulative))
+
+	return b.Flush()
+}
+
+// header writes the HELP and TYPE lines of a metric
+func (e *Exporter) header(b *bufio.Writer, name, typ, help string) {
+	fmt.Fprintf(b, "# HELP %s%s %s\n# TYPE %s%s %s\n", e.prefix, name, help, e.prefix, name, typ)
+}
+
+// sample writes one sample line with the constant labels and labels
+func (e *Exporter) sample(b *bufio.Writer, name, labels string, value float64) {
+	switch {
+	case e.labels != "" && labels != "":
+		labels = e.labels + "," + labels
+	case labels == "":
+		labels = e.labels
+	}
+
+	b.WriteString(e.prefix)
+	b.WriteString(name)
+	if labels != "" {
+		b.WriteString("{" + labels + "}")
+	}
+	b.WriteString(" " + formatFloat(value) + "\n")
+}
+
+// formatLabels renders labels sorted by name
+func formatLabels(labels map[string]string) string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = name + `="` + escape(labels[name]) + `"`
+	}
+	return strings.Join(pairs, ",")
+}
+
+// labelEscaper escapes label values for the text exposition format
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// escape escapes a label value for the text exposition format
+func escape(value string) string {
+	return labelEscaper.Replace(value)
+}
+
+// formatFloat renders a sample value, including the special values
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
@@ -0,0 +1,101 @@
+package chashprom
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mohdrashid9678/dcore/chash"
+)
+
+func TestExporterWritesRingMetrics(t *testing.T) {
+	ring := chash.New(chash.Config{Replicas: 10})
+	for _, node := range []string{"node1", "node2", "node3"} {
+		if err := ring.AddNode(node); err != nil {
+			t.Fatalf("AddNode failed: %v", err)
+		}
+	}
+	if err := ring.SetNodeState("node3", chash.NodeDraining); err != nil {
+		t.Fatalf("SetNodeState failed: %v", err)
+	}
+
+	c := NewExporter(ring, Config{ConstLabels: map[string]string{"ring": "sessions"}})
+	for _, key := range []string{"a", "b", "c"} {
+		if _, err := c.GetNode(key); err != nil {
+			t.Fatalf("GetNode failed: %v", err)
+		}
+	}
+
+	var b strings.Builder
+	if err := c.WriteMetrics(&b); err != nil {
+		t.Fatalf("WriteMetrics failed: %v", err)
+	}
+	out := b.String()
+
+	for _, want := range []string{
+		"# TYPE dcore_chash_nodes gauge\n",
+		`dcore_chash_nodes{ring="sessions",state="active"} 2` + "\n",
+		`dcore_chash_nodes{ring="sessions",state="draining"} 1` + "\n",
+		`dcore_chash_virtual_nodes{ring="sessions"} 30` + "\n",
+		`dcore_chash_lookups_total{ring="sessions"} 3` + "\n",
+		"# TYPE dcore_chash_lookup_duration_seconds histogram\n",
+		`dcore_chash_lookup_duration_seconds_bucket{ring="sessions",le="+Inf"} 3` + "\n",
+		`dcore_chash_lookup_duration_seconds_count{ring="sessions"} 3` + "\n",
+		`dcore_chash_keyspace_share{ring="sessions",node="node1"} `,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+	if !strings.Contains(out, `dcore_chash_topology_version{ring="sessions"} `+formatFloat(float64(ring.Version()))+"\n") {
+		t.Errorf("expected the ring version, got:\n%s", out)
+	}
+}
+
+func TestExporterHistogramBuckets(t *testing.T) {
+	ring := chash.New(chash.Config{})
+	c := NewExporter(ring, Config{Namespace: "app", Buckets: []float64{0.001, 0.01}})
+
+	c.Observe(500 * time.Microsecond)
+	c.Observe(time.Millisecond) // Upper bounds are inclusive
+	c.Observe(5 * time.Millisecond)
+	c.Observe(time.Second)
+
+	var b strings.Builder
+	if err := c.WriteMetrics(&b); err != nil {
+		t.Fatalf("WriteMetrics failed: %v", err)
+	}
+	out := b.String()
+
+	for _, want := range []string{
+		`app_chash_lookup_duration_seconds_bucket{le="0.001"} 2` + "\n",
+		`app_chash_lookup_duration_seconds_bucket{le="0.01"} 3` + "\n",
+		`app_chash_lookup_duration_seconds_bucket{le="+Inf"} 4` + "\n",
+		"app_chash_lookup_duration_seconds_sum 1.0065\n",
+		"app_chash_lookup_duration_seconds_count 4\n",
+		"app_chash_virtual_nodes 0\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestExporterServeHTTP(t *testing.T) {
+	ring := chash.New(chash.Config{})
+	if err := ring.AddNode("node\"1"); err != nil {
+		t.Fatalf("AddNode failed: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	NewExporter(ring, Config{}).ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	if ct := rec.Header().Get("Content-Type"); ct != contentType {
+		t.Errorf("expected content type %q, got %q", contentType, ct)
+	}
+	// Label values are escaped
+	if want := `dcore_chash_keyspace_share{node="node\"1"} `; !strings.Contains(rec.Body.String(), want) {
+		t.Errorf("expected body to contain %q, got:\n%s", want, rec.Body.String())
+	}
+}
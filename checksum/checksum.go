@@ -0,0 +1,177 @@
+// Package checksum frames byte payloads with their length and a CRC-32C
+// checksum so bit rot and truncation are detected when data is read back or
+// applied, rather than silently served.
+
+package checksum
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"math"
+	"sync/atomic"
+)
+
+var (
+	// ErrCorrupt is returned when a payload does not match its checksum
+	ErrCorrupt = errors.New("checksum mismatch")
+
+	// ErrTruncated is returned when a frame is shorter than its header says
+	ErrTruncated = errors.New("frame truncated")
+
+	// ErrTooLarge is returned when a payload does not fit in a frame or a
+	// frame exceeds a reader's limit
+	ErrTooLarge = errors.New("frame too large")
+)
+
+// Overhead is the number of bytes a frame adds to its payload: a 4-byte
+// big-endian length before it and a 4-byte CRC-32C of the length and payload
+// after it
+const Overhead = 8
+
+// castagnoli is the CRC-32C table, which modern CPUs compute in hardware
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// Sum returns the CRC-32C of data
+func Sum(data []byte) uint32 {
+	return crc32.Checksum(data, castagnoli)
+}
+
+// Seal returns data framed with its length and checksum
+func Seal(data []byte) ([]byte, error) {
+	return Append(make([]byte, 0, len(data)+Overhead), data)
+}
+
+// Append appends data framed with its length and checksum to dst
+func Append(dst, data []byte) ([]byte, error) {
+	if uint64(len(data)) > math.MaxUint32 {
+		return dst, fmt.Errorf("%w: %d bytes", ErrTooLarge, len(data))
+	}
+
+	start := len(dst)
+	dst = binary.BigEndian.AppendUint32(dst, uint32(len(data)))
+	dst = append(dst, data...)
+	return binary.BigEndian.AppendUint32(dst, Sum(dst[start:])), nil
+}
+
+// Open verifies a frame produced by Seal and returns its payload, which
+// aliases frame
+// Returns ErrTruncated if frame is cut short and ErrCorrupt if the payload or
+// length was altered or bytes follow the frame
+func Open(frame []byte) ([]byte, error) {
+	payload, rest, err := Next(frame)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("%w: %d trailing bytes", ErrCorrupt, len(rest))
+	}
+	return payload, nil
+}
+
+// Next verifies the first frame in buf and returns its payload and the bytes
+// after it, for buffers holding a sequence of frames such as snapshot chunks
+// or log records
+func Next(buf []byte) (payload, rest []byte, err error) {
+	if len(buf) < Overhead {
+		return nil, nil, fmt.Errorf("%w: %d bytes, need at least %d", ErrTruncated, len(buf), Overhead)
+	}
+
+	n := uint64(binary.BigEndian.Uint32(buf))
+	if uint64(len(buf)) < n+Overhead {
+		return nil, nil, fmt.Errorf("%w: have %d of %d bytes", ErrTruncated, len(buf), n+Overhead)
+	}
+
+	end := 4 + int(n)
+	if want, got := binary.BigEndian.Uint32(buf[end:]), Sum(buf[:end]); want != got {
+		return nil, nil, fmt.Errorf("%w: stored %08x, computed %08x", ErrCorrupt, want, got)
+	}
+	return buf[4:end], buf[end+4:], nil
+}
+
+// Event describes a frame that failed verification
+type Event struct {
+	// Source names where the frame came from, such as "cache" or "snapshot"
+	Source string
+
+	// Size is the number of bytes that were verified
+	Size int
+
+	// Err wraps ErrCorrupt or ErrTruncated
+	Err error
+}
+
+// Stats counts the frames a verifier has checked
+type Stats struct {
+	Verified  uint64
+	Corrupted uint64
+	Truncated uint64
+}
+
+// VerifierConfig holds configuration options for creating a new Verifier
+type VerifierConfig struct {
+	// OnCorruption is called for every frame that fails verification, such
+	// as to log it, page someone or re-fetch the data from a replica
+	// Default: none
+	OnCorruption func(Event)
+}
+
+// Verifier opens frames like Open while counting failures and reporting them,
+// so corruption is visible in metrics even where callers recover from it
+type Verifier struct {
+	onCorruption func(Event)
+
+	verified  atomic.Uint64
+	corrupted atomic.Uint64
+	truncated atomic.Uint64
+}
+
+// NewVerifier creates a new verifier
+func NewVerifier(config VerifierConfig) *Verifier {
+	return &Verifier{onCorruption: config.OnCorruption}
+}
+
+// Open verifies a frame read from source and returns its payload
+func (v *Verifier) Open(source string, frame []byte) ([]byte, error) {
+	payload, err := Open(frame)
+	v.record(source, len(frame), err)
+	return payload, err
+}
+
+// Next verifies the first frame in buf read from source, like Next
+func (v *Verifier) Next(source string, buf []byte) (payload, rest []byte, err error) {
+	payload, rest, err = Next(buf)
+	size := len(buf) - len(rest)
+	if err != nil {
+		size = len(buf)
+	}
+	v.record(source, size, err)
+	return payload, rest, err
+}
+
+// Stats returns the verification counters
+func (v *Verifier) Stats() Stats {
+	return Stats{
+		Verified:  v.verified.Load(),
+		Corrupted: v.corrupted.Load(),
+		Truncated: v.truncated.Load(),
+	}
+}
+
+// record counts a verification and reports it if it failed
+func (v *Verifier) record(source string, size int, err error) {
+	switch {
+	case err == nil:
+		v.verified.Add(1)
+		return
+	case errors.Is(err, ErrTruncated):
+		v.truncated.Add(1)
+	default:
+		v.corrupted.Add(1)
+	}
+
+	if v.onCorruption != nil {
+		v.onCorruption(Event{Source: source, Size: size, Err: err})
+	}
+}
@@ -0,0 +1,110 @@
+package checksum
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestSealOpen(t *testing.T) {
+	for _, data := range [][]byte{nil, []byte("x"), bytes.Repeat([]byte("value"), 1000)} {
+		frame, err := Seal(data)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(frame) != len(data)+Overhead {
+			t.Errorf("expected %d bytes, got %d", len(data)+Overhead, len(frame))
+		}
+
+		payload, err := Open(frame)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if !bytes.Equal(payload, data) {
+			t.Errorf("expected %q, got %q", data, payload)
+		}
+	}
+}
+
+func TestOpenDetectsCorruption(t *testing.T) {
+	frame, _ := Seal([]byte("cache entry"))
+
+	// Flip every bit in turn, including those of the length and checksum
+	for i := 0; i < len(frame)*8; i++ {
+		corrupt := append([]byte(nil), frame...)
+		corrupt[i/8] ^= 1 << (i % 8)
+
+		if _, err := Open(corrupt); !errors.Is(err, ErrCorrupt) && !errors.Is(err, ErrTruncated) {
+			t.Fatalf("bit %d: expected corruption detected, got %v", i, err)
+		}
+	}
+
+	if _, err := Open(append(frame, 0)); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("expected trailing bytes to be rejected, got %v", err)
+	}
+}
+
+func TestOpenDetectsTruncation(t *testing.T) {
+	frame, _ := Seal([]byte("snapshot chunk"))
+
+	for n := 0; n < len(frame); n++ {
+		if _, err := Open(frame[:n]); !errors.Is(err, ErrTruncated) {
+			t.Fatalf("%d bytes: expected ErrTruncated, got %v", n, err)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	var buf []byte
+	records := []string{"first", "", "third"}
+	for _, r := range records {
+		buf, _ = Append(buf, []byte(r))
+	}
+
+	for _, want := range records {
+		payload, rest, err := Next(buf)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if string(payload) != want {
+			t.Errorf("expected %q, got %q", want, payload)
+		}
+		buf = rest
+	}
+	if len(buf) != 0 {
+		t.Errorf("expected no bytes left, got %d", len(buf))
+	}
+}
+
+func TestVerifier(t *testing.T) {
+	var events []Event
+	v := NewVerifier(VerifierConfig{OnCorruption: func(e Event) { events = append(events, e) }})
+
+	frame, _ := Seal([]byte("hint"))
+	if _, err := v.Open("hints", frame); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	corrupt := append([]byte(nil), frame...)
+	corrupt[5] ^= 0xff
+	if _, err := v.Open("hints", corrupt); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("expected ErrCorrupt, got %v", err)
+	}
+	if _, _, err := v.Next("wal", frame[:6]); !errors.Is(err, ErrTruncated) {
+		t.Errorf("expected ErrTruncated, got %v", err)
+	}
+
+	if stats := v.Stats(); stats != (Stats{Verified: 1, Corrupted: 1, Truncated: 1}) {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	if events[0].Source != "hints" || events[0].Size != len(frame) || !errors.Is(events[0].Err, ErrCorrupt) {
+		t.Errorf("unexpected event %+v", events[0])
+	}
+	if events[1].Source != "wal" || events[1].Size != 6 {
+		t.Errorf("unexpected event %+v", events[1])
+	}
+}
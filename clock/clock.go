@@ -0,0 +1,327 @@
+// Package clock abstracts time so time-dependent subsystems can be driven by a
+// controllable fake in tests instead of sleeping on the wall clock.
+
+package clock
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock tells the time and creates timers
+type Clock interface {
+	// Now returns the current time
+	Now() time.Time
+
+	// Since returns the time elapsed since t
+	Since(t time.Time) time.Duration
+
+	// Until returns the duration until t
+	Until(t time.Time) time.Duration
+
+	// Sleep blocks for d
+	Sleep(d time.Duration)
+
+	// NewTimer creates a timer that fires once after d
+	NewTimer(d time.Duration) Timer
+
+	// NewTicker creates a ticker that fires every d; d must be positive
+	NewTicker(d time.Duration) Ticker
+}
+
+// Timer is a single-shot timer, like time.Timer
+type Timer interface {
+	// C returns the channel the time is delivered on
+	C() <-chan time.Time
+
+	// Stop prevents the timer from firing and reports whether it was active
+	Stop() bool
+
+	// Reset restarts the timer to fire after d and reports whether it was active
+	Reset(d time.Duration) bool
+}
+
+// Ticker delivers ticks at intervals, like time.Ticker
+type Ticker interface {
+	// C returns the channel ticks are delivered on
+	C() <-chan time.Time
+
+	// Stop turns the ticker off
+	Stop()
+}
+
+// Real returns the wall clock, backed by the time package
+func Real() Clock {
+	return realClock{}
+}
+
+// OrReal returns c, or the wall clock if c is nil
+// Constructors use it to default an optional Clock config field
+func OrReal(c Clock) Clock {
+	if c == nil {
+		return Real()
+	}
+	return c
+}
+
+// WithTimeout is context.WithTimeout measured on c
+// On a fake clock the context ends with context.DeadlineExceeded as its cause
+// once the fake time passes the timeout; its Deadline is not set
+func WithTimeout(ctx context.Context, c Clock, d time.Duration) (context.Context, context.CancelFunc) {
+	if _, ok := c.(realClock); ok {
+		return context.WithTimeout(ctx, d)
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	timer := c.NewTimer(d)
+
+	go func() {
+		select {
+		case <-timer.C():
+			cancel(context.DeadlineExceeded)
+		case <-ctx.Done():
+			timer.Stop()
+		}
+	}()
+
+	return ctx, func() { cancel(context.Canceled) }
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                  { return time.Now() }
+func (realClock) Since(t time.Time) time.Duration { return time.Since(t) }
+func (realClock) Until(t time.Time) time.Duration { return time.Until(t) }
+func (realClock) Sleep(d time.Duration)           { time.Sleep(d) }
+
+func (realClock) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+func (realClock) NewTicker(d time.Duration) Ticker {
+	return realTicker{time.NewTicker(d)}
+}
+
+type realTimer struct{ t *time.Timer }
+
+func (t realTimer) C() <-chan time.Time        { return t.t.C }
+func (t realTimer) Stop() bool                 { return t.t.Stop() }
+func (t realTimer) Reset(d time.Duration) bool { return t.t.Reset(d) }
+
+type realTicker struct{ t *time.Ticker }
+
+func (t realTicker) C() <-chan time.Time { return t.t.C }
+func (t realTicker) Stop()               { t.t.Stop() }
+
+// Fake is a Clock that only moves when told to
+// Timers, tickers and sleeps fire as Advance or Set moves the time past their
+// deadline, so tests run instantly and deterministically
+type Fake struct {
+	// mu protects all fields below
+	mu sync.Mutex
+
+	// waitersChanged is broadcast whenever a waiter is added
+	waitersChanged *sync.Cond
+
+	now     time.Time
+	waiters []*fakeWaiter
+}
+
+// fakeWaiter is a pending timer, ticker or sleep
+type fakeWaiter struct {
+	fake   *Fake
+	c      chan time.Time
+	until  time.Time
+	period time.Duration // positive for tickers
+}
+
+// NewFake creates a fake clock set to start
+func NewFake(start time.Time) *Fake {
+	f := &Fake{now: start}
+	f.waitersChanged = sync.NewCond(&f.mu)
+	return f
+}
+
+// Now returns the fake time
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.now
+}
+
+// Since returns the fake time elapsed since t
+func (f *Fake) Since(t time.Time) time.Duration {
+	return f.Now().Sub(t)
+}
+
+// Until returns the fake duration until t
+func (f *Fake) Until(t time.Time) time.Duration {
+	return t.Sub(f.Now())
+}
+
+// Sleep blocks until the fake time has advanced by d
+func (f *Fake) Sleep(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	<-f.NewTimer(d).C()
+}
+
+// NewTimer creates a timer that fires when the fake time passes now + d
+func (f *Fake) NewTimer(d time.Duration) Timer {
+	t := fakeTimer{&fakeWaiter{fake: f, c: make(chan time.Time, 1)}}
+	t.Reset(d)
+	return t
+}
+
+// NewTicker creates a ticker that fires each time the fake time passes a
+// multiple of d from now; like time.Ticker, it drops ticks for slow readers
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: non-positive interval for NewTicker")
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	w := &fakeWaiter{fake: f, c: make(chan time.Time, 1), until: f.now.Add(d), period: d}
+	f.addLocked(w)
+	return fakeTicker{w}
+}
+
+// Advance moves the fake time forward by d, firing due timers in deadline order
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.setLocked(f.now.Add(d))
+}
+
+// Set moves the fake time to t, firing due timers; moving backwards fires nothing
+func (f *Fake) Set(t time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.setLocked(t)
+}
+
+// BlockUntil waits until at least n timers, tickers or sleeps are pending
+// Tests use it to advance only once a goroutine is waiting on the clock
+func (f *Fake) BlockUntil(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for len(f.waiters) < n {
+		f.waitersChanged.Wait()
+	}
+}
+
+// Waiters returns the number of pending timers, tickers and sleeps
+func (f *Fake) Waiters() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return len(f.waiters)
+}
+
+// setLocked moves the time to t and fires due waiters
+// Caller must hold f.mu
+func (f *Fake) setLocked(t time.Time) {
+	if t.After(f.now) {
+		f.now = t
+	}
+
+	sort.SliceStable(f.waiters, func(i, j int) bool {
+		return f.waiters[i].until.Before(f.waiters[j].until)
+	})
+
+	kept := f.waiters[:0]
+	for _, w := range f.waiters {
+		if w.until.After(f.now) {
+			kept = append(kept, w)
+			continue
+		}
+
+		// A ticker passed several times by one advance delivers only its
+		// latest tick
+		fired := w.until
+		if w.period > 0 {
+			for !w.until.After(f.now) {
+				fired = w.until
+				w.until = w.until.Add(w.period)
+			}
+			kept = append(kept, w)
+		}
+
+		// Never block the clock on a slow reader
+		select {
+		case w.c <- fired:
+		default:
+		}
+	}
+
+	// Clear the dropped tail so fired waiters can be collected
+	for i := len(kept); i < len(f.waiters); i++ {
+		f.waiters[i] = nil
+	}
+	f.waiters = kept
+}
+
+// addLocked registers a waiter
+// Caller must hold f.mu
+func (f *Fake) addLocked(w *fakeWaiter) {
+	f.waiters = append(f.waiters, w)
+	f.waitersChanged.Broadcast()
+}
+
+// removeLocked unregisters a waiter and reports whether it was pending
+// Caller must hold f.mu
+func (f *Fake) removeLocked(w *fakeWaiter) bool {
+	for i, pending := range f.waiters {
+		if pending == w {
+			f.waiters = append(f.waiters[:i], f.waiters[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// stop cancels the waiter and reports whether it was pending
+func (w *fakeWaiter) stop() bool {
+	w.fake.mu.Lock()
+	defer w.fake.mu.Unlock()
+
+	return w.fake.removeLocked(w)
+}
+
+// fakeTimer is a Timer on a Fake clock
+type fakeTimer struct{ w *fakeWaiter }
+
+func (t fakeTimer) C() <-chan time.Time { return t.w.c }
+func (t fakeTimer) Stop() bool          { return t.w.stop() }
+
+// Reset reschedules the timer to fire after d
+func (t fakeTimer) Reset(d time.Duration) bool {
+	f := t.w.fake
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	active := f.removeLocked(t.w)
+	t.w.until = f.now.Add(d)
+	f.addLocked(t.w)
+
+	// A timer already due fires immediately, like time.NewTimer(0)
+	if d <= 0 {
+		f.setLocked(f.now)
+	}
+	return active
+}
+
+// fakeTicker is a Ticker on a Fake clock
+type fakeTicker struct{ w *fakeWaiter }
+
+func (t fakeTicker) C() <-chan time.Time { return t.w.c }
+func (t fakeTicker) Stop()               { t.w.stop() }
@@ -0,0 +1,186 @@
+package clock
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestFakeTimer(t *testing.T) {
+	f := NewFake(epoch)
+	timer := f.NewTimer(time.Minute)
+
+	f.Advance(59 * time.Second)
+	select {
+	case <-timer.C():
+		t.Fatal("timer fired early")
+	default:
+	}
+
+	f.Advance(time.Second)
+	select {
+	case at := <-timer.C():
+		if !at.Equal(epoch.Add(time.Minute)) {
+			t.Errorf("expected fire time %v, got %v", epoch.Add(time.Minute), at)
+		}
+	default:
+		t.Fatal("expected timer to fire")
+	}
+
+	if timer.Stop() {
+		t.Error("expected Stop on a fired timer to return false")
+	}
+	if f.Waiters() != 0 {
+		t.Errorf("expected no waiters, got %d", f.Waiters())
+	}
+}
+
+func TestFakeTimerStopAndReset(t *testing.T) {
+	f := NewFake(epoch)
+	timer := f.NewTimer(time.Minute)
+
+	if !timer.Stop() {
+		t.Error("expected Stop on a pending timer to return true")
+	}
+	f.Advance(time.Hour)
+	select {
+	case <-timer.C():
+		t.Fatal("stopped timer fired")
+	default:
+	}
+
+	timer.Reset(time.Second)
+	f.Advance(time.Second)
+	select {
+	case <-timer.C():
+	default:
+		t.Fatal("expected reset timer to fire")
+	}
+
+	// A zero timer fires without advancing, like the real one
+	select {
+	case <-f.NewTimer(0).C():
+	default:
+		t.Fatal("expected zero timer to fire immediately")
+	}
+}
+
+func TestFakeTicker(t *testing.T) {
+	f := NewFake(epoch)
+	ticker := f.NewTicker(10 * time.Second)
+	defer ticker.Stop()
+
+	for i := 1; i <= 3; i++ {
+		f.Advance(10 * time.Second)
+		at := <-ticker.C()
+		if expected := epoch.Add(time.Duration(i) * 10 * time.Second); !at.Equal(expected) {
+			t.Errorf("expected tick at %v, got %v", expected, at)
+		}
+	}
+
+	// Ticks are dropped rather than queued for a slow reader
+	f.Advance(time.Minute)
+	if at := <-ticker.C(); !at.Equal(epoch.Add(90 * time.Second)) {
+		t.Errorf("expected the latest tick, got %v", at)
+	}
+	select {
+	case <-ticker.C():
+		t.Fatal("expected missed ticks to be dropped")
+	default:
+	}
+
+	ticker.Stop()
+	if f.Waiters() != 0 {
+		t.Errorf("expected stopped ticker to be removed, got %d waiters", f.Waiters())
+	}
+}
+
+func TestFakeSleepAndBlockUntil(t *testing.T) {
+	f := NewFake(epoch)
+
+	done := make(chan struct{})
+	go func() {
+		f.Sleep(time.Hour)
+		close(done)
+	}()
+
+	f.BlockUntil(1)
+	f.Advance(time.Hour)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected sleeper to wake")
+	}
+
+	if f.Since(epoch) != time.Hour || f.Until(epoch) != -time.Hour {
+		t.Errorf("unexpected elapsed time %v", f.Since(epoch))
+	}
+}
+
+func TestFakeSetBackwards(t *testing.T) {
+	f := NewFake(epoch)
+	f.Set(epoch.Add(-time.Hour))
+
+	if !f.Now().Equal(epoch) {
+		t.Errorf("expected clock not to move backwards, got %v", f.Now())
+	}
+}
+
+func TestReal(t *testing.T) {
+	c := OrReal(nil)
+
+	before := time.Now()
+	if c.Now().Before(before) {
+		t.Error("expected real clock to follow time.Now")
+	}
+
+	timer := c.NewTimer(time.Millisecond)
+	<-timer.C()
+
+	ticker := c.NewTicker(time.Millisecond)
+	<-ticker.C()
+	ticker.Stop()
+
+	f := NewFake(epoch)
+	if OrReal(f) != Clock(f) {
+		t.Error("expected OrReal to keep a non-nil clock")
+	}
+}
+
+func TestWithTimeout(t *testing.T) {
+	f := NewFake(epoch)
+
+	ctx, cancel := WithTimeout(context.Background(), f, time.Minute)
+	defer cancel()
+
+	f.BlockUntil(1)
+	f.Advance(time.Minute)
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("expected context to time out on the fake clock")
+	}
+	if !errors.Is(context.Cause(ctx), context.DeadlineExceeded) {
+		t.Errorf("expected DeadlineExceeded cause, got %v", context.Cause(ctx))
+	}
+
+	// Cancelling early releases the timer
+	ctx, cancel = WithTimeout(context.Background(), f, time.Minute)
+	f.BlockUntil(1)
+	cancel()
+	<-ctx.Done()
+	for f.Waiters() != 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	realCtx, cancel := WithTimeout(context.Background(), Real(), time.Hour)
+	defer cancel()
+	if _, ok := realCtx.Deadline(); !ok {
+		t.Error("expected real clock context to carry a deadline")
+	}
+}
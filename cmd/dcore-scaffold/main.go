@@ -0,0 +1,52 @@
+// Command dcore-scaffold generates a runnable skeleton of a sharded service
+// that wires the dcore packages together: ring-based sharding, membership,
+// adaptive timeouts and concurrency limits, health checks, metrics and
+// graceful shutdown.
+//
+// Usage:
+//
+//	dcore-scaffold -module example.com/kvstore [-name kvstore] [-dir kvstore] [-force]
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+)
+
+func main() {
+	module := flag.String("module", "", "Go module path of the service (required)")
+	name := flag.String("name", "", "command name of the service (default: last element of -module)")
+	dir := flag.String("dir", "", "directory to generate into (default: the service name)")
+	force := flag.Bool("force", false, "overwrite existing files")
+	flag.Parse()
+
+	if *module == "" {
+		fmt.Fprintln(os.Stderr, "dcore-scaffold: -module is required")
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *dir == "" {
+		*dir = *name
+		if *dir == "" {
+			*dir = path.Base(*module)
+		}
+	}
+
+	written, err := generate(*dir, options{Module: *module, Name: *name}, *force)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "dcore-scaffold:", err)
+		if errors.Is(err, errExists) {
+			fmt.Fprintln(os.Stderr, "dcore-scaffold: use -force to overwrite")
+		}
+		os.Exit(1)
+	}
+
+	for _, file := range written {
+		fmt.Println("wrote", file)
+	}
+	fmt.Printf("\nNext: cd %s && go mod tidy && go run .\n", *dir)
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"go/format"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// errExists is returned when a generated file would overwrite an existing one
+var errExists = errors.New("file already exists")
+
+//go:embed templates/*.tmpl
+var templates embed.FS
+
+// namePattern restricts service names to what is valid as a command name
+var namePattern = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+
+// options describe the service to generate
+type options struct {
+	// Module is the Go module path of the service
+	Module string
+
+	// Name is the command name of the service
+	// Default: the last element of Module
+	Name string
+}
+
+// generate writes the service skeleton into dir and returns the paths of the
+// files written
+// Existing files are left alone and reported with errExists unless force is set
+func generate(dir string, opts options, force bool) ([]string, error) {
+	if opts.Module == "" {
+		return nil, errors.New("module path is required")
+	}
+	if opts.Name == "" {
+		opts.Name = path.Base(opts.Module)
+	}
+	if !namePattern.MatchString(opts.Name) {
+		return nil, fmt.Errorf("invalid service name %q: use lowercase letters, digits and dashes", opts.Name)
+	}
+
+	files, err := render(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if !force {
+		for name := range files {
+			if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+				return nil, fmt.Errorf("%w: %s", errExists, filepath.Join(dir, name))
+			}
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	var written []string
+	for _, name := range sortedKeys(files) {
+		target := filepath.Join(dir, name)
+		if err := os.WriteFile(target, files[name], 0o644); err != nil {
+			return written, err
+		}
+		written = append(written, target)
+	}
+	return written, nil
+}
+
+// render executes every template, keyed by the name of the file it produces
+func render(opts options) (map[string][]byte, error) {
+	names, err := fs.Glob(templates, "templates/*.tmpl")
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string][]byte, len(names))
+	for _, name := range names {
+		tmpl, err := template.ParseFS(templates, name)
+		if err != nil {
+			return nil, err
+		}
+
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, opts); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+
+		out := strings.TrimSuffix(path.Base(name), ".tmpl")
+		content := buf.Bytes()
+		if strings.HasSuffix(out, ".go") {
+			if content, err = format.Source(content); err != nil {
+				return nil, fmt.Errorf("%s: %w", out, err)
+			}
+		}
+		files[out] = content
+	}
+	return files, nil
+}
+
+// sortedKeys returns the keys of files in order
+func sortedKeys(files map[string][]byte) []string {
+	keys := make([]string, 0, len(files))
+	for name := range files {
+		keys = append(keys, name)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "kvstore")
+
+	written, err := generate(dir, options{Module: "example.com/kvstore"}, false)
+	if err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+	if len(written) != 4 {
+		t.Errorf("Expected 4 files, got %v", written)
+	}
+
+	mod, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		t.Fatalf("go.mod not written: %v", err)
+	}
+	if !strings.HasPrefix(string(mod), "module example.com/kvstore\n") {
+		t.Errorf("Unexpected go.mod:\n%s", mod)
+	}
+
+	fset := token.NewFileSet()
+	for _, name := range []string{"main.go", "service.go"} {
+		file, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.ImportsOnly)
+		if err != nil {
+			t.Fatalf("%s does not parse: %v", name, err)
+		}
+		if file.Name.Name != "main" {
+			t.Errorf("Expected %s in package main, got %s", name, file.Name.Name)
+		}
+	}
+
+	readme, _ := os.ReadFile(filepath.Join(dir, "README.md"))
+	if !strings.HasPrefix(string(readme), "# kvstore\n") {
+		t.Errorf("Expected README titled with the service name, got:\n%s", readme)
+	}
+}
+
+func TestGenerateRefusesToOverwrite(t *testing.T) {
+	dir := t.TempDir()
+	opts := options{Module: "example.com/svc", Name: "svc"}
+
+	if _, err := generate(dir, opts, false); err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+	if _, err := generate(dir, opts, false); !errors.Is(err, errExists) {
+		t.Errorf("Expected errExists, got %v", err)
+	}
+	if _, err := generate(dir, opts, true); err != nil {
+		t.Errorf("Expected force to overwrite, got %v", err)
+	}
+}
+
+func TestGenerateValidation(t *testing.T) {
+	dir := t.TempDir()
+
+	if _, err := generate(dir, options{}, false); err == nil {
+		t.Error("Expected error without a module path")
+	}
+	if _, err := generate(dir, options{Module: "example.com/svc", Name: "Bad Name"}, false); err == nil {
+		t.Error("Expected error for an invalid name")
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("Expected nothing written on error, got %d entries", len(entries))
+	}
+}
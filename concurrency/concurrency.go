@@ -0,0 +1,302 @@
+// Package concurrency limits the requests in flight to each destination,
+// shrinking a limit as the destination's latency rises above its unloaded
+// baseline so slow shards receive less concurrent work instead of queueing it.
+
+package concurrency
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/mohdrashid9678/dcore/clock"
+)
+
+var (
+	// ErrLimitExceeded is returned when a destination already has as many
+	// requests in flight as its limit allows
+	ErrLimitExceeded = errors.New("concurrency limit exceeded")
+
+	// ErrEmptyDestination is returned when an empty destination name is provided
+	ErrEmptyDestination = errors.New("destination cannot be empty")
+)
+
+// Config holds configuration options for creating a new Limiter
+type Config struct {
+	// InitialLimit is the limit of a destination until its first window closes
+	// Default: 20
+	InitialLimit int
+
+	// MinLimit is the smallest limit ever set
+	// Default: 1
+	MinLimit int
+
+	// MaxLimit is the largest limit ever set
+	// Default: 1000
+	MaxLimit int
+
+	// Window is how long completions are counted to measure throughput
+	// Default: 1s
+	Window time.Duration
+
+	// History is the number of windows the baseline latency and the peak
+	// throughput are taken over; older windows are forgotten so the limit
+	// follows a destination whose capacity changes
+	// Default: 10
+	History int
+
+	// Queue is how many requests the limit allows beyond the latency
+	// gradient's estimate, and how much it grows per window while latency
+	// holds; zero uses the square root of the current limit
+	// Default: 0
+	Queue int
+
+	// Tolerance is how far a window's average latency may rise above the
+	// baseline before the limit shrinks
+	// Default: 1.5
+	Tolerance float64
+
+	// Clock is the time source
+	// Default: clock.Real()
+	Clock clock.Clock
+}
+
+// Limiter enforces an adaptive concurrency limit per destination
+// At the end of each window the limit follows the latency gradient, the
+// baseline latency over the window's average latency: when latency rises past
+// Tolerance times the baseline the limit shrinks to limit * gradient + Queue,
+// and while it holds the limit grows by Queue if the window used at least
+// half of it. The baseline is the lowest latency over the last History
+// windows
+// The limit never drops below the requests the destination served at
+// baseline latency: by Little's law its peak throughput times the baseline
+// Requests beyond the limit fail fast with ErrLimitExceeded, so a shard that
+// slows down sees its queue bounded rather than every caller timing out
+type Limiter struct {
+	// mu protects dests and every destination in it
+	mu sync.Mutex
+
+	// config holds the validated configuration
+	config Config
+
+	// dests maps destination names to their state
+	dests map[string]*destination
+}
+
+// destination is the limiter state of a single destination
+type destination struct {
+	// limit is the current concurrency limit
+	limit int
+
+	// inflight is the number of requests currently in progress
+	inflight int
+
+	// start is when the current window began
+	start time.Time
+
+	// completions counts the requests completed in the current window
+	completions int
+
+	// minLatency is the lowest latency seen in the current window
+	minLatency time.Duration
+
+	// totalLatency sums the latencies seen in the current window
+	totalLatency time.Duration
+
+	// peakInflight is the most requests in flight during the current window
+	peakInflight int
+
+	// windows holds the closed windows, oldest first
+	windows []window
+}
+
+// window is the measurement of one closed window
+type window struct {
+	throughput float64 // Completions per second
+	minLatency time.Duration
+}
+
+// New creates a new limiter with the given configuration
+func New(config Config) *Limiter {
+	if config.MinLimit <= 0 {
+		config.MinLimit = 1
+	}
+	if config.MaxLimit <= 0 {
+		config.MaxLimit = 1000
+	}
+	if config.MaxLimit < config.MinLimit {
+		config.MaxLimit = config.MinLimit
+	}
+	if config.InitialLimit <= 0 {
+		config.InitialLimit = 20
+	}
+	config.InitialLimit = min(max(config.InitialLimit, config.MinLimit), config.MaxLimit)
+	if config.Window <= 0 {
+		config.Window = time.Second
+	}
+	if config.History <= 0 {
+		config.History = 10
+	}
+	if config.Queue < 0 {
+		config.Queue = 0
+	}
+	if config.Tolerance < 1 {
+		config.Tolerance = 1.5
+	}
+	config.Clock = clock.OrReal(config.Clock)
+
+	return &Limiter{
+		config: config,
+		dests:  make(map[string]*destination),
+	}
+}
+
+// Acquire admits a request to dest if it is under its limit
+// The returned done function must be called exactly once when the request
+// finishes; ok reports whether it completed, and requests that failed or were
+// cancelled do not count toward the capacity estimate
+// Returns ErrLimitExceeded if dest is at its limit
+func (l *Limiter) Acquire(dest string) (func(ok bool), error) {
+	if dest == "" {
+		return nil, ErrEmptyDestination
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	d := l.destinationLocked(dest)
+	if d.inflight >= d.limit {
+		return nil, ErrLimitExceeded
+	}
+	d.inflight++
+	d.peakInflight = max(d.peakInflight, d.inflight)
+
+	start := l.config.Clock.Now()
+	var once sync.Once
+	return func(ok bool) {
+		once.Do(func() { l.release(dest, d, start, ok) })
+	}, nil
+}
+
+// release ends a request to d started at start and closes the window if it
+// is due
+func (l *Limiter) release(dest string, d *destination, start time.Time, ok bool) {
+	now := l.config.Clock.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.dests[dest] != d {
+		return // Forgotten while the request was in flight
+	}
+	d.inflight--
+
+	if ok {
+		latency := now.Sub(start)
+		if d.completions == 0 || latency < d.minLatency {
+			d.minLatency = latency
+		}
+		d.totalLatency += latency
+		d.completions++
+	}
+
+	if elapsed := now.Sub(d.start); elapsed >= l.config.Window {
+		l.closeWindowLocked(d, elapsed)
+		d.start = now
+	}
+}
+
+// closeWindowLocked records the window that lasted elapsed and recomputes
+// the limit
+// Caller must hold l.mu
+func (l *Limiter) closeWindowLocked(d *destination, elapsed time.Duration) {
+	completions, latency, peakInflight := d.completions, d.totalLatency, d.peakInflight
+	d.completions, d.totalLatency, d.peakInflight = 0, 0, 0
+	if completions == 0 {
+		return
+	}
+
+	d.windows = append(d.windows, window{
+		throughput: float64(completions) / elapsed.Seconds(),
+		minLatency: d.minLatency,
+	})
+	if len(d.windows) > l.config.History {
+		d.windows = d.windows[1:]
+	}
+
+	var peak float64
+	baseline := time.Duration(math.MaxInt64)
+	for _, w := range d.windows {
+		peak = max(peak, w.throughput)
+		baseline = min(baseline, w.minLatency)
+	}
+
+	limit := float64(d.limit)
+	queue := float64(l.config.Queue)
+	if queue == 0 {
+		queue = math.Ceil(math.Sqrt(limit))
+	}
+
+	average := latency / time.Duration(completions)
+	gradient := l.config.Tolerance * float64(baseline) / float64(max(average, 1))
+	switch {
+	case gradient < 1:
+		// Latency rose: shrink, by at most half per window
+		limit = min(limit, math.Ceil(limit*max(gradient, 0.5)+queue))
+	case peakInflight*2 >= d.limit:
+		// Latency held under load: probe for more capacity
+		limit += queue
+	}
+
+	// Never below what the destination served without queueing
+	floor := math.Ceil(peak * baseline.Seconds())
+	limit = max(limit, floor)
+
+	d.limit = int(min(max(limit, float64(l.config.MinLimit)), float64(l.config.MaxLimit)))
+}
+
+// Limit returns the current concurrency limit of dest
+func (l *Limiter) Limit(dest string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if d, exists := l.dests[dest]; exists {
+		return d.limit
+	}
+	return l.config.InitialLimit
+}
+
+// Inflight returns the number of requests currently in progress at dest
+func (l *Limiter) Inflight(dest string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if d, exists := l.dests[dest]; exists {
+		return d.inflight
+	}
+	return 0
+}
+
+// Forget drops the state of dest, such as after it leaves the ring
+// Requests still in flight are released without effect
+func (l *Limiter) Forget(dest string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.dests, dest)
+}
+
+// destinationLocked returns the state of dest, creating it if needed
+// Caller must hold l.mu
+func (l *Limiter) destinationLocked(dest string) *destination {
+	d, exists := l.dests[dest]
+	if !exists {
+		d = &destination{
+			limit: l.config.InitialLimit,
+			start: l.config.Clock.Now(),
+		}
+		l.dests[dest] = d
+	}
+	return d
+}
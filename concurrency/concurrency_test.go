@@ -0,0 +1,205 @@
+package concurrency
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mohdrashid9678/dcore/clock"
+)
+
+// serve completes n sequential requests to dest, each taking latency
+func serve(t *testing.T, l *Limiter, clk *clock.Fake, dest string, n int, latency time.Duration) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		done, err := l.Acquire(dest)
+		if err != nil {
+			t.Fatalf("expected request %d admitted, got %v", i, err)
+		}
+		clk.Advance(latency)
+		done(true)
+	}
+}
+
+func TestAcquireEnforcesLimit(t *testing.T) {
+	l := New(Config{InitialLimit: 2})
+
+	done1, err := l.Acquire("server1")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, err := l.Acquire("server1"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, err := l.Acquire("server1"); err != ErrLimitExceeded {
+		t.Errorf("expected ErrLimitExceeded, got %v", err)
+	}
+
+	// Other destinations have their own limit
+	if _, err := l.Acquire("server2"); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+
+	done1(true)
+	done1(true) // Calling twice releases once
+	if n := l.Inflight("server1"); n != 1 {
+		t.Errorf("expected 1 in flight, got %d", n)
+	}
+	if _, err := l.Acquire("server1"); err != nil {
+		t.Errorf("expected a slot after release, got %v", err)
+	}
+
+	if _, err := l.Acquire(""); err != ErrEmptyDestination {
+		t.Errorf("expected ErrEmptyDestination, got %v", err)
+	}
+}
+
+// saturate runs rounds of requests to dest, each admitting as many as the
+// limit allows and completing them after latency(n) for n in flight
+func saturate(t *testing.T, l *Limiter, clk *clock.Fake, dest string, rounds int, latency func(n int) time.Duration) {
+	t.Helper()
+	for i := 0; i < rounds; i++ {
+		var dones []func(bool)
+		for {
+			done, err := l.Acquire(dest)
+			if err != nil {
+				break
+			}
+			dones = append(dones, done)
+		}
+		clk.Advance(latency(len(dones)))
+		for _, done := range dones {
+			done(true)
+		}
+	}
+}
+
+// capacity returns the latency of a destination that serves n requests
+// at a time in 10ms and queues the rest
+func capacity(n int) func(int) time.Duration {
+	return func(inflight int) time.Duration {
+		return 10 * time.Millisecond * time.Duration(max(inflight, n)) / time.Duration(n)
+	}
+}
+
+func TestLimitGrowsWhileLatencyHolds(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	l := New(Config{Clock: clk, InitialLimit: 8, Queue: 2})
+
+	if limit := l.Limit("server1"); limit != 8 {
+		t.Fatalf("expected initial limit 8, got %d", limit)
+	}
+
+	// Eight in flight at a steady 10ms for one window
+	saturate(t, l, clk, "server1", 100, capacity(100))
+	if limit := l.Limit("server1"); limit != 10 {
+		t.Errorf("expected limit 10, got %d", limit)
+	}
+
+	// An idle destination does not grow its limit
+	serve(t, l, clk, "server1", 100, 10*time.Millisecond)
+	if limit := l.Limit("server1"); limit != 10 {
+		t.Errorf("expected limit 10 while under-used, got %d", limit)
+	}
+}
+
+func TestSlowDestinationGetsLowerLimit(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	l := New(Config{Clock: clk, Queue: 1})
+
+	// Both destinations learn a 10ms baseline unloaded
+	serve(t, l, clk, "fast", 100, 10*time.Millisecond)
+	serve(t, l, clk, "slow", 100, 10*time.Millisecond)
+
+	// The fast destination takes 40 at a time without queueing, the slow one
+	// queues beyond 4
+	saturate(t, l, clk, "fast", 500, capacity(40))
+	saturate(t, l, clk, "slow", 500, capacity(4))
+
+	if limit := l.Limit("fast"); limit <= 20 {
+		t.Errorf("expected the fast limit to grow past 20, got %d", limit)
+	}
+	if limit := l.Limit("slow"); limit != 7 {
+		t.Errorf("expected the slow limit to settle at 7, got %d", limit)
+	}
+}
+
+func TestLimitKeepsBaselineInflight(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	l := New(Config{Clock: clk, InitialLimit: 8, Queue: 1})
+
+	// Eight in flight at 10ms, then a window of slow requests
+	saturate(t, l, clk, "server1", 100, capacity(100))
+	saturate(t, l, clk, "server1", 12, capacity(1))
+	if limit := l.Limit("server1"); limit != 8 {
+		t.Errorf("expected the limit held at the 8 served at baseline, got %d", limit)
+	}
+}
+
+func TestFailedRequestsIgnored(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	l := New(Config{Clock: clk})
+
+	for i := 0; i < 20; i++ {
+		done, _ := l.Acquire("server1")
+		clk.Advance(100 * time.Millisecond)
+		done(false)
+	}
+	if limit := l.Limit("server1"); limit != 20 {
+		t.Errorf("expected failures to leave the limit at 20, got %d", limit)
+	}
+}
+
+func TestHistoryForgetsOldWindows(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	l := New(Config{Clock: clk, History: 2, InitialLimit: 8, Queue: 1})
+
+	// Eight in flight at 10ms, then the destination degrades to 100ms
+	saturate(t, l, clk, "server1", 100, capacity(100))
+	slow := func(int) time.Duration { return 100 * time.Millisecond }
+	saturate(t, l, clk, "server1", 10, slow)
+	if limit := l.Limit("server1"); limit != 8 {
+		t.Fatalf("expected limit 8 against the 10ms baseline, got %d", limit)
+	}
+
+	// Once the fast windows age out 100ms is the baseline and the limit
+	// grows again
+	saturate(t, l, clk, "server1", 20, slow)
+	if limit := l.Limit("server1"); limit <= 8 {
+		t.Errorf("expected the limit to grow once the fast windows aged out, got %d", limit)
+	}
+}
+
+func TestBounds(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	l := New(Config{Clock: clk, MinLimit: 4, MaxLimit: 6, InitialLimit: 50, Queue: 1})
+
+	if limit := l.Limit("server1"); limit != 6 {
+		t.Errorf("expected initial limit clamped to 6, got %d", limit)
+	}
+
+	saturate(t, l, clk, "server1", 300, capacity(100))
+	if limit := l.Limit("server1"); limit != 6 {
+		t.Errorf("expected limit capped at MaxLimit 6, got %d", limit)
+	}
+
+	serve(t, l, clk, "server2", 100, 10*time.Millisecond)
+	saturate(t, l, clk, "server2", 100, capacity(1))
+	if limit := l.Limit("server2"); limit != 4 {
+		t.Errorf("expected limit held at MinLimit 4, got %d", limit)
+	}
+}
+
+func TestForget(t *testing.T) {
+	l := New(Config{InitialLimit: 1})
+
+	done, _ := l.Acquire("server1")
+	l.Forget("server1")
+	if _, err := l.Acquire("server1"); err != nil {
+		t.Errorf("expected a fresh limit after Forget, got %v", err)
+	}
+
+	done(true) // Released without effect on the new state
+	if n := l.Inflight("server1"); n != 1 {
+		t.Errorf("expected 1 in flight, got %d", n)
+	}
+}
@@ -0,0 +1,264 @@
+// Package cursor implements opaque pagination cursors for scatter-gather list
+// APIs that stay consistent when the ring topology changes between pages.
+
+package cursor
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/mohdrashid9678/dcore/chash"
+	"github.com/mohdrashid9678/dcore/chash/core"
+)
+
+var (
+	// ErrInvalidCursor is returned when decoding a malformed cursor token
+	ErrInvalidCursor = errors.New("invalid pagination cursor")
+
+	// ErrNoRing is returned when a scan is planned without a ring
+	ErrNoRing = errors.New("cursor requires a ring")
+
+	// ErrNoNodes is returned when a scan is planned on an empty ring
+	ErrNoNodes = errors.New("no nodes in ring to scan")
+
+	// ErrNoFetcher is returned when a page is requested without a Fetch function
+	ErrNoFetcher = errors.New("cursor requires a Fetch function")
+)
+
+// cursorVersion prefixes the encoding so the format can evolve
+const cursorVersion = 1
+
+// Task is the part of a scan still to be done on one shard: the keys whose
+// hash lies in Range, listed in ring order starting after Range.Start
+type Task struct {
+	Shard string
+	Range chash.Range
+}
+
+// Cursor is the position of a scan across every shard
+// Progress is tracked in the hash space rather than per shard, so a cursor
+// taken under one topology can be re-planned under another without skipping
+// or repeating keys
+type Cursor struct {
+	// Epoch is the ring's topology change count the tasks were planned under
+	Epoch uint64
+
+	// Tasks are the remaining ranges, in ring order; none means the scan is done
+	Tasks []Task
+}
+
+// Done returns true if the scan has no ranges left
+func (c Cursor) Done() bool {
+	return len(c.Tasks) == 0
+}
+
+// Plan returns a cursor for a full scan of ring, one task per owned range
+func Plan(ring *chash.Ring) (Cursor, error) {
+	if ring == nil {
+		return Cursor{}, ErrNoRing
+	}
+
+	// Read the epoch before the table: if the ring changes in between, the
+	// cursor looks stale and is re-planned on resume, which is always safe
+	epoch := ring.Version()
+
+	table, names := ring.Table()
+	if table.Len() == 0 {
+		return Cursor{}, ErrNoNodes
+	}
+
+	return Cursor{Epoch: epoch, Tasks: split(table, names, chash.Range{Start: 0, End: 0})}, nil
+}
+
+// Resume returns the cursor re-planned against the ring's current topology
+// If the epoch is unchanged the cursor is returned as is; otherwise every
+// remaining range is split at the current owners so moved ranges are read
+// from their new shard. The second result reports whether re-planning happened
+func Resume(ring *chash.Ring, c Cursor) (Cursor, bool, error) {
+	if ring == nil {
+		return Cursor{}, false, ErrNoRing
+	}
+
+	epoch := ring.Version()
+	if epoch == c.Epoch || c.Done() {
+		return c, false, nil
+	}
+
+	table, names := ring.Table()
+	if table.Len() == 0 {
+		return Cursor{}, false, ErrNoNodes
+	}
+
+	replanned := Cursor{Epoch: epoch}
+	for _, task := range c.Tasks {
+		replanned.Tasks = append(replanned.Tasks, split(table, names, task.Range)...)
+	}
+	return replanned, true, nil
+}
+
+// split divides rng at the virtual nodes of table into maximal ranges with a
+// single owner, in ring order starting after rng.Start
+func split(table core.Table, names []string, rng chash.Range) []Task {
+	// Virtual node positions inside the range, clockwise from its start
+	var cuts []uint64
+	first := sort.Search(len(table.Points), func(i int) bool {
+		return table.Points[i] > rng.Start
+	})
+	for k := 0; k < len(table.Points); k++ {
+		p := table.Points[(first+k)%len(table.Points)]
+		if !rng.Contains(p) || (len(cuts) > 0 && cuts[len(cuts)-1] == p) {
+			continue
+		}
+		cuts = append(cuts, p)
+	}
+	if len(cuts) == 0 || cuts[len(cuts)-1] != rng.End {
+		cuts = append(cuts, rng.End)
+	}
+
+	var tasks []Task
+	start := rng.Start
+	for _, end := range cuts {
+		shard := names[table.Lookup(end)]
+		if n := len(tasks); n > 0 && tasks[n-1].Shard == shard {
+			tasks[n-1].Range.End = end
+		} else {
+			tasks = append(tasks, Task{Shard: shard, Range: chash.Range{Start: start, End: end}})
+		}
+		start = end
+	}
+	return tasks
+}
+
+// Encode returns the cursor as an opaque URL-safe token
+// A finished scan encodes as the empty string
+func (c Cursor) Encode() string {
+	if c.Done() {
+		return ""
+	}
+
+	// Shard names repeat across tasks, so they are stored once
+	index := make(map[string]uint64)
+	var shards []string
+	for _, task := range c.Tasks {
+		if _, ok := index[task.Shard]; !ok {
+			index[task.Shard] = uint64(len(shards))
+			shards = append(shards, task.Shard)
+		}
+	}
+
+	buf := []byte{cursorVersion}
+	buf = binary.AppendUvarint(buf, c.Epoch)
+	buf = binary.AppendUvarint(buf, uint64(len(shards)))
+	for _, shard := range shards {
+		buf = binary.AppendUvarint(buf, uint64(len(shard)))
+		buf = append(buf, shard...)
+	}
+	buf = binary.AppendUvarint(buf, uint64(len(c.Tasks)))
+	for _, task := range c.Tasks {
+		buf = binary.AppendUvarint(buf, index[task.Shard])
+		buf = binary.BigEndian.AppendUint64(buf, task.Range.Start)
+		buf = binary.BigEndian.AppendUint64(buf, task.Range.End)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
+
+// Decode parses a token returned by Encode
+// The empty string decodes to a finished cursor
+func Decode(token string) (Cursor, error) {
+	if token == "" {
+		return Cursor{}, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return Cursor{}, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	d := decoder{data: data}
+
+	if version := d.byte(); d.ok() && version != cursorVersion {
+		return Cursor{}, fmt.Errorf("%w: unsupported version %d", ErrInvalidCursor, version)
+	}
+
+	c := Cursor{Epoch: d.uvarint()}
+
+	var shards []string
+	for n := d.uvarint(); n > 0 && d.ok(); n-- {
+		shard := string(d.bytes(d.uvarint()))
+		if shard == "" {
+			d.fail()
+		}
+		shards = append(shards, shard)
+	}
+
+	for n := d.uvarint(); n > 0 && d.ok(); n-- {
+		idx := d.uvarint()
+		task := Task{Range: chash.Range{Start: d.uint64(), End: d.uint64()}}
+		if idx >= uint64(len(shards)) {
+			d.fail()
+			break
+		}
+		task.Shard = shards[idx]
+		c.Tasks = append(c.Tasks, task)
+	}
+
+	if !d.ok() || len(d.data) != 0 || len(c.Tasks) == 0 {
+		return Cursor{}, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// decoder reads an encoded cursor, failing on the first malformed field
+type decoder struct {
+	data   []byte
+	failed bool
+}
+
+func (d *decoder) ok() bool {
+	return !d.failed
+}
+
+func (d *decoder) fail() {
+	d.failed = true
+	d.data = nil
+}
+
+func (d *decoder) bytes(n uint64) []byte {
+	if d.failed || n > uint64(len(d.data)) {
+		d.fail()
+		return nil
+	}
+	b := d.data[:n]
+	d.data = d.data[n:]
+	return b
+}
+
+func (d *decoder) byte() byte {
+	if b := d.bytes(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (d *decoder) uint64() uint64 {
+	if b := d.bytes(8); b != nil {
+		return binary.BigEndian.Uint64(b)
+	}
+	return 0
+}
+
+func (d *decoder) uvarint() uint64 {
+	if d.failed {
+		return 0
+	}
+	v, n := binary.Uvarint(d.data)
+	if n <= 0 {
+		d.fail()
+		return 0
+	}
+	d.data = d.data[n:]
+	return v
+}
@@ -0,0 +1,145 @@
+package cursor
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mohdrashid9678/dcore/chash"
+)
+
+// coverage returns the share of the hash space covered by the tasks
+func coverage(tasks []Task) float64 {
+	total := 0.0
+	for _, task := range tasks {
+		total += task.Range.Fraction()
+	}
+	return total
+}
+
+// checkOwners fails if any task is not owned by its shard in ring
+func checkOwners(t *testing.T, ring *chash.Ring, tasks []Task) {
+	t.Helper()
+
+	for _, task := range tasks {
+		owned := false
+		for _, rng := range ring.OwnedRanges(task.Shard) {
+			if rng.Contains(task.Range.End) {
+				owned = true
+			}
+		}
+		if !owned {
+			t.Errorf("task %v is not owned by %s", task.Range, task.Shard)
+		}
+	}
+}
+
+func TestPlanCoversRing(t *testing.T) {
+	ring := chash.NewWithNodes(chash.Config{Replicas: 10}, []string{"server1", "server2", "server3"})
+
+	c, err := Plan(ring)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if c.Epoch != ring.GetStats().TopologyChanges {
+		t.Errorf("expected epoch %d, got %d", ring.GetStats().TopologyChanges, c.Epoch)
+	}
+	if f := coverage(c.Tasks); f < 0.999999 || f > 1.000001 {
+		t.Errorf("expected plan to cover the ring once, got %v", f)
+	}
+	checkOwners(t, ring, c.Tasks)
+
+	if _, err := Plan(chash.New(chash.Config{})); !errors.Is(err, ErrNoNodes) {
+		t.Errorf("expected ErrNoNodes, got %v", err)
+	}
+}
+
+func TestPlanSingleNode(t *testing.T) {
+	ring := chash.NewWithNodes(chash.Config{Replicas: 10}, []string{"server1"})
+
+	c, err := Plan(ring)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(c.Tasks) != 1 || c.Tasks[0].Range.Start != c.Tasks[0].Range.End {
+		t.Errorf("expected one whole-ring task, got %v", c.Tasks)
+	}
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	ring := chash.NewWithNodes(chash.Config{Replicas: 10}, []string{"server1", "server2", "server3"})
+
+	c, err := Plan(ring)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	decoded, err := Decode(c.Encode())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if decoded.Epoch != c.Epoch || len(decoded.Tasks) != len(c.Tasks) {
+		t.Fatalf("expected %d tasks at epoch %d, got %d at %d", len(c.Tasks), c.Epoch, len(decoded.Tasks), decoded.Epoch)
+	}
+	for i := range c.Tasks {
+		if decoded.Tasks[i] != c.Tasks[i] {
+			t.Errorf("task %d: expected %v, got %v", i, c.Tasks[i], decoded.Tasks[i])
+		}
+	}
+
+	if token := (Cursor{Epoch: 5}).Encode(); token != "" {
+		t.Errorf("expected empty token for a finished scan, got %q", token)
+	}
+	if done, err := Decode(""); err != nil || !done.Done() {
+		t.Errorf("expected empty token to decode as done, got %v, %v", done, err)
+	}
+}
+
+func TestDecodeRejectsMalformed(t *testing.T) {
+	ring := chash.NewWithNodes(chash.Config{Replicas: 10}, []string{"server1", "server2"})
+
+	c, err := Plan(ring)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	token := c.Encode()
+
+	for _, bad := range []string{"!!!", token[:len(token)-3], token + "AA", "Ag"} {
+		if _, err := Decode(bad); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("%q: expected ErrInvalidCursor, got %v", bad, err)
+		}
+	}
+}
+
+func TestResumeReplansMovedRanges(t *testing.T) {
+	ring := chash.NewWithNodes(chash.Config{Replicas: 10}, []string{"server1", "server2", "server3"})
+
+	c, err := Plan(ring)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// Pretend the first half of the tasks are done
+	c.Tasks = c.Tasks[len(c.Tasks)/2:]
+	remaining := coverage(c.Tasks)
+
+	same, replanned, err := Resume(ring, c)
+	if err != nil || replanned || len(same.Tasks) != len(c.Tasks) {
+		t.Fatalf("expected unchanged cursor, got %d tasks, replanned %v, err %v", len(same.Tasks), replanned, err)
+	}
+
+	if err := ring.AddNode("server4"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	moved, replanned, err := Resume(ring, c)
+	if err != nil || !replanned {
+		t.Fatalf("expected re-planned cursor, got replanned %v, err %v", replanned, err)
+	}
+	if moved.Epoch != ring.GetStats().TopologyChanges {
+		t.Errorf("expected epoch %d, got %d", ring.GetStats().TopologyChanges, moved.Epoch)
+	}
+	if f := coverage(moved.Tasks); f < remaining-1e-9 || f > remaining+1e-9 {
+		t.Errorf("expected re-planned tasks to cover %v, got %v", remaining, f)
+	}
+	checkOwners(t, ring, moved.Tasks)
+}
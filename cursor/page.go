@@ -0,0 +1,152 @@
+package cursor
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/mohdrashid9678/dcore/chash"
+)
+
+// Item is a listed value and the ring hash of its key
+type Item[T any] struct {
+	Hash  uint64
+	Value T
+}
+
+// FetchFunc lists up to limit items stored on task.Shard whose key hash lies
+// in task.Range, in ring order starting after task.Range.Start
+// For a range with Start > End that is the hashes above Start followed by
+// the hashes up to End. Returning fewer than limit items ends the range
+type FetchFunc[T any] func(ctx context.Context, task Task, limit int) ([]Item[T], error)
+
+// PageConfig holds configuration options for a page
+type PageConfig[T any] struct {
+	// Ring plans the scan and detects topology changes (required)
+	Ring *chash.Ring
+
+	// Fetch lists items from one shard range (required)
+	Fetch FetchFunc[T]
+
+	// Limit is the maximum number of items in the page
+	// Default: 100
+	Limit int
+
+	// Concurrency is the number of ranges fetched in parallel
+	// Default: 4
+	Concurrency int
+}
+
+// Page is one page of a scan
+type Page[T any] struct {
+	// Items are the listed items in scan order
+	Items []Item[T]
+
+	// Next is the token for the following page, or "" when the scan is done
+	Next string
+
+	// Replanned reports that the ring changed since the token was issued and
+	// the remaining ranges were re-planned
+	Replanned bool
+}
+
+// Fetch returns the page of the scan at token; the empty token starts a scan
+// Ranges are fetched in waves of Concurrency until the page is full, and each
+// range's position advances only past the items that made it into the page,
+// so over-fetched items are returned again by the next page
+// On error no position advances and the same token can be retried
+func Fetch[T any](ctx context.Context, config PageConfig[T], token string) (Page[T], error) {
+	if config.Ring == nil {
+		return Page[T]{}, ErrNoRing
+	}
+	if config.Fetch == nil {
+		return Page[T]{}, ErrNoFetcher
+	}
+
+	if config.Limit <= 0 {
+		config.Limit = 100 // Default page size
+	}
+	if config.Concurrency <= 0 {
+		config.Concurrency = 4 // Default fetch concurrency
+	}
+
+	if token == "" {
+		c, err := Plan(config.Ring)
+		if err != nil {
+			return Page[T]{}, err
+		}
+		return fetch(ctx, config, c)
+	}
+
+	c, err := Decode(token)
+	if err != nil || c.Done() {
+		return Page[T]{}, err
+	}
+
+	c, replanned, err := Resume(config.Ring, c)
+	if err != nil {
+		return Page[T]{}, err
+	}
+
+	page, err := fetch(ctx, config, c)
+	page.Replanned = replanned && err == nil
+	return page, err
+}
+
+// fetch fills one page from the tasks of c
+func fetch[T any](ctx context.Context, config PageConfig[T], c Cursor) (Page[T], error) {
+	var page Page[T]
+	tasks := append([]Task(nil), c.Tasks...)
+
+	i := 0
+	for i < len(tasks) && len(page.Items) < config.Limit {
+		need := config.Limit - len(page.Items)
+		wave := tasks[i:min(i+config.Concurrency, len(tasks))]
+
+		results := make([][]Item[T], len(wave))
+		errs := make([]error, len(wave))
+
+		var wg sync.WaitGroup
+		for j, task := range wave {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				results[j], errs[j] = config.Fetch(ctx, task, need)
+			}()
+		}
+		wg.Wait()
+
+		if err := errors.Join(errs...); err != nil {
+			return Page[T]{}, err
+		}
+
+		// Consume the wave in ring order until the page is full
+		for j, items := range results {
+			if len(page.Items) == config.Limit {
+				break
+			}
+
+			take := min(len(items), config.Limit-len(page.Items))
+			page.Items = append(page.Items, items[:take]...)
+
+			if take == len(items) && len(items) < need {
+				// The range is exhausted
+				i++
+				continue
+			}
+			if take > 0 {
+				last := items[take-1].Hash
+				if last == wave[j].Range.End {
+					i++
+					continue
+				}
+				tasks[i].Range.Start = last
+			}
+			break
+		}
+	}
+
+	c.Tasks = tasks[i:]
+	page.Next = c.Encode()
+	return page, nil
+}
@@ -0,0 +1,166 @@
+package cursor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/mohdrashid9678/dcore/chash"
+)
+
+// store lists keys from the shard that owns them in the ring's current topology,
+// as if data moved the moment the topology changed
+func store(ring *chash.Ring, keys int) FetchFunc[string] {
+	all := make([]Item[string], keys)
+	for i := range all {
+		key := fmt.Sprintf("key-%d", i)
+		all[i] = Item[string]{Hash: chash.DefaultHashFunc(key), Value: key}
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Hash < all[j].Hash })
+
+	return func(ctx context.Context, task Task, limit int) ([]Item[string], error) {
+		// Ring order after Start: hashes above Start, then the wrapped ones
+		first := sort.Search(len(all), func(i int) bool { return all[i].Hash > task.Range.Start })
+		ordered := append(append([]Item[string](nil), all[first:]...), all[:first]...)
+
+		var items []Item[string]
+		for _, item := range ordered {
+			if len(items) == limit {
+				break
+			}
+			if !task.Range.Contains(item.Hash) {
+				continue
+			}
+			if owner, _ := ring.GetNode(item.Value); owner != task.Shard {
+				continue
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	}
+}
+
+// scan reads every page, calling between after each one, and counts the keys
+func scan(t *testing.T, config PageConfig[string], between func(page int)) (map[string]int, bool) {
+	t.Helper()
+
+	seen := make(map[string]int)
+	replanned := false
+	token := ""
+	for page := 0; ; page++ {
+		if page > 1000 {
+			t.Fatal("scan did not finish")
+		}
+
+		p, err := Fetch(context.Background(), config, token)
+		if err != nil {
+			t.Fatalf("page %d: expected no error, got %v", page, err)
+		}
+		if len(p.Items) > config.Limit {
+			t.Fatalf("page %d: expected at most %d items, got %d", page, config.Limit, len(p.Items))
+		}
+		for _, item := range p.Items {
+			seen[item.Value]++
+		}
+		replanned = replanned || p.Replanned
+
+		if p.Next == "" {
+			return seen, replanned
+		}
+		token = p.Next
+		if between != nil {
+			between(page)
+		}
+	}
+}
+
+// checkOnce fails unless every key was listed exactly once
+func checkOnce(t *testing.T, seen map[string]int, keys int) {
+	t.Helper()
+
+	if len(seen) != keys {
+		t.Errorf("expected %d keys, got %d", keys, len(seen))
+	}
+	for key, n := range seen {
+		if n != 1 {
+			t.Errorf("expected %s once, got %d", key, n)
+		}
+	}
+}
+
+func TestFetchListsEveryKeyOnce(t *testing.T) {
+	ring := chash.NewWithNodes(chash.Config{Replicas: 20}, []string{"server1", "server2", "server3"})
+
+	seen, replanned := scan(t, PageConfig[string]{Ring: ring, Fetch: store(ring, 500), Limit: 7}, nil)
+
+	checkOnce(t, seen, 500)
+	if replanned {
+		t.Error("expected no re-planning on a stable ring")
+	}
+}
+
+func TestFetchAcrossTopologyChanges(t *testing.T) {
+	ring := chash.NewWithNodes(chash.Config{Replicas: 20}, []string{"server1", "server2", "server3"})
+
+	seen, replanned := scan(t, PageConfig[string]{Ring: ring, Fetch: store(ring, 500), Limit: 25}, func(page int) {
+		switch page {
+		case 3:
+			ring.AddNode("server4")
+		case 8:
+			ring.RemoveNode("server2")
+		}
+	})
+
+	checkOnce(t, seen, 500)
+	if !replanned {
+		t.Error("expected the scan to be re-planned")
+	}
+}
+
+func TestFetchErrorKeepsToken(t *testing.T) {
+	ring := chash.NewWithNodes(chash.Config{Replicas: 10}, []string{"server1", "server2"})
+	good := store(ring, 100)
+
+	failing := true
+	fetch := func(ctx context.Context, task Task, limit int) ([]Item[string], error) {
+		if failing {
+			return nil, errors.New("shard unavailable")
+		}
+		return good(ctx, task, limit)
+	}
+
+	config := PageConfig[string]{Ring: ring, Fetch: fetch, Limit: 10}
+	first, err := Fetch(context.Background(), PageConfig[string]{Ring: ring, Fetch: good, Limit: 10}, "")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if _, err := Fetch(context.Background(), config, first.Next); err == nil {
+		t.Fatal("expected the shard error")
+	}
+
+	failing = false
+	second, err := Fetch(context.Background(), config, first.Next)
+	if err != nil || len(second.Items) != 10 {
+		t.Fatalf("expected retry to return a full page, got %d items, err %v", len(second.Items), err)
+	}
+	if second.Items[0].Value == first.Items[0].Value {
+		t.Error("expected retry to continue after the first page")
+	}
+}
+
+func TestFetchRequiresConfig(t *testing.T) {
+	ring := chash.NewWithNodes(chash.Config{}, []string{"server1"})
+
+	if _, err := Fetch(context.Background(), PageConfig[string]{Fetch: store(ring, 1)}, ""); !errors.Is(err, ErrNoRing) {
+		t.Errorf("expected ErrNoRing, got %v", err)
+	}
+	if _, err := Fetch(context.Background(), PageConfig[string]{Ring: ring}, ""); !errors.Is(err, ErrNoFetcher) {
+		t.Errorf("expected ErrNoFetcher, got %v", err)
+	}
+	if _, err := Fetch(context.Background(), PageConfig[string]{Ring: ring, Fetch: store(ring, 1)}, "not a cursor"); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("expected ErrInvalidCursor, got %v", err)
+	}
+}
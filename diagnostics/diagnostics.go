@@ -0,0 +1,280 @@
+// Package diagnostics captures CPU, heap and goroutine profiles plus a state
+// dump when a monitored signal crosses its threshold, so transient production
+// issues leave evidence behind.
+
+package diagnostics
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime/pprof"
+	"sync"
+	"time"
+
+	"github.com/mohdrashid9678/dcore/chash"
+	"github.com/mohdrashid9678/dcore/clock"
+)
+
+var (
+	// ErrNoDir is returned when a capturer is created without an output directory
+	ErrNoDir = errors.New("diagnostics output directory is required")
+
+	// ErrInvalidTrigger is returned when a trigger has no name or value function
+	ErrInvalidTrigger = errors.New("trigger must have a name and a value function")
+)
+
+// Trigger fires when its value exceeds the threshold
+// Typical values are breaker-open rates, queue depth or lookup latency
+type Trigger struct {
+	// Name identifies the trigger in capture file names
+	Name string
+
+	// Value samples the monitored signal
+	Value func() float64
+
+	// Threshold is the value above which the trigger fires
+	Threshold float64
+}
+
+// Capture describes one set of captured diagnostics
+type Capture struct {
+	// Trigger is the name of the trigger that fired
+	Trigger string
+
+	// Value is the sampled value that crossed the threshold
+	Value float64
+
+	// Time is when the capture started
+	Time time.Time
+
+	// Files are the paths written, in capture order
+	Files []string
+
+	// Err joins any errors from individual artifacts; the rest are still written
+	Err error
+}
+
+// Config holds configuration options for creating a new Capturer
+type Config struct {
+	// Dir is where capture files are written (required)
+	Dir string
+
+	// Triggers are evaluated on every check
+	Triggers []Trigger
+
+	// Cooldown is the minimum time between captures, across all triggers
+	// Default: 10 minutes
+	Cooldown time.Duration
+
+	// CPUProfile is how long the CPU profile runs; negative disables it
+	// Default: 10 seconds
+	CPUProfile time.Duration
+
+	// StateDump writes a dump of application state alongside the profiles
+	// Default: nil (no state dump)
+	StateDump func(io.Writer) error
+
+	// OnCapture is called after each capture
+	// Default: nil
+	OnCapture func(Capture)
+
+	// Clock supplies the time for cooldowns, the CPU profile duration and the
+	// Start ticker
+	// Default: the wall clock
+	Clock clock.Clock
+}
+
+// Capturer evaluates triggers and captures diagnostics with rate limiting
+type Capturer struct {
+	config Config
+
+	// mu protects all fields below
+	mu          sync.Mutex
+	lastCapture time.Time
+	capturing   bool
+	stop        chan struct{}
+}
+
+// New creates a new capturer with the given configuration
+func New(config Config) (*Capturer, error) {
+	if config.Dir == "" {
+		return nil, ErrNoDir
+	}
+
+	for _, trigger := range config.Triggers {
+		if trigger.Name == "" || trigger.Value == nil {
+			return nil, ErrInvalidTrigger
+		}
+	}
+
+	if config.Cooldown <= 0 {
+		config.Cooldown = 10 * time.Minute // Default capture cooldown
+	}
+
+	if config.CPUProfile == 0 {
+		config.CPUProfile = 10 * time.Second // Default CPU profile duration
+	}
+
+	config.Clock = clock.OrReal(config.Clock)
+
+	return &Capturer{config: config}, nil
+}
+
+// Check evaluates the triggers and captures diagnostics for the first one
+// that fired, unless a capture ran within the cooldown or is still running
+// It returns the capture, or false if nothing was captured
+func (c *Capturer) Check() (Capture, bool) {
+	for _, trigger := range c.config.Triggers {
+		value := trigger.Value()
+		if value <= trigger.Threshold {
+			continue
+		}
+		return c.capture(trigger.Name, value)
+	}
+	return Capture{}, false
+}
+
+// CaptureNow captures diagnostics immediately under the given reason,
+// subject to the same cooldown as triggered captures
+func (c *Capturer) CaptureNow(reason string) (Capture, bool) {
+	return c.capture(reason, 0)
+}
+
+// capture writes every artifact for a fired trigger
+func (c *Capturer) capture(name string, value float64) (Capture, bool) {
+	now := c.config.Clock.Now()
+
+	c.mu.Lock()
+	if c.capturing || (!c.lastCapture.IsZero() && now.Sub(c.lastCapture) < c.config.Cooldown) {
+		c.mu.Unlock()
+		return Capture{}, false
+	}
+	c.capturing = true
+	c.lastCapture = now
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		c.capturing = false
+		c.mu.Unlock()
+	}()
+
+	capture := Capture{Trigger: name, Value: value, Time: now}
+	prefix := filepath.Join(c.config.Dir, fmt.Sprintf("%s-%s", now.UTC().Format("20060102T150405Z"), name))
+
+	var errs []error
+	write := func(suffix string, fn func(io.Writer) error) {
+		path := prefix + suffix
+		if err := writeFile(path, fn); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", suffix, err))
+			return
+		}
+		capture.Files = append(capture.Files, path)
+	}
+
+	if err := os.MkdirAll(c.config.Dir, 0o755); err != nil {
+		errs = append(errs, err)
+	} else {
+		write("-goroutine.txt", func(w io.Writer) error {
+			return pprof.Lookup("goroutine").WriteTo(w, 2)
+		})
+		write("-heap.pprof", func(w io.Writer) error {
+			return pprof.Lookup("heap").WriteTo(w, 0)
+		})
+		if c.config.StateDump != nil {
+			write("-state.json", c.config.StateDump)
+		}
+		if c.config.CPUProfile > 0 {
+			write("-cpu.pprof", func(w io.Writer) error {
+				if err := pprof.StartCPUProfile(w); err != nil {
+					return err
+				}
+				c.config.Clock.Sleep(c.config.CPUProfile)
+				pprof.StopCPUProfile()
+				return nil
+			})
+		}
+	}
+
+	capture.Err = errors.Join(errs...)
+
+	if c.config.OnCapture != nil {
+		c.config.OnCapture(capture)
+	}
+	return capture, true
+}
+
+// Start checks the triggers every interval in a background goroutine
+// Calling Start on a running capturer has no effect; call Stop to end checks
+func (c *Capturer) Start(interval time.Duration) {
+	c.mu.Lock()
+	if c.stop != nil {
+		c.mu.Unlock()
+		return
+	}
+	stop := make(chan struct{})
+	c.stop = stop
+	c.mu.Unlock()
+
+	go func() {
+		ticker := c.config.Clock.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C():
+				c.Check()
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends background checks started by Start
+func (c *Capturer) Stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.stop != nil {
+		close(c.stop)
+		c.stop = nil
+	}
+}
+
+// RingDump returns a StateDump that writes the ring's statistics and topology
+func RingDump(ring *chash.Ring) func(io.Writer) error {
+	return func(w io.Writer) error {
+		topology, err := ring.MarshalJSON()
+		if err != nil {
+			return err
+		}
+
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(struct {
+			Stats    chash.Stats     `json:"stats"`
+			Topology json.RawMessage `json:"topology"`
+		}{ring.GetStats(), topology})
+	}
+}
+
+// writeFile creates path and fills it with fn, removing it on failure
+func writeFile(path string, fn func(io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(f); err != nil {
+		f.Close()
+		os.Remove(path)
+		return err
+	}
+
+	return f.Close()
+}
@@ -0,0 +1,142 @@
+package diagnostics
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mohdrashid9678/dcore/chash"
+)
+
+func TestCaptureOnThreshold(t *testing.T) {
+	dir := t.TempDir()
+
+	var depth atomic.Int64
+	ring := chash.NewWithNodes(chash.Config{Replicas: 5}, []string{"server1", "server2"})
+
+	c, err := New(Config{
+		Dir:        dir,
+		Triggers:   []Trigger{{Name: "queue-depth", Value: func() float64 { return float64(depth.Load()) }, Threshold: 100}},
+		CPUProfile: 10 * time.Millisecond,
+		StateDump:  RingDump(ring),
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if _, ok := c.Check(); ok {
+		t.Error("expected no capture below threshold")
+	}
+
+	depth.Store(500)
+	capture, ok := c.Check()
+	if !ok {
+		t.Fatal("expected capture above threshold")
+	}
+	if capture.Err != nil {
+		t.Fatalf("expected no capture errors, got %v", capture.Err)
+	}
+	if capture.Trigger != "queue-depth" || capture.Value != 500 {
+		t.Errorf("unexpected capture %+v", capture)
+	}
+
+	suffixes := []string{"-goroutine.txt", "-heap.pprof", "-state.json", "-cpu.pprof"}
+	if len(capture.Files) != len(suffixes) {
+		t.Fatalf("expected %d files, got %v", len(suffixes), capture.Files)
+	}
+	for i, suffix := range suffixes {
+		if !strings.HasSuffix(capture.Files[i], suffix) {
+			t.Errorf("expected file %d to end in %s, got %s", i, suffix, capture.Files[i])
+		}
+		if info, err := os.Stat(capture.Files[i]); err != nil || info.Size() == 0 {
+			t.Errorf("expected non-empty %s, got %v", capture.Files[i], err)
+		}
+	}
+
+	// The state dump holds the ring's stats and topology
+	data, _ := os.ReadFile(capture.Files[2])
+	var dump struct {
+		Stats    chash.Stats
+		Topology struct{ Nodes []struct{ Name string } }
+	}
+	if err := json.Unmarshal(data, &dump); err != nil {
+		t.Fatalf("expected valid state dump, got %v", err)
+	}
+	if dump.Stats.PhysicalNodes != 2 || len(dump.Topology.Nodes) != 2 {
+		t.Errorf("unexpected state dump %s", data)
+	}
+}
+
+func TestCaptureCooldown(t *testing.T) {
+	captures := 0
+	c, _ := New(Config{
+		Dir:        t.TempDir(),
+		Triggers:   []Trigger{{Name: "latency", Value: func() float64 { return 1 }, Threshold: 0.5}},
+		CPUProfile: -1,
+		Cooldown:   time.Hour,
+		OnCapture:  func(Capture) { captures++ },
+	})
+
+	c.Check()
+	c.Check()
+	if _, ok := c.CaptureNow("manual"); ok {
+		t.Error("expected manual capture to respect cooldown")
+	}
+
+	if captures != 1 {
+		t.Errorf("expected one capture within cooldown, got %d", captures)
+	}
+}
+
+func TestCapturePartialFailure(t *testing.T) {
+	c, _ := New(Config{
+		Dir:        t.TempDir(),
+		CPUProfile: -1,
+		StateDump:  func(io.Writer) error { return errors.New("dump failed") },
+	})
+
+	capture, ok := c.CaptureNow("manual")
+	if !ok {
+		t.Fatal("expected capture")
+	}
+	if capture.Err == nil || len(capture.Files) != 2 {
+		t.Errorf("expected profiles written despite dump failure, got %+v", capture)
+	}
+}
+
+func TestNewValidation(t *testing.T) {
+	if _, err := New(Config{}); err != ErrNoDir {
+		t.Errorf("expected ErrNoDir, got %v", err)
+	}
+	if _, err := New(Config{Dir: "x", Triggers: []Trigger{{Name: "t"}}}); err != ErrInvalidTrigger {
+		t.Errorf("expected ErrInvalidTrigger, got %v", err)
+	}
+}
+
+func TestStartStop(t *testing.T) {
+	captured := make(chan Capture, 1)
+	c, _ := New(Config{
+		Dir:        t.TempDir(),
+		Triggers:   []Trigger{{Name: "always", Value: func() float64 { return 1 }}},
+		CPUProfile: -1,
+		OnCapture:  func(cap Capture) { captured <- cap },
+	})
+
+	c.Start(time.Millisecond)
+	c.Start(time.Millisecond) // No effect while running
+	defer c.Stop()
+
+	select {
+	case cap := <-captured:
+		if cap.Trigger != "always" {
+			t.Errorf("unexpected capture %+v", cap)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected background capture")
+	}
+}
@@ -0,0 +1,231 @@
+// Package errs provides a cross-package error taxonomy so retry, breaker and
+// quorum logic can make policy decisions from error kinds rather than
+// matching error strings.
+
+package errs
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/mohdrashid9678/dcore/chash"
+)
+
+// Kind classifies a failure
+type Kind int
+
+const (
+	// Unknown is the kind of errors that carry no classification
+	Unknown Kind = iota
+
+	// Retryable marks transient failures that are safe to retry immediately
+	Retryable
+
+	// Overload marks failures caused by the destination shedding load
+	// Retrying is safe but should back off
+	Overload
+
+	// NotOwner marks requests sent to a node that does not own the key
+	// The caller should refresh its routing table and retry
+	NotOwner
+
+	// Conflict marks concurrent modification or version mismatch failures
+	Conflict
+
+	// Unavailable marks failures where the destination could not be reached
+	Unavailable
+
+	// InvariantViolation marks internal consistency errors that must never be retried
+	InvariantViolation
+)
+
+// String returns the name of the kind
+func (k Kind) String() string {
+	switch k {
+	case Retryable:
+		return "retryable"
+	case Overload:
+		return "overload"
+	case NotOwner:
+		return "not-owner"
+	case Conflict:
+		return "conflict"
+	case Unavailable:
+		return "unavailable"
+	case InvariantViolation:
+		return "invariant-violation"
+	default:
+		return "unknown"
+	}
+}
+
+// Retryable returns true if errors of this kind may succeed when retried
+func (k Kind) Retryable() bool {
+	switch k {
+	case Retryable, Overload, NotOwner, Unavailable:
+		return true
+	default:
+		return false
+	}
+}
+
+// gRPC status codes, matching google.golang.org/grpc/codes
+const (
+	grpcUnknown            uint32 = 2
+	grpcResourceExhausted  uint32 = 8
+	grpcFailedPrecondition uint32 = 9
+	grpcAborted            uint32 = 10
+	grpcInternal           uint32 = 13
+	grpcUnavailable        uint32 = 14
+)
+
+// Error is an error annotated with a Kind
+type Error struct {
+	// Kind is the classification of the failure
+	Kind Kind
+
+	// Err is the underlying error
+	Err error
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return e.Kind.String()
+	}
+	return e.Kind.String() + ": " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// New creates a new error of the given kind with the given message
+func New(kind Kind, message string) error {
+	return &Error{Kind: kind, Err: errors.New(message)}
+}
+
+// Wrap annotates err with the given kind
+// Returns nil if err is nil
+func Wrap(kind Kind, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Kind: kind, Err: err}
+}
+
+// KindOf returns the kind of err
+// The outermost *Error in the chain wins; well-known errors from the standard
+// library and dcore packages are classified when no explicit kind is present
+// chash.ErrNodeNotFound is left Unknown: it reports a membership call naming
+// a node the ring lacks, which retrying cannot fix
+func KindOf(err error) Kind {
+	if err == nil {
+		return Unknown
+	}
+
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return Retryable
+	case errors.Is(err, chash.ErrNoNodes):
+		return Unavailable
+	}
+
+	return Unknown
+}
+
+// Is returns true if err is classified as kind
+func Is(err error, kind Kind) bool {
+	return err != nil && KindOf(err) == kind
+}
+
+// IsRetryable returns true if err may succeed when retried
+func IsRetryable(err error) bool {
+	return err != nil && KindOf(err).Retryable()
+}
+
+// HTTPStatus returns the HTTP status code that best represents err
+func HTTPStatus(err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+
+	switch KindOf(err) {
+	case Retryable, Unavailable:
+		return http.StatusServiceUnavailable
+	case Overload:
+		return http.StatusTooManyRequests
+	case NotOwner:
+		return http.StatusMisdirectedRequest
+	case Conflict:
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// FromHTTPStatus returns the kind that corresponds to an HTTP status code
+func FromHTTPStatus(status int) Kind {
+	switch status {
+	case http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusGatewayTimeout:
+		return Unavailable
+	case http.StatusTooManyRequests:
+		return Overload
+	case http.StatusMisdirectedRequest:
+		return NotOwner
+	case http.StatusConflict, http.StatusPreconditionFailed:
+		return Conflict
+	case http.StatusRequestTimeout:
+		return Retryable
+	default:
+		return Unknown
+	}
+}
+
+// GRPCCode returns the gRPC status code that best represents err
+// Returns 0 (OK) if err is nil
+func GRPCCode(err error) uint32 {
+	if err == nil {
+		return 0
+	}
+
+	switch KindOf(err) {
+	case Retryable, Unavailable:
+		return grpcUnavailable
+	case Overload:
+		return grpcResourceExhausted
+	case NotOwner:
+		return grpcFailedPrecondition
+	case Conflict:
+		return grpcAborted
+	case InvariantViolation:
+		return grpcInternal
+	default:
+		return grpcUnknown
+	}
+}
+
+// FromGRPCCode returns the kind that corresponds to a gRPC status code
+func FromGRPCCode(code uint32) Kind {
+	switch code {
+	case grpcUnavailable:
+		return Unavailable
+	case grpcResourceExhausted:
+		return Overload
+	case grpcFailedPrecondition:
+		return NotOwner
+	case grpcAborted:
+		return Conflict
+	case grpcInternal:
+		return InvariantViolation
+	default:
+		return Unknown
+	}
+}
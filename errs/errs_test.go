@@ -0,0 +1,138 @@
+package errs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/mohdrashid9678/dcore/chash"
+)
+
+func TestWrapAndKindOf(t *testing.T) {
+	base := errors.New("connection refused")
+
+	if Wrap(Unavailable, nil) != nil {
+		t.Error("expected Wrap of nil to return nil")
+	}
+
+	err := Wrap(Unavailable, base)
+	if KindOf(err) != Unavailable {
+		t.Errorf("expected Unavailable, got %v", KindOf(err))
+	}
+
+	if !errors.Is(err, base) {
+		t.Error("expected wrapped error to unwrap to base")
+	}
+
+	// Kinds survive further wrapping with fmt.Errorf
+	outer := fmt.Errorf("get user: %w", err)
+	if !Is(outer, Unavailable) {
+		t.Errorf("expected Unavailable through fmt wrapping, got %v", KindOf(outer))
+	}
+
+	if err.Error() != "unavailable: connection refused" {
+		t.Errorf("unexpected error string: %s", err.Error())
+	}
+}
+
+func TestKindOfWellKnownErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected Kind
+	}{
+		{"nil", nil, Unknown},
+		{"plain error", errors.New("boom"), Unknown},
+		{"deadline", context.DeadlineExceeded, Retryable},
+		{"empty ring", chash.ErrNoNodes, Unavailable},
+		{"node not found is not retryable", chash.ErrNodeNotFound, Unknown},
+		{"explicit kind wins", Wrap(Conflict, chash.ErrNoNodes), Conflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if kind := KindOf(tt.err); kind != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, kind)
+			}
+		})
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		kind     Kind
+		expected bool
+	}{
+		{Unknown, false},
+		{Retryable, true},
+		{Overload, true},
+		{NotOwner, true},
+		{Conflict, false},
+		{Unavailable, true},
+		{InvariantViolation, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.kind.String(), func(t *testing.T) {
+			if IsRetryable(New(tt.kind, "failure")) != tt.expected {
+				t.Errorf("expected retryable=%v for %v", tt.expected, tt.kind)
+			}
+		})
+	}
+
+	if IsRetryable(nil) {
+		t.Error("nil error should not be retryable")
+	}
+}
+
+func TestHTTPStatusMapping(t *testing.T) {
+	tests := []struct {
+		kind   Kind
+		status int
+	}{
+		{Retryable, http.StatusServiceUnavailable},
+		{Overload, http.StatusTooManyRequests},
+		{NotOwner, http.StatusMisdirectedRequest},
+		{Conflict, http.StatusConflict},
+		{Unavailable, http.StatusServiceUnavailable},
+		{InvariantViolation, http.StatusInternalServerError},
+		{Unknown, http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.kind.String(), func(t *testing.T) {
+			if status := HTTPStatus(New(tt.kind, "failure")); status != tt.status {
+				t.Errorf("expected status %d, got %d", tt.status, status)
+			}
+		})
+	}
+
+	if HTTPStatus(nil) != http.StatusOK {
+		t.Error("expected 200 for nil error")
+	}
+
+	// Round trip for kinds with a unique status
+	for _, kind := range []Kind{Overload, NotOwner, Conflict, Unavailable} {
+		if got := FromHTTPStatus(HTTPStatus(New(kind, "failure"))); got != kind {
+			t.Errorf("expected round trip of %v, got %v", kind, got)
+		}
+	}
+}
+
+func TestGRPCCodeMapping(t *testing.T) {
+	if GRPCCode(nil) != 0 {
+		t.Error("expected OK for nil error")
+	}
+
+	for _, kind := range []Kind{Overload, NotOwner, Conflict, Unavailable, InvariantViolation} {
+		if got := FromGRPCCode(GRPCCode(New(kind, "failure"))); got != kind {
+			t.Errorf("expected round trip of %v, got %v", kind, got)
+		}
+	}
+
+	if GRPCCode(errors.New("boom")) != grpcUnknown {
+		t.Error("expected Unknown code for unclassified error")
+	}
+}
@@ -0,0 +1,211 @@
+package fanout
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/mohdrashid9678/dcore/invariant"
+)
+
+// CacheConfig holds configuration options for creating a new Cache
+type CacheConfig struct {
+	// Epoch returns the current ring epoch
+	// Required; entries cached under an older epoch are treated as stale
+	Epoch func() uint64
+
+	// ShardVersion returns the current data version of a shard
+	// Optional; when set, entries are stale once any contributing shard's version changes
+	ShardVersion func(shard string) uint64
+
+	// MaxEntries bounds the number of cached results
+	// The least recently used entry is evicted first
+	// Default: 1024
+	MaxEntries int
+}
+
+// Stamp records the ring epoch and shard versions a result was computed against
+// Take it before starting the fan-out so changes during the query invalidate the result
+type Stamp struct {
+	Epoch  uint64
+	Shards map[string]uint64
+}
+
+// Cache stores merged fan-out results tagged with the ring epoch and shard
+// versions they were computed from, invalidating them when ownership changes
+type Cache[T any] struct {
+	// mu protects entries, lru and lastEpoch
+	mu sync.Mutex
+
+	epoch        func() uint64
+	shardVersion func(shard string) uint64
+	maxEntries   int
+
+	// entries maps cache keys to their element in lru
+	entries map[string]*list.Element
+
+	// lru orders entries from most to least recently used
+	lru *list.List
+
+	// lastEpoch is the highest epoch seen, to check that epochs never go back
+	lastEpoch uint64
+}
+
+// cacheEntry is a cached result and the stamp it was computed against
+type cacheEntry[T any] struct {
+	key   string
+	value T
+	stamp Stamp
+}
+
+// NewCache creates a new epoch-aware result cache
+func NewCache[T any](config CacheConfig) (*Cache[T], error) {
+	if config.Epoch == nil {
+		return nil, errors.New("epoch function is required")
+	}
+
+	if config.MaxEntries <= 0 {
+		config.MaxEntries = 1024 // Default capacity
+	}
+
+	return &Cache[T]{
+		epoch:        config.Epoch,
+		shardVersion: config.ShardVersion,
+		maxEntries:   config.MaxEntries,
+		entries:      make(map[string]*list.Element),
+		lru:          list.New(),
+	}, nil
+}
+
+// Stamp captures the current epoch and the versions of the given shards
+func (c *Cache[T]) Stamp(shards []string) Stamp {
+	stamp := Stamp{Epoch: c.epoch()}
+
+	if c.shardVersion != nil {
+		stamp.Shards = make(map[string]uint64, len(shards))
+		for _, shard := range shards {
+			stamp.Shards[shard] = c.shardVersion(shard)
+		}
+	}
+
+	return stamp
+}
+
+// Get returns the cached result for key if it is still valid
+// Stale entries are removed
+func (c *Cache[T]) Get(key string) (T, bool) {
+	var zero T
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, exists := c.entries[key]
+	if !exists {
+		return zero, false
+	}
+
+	entry := elem.Value.(*cacheEntry[T])
+	if !c.validLocked(entry.stamp) {
+		c.lru.Remove(elem)
+		delete(c.entries, key)
+		return zero, false
+	}
+
+	c.lru.MoveToFront(elem)
+	return entry.value, true
+}
+
+// Put stores a result computed against stamp
+// Results whose stamp is already stale are not stored
+func (c *Cache[T]) Put(key string, value T, stamp Stamp) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.validLocked(stamp) {
+		return
+	}
+
+	if elem, exists := c.entries[key]; exists {
+		elem.Value = &cacheEntry[T]{key: key, value: value, stamp: stamp}
+		c.lru.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key] = c.lru.PushFront(&cacheEntry[T]{key: key, value: value, stamp: stamp})
+
+	// Evict the least recently used entry when over capacity
+	if c.lru.Len() > c.maxEntries {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry[T]).key)
+	}
+}
+
+// Invalidate removes the cached result for key
+func (c *Cache[T]) Invalidate(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, exists := c.entries[key]; exists {
+		c.lru.Remove(elem)
+		delete(c.entries, key)
+	}
+}
+
+// Len returns the number of cached entries, including ones not yet found stale
+func (c *Cache[T]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.lru.Len()
+}
+
+// validLocked returns true if stamp matches the current epoch and shard versions
+// Caller must hold c.mu
+func (c *Cache[T]) validLocked(stamp Stamp) bool {
+	epoch := c.epoch()
+	invariant.Check(epoch >= c.lastEpoch, "fanout/monotonic-epoch",
+		"epoch went from %d back to %d", c.lastEpoch, epoch)
+	c.lastEpoch = max(c.lastEpoch, epoch)
+
+	if stamp.Epoch != epoch {
+		return false
+	}
+
+	if c.shardVersion != nil {
+		for shard, version := range stamp.Shards {
+			if c.shardVersion(shard) != version {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// CachedGather returns the cached result for key or runs the fan-out and
+// caches its merged result
+// Partial responses are returned but never cached
+func CachedGather[T, R any](ctx context.Context, cache *Cache[R], key string, shards []string,
+	call CallFunc[T], policy Policy[T], merge func(Response[T]) R) (R, error) {
+
+	if value, ok := cache.Get(key); ok {
+		return value, nil
+	}
+
+	stamp := cache.Stamp(shards)
+
+	resp, err := Gather(ctx, shards, call, policy)
+	if err != nil {
+		var zero R
+		return zero, err
+	}
+
+	value := merge(resp)
+	if !resp.Partial {
+		cache.Put(key, value, stamp)
+	}
+
+	return value, nil
+}
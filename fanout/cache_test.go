@@ -0,0 +1,164 @@
+package fanout
+
+import (
+	"context"
+	"os"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mohdrashid9678/dcore/invariant"
+)
+
+func TestMain(m *testing.M) {
+	invariant.SetMode(invariant.Panic)
+	os.Exit(m.Run())
+}
+
+func TestNewCacheRequiresEpoch(t *testing.T) {
+	if _, err := NewCache[int](CacheConfig{}); err == nil {
+		t.Error("expected error without epoch function")
+	}
+}
+
+func TestCacheEpochInvalidation(t *testing.T) {
+	var epoch uint64 = 1
+	cache, _ := NewCache[int](CacheConfig{
+		Epoch: func() uint64 { return atomic.LoadUint64(&epoch) },
+	})
+
+	stamp := cache.Stamp([]string{"a", "b"})
+	cache.Put("count", 42, stamp)
+
+	value, ok := cache.Get("count")
+	if !ok || value != 42 {
+		t.Fatalf("expected cached 42, got %d (ok=%v)", value, ok)
+	}
+
+	// Ownership changes bump the epoch
+	atomic.StoreUint64(&epoch, 2)
+
+	if _, ok := cache.Get("count"); ok {
+		t.Error("expected entry to be stale after epoch change")
+	}
+	if cache.Len() != 0 {
+		t.Errorf("expected stale entry to be removed, got %d entries", cache.Len())
+	}
+
+	// Results computed against an old stamp are never stored
+	cache.Put("count", 7, stamp)
+	if _, ok := cache.Get("count"); ok {
+		t.Error("expected stale stamp to be rejected")
+	}
+}
+
+func TestCacheShardVersionInvalidation(t *testing.T) {
+	versions := map[string]uint64{"a": 1, "b": 1}
+	cache, _ := NewCache[string](CacheConfig{
+		Epoch:        func() uint64 { return 1 },
+		ShardVersion: func(shard string) uint64 { return versions[shard] },
+	})
+
+	cache.Put("q", "result", cache.Stamp([]string{"a", "b"}))
+
+	if _, ok := cache.Get("q"); !ok {
+		t.Fatal("expected cached result")
+	}
+
+	versions["b"] = 2
+	if _, ok := cache.Get("q"); ok {
+		t.Error("expected entry to be stale after shard version change")
+	}
+}
+
+func TestCacheEviction(t *testing.T) {
+	cache, _ := NewCache[int](CacheConfig{
+		Epoch:      func() uint64 { return 1 },
+		MaxEntries: 2,
+	})
+
+	stamp := cache.Stamp(nil)
+	cache.Put("a", 1, stamp)
+	cache.Put("b", 2, stamp)
+	cache.Get("a") // a is now most recently used
+	cache.Put("c", 3, stamp)
+
+	if _, ok := cache.Get("b"); ok {
+		t.Error("expected least recently used entry to be evicted")
+	}
+	if _, ok := cache.Get("a"); !ok {
+		t.Error("expected recently used entry to survive")
+	}
+
+	cache.Invalidate("a")
+	if _, ok := cache.Get("a"); ok {
+		t.Error("expected entry to be invalidated")
+	}
+}
+
+func TestCachedGather(t *testing.T) {
+	cache, _ := NewCache[int](CacheConfig{Epoch: func() uint64 { return 1 }})
+
+	var calls int32
+	call := func(ctx context.Context, shard string) (int, error) {
+		atomic.AddInt32(&calls, 1)
+		return 1, nil
+	}
+	sum := func(resp Response[int]) int {
+		total := 0
+		for _, r := range resp.Results {
+			total += r.Value
+		}
+		return total
+	}
+
+	shards := []string{"a", "b", "c"}
+	for i := 0; i < 3; i++ {
+		total, err := CachedGather(context.Background(), cache, "sum", shards, call, Policy[int]{}, sum)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if total != 3 {
+			t.Errorf("expected 3, got %d", total)
+		}
+	}
+
+	if atomic.LoadInt32(&calls) != 3 {
+		t.Errorf("expected a single fan-out of 3 calls, got %d calls", calls)
+	}
+
+	// Partial responses are not cached
+	slow := func(ctx context.Context, shard string) (int, error) {
+		if shard != "a" {
+			<-ctx.Done()
+			return 0, ctx.Err()
+		}
+		return 1, nil
+	}
+	total, err := CachedGather(context.Background(), cache, "partial", shards, slow, Policy[int]{MinResponses: 1}, sum)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if total != 1 {
+		t.Errorf("expected partial total 1, got %d", total)
+	}
+	if _, ok := cache.Get("partial"); ok {
+		t.Error("expected partial response not to be cached")
+	}
+}
+
+func TestCacheEpochGoingBackIsViolation(t *testing.T) {
+	var epoch uint64 = 5
+	cache, _ := NewCache[int](CacheConfig{
+		Epoch: func() uint64 { return atomic.LoadUint64(&epoch) },
+	})
+	cache.Put("count", 42, cache.Stamp(nil))
+
+	atomic.StoreUint64(&epoch, 4)
+
+	defer func() {
+		if _, ok := recover().(invariant.Violation); !ok {
+			t.Error("expected an invariant violation for an epoch going back")
+		}
+	}()
+	cache.Get("count")
+}
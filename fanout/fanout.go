@@ -0,0 +1,152 @@
+// Package fanout implements scatter-gather reads across shards with
+// early-exit policies that trade completeness for tail latency.
+
+package fanout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+var (
+	// ErrNoShards is returned when a fan-out is started without any shard
+	ErrNoShards = errors.New("no shards to query")
+
+	// ErrInsufficientResponses is returned when too many shards failed to satisfy the policy
+	ErrInsufficientResponses = errors.New("insufficient shard responses")
+)
+
+// CallFunc queries a single shard
+// The context is cancelled when the shard's answer is no longer needed
+type CallFunc[T any] func(ctx context.Context, shard string) (T, error)
+
+// Result is the outcome of querying a single shard
+type Result[T any] struct {
+	Shard string
+	Value T
+	Err   error
+}
+
+// Policy decides when a fan-out has gathered enough results
+// The zero value waits for every shard
+type Policy[T any] struct {
+	// MinResponses returns as soon as this many shards answered successfully
+	// Zero means all shards
+	MinResponses int
+
+	// Confidence scores the successful results gathered so far in [0, 1]
+	// Optional; when set, the fan-out returns once the score reaches Threshold
+	Confidence func(results []Result[T]) float64
+
+	// Threshold is the confidence score that ends the fan-out early
+	Threshold float64
+}
+
+// Response holds the gathered results and describes how complete they are
+type Response[T any] struct {
+	// Results holds successful results in arrival order
+	Results []Result[T]
+
+	// Failed holds the shards that returned an error
+	Failed []Result[T]
+
+	// Cancelled lists the straggler shards that were cancelled
+	Cancelled []string
+
+	// Partial is true if not every shard contributed a result
+	Partial bool
+
+	// Confidence is the last confidence score, or 1 when no scorer is set
+	// and every shard answered
+	Confidence float64
+}
+
+// Gather queries every shard concurrently and returns once the policy is satisfied
+// Shards still running at that point are cancelled and reported as stragglers
+func Gather[T any](ctx context.Context, shards []string, call CallFunc[T], policy Policy[T]) (Response[T], error) {
+	var resp Response[T]
+
+	if len(shards) == 0 {
+		return resp, ErrNoShards
+	}
+
+	need := policy.MinResponses
+	if need <= 0 || need > len(shards) {
+		need = len(shards)
+	}
+
+	callCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan Result[T], len(shards))
+	for _, shard := range shards {
+		go func(shard string) {
+			value, err := call(callCtx, shard)
+			results <- Result[T]{Shard: shard, Value: value, Err: err}
+		}(shard)
+	}
+
+	pending := make(map[string]struct{}, len(shards))
+	for _, shard := range shards {
+		pending[shard] = struct{}{}
+	}
+
+	done := false
+	for !done && len(pending) > 0 {
+		select {
+		case r := <-results:
+			delete(pending, r.Shard)
+
+			if r.Err != nil {
+				resp.Failed = append(resp.Failed, r)
+				// Stop waiting if the target can no longer be reached
+				if len(resp.Results)+len(pending) < need && policy.Confidence == nil {
+					done = true
+				}
+				continue
+			}
+
+			resp.Results = append(resp.Results, r)
+
+			if policy.Confidence != nil {
+				resp.Confidence = policy.Confidence(resp.Results)
+				if resp.Confidence >= policy.Threshold {
+					done = true
+				}
+			}
+			if len(resp.Results) >= need {
+				done = true
+			}
+
+		case <-ctx.Done():
+			done = true
+		}
+	}
+
+	// Cancel and report the stragglers
+	cancel()
+	for shard := range pending {
+		resp.Cancelled = append(resp.Cancelled, shard)
+	}
+	sort.Strings(resp.Cancelled)
+
+	resp.Partial = len(resp.Results) < len(shards)
+	if policy.Confidence == nil && !resp.Partial {
+		resp.Confidence = 1
+	}
+
+	satisfied := len(resp.Results) >= need ||
+		(policy.Confidence != nil && len(resp.Results) > 0 && resp.Confidence >= policy.Threshold)
+
+	if !satisfied {
+		if err := ctx.Err(); err != nil {
+			return resp, err
+		}
+		return resp, fmt.Errorf("%w: %d of %d shards answered, %d failed",
+			ErrInsufficientResponses, len(resp.Results), len(shards), len(resp.Failed))
+	}
+
+	return resp, nil
+}
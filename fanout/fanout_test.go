@@ -0,0 +1,150 @@
+package fanout
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// shardCall returns a CallFunc answering with the shard name after the
+// configured delay, failing for shards listed in failing
+func shardCall(delays map[string]time.Duration, failing map[string]bool, cancelled *int32) CallFunc[string] {
+	return func(ctx context.Context, shard string) (string, error) {
+		select {
+		case <-time.After(delays[shard]):
+		case <-ctx.Done():
+			atomic.AddInt32(cancelled, 1)
+			return "", ctx.Err()
+		}
+		if failing[shard] {
+			return "", errors.New("shard failed")
+		}
+		return shard, nil
+	}
+}
+
+func TestGatherAll(t *testing.T) {
+	var cancelled int32
+	call := shardCall(map[string]time.Duration{}, nil, &cancelled)
+
+	resp, err := Gather(context.Background(), []string{"a", "b", "c"}, call, Policy[string]{})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(resp.Results) != 3 {
+		t.Errorf("expected 3 results, got %d", len(resp.Results))
+	}
+	if resp.Partial {
+		t.Error("expected complete response")
+	}
+	if resp.Confidence != 1 {
+		t.Errorf("expected confidence 1, got %v", resp.Confidence)
+	}
+}
+
+func TestGatherNoShards(t *testing.T) {
+	var cancelled int32
+	_, err := Gather(context.Background(), nil, shardCall(nil, nil, &cancelled), Policy[string]{})
+	if err != ErrNoShards {
+		t.Errorf("expected ErrNoShards, got %v", err)
+	}
+}
+
+func TestGatherKOfN(t *testing.T) {
+	var cancelled int32
+	delays := map[string]time.Duration{
+		"fast1": 0,
+		"fast2": 0,
+		"slow":  time.Second,
+	}
+
+	start := time.Now()
+	resp, err := Gather(context.Background(), []string{"fast1", "fast2", "slow"},
+		shardCall(delays, nil, &cancelled), Policy[string]{MinResponses: 2})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if time.Since(start) > 500*time.Millisecond {
+		t.Error("expected early exit before the straggler finished")
+	}
+	if len(resp.Results) != 2 {
+		t.Errorf("expected 2 results, got %d", len(resp.Results))
+	}
+	if !resp.Partial {
+		t.Error("expected partial response")
+	}
+	if len(resp.Cancelled) != 1 || resp.Cancelled[0] != "slow" {
+		t.Errorf("expected slow to be cancelled, got %v", resp.Cancelled)
+	}
+
+	// The straggler observes the cancellation
+	deadline := time.Now().Add(time.Second)
+	for atomic.LoadInt32(&cancelled) == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if atomic.LoadInt32(&cancelled) != 1 {
+		t.Error("expected straggler context to be cancelled")
+	}
+}
+
+func TestGatherConfidence(t *testing.T) {
+	var cancelled int32
+	delays := map[string]time.Duration{
+		"a": 0,
+		"b": 10 * time.Millisecond,
+		"c": time.Second,
+		"d": time.Second,
+	}
+
+	policy := Policy[string]{
+		Confidence: func(results []Result[string]) float64 {
+			return float64(len(results)) / 4
+		},
+		Threshold: 0.5,
+	}
+
+	resp, err := Gather(context.Background(), []string{"a", "b", "c", "d"},
+		shardCall(delays, nil, &cancelled), policy)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if resp.Confidence != 0.5 {
+		t.Errorf("expected confidence 0.5, got %v", resp.Confidence)
+	}
+	if len(resp.Cancelled) != 2 {
+		t.Errorf("expected 2 cancelled stragglers, got %v", resp.Cancelled)
+	}
+}
+
+func TestGatherInsufficient(t *testing.T) {
+	var cancelled int32
+	failing := map[string]bool{"b": true, "c": true}
+
+	resp, err := Gather(context.Background(), []string{"a", "b", "c"},
+		shardCall(nil, failing, &cancelled), Policy[string]{MinResponses: 2})
+	if !errors.Is(err, ErrInsufficientResponses) {
+		t.Fatalf("expected ErrInsufficientResponses, got %v", err)
+	}
+
+	if len(resp.Failed) != 2 {
+		t.Errorf("expected 2 failed shards, got %d", len(resp.Failed))
+	}
+}
+
+func TestGatherParentCancelled(t *testing.T) {
+	var cancelled int32
+	delays := map[string]time.Duration{"a": time.Second}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := Gather(ctx, []string{"a"}, shardCall(delays, nil, &cancelled), Policy[string]{})
+	if err != context.DeadlineExceeded {
+		t.Errorf("expected DeadlineExceeded, got %v", err)
+	}
+}
@@ -0,0 +1,265 @@
+package fanout
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Stream yields a shard's results one at a time so large responses never
+// have to be buffered in full
+type Stream[T any] interface {
+	// Next returns the next item; the boolean is false once the stream is exhausted
+	Next() (T, bool, error)
+
+	// Close releases the stream's resources
+	Close() error
+}
+
+// OpenFunc opens a result stream on a single shard
+type OpenFunc[T any] func(ctx context.Context, shard string) (Stream[T], error)
+
+// Open opens a stream on every shard concurrently
+// If any shard fails to open, the streams already opened are closed
+func Open[T any](ctx context.Context, shards []string, open OpenFunc[T]) ([]Stream[T], error) {
+	if len(shards) == 0 {
+		return nil, ErrNoShards
+	}
+
+	streams := make([]Stream[T], len(shards))
+	errs := make([]error, len(shards))
+
+	var wg sync.WaitGroup
+	for i, shard := range shards {
+		wg.Add(1)
+		go func(i int, shard string) {
+			defer wg.Done()
+			streams[i], errs[i] = open(ctx, shard)
+			if errs[i] != nil {
+				errs[i] = fmt.Errorf("shard %s: %w", shard, errs[i])
+			}
+		}(i, shard)
+	}
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		closeAll(streams)
+		return nil, err
+	}
+
+	return streams, nil
+}
+
+// sliceStream is a Stream over an in-memory slice
+type sliceStream[T any] struct {
+	items []T
+	pos   int
+}
+
+// SliceStream returns a Stream that yields the given items
+func SliceStream[T any](items []T) Stream[T] {
+	return &sliceStream[T]{items: items}
+}
+
+func (s *sliceStream[T]) Next() (T, bool, error) {
+	var zero T
+	if s.pos >= len(s.items) {
+		return zero, false, nil
+	}
+	item := s.items[s.pos]
+	s.pos++
+	return item, true, nil
+}
+
+func (s *sliceStream[T]) Close() error {
+	return nil
+}
+
+// mergeItem is the current head of one input stream
+type mergeItem[T any] struct {
+	value  T
+	source int
+}
+
+// mergeHeap is a min-heap of stream heads ordered by less
+type mergeHeap[T any] struct {
+	items []mergeItem[T]
+	less  func(a, b T) bool
+}
+
+func (h *mergeHeap[T]) Len() int           { return len(h.items) }
+func (h *mergeHeap[T]) Less(i, j int) bool { return h.less(h.items[i].value, h.items[j].value) }
+func (h *mergeHeap[T]) Swap(i, j int)      { h.items[i], h.items[j] = h.items[j], h.items[i] }
+func (h *mergeHeap[T]) Push(x any)         { h.items = append(h.items, x.(mergeItem[T])) }
+
+func (h *mergeHeap[T]) Pop() any {
+	n := len(h.items)
+	item := h.items[n-1]
+	h.items = h.items[:n-1]
+	return item
+}
+
+// mergeStream lazily merges sorted input streams
+type mergeStream[T any] struct {
+	streams []Stream[T]
+	heap    *mergeHeap[T]
+	started bool
+
+	// err is the first input error, returned by every later call
+	err error
+}
+
+// MergeSorted returns a Stream yielding the items of all streams in order
+// Every input stream must already be sorted by less
+// Only one item per input stream is held in memory at a time
+// Once an input stream fails, Next returns its error on every call
+func MergeSorted[T any](streams []Stream[T], less func(a, b T) bool) Stream[T] {
+	return &mergeStream[T]{
+		streams: streams,
+		heap:    &mergeHeap[T]{less: less},
+	}
+}
+
+func (m *mergeStream[T]) Next() (T, bool, error) {
+	var zero T
+	if m.err != nil {
+		return zero, false, m.err
+	}
+
+	// Prime the heap with the head of every stream
+	if !m.started {
+		for i := range m.streams {
+			if m.err = m.advance(i); m.err != nil {
+				return zero, false, m.err
+			}
+		}
+		m.started = true
+	}
+
+	if m.heap.Len() == 0 {
+		return zero, false, nil
+	}
+
+	item := heap.Pop(m.heap).(mergeItem[T])
+	if m.err = m.advance(item.source); m.err != nil {
+		return zero, false, m.err
+	}
+
+	return item.value, true, nil
+}
+
+// advance pushes the next item of stream i onto the heap
+func (m *mergeStream[T]) advance(i int) error {
+	value, ok, err := m.streams[i].Next()
+	if err != nil {
+		return err
+	}
+	if ok {
+		heap.Push(m.heap, mergeItem[T]{value: value, source: i})
+	}
+	return nil
+}
+
+func (m *mergeStream[T]) Close() error {
+	return closeAll(m.streams)
+}
+
+// TopK consumes every stream and returns the k smallest items according to less,
+// sorted ascending
+// Memory use is bounded by k regardless of the stream sizes
+func TopK[T any](streams []Stream[T], k int, less func(a, b T) bool) ([]T, error) {
+	defer closeAll(streams)
+
+	if k <= 0 {
+		return nil, errors.New("k must be positive")
+	}
+
+	// Max-heap of the best k items seen so far, so the worst is evicted first
+	h := &mergeHeap[T]{less: func(a, b T) bool { return less(b, a) }}
+
+	for _, s := range streams {
+		for {
+			value, ok, err := s.Next()
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				break
+			}
+
+			if h.Len() < k {
+				heap.Push(h, mergeItem[T]{value: value})
+			} else if less(value, h.items[0].value) {
+				h.items[0] = mergeItem[T]{value: value}
+				heap.Fix(h, 0)
+			}
+		}
+	}
+
+	result := make([]T, h.Len())
+	for i := range result {
+		result[i] = h.items[i].value
+	}
+	sort.Slice(result, func(i, j int) bool { return less(result[i], result[j]) })
+
+	return result, nil
+}
+
+// GroupBy consumes every stream and folds items into one accumulator per key
+// Memory use is bounded by the number of distinct keys
+func GroupBy[T any, K comparable, A any](streams []Stream[T], key func(T) K, fold func(acc A, item T) A) (map[K]A, error) {
+	defer closeAll(streams)
+
+	groups := make(map[K]A)
+
+	for _, s := range streams {
+		for {
+			value, ok, err := s.Next()
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				break
+			}
+
+			k := key(value)
+			groups[k] = fold(groups[k], value)
+		}
+	}
+
+	return groups, nil
+}
+
+// Collect drains a stream into a slice and closes it
+func Collect[T any](s Stream[T]) ([]T, error) {
+	defer s.Close()
+
+	var items []T
+	for {
+		value, ok, err := s.Next()
+		if err != nil {
+			return items, err
+		}
+		if !ok {
+			return items, nil
+		}
+		items = append(items, value)
+	}
+}
+
+// closeAll closes every non-nil stream and joins the errors
+func closeAll[T any](streams []Stream[T]) error {
+	var errs []error
+	for _, s := range streams {
+		if s == nil {
+			continue
+		}
+		if err := s.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,177 @@
+package fanout
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// errStream fails after yielding its items
+type errStream struct {
+	Stream[int]
+	closed bool
+}
+
+func (s *errStream) Next() (int, bool, error) {
+	v, ok, _ := s.Stream.Next()
+	if !ok {
+		return 0, false, errors.New("stream broken")
+	}
+	return v, true, nil
+}
+
+func (s *errStream) Close() error {
+	s.closed = true
+	return nil
+}
+
+func intLess(a, b int) bool { return a < b }
+
+func TestMergeSorted(t *testing.T) {
+	streams := []Stream[int]{
+		SliceStream([]int{1, 4, 7, 10}),
+		SliceStream([]int{2, 5, 8}),
+		SliceStream([]int{}),
+		SliceStream([]int{3, 6, 9}),
+	}
+
+	merged, err := Collect(MergeSorted(streams, intLess))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(merged) != 10 {
+		t.Fatalf("expected 10 items, got %d", len(merged))
+	}
+	for i, v := range merged {
+		if v != i+1 {
+			t.Fatalf("expected sorted output, got %v", merged)
+		}
+	}
+}
+
+func TestMergeSortedError(t *testing.T) {
+	broken := &errStream{Stream: SliceStream([]int{1})}
+	streams := []Stream[int]{SliceStream([]int{2, 3}), broken}
+
+	_, err := Collect(MergeSorted(streams, intLess))
+	if err == nil {
+		t.Error("expected stream error to propagate")
+	}
+	if !broken.closed {
+		t.Error("expected input streams to be closed")
+	}
+}
+
+func TestMergeSortedPrimingError(t *testing.T) {
+	// The middle stream fails on its first item
+	streams := []Stream[int]{
+		SliceStream([]int{1, 4}),
+		&errStream{Stream: SliceStream([]int{})},
+		SliceStream([]int{2, 3}),
+	}
+	merged := MergeSorted(streams, intLess)
+
+	for i := 0; i < 3; i++ {
+		if _, ok, err := merged.Next(); err == nil || ok {
+			t.Fatalf("call %d: expected the priming error, got ok=%v err=%v", i, ok, err)
+		}
+	}
+}
+
+func TestTopK(t *testing.T) {
+	streams := []Stream[int]{
+		SliceStream([]int{50, 3, 99, 12}),
+		SliceStream([]int{7, 1, 64}),
+		SliceStream([]int{8, 2}),
+	}
+
+	top, err := TopK(streams, 4, intLess)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	expected := []int{1, 2, 3, 7}
+	if len(top) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, top)
+	}
+	for i := range expected {
+		if top[i] != expected[i] {
+			t.Fatalf("expected %v, got %v", expected, top)
+		}
+	}
+
+	// Largest-first by inverting the comparison
+	top, _ = TopK([]Stream[int]{SliceStream([]int{5, 1, 9})}, 2, func(a, b int) bool { return a > b })
+	if top[0] != 9 || top[1] != 5 {
+		t.Errorf("expected [9 5], got %v", top)
+	}
+
+	if _, err := TopK([]Stream[int]{SliceStream([]int{1})}, 0, intLess); err == nil {
+		t.Error("expected error for non-positive k")
+	}
+}
+
+func TestGroupBy(t *testing.T) {
+	type sale struct {
+		region string
+		amount int
+	}
+
+	streams := []Stream[sale]{
+		SliceStream([]sale{{"eu", 10}, {"us", 5}}),
+		SliceStream([]sale{{"eu", 1}, {"apac", 7}, {"us", 5}}),
+	}
+
+	totals, err := GroupBy(streams,
+		func(s sale) string { return s.region },
+		func(acc int, s sale) int { return acc + s.amount },
+	)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	expected := map[string]int{"eu": 11, "us": 10, "apac": 7}
+	for region, total := range expected {
+		if totals[region] != total {
+			t.Errorf("expected %s total %d, got %d", region, total, totals[region])
+		}
+	}
+}
+
+func TestOpen(t *testing.T) {
+	data := map[string][]int{
+		"a": {1, 3},
+		"b": {2, 4},
+	}
+
+	open := func(ctx context.Context, shard string) (Stream[int], error) {
+		return SliceStream(data[shard]), nil
+	}
+
+	streams, err := Open(context.Background(), []string{"a", "b"}, open)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	merged, _ := Collect(MergeSorted(streams, intLess))
+	if len(merged) != 4 || merged[0] != 1 || merged[3] != 4 {
+		t.Errorf("expected [1 2 3 4], got %v", merged)
+	}
+
+	// A failing shard closes the already opened streams
+	opened := &errStream{Stream: SliceStream([]int{})}
+	failing := func(ctx context.Context, shard string) (Stream[int], error) {
+		if shard == "b" {
+			return nil, errors.New("unreachable")
+		}
+		return opened, nil
+	}
+
+	if _, err := Open(context.Background(), []string{"a", "b"}, failing); err == nil {
+		t.Error("expected open error")
+	}
+	if !opened.closed {
+		t.Error("expected opened stream to be closed on failure")
+	}
+}
@@ -0,0 +1,187 @@
+// Package feature gates dcore behaviour changes per process, so large
+// deployments can roll a change out gradually across a fleet and compare
+// metrics tagged with the gate state before enabling it everywhere.
+
+package feature
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrUnknownGate is returned when setting a gate that was never registered
+var ErrUnknownGate = errors.New("unknown feature gate")
+
+// Gate is a named switch read on hot paths
+type Gate struct {
+	name        string
+	description string
+	def         bool
+	enabled     atomic.Bool
+}
+
+// Status describes a gate for admin endpoints
+type Status struct {
+	Name        string
+	Description string
+	Default     bool
+	Enabled     bool
+}
+
+var (
+	// mu protects gates and overrides
+	mu    sync.Mutex
+	gates = make(map[string]*Gate)
+
+	// overrides holds gate states set before the gate was registered, such
+	// as from DCORE_FEATURES
+	overrides = make(map[string]bool)
+)
+
+// init reads DCORE_FEATURES, a comma-separated list of name=on|off entries
+// applied to gates as they register; malformed entries are ignored
+func init() {
+	if spec := os.Getenv("DCORE_FEATURES"); spec != "" {
+		Apply(spec)
+	}
+}
+
+// Register creates a gate with the given default state, overridden by
+// DCORE_FEATURES or an earlier Apply
+// It panics if name is already registered: two packages sharing a gate name
+// would flip each other's behaviour
+func Register(name string, def bool, description string) *Gate {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, exists := gates[name]; exists {
+		panic(fmt.Sprintf("feature: gate %q registered twice", name))
+	}
+
+	g := &Gate{name: name, description: description, def: def}
+	state, overridden := overrides[name]
+	if !overridden {
+		state = def
+	}
+	g.enabled.Store(state)
+	gates[name] = g
+	return g
+}
+
+// Name returns the gate name
+func (g *Gate) Name() string {
+	return g.name
+}
+
+// Enabled returns true if the gate is on; it costs a single atomic load
+func (g *Gate) Enabled() bool {
+	return g.enabled.Load()
+}
+
+// Set turns the gate named name on or off at runtime
+func Set(name string, enabled bool) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	g, exists := gates[name]
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrUnknownGate, name)
+	}
+	g.enabled.Store(enabled)
+	return nil
+}
+
+// Apply sets gates from a comma-separated list of name=on|off entries, the
+// format of DCORE_FEATURES, such as from a reloaded configuration file
+// Entries for gates not registered yet take effect when they register. A
+// malformed entry fails the whole list before any gate changes
+func Apply(spec string) error {
+	states := make(map[string]bool)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(entry, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return fmt.Errorf("malformed feature entry %q", entry)
+		}
+
+		switch strings.ToLower(strings.TrimSpace(value)) {
+		case "on", "true", "1":
+			states[name] = true
+		case "off", "false", "0":
+			states[name] = false
+		default:
+			return fmt.Errorf("malformed feature entry %q", entry)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	for name, state := range states {
+		if g, exists := gates[name]; exists {
+			g.enabled.Store(state)
+		} else {
+			overrides[name] = state
+		}
+	}
+	return nil
+}
+
+// Gates returns the state of every registered gate, sorted by name
+func Gates() []Status {
+	mu.Lock()
+	defer mu.Unlock()
+
+	statuses := make([]Status, 0, len(gates))
+	for _, g := range gates {
+		statuses = append(statuses, Status{
+			Name:        g.name,
+			Description: g.description,
+			Default:     g.def,
+			Enabled:     g.Enabled(),
+		})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+// Label returns the gates that differ from their default as a stable
+// "name=on,name=off" string, or "default" if none do
+// Attach it to metrics as a label so processes running a gated change can be
+// compared with the rest of the fleet; gates left at their default are
+// omitted to keep the label's cardinality low
+func Label() string {
+	var parts []string
+	for _, s := range Gates() {
+		if s.Enabled == s.Default {
+			continue
+		}
+		state := "off"
+		if s.Enabled {
+			state = "on"
+		}
+		parts = append(parts, s.Name+"="+state)
+	}
+	if len(parts) == 0 {
+		return "default"
+	}
+	return strings.Join(parts, ",")
+}
+
+// unregister removes a gate; tests use it to register the same name again
+func unregister(name string) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	delete(gates, name)
+	delete(overrides, name)
+}
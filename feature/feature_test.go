@@ -0,0 +1,83 @@
+package feature
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestGateLifecycle(t *testing.T) {
+	defer unregister("test/lifecycle")
+
+	g := Register("test/lifecycle", false, "exercises a gate")
+	if g.Enabled() || g.Name() != "test/lifecycle" {
+		t.Fatal("expected the gate off by default")
+	}
+
+	if err := Set("test/lifecycle", true); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if !g.Enabled() {
+		t.Error("expected the gate on after Set")
+	}
+
+	if err := Set("test/missing", true); !errors.Is(err, ErrUnknownGate) {
+		t.Errorf("expected ErrUnknownGate, got %v", err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic registering a gate twice")
+		}
+	}()
+	Register("test/lifecycle", true, "")
+}
+
+func TestApply(t *testing.T) {
+	defer unregister("test/a")
+	defer unregister("test/b")
+
+	a := Register("test/a", false, "")
+
+	// Entries for gates not registered yet apply when they register
+	if err := Apply("test/a=on, test/b=off"); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	b := Register("test/b", true, "")
+
+	if !a.Enabled() || b.Enabled() {
+		t.Errorf("expected a on and b off, got %v %v", a.Enabled(), b.Enabled())
+	}
+
+	// A malformed list changes nothing
+	for _, spec := range []string{"test/a=off,test/b", "test/a=maybe", "=on"} {
+		if err := Apply(spec); err == nil {
+			t.Errorf("%q: expected an error", spec)
+		}
+	}
+	if !a.Enabled() {
+		t.Error("expected a malformed list to leave gates unchanged")
+	}
+}
+
+func TestStatusAndLabel(t *testing.T) {
+	defer unregister("test/x")
+	defer unregister("test/y")
+
+	Register("test/x", false, "first")
+	Register("test/y", true, "second")
+
+	if l := Label(); l != "default" {
+		t.Errorf("expected default label, got %q", l)
+	}
+
+	Set("test/x", true)
+	Set("test/y", false)
+	if l := Label(); l != "test/x=on,test/y=off" {
+		t.Errorf("unexpected label %q", l)
+	}
+
+	statuses := Gates()
+	if len(statuses) != 2 || statuses[0].Name != "test/x" || !statuses[0].Enabled || statuses[0].Description != "first" {
+		t.Errorf("unexpected statuses %+v", statuses)
+	}
+}
@@ -0,0 +1,184 @@
+// Package inflight tracks in-flight requests per node and supports graceful
+// draining of a node before it is removed from a hash ring.
+
+package inflight
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/mohdrashid9678/dcore/chash"
+)
+
+var (
+	// ErrDraining is returned when acquiring a slot on a node that is being drained
+	ErrDraining = errors.New("node is draining")
+
+	// ErrEmptyNode is returned when an empty node name is provided
+	ErrEmptyNode = errors.New("node cannot be empty")
+)
+
+// Tracker counts in-flight requests per node
+type Tracker struct {
+	// mu protects nodes and every counter in it
+	mu sync.Mutex
+
+	// nodes maps node names to their in-flight state
+	nodes map[string]*counter
+}
+
+// counter is the in-flight state of a single node
+type counter struct {
+	// inflight is the number of requests currently in progress
+	inflight int64
+
+	// draining rejects new requests when set
+	draining bool
+
+	// waiters are closed once inflight drops to zero
+	waiters []chan struct{}
+}
+
+// New creates a new in-flight request tracker
+func New() *Tracker {
+	return &Tracker{
+		nodes: make(map[string]*counter),
+	}
+}
+
+// Acquire registers a new in-flight request on the given node
+// The returned release function must be called exactly once when the request finishes
+// Returns ErrDraining if the node is being drained
+func (t *Tracker) Acquire(node string) (func(), error) {
+	if node == "" {
+		return nil, ErrEmptyNode
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	c := t.counterLocked(node)
+	if c.draining {
+		return nil, ErrDraining
+	}
+	c.inflight++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { t.release(node) })
+	}, nil
+}
+
+// release decrements the in-flight counter and wakes drain waiters
+func (t *Tracker) release(node string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	c, exists := t.nodes[node]
+	if !exists || c.inflight == 0 {
+		return
+	}
+
+	c.inflight--
+	if c.inflight == 0 {
+		for _, w := range c.waiters {
+			close(w)
+		}
+		c.waiters = nil
+	}
+}
+
+// Inflight returns the number of requests currently in progress on the node
+func (t *Tracker) Inflight(node string) int64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if c, exists := t.nodes[node]; exists {
+		return c.inflight
+	}
+	return 0
+}
+
+// IsDraining returns true if the node is being drained
+func (t *Tracker) IsDraining(node string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	c, exists := t.nodes[node]
+	return exists && c.draining
+}
+
+// Drain stops new requests to the node and blocks until all in-flight
+// requests have finished or the context is done
+// The node stays in draining state until Undrain is called
+func (t *Tracker) Drain(ctx context.Context, node string) error {
+	if node == "" {
+		return ErrEmptyNode
+	}
+
+	t.mu.Lock()
+	c := t.counterLocked(node)
+	c.draining = true
+
+	if c.inflight == 0 {
+		t.mu.Unlock()
+		return nil
+	}
+
+	done := make(chan struct{})
+	c.waiters = append(c.waiters, done)
+	t.mu.Unlock()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Undrain allows new requests to the node again
+func (t *Tracker) Undrain(node string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if c, exists := t.nodes[node]; exists {
+		c.draining = false
+	}
+}
+
+// DrainAndRemove drains the node and removes it from the ring once all
+// in-flight requests have finished, then forgets the node so it can be added
+// again under the same name
+// If the context expires first the node is left in the ring (still draining)
+// and the context error is returned, so the caller can decide whether to force removal
+func (t *Tracker) DrainAndRemove(ctx context.Context, ring *chash.Ring, node string) error {
+	if err := t.Drain(ctx, node); err != nil {
+		return err
+	}
+
+	if err := ring.RemoveNode(node); err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// Draining blocked new requests, so none can be in flight
+	if c, exists := t.nodes[node]; exists && c.inflight == 0 {
+		delete(t.nodes, node)
+	}
+	return nil
+}
+
+// counterLocked returns the counter for node, creating it if needed
+// Caller must hold t.mu
+func (t *Tracker) counterLocked(node string) *counter {
+	c, exists := t.nodes[node]
+	if !exists {
+		c = &counter{}
+		t.nodes[node] = c
+	}
+	return c
+}
@@ -0,0 +1,162 @@
+package inflight
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mohdrashid9678/dcore/chash"
+)
+
+func TestAcquireRelease(t *testing.T) {
+	tracker := New()
+
+	// Test empty node
+	if _, err := tracker.Acquire(""); err != ErrEmptyNode {
+		t.Errorf("expected ErrEmptyNode, got %v", err)
+	}
+
+	release1, err := tracker.Acquire("server1")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	release2, _ := tracker.Acquire("server1")
+
+	if tracker.Inflight("server1") != 2 {
+		t.Errorf("expected 2 in-flight, got %d", tracker.Inflight("server1"))
+	}
+
+	release1()
+	release1() // Releasing twice must not double count
+	if tracker.Inflight("server1") != 1 {
+		t.Errorf("expected 1 in-flight, got %d", tracker.Inflight("server1"))
+	}
+
+	release2()
+	if tracker.Inflight("server1") != 0 {
+		t.Errorf("expected 0 in-flight, got %d", tracker.Inflight("server1"))
+	}
+}
+
+func TestDrain(t *testing.T) {
+	tracker := New()
+
+	release, _ := tracker.Acquire("server1")
+
+	drained := make(chan error, 1)
+	go func() {
+		drained <- tracker.Drain(context.Background(), "server1")
+	}()
+
+	// Wait until the drain has started
+	for !tracker.IsDraining("server1") {
+		time.Sleep(time.Millisecond)
+	}
+
+	// New requests are rejected while draining
+	if _, err := tracker.Acquire("server1"); err != ErrDraining {
+		t.Errorf("expected ErrDraining, got %v", err)
+	}
+
+	select {
+	case <-drained:
+		t.Fatal("drain finished while requests were in flight")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	release()
+
+	select {
+	case err := <-drained:
+		if err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("drain did not finish after release")
+	}
+
+	tracker.Undrain("server1")
+	if _, err := tracker.Acquire("server1"); err != nil {
+		t.Errorf("expected no error after undrain, got %v", err)
+	}
+}
+
+func TestDrainDeadline(t *testing.T) {
+	tracker := New()
+	tracker.Acquire("server1")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := tracker.Drain(ctx, "server1")
+	if err != context.DeadlineExceeded {
+		t.Errorf("expected DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestDrainAndRemove(t *testing.T) {
+	ring := chash.NewWithNodes(chash.Config{Replicas: 3}, []string{"server1", "server2"})
+	tracker := New()
+
+	// Node with outstanding requests stays in the ring on timeout
+	release, _ := tracker.Acquire("server1")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := tracker.DrainAndRemove(ctx, ring, "server1"); err != context.DeadlineExceeded {
+		t.Errorf("expected DeadlineExceeded, got %v", err)
+	}
+	if ring.NodeCount() != 2 {
+		t.Errorf("expected node to remain in ring, got %d nodes", ring.NodeCount())
+	}
+
+	release()
+
+	if err := tracker.DrainAndRemove(context.Background(), ring, "server1"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if ring.NodeCount() != 1 {
+		t.Errorf("expected 1 node after removal, got %d", ring.NodeCount())
+	}
+
+	// A node re-added under the same name takes requests again
+	if tracker.IsDraining("server1") {
+		t.Error("expected the removed node to be forgotten")
+	}
+	if err := ring.AddNode("server1"); err != nil {
+		t.Fatalf("AddNode failed: %v", err)
+	}
+	release, err := tracker.Acquire("server1")
+	if err != nil {
+		t.Fatalf("expected re-added node to accept requests, got %v", err)
+	}
+	release()
+	if n := len(tracker.nodes); n != 1 {
+		t.Errorf("expected one tracked node, got %d", n)
+	}
+}
+
+func TestConcurrentAcquire(t *testing.T) {
+	tracker := New()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			release, err := tracker.Acquire("server1")
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+			release()
+		}()
+	}
+
+	wg.Wait()
+
+	if tracker.Inflight("server1") != 0 {
+		t.Errorf("expected 0 in-flight, got %d", tracker.Inflight("server1"))
+	}
+}
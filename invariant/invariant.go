@@ -0,0 +1,170 @@
+// Package invariant checks internal consistency conditions, such as ring
+// ownership and monotonic epochs, that should never fail. Violations panic
+// in tests and are logged and counted in production, and checks cost a
+// single atomic load while disabled.
+
+package invariant
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Mode controls what happens when an invariant is violated
+type Mode int32
+
+const (
+	// Off skips checks entirely
+	Off Mode = iota
+
+	// Log logs and counts violations
+	Log
+
+	// Panic counts violations and panics with a Violation
+	Panic
+)
+
+// String returns the mode name
+func (m Mode) String() string {
+	switch m {
+	case Off:
+		return "off"
+	case Log:
+		return "log"
+	case Panic:
+		return "panic"
+	default:
+		return fmt.Sprintf("Mode(%d)", int32(m))
+	}
+}
+
+// ParseMode parses a mode name as printed by String
+func ParseMode(s string) (Mode, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "off":
+		return Off, nil
+	case "log":
+		return Log, nil
+	case "panic":
+		return Panic, nil
+	default:
+		return Off, fmt.Errorf("unknown invariant mode %q", s)
+	}
+}
+
+// Violation describes a failed invariant; it is the value Panic mode panics with
+type Violation struct {
+	// Name identifies the invariant, such as "chash/ring-sorted"
+	Name string
+
+	// Message describes the inconsistent state
+	Message string
+}
+
+// Error returns the violation as a message
+func (v Violation) Error() string {
+	return "invariant violated: " + v.Name + ": " + v.Message
+}
+
+var (
+	// mode is the current Mode
+	mode atomic.Int32
+
+	// mu protects counts and handler
+	mu      sync.Mutex
+	counts  = make(map[string]uint64)
+	handler = func(v Violation) { log.Print(v.Error()) }
+)
+
+// init picks the starting mode: DCORE_INVARIANTS if set, Panic with the
+// dcore_invariants build tag, and Off otherwise
+// Tests switch to Panic with SetMode from TestMain; checking for go test here
+// would link the testing package into every binary
+func init() {
+	m := buildMode
+	if env := os.Getenv("DCORE_INVARIANTS"); env != "" {
+		if parsed, err := ParseMode(env); err == nil {
+			m = parsed
+		}
+	}
+	mode.Store(int32(m))
+}
+
+// SetMode changes the mode at runtime and returns the previous one
+func SetMode(m Mode) Mode {
+	return Mode(mode.Swap(int32(m)))
+}
+
+// GetMode returns the current mode
+func GetMode() Mode {
+	return Mode(mode.Load())
+}
+
+// Enabled returns true unless checks are off; guard expensive checks with it
+func Enabled() bool {
+	return mode.Load() != int32(Off)
+}
+
+// SetHandler replaces the function Log mode reports violations to and
+// returns the previous one
+// Default: the standard logger
+func SetHandler(h func(Violation)) func(Violation) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	prev := handler
+	handler = h
+	return prev
+}
+
+// Check reports a violation of the invariant name if cond is false
+// The message is only formatted on failure
+func Check(cond bool, name, format string, args ...any) {
+	if cond {
+		return
+	}
+
+	m := GetMode()
+	if m == Off {
+		return
+	}
+
+	v := Violation{Name: name, Message: fmt.Sprintf(format, args...)}
+
+	mu.Lock()
+	counts[name]++
+	h := handler
+	mu.Unlock()
+
+	if m == Panic {
+		panic(v)
+	}
+	if h != nil {
+		h(v)
+	}
+}
+
+// Violations returns the number of violations of each invariant since start
+// or the last Reset, for export as metrics
+func Violations() map[string]uint64 {
+	mu.Lock()
+	defer mu.Unlock()
+
+	out := make(map[string]uint64, len(counts))
+	for name, n := range counts {
+		out[name] = n
+	}
+	return out
+}
+
+// Reset clears the violation counts
+func Reset() {
+	mu.Lock()
+	defer mu.Unlock()
+
+	clear(counts)
+}
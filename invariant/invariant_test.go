@@ -0,0 +1,93 @@
+package invariant
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	SetMode(Panic)
+	os.Exit(m.Run())
+}
+
+func TestModeUnderTest(t *testing.T) {
+	if GetMode() != Panic {
+		t.Errorf("expected Panic from TestMain, got %v", GetMode())
+	}
+	if !Enabled() {
+		t.Error("expected checks enabled")
+	}
+}
+
+func TestCheckPanics(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	defer func() {
+		r := recover()
+		v, ok := r.(Violation)
+		if !ok {
+			t.Fatalf("expected a Violation panic, got %v", r)
+		}
+		if v.Name != "test/panic" || v.Message != "got 3" {
+			t.Errorf("unexpected violation %+v", v)
+		}
+		var err error = v
+		if !errors.As(err, &v) || err.Error() != "invariant violated: test/panic: got 3" {
+			t.Errorf("unexpected error %q", err)
+		}
+		if n := Violations()["test/panic"]; n != 1 {
+			t.Errorf("expected 1 violation counted, got %d", n)
+		}
+	}()
+
+	Check(true, "test/panic", "never formatted")
+	Check(false, "test/panic", "got %d", 3)
+}
+
+func TestCheckLogs(t *testing.T) {
+	Reset()
+	defer Reset()
+	defer SetMode(SetMode(Log))
+
+	var got []Violation
+	defer SetHandler(SetHandler(func(v Violation) { got = append(got, v) }))
+
+	Check(false, "test/log", "first")
+	Check(false, "test/log", "second")
+	Check(true, "test/log", "fine")
+
+	if len(got) != 2 || got[1].Message != "second" {
+		t.Errorf("expected 2 reported violations, got %+v", got)
+	}
+	if n := Violations()["test/log"]; n != 2 {
+		t.Errorf("expected 2 violations counted, got %d", n)
+	}
+}
+
+func TestCheckOff(t *testing.T) {
+	Reset()
+	defer Reset()
+	defer SetMode(SetMode(Off))
+
+	if Enabled() {
+		t.Error("expected checks disabled")
+	}
+	Check(false, "test/off", "ignored")
+	if len(Violations()) != 0 {
+		t.Errorf("expected nothing counted, got %v", Violations())
+	}
+}
+
+func TestParseMode(t *testing.T) {
+	for _, m := range []Mode{Off, Log, Panic} {
+		parsed, err := ParseMode(m.String())
+		if err != nil || parsed != m {
+			t.Errorf("expected %v, got %v, %v", m, parsed, err)
+		}
+	}
+	if _, err := ParseMode("loud"); err == nil {
+		t.Error("expected error for unknown mode")
+	}
+}
@@ -0,0 +1,6 @@
+//go:build !dcore_invariants
+
+package invariant
+
+// buildMode is the starting mode without the dcore_invariants build tag
+const buildMode = Off
@@ -0,0 +1,194 @@
+// Package latency tracks per-peer round-trip latency and provides a read
+// policy that prefers the lowest-latency replica of a key.
+
+package latency
+
+import (
+	"errors"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/mohdrashid9678/dcore/chash"
+)
+
+// ErrNoReplicas is returned when a policy is asked to choose from an empty replica set
+var ErrNoReplicas = errors.New("no replicas to choose from")
+
+// Tracker keeps an exponentially weighted moving average (EWMA) of the
+// round-trip latency observed for each peer
+type Tracker struct {
+	// mu protects peers
+	mu sync.RWMutex
+
+	// alpha is the smoothing factor applied to new samples
+	alpha float64
+
+	// peers maps a peer name to its current estimate
+	peers map[string]*estimate
+}
+
+// estimate is the running latency estimate for a single peer
+type estimate struct {
+	ewma    float64
+	samples int64
+}
+
+// TrackerConfig holds configuration options for creating a new Tracker
+type TrackerConfig struct {
+	// Alpha is the EWMA smoothing factor in (0, 1]
+	// Higher values react faster to latency changes but are noisier
+	// Default: 0.3
+	Alpha float64
+}
+
+// NewTracker creates a new latency tracker with the given configuration
+func NewTracker(config TrackerConfig) *Tracker {
+	if config.Alpha <= 0 || config.Alpha > 1 {
+		config.Alpha = 0.3 // Default smoothing factor
+	}
+
+	return &Tracker{
+		alpha: config.Alpha,
+		peers: make(map[string]*estimate),
+	}
+}
+
+// Observe records a round-trip latency sample for the given peer
+// Callers typically invoke this from their transport after every request
+func (t *Tracker) Observe(peer string, rtt time.Duration) {
+	if peer == "" || rtt < 0 {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	e, exists := t.peers[peer]
+	if !exists {
+		// The first sample seeds the average directly
+		t.peers[peer] = &estimate{ewma: float64(rtt), samples: 1}
+		return
+	}
+
+	e.ewma = t.alpha*float64(rtt) + (1-t.alpha)*e.ewma
+	e.samples++
+}
+
+// Estimate returns the current latency estimate for the given peer
+// The boolean is false if no samples have been observed yet
+func (t *Tracker) Estimate(peer string) (time.Duration, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	e, exists := t.peers[peer]
+	if !exists {
+		return 0, false
+	}
+
+	return time.Duration(e.ewma), true
+}
+
+// Samples returns the number of samples observed for the given peer
+func (t *Tracker) Samples(peer string) int64 {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if e, exists := t.peers[peer]; exists {
+		return e.samples
+	}
+	return 0
+}
+
+// Forget drops all latency history for the given peer
+// Useful when a node is removed from the ring
+func (t *Tracker) Forget(peer string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.peers, peer)
+}
+
+// NearestPolicy selects the lowest-latency replica from an ownership set
+// A small fraction of picks explore a random replica so that estimates for
+// slower or unknown peers keep getting refreshed
+type NearestPolicy struct {
+	tracker *Tracker
+
+	// exploration is the probability of picking a random replica
+	exploration float64
+
+	// random returns a float in [0, 1); replaceable in tests
+	random func() float64
+
+	// intn returns an int in [0, n); replaceable in tests
+	intn func(n int) int
+}
+
+// PolicyConfig holds configuration options for creating a new NearestPolicy
+type PolicyConfig struct {
+	// Exploration is the probability in [0, 1] of choosing a random replica
+	// instead of the fastest one
+	// Default: 0.05
+	Exploration float64
+}
+
+// NewNearestPolicy creates a read policy backed by the given tracker
+func NewNearestPolicy(tracker *Tracker, config PolicyConfig) *NearestPolicy {
+	if config.Exploration <= 0 || config.Exploration > 1 {
+		config.Exploration = 0.05 // Default exploration rate
+	}
+
+	return &NearestPolicy{
+		tracker:     tracker,
+		exploration: config.Exploration,
+		random:      rand.Float64,
+		intn:        rand.IntN,
+	}
+}
+
+// Pick returns the replica with the lowest latency estimate
+// Replicas without any samples are only chosen through exploration, or when
+// no replica has an estimate, in which case the first (primary) replica wins
+func (p *NearestPolicy) Pick(replicas []string) (string, error) {
+	if len(replicas) == 0 {
+		return "", ErrNoReplicas
+	}
+
+	if len(replicas) > 1 && p.random() < p.exploration {
+		return replicas[p.intn(len(replicas))], nil
+	}
+
+	best := replicas[0]
+	var bestLatency time.Duration
+	found := false
+
+	p.tracker.mu.RLock()
+	defer p.tracker.mu.RUnlock()
+
+	for _, replica := range replicas {
+		e, exists := p.tracker.peers[replica]
+		if !exists {
+			continue
+		}
+
+		latency := time.Duration(e.ewma)
+		if !found || latency < bestLatency {
+			best = replica
+			bestLatency = latency
+			found = true
+		}
+	}
+
+	return best, nil
+}
+
+// GetNode returns the lowest-latency node among the first count owners of key
+func (p *NearestPolicy) GetNode(ring *chash.Ring, key string, count int) (string, error) {
+	replicas, err := ring.GetNodes(key, count)
+	if err != nil {
+		return "", err
+	}
+
+	return p.Pick(replicas)
+}
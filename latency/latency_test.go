@@ -0,0 +1,136 @@
+package latency
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mohdrashid9678/dcore/chash"
+)
+
+func TestTrackerObserve(t *testing.T) {
+	tracker := NewTracker(TrackerConfig{Alpha: 0.5})
+
+	if _, ok := tracker.Estimate("server1"); ok {
+		t.Error("expected no estimate for unknown peer")
+	}
+
+	tracker.Observe("server1", 100*time.Millisecond)
+	estimate, ok := tracker.Estimate("server1")
+	if !ok {
+		t.Fatal("expected estimate after first sample")
+	}
+	if estimate != 100*time.Millisecond {
+		t.Errorf("expected first sample to seed estimate, got %v", estimate)
+	}
+
+	tracker.Observe("server1", 200*time.Millisecond)
+	estimate, _ = tracker.Estimate("server1")
+	if estimate != 150*time.Millisecond {
+		t.Errorf("expected 150ms, got %v", estimate)
+	}
+
+	if tracker.Samples("server1") != 2 {
+		t.Errorf("expected 2 samples, got %d", tracker.Samples("server1"))
+	}
+
+	tracker.Forget("server1")
+	if _, ok := tracker.Estimate("server1"); ok {
+		t.Error("expected estimate to be dropped after Forget")
+	}
+}
+
+func TestNewTrackerDefaults(t *testing.T) {
+	tests := []struct {
+		name     string
+		alpha    float64
+		expected float64
+	}{
+		{"zero alpha defaults", 0, 0.3},
+		{"negative alpha defaults", -1, 0.3},
+		{"alpha above one defaults", 2, 0.3},
+		{"custom alpha", 0.8, 0.8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := NewTracker(TrackerConfig{Alpha: tt.alpha})
+			if tracker.alpha != tt.expected {
+				t.Errorf("expected alpha %v, got %v", tt.expected, tracker.alpha)
+			}
+		})
+	}
+}
+
+func TestNearestPolicyPick(t *testing.T) {
+	tracker := NewTracker(TrackerConfig{})
+	policy := NewNearestPolicy(tracker, PolicyConfig{})
+	policy.random = func() float64 { return 1 } // never explore
+
+	// Test empty replica set
+	if _, err := policy.Pick(nil); err != ErrNoReplicas {
+		t.Errorf("expected ErrNoReplicas, got %v", err)
+	}
+
+	replicas := []string{"server1", "server2", "server3"}
+
+	// Without estimates the primary wins
+	node, err := policy.Pick(replicas)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if node != "server1" {
+		t.Errorf("expected primary server1, got %s", node)
+	}
+
+	tracker.Observe("server1", 50*time.Millisecond)
+	tracker.Observe("server2", 5*time.Millisecond)
+	tracker.Observe("server3", 20*time.Millisecond)
+
+	node, _ = policy.Pick(replicas)
+	if node != "server2" {
+		t.Errorf("expected fastest server2, got %s", node)
+	}
+}
+
+func TestNearestPolicyExploration(t *testing.T) {
+	tracker := NewTracker(TrackerConfig{})
+	tracker.Observe("server1", 5*time.Millisecond)
+	tracker.Observe("server2", 50*time.Millisecond)
+
+	policy := NewNearestPolicy(tracker, PolicyConfig{Exploration: 0.5})
+	policy.random = func() float64 { return 0 } // always explore
+	policy.intn = func(n int) int { return n - 1 }
+
+	node, _ := policy.Pick([]string{"server1", "server2"})
+	if node != "server2" {
+		t.Errorf("expected exploration to pick server2, got %s", node)
+	}
+}
+
+func TestNearestPolicyGetNode(t *testing.T) {
+	ring := chash.NewWithNodes(chash.Config{Replicas: 10}, []string{"server1", "server2", "server3"})
+	tracker := NewTracker(TrackerConfig{})
+	policy := NewNearestPolicy(tracker, PolicyConfig{})
+	policy.random = func() float64 { return 1 }
+
+	owners, err := ring.GetNodes("user123", 2)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// Make the secondary owner the fastest
+	tracker.Observe(owners[0], 40*time.Millisecond)
+	tracker.Observe(owners[1], 4*time.Millisecond)
+
+	node, err := policy.GetNode(ring, "user123", 2)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if node != owners[1] {
+		t.Errorf("expected %s, got %s", owners[1], node)
+	}
+
+	if _, err := policy.GetNode(ring, "", 2); err != chash.ErrEmptyKey {
+		t.Errorf("expected ErrEmptyKey, got %v", err)
+	}
+}
@@ -0,0 +1,368 @@
+// Package maintenance schedules maintenance windows for ring nodes: takeover
+// owners are pre-computed and warmed ahead of time, and nodes are drained,
+// disabled and restored automatically at the window boundaries.
+
+package maintenance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mohdrashid9678/dcore/chash"
+	"github.com/mohdrashid9678/dcore/clock"
+	"github.com/mohdrashid9678/dcore/inflight"
+)
+
+var (
+	// ErrNoRing is returned when a scheduler is created without a ring
+	ErrNoRing = errors.New("maintenance scheduler requires a ring")
+
+	// ErrInvalidWindow is returned when a window has no nodes or ends before it starts
+	ErrInvalidWindow = errors.New("window must name nodes and end after it starts")
+
+	// ErrWindowNotFound is returned when referring to an unknown or finished window
+	ErrWindowNotFound = errors.New("maintenance window not found")
+
+	// ErrClosed is returned when scheduling on a closed scheduler
+	ErrClosed = errors.New("maintenance scheduler is closed")
+)
+
+// Phase is the lifecycle stage of a maintenance window
+type Phase int
+
+const (
+	// Scheduled windows are waiting for their warm-up to begin
+	Scheduled Phase = iota
+
+	// Warming windows have computed their takeover plan and are pre-warming owners
+	Warming
+
+	// Draining windows are waiting for in-flight requests before disabling nodes
+	Draining
+
+	// Active windows have their nodes disabled
+	Active
+
+	// Completed windows have restored their nodes
+	Completed
+
+	// Cancelled windows were stopped early; any disabled nodes were restored
+	Cancelled
+)
+
+// String returns the phase name
+func (p Phase) String() string {
+	switch p {
+	case Scheduled:
+		return "scheduled"
+	case Warming:
+		return "warming"
+	case Draining:
+		return "draining"
+	case Active:
+		return "active"
+	case Completed:
+		return "completed"
+	case Cancelled:
+		return "cancelled"
+	default:
+		return "unknown"
+	}
+}
+
+// Window declares that nodes will be out of service between Start and End
+// To take a whole zone or rack down, list all of its nodes
+type Window struct {
+	Nodes  []string
+	Start  time.Time
+	End    time.Time
+	Reason string
+}
+
+// Event notifies a window's transition to a new phase
+type Event struct {
+	ID     int
+	Window Window
+	Phase  Phase
+
+	// Plan is the takeover plan, set from the Warming phase onwards
+	Plan []chash.Handoff
+
+	// Err reports a problem during the transition, such as a warm-up failure,
+	// a drain that hit its timeout before nodes were disabled anyway, or a node
+	// that could not be restored because it left the ring during the window
+	Err error
+}
+
+// WarmFunc pre-warms takeover owners before a window starts
+type WarmFunc func(ctx context.Context, window Window, plan []chash.Handoff) error
+
+// Config holds configuration options for creating a new Scheduler
+type Config struct {
+	// Ring is the ring whose nodes are disabled and restored (required)
+	Ring *chash.Ring
+
+	// Tracker waits for in-flight requests before nodes are disabled
+	// Default: nil (nodes are disabled immediately)
+	Tracker *inflight.Tracker
+
+	// WarmLead is how long before Start the takeover plan is computed and warmed
+	// Default: 5 minutes
+	WarmLead time.Duration
+
+	// DrainTimeout bounds the wait for in-flight requests; nodes are disabled
+	// when it expires
+	// Default: 30 seconds
+	DrainTimeout time.Duration
+
+	// Warm is called with the takeover plan at the start of the Warming phase
+	// Default: nil (no warm-up)
+	Warm WarmFunc
+
+	// Notify is called on every phase transition from the window's goroutine
+	// Default: nil (no notifications)
+	Notify func(Event)
+
+	// Clock supplies the time windows are scheduled against and drains are timed on
+	// Default: the wall clock
+	Clock clock.Clock
+}
+
+// Scheduler runs maintenance windows against a ring
+type Scheduler struct {
+	config Config
+
+	// mu protects all fields below
+	mu      sync.Mutex
+	nextID  int
+	windows map[int]*scheduled
+	closed  bool
+
+	// wg tracks window goroutines
+	wg sync.WaitGroup
+}
+
+// scheduled is the state of a pending or running window
+type scheduled struct {
+	window Window
+	phase  Phase
+	cancel context.CancelFunc
+}
+
+// New creates a new maintenance scheduler with the given configuration
+func New(config Config) (*Scheduler, error) {
+	if config.Ring == nil {
+		return nil, ErrNoRing
+	}
+
+	if config.WarmLead <= 0 {
+		config.WarmLead = 5 * time.Minute // Default warm-up lead time
+	}
+
+	if config.DrainTimeout <= 0 {
+		config.DrainTimeout = 30 * time.Second // Default drain timeout
+	}
+
+	config.Clock = clock.OrReal(config.Clock)
+
+	return &Scheduler{
+		config:  config,
+		windows: make(map[int]*scheduled),
+	}, nil
+}
+
+// Schedule registers a window and returns its ID
+// Windows whose start has already passed begin immediately
+func (s *Scheduler) Schedule(window Window) (int, error) {
+	if len(window.Nodes) == 0 || !window.End.After(window.Start) {
+		return 0, ErrInvalidWindow
+	}
+	window.Nodes = append([]string(nil), window.Nodes...)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return 0, ErrClosed
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	s.nextID++
+	id := s.nextID
+	sw := &scheduled{window: window, phase: Scheduled, cancel: cancel}
+	s.windows[id] = sw
+
+	s.wg.Add(1)
+	go s.run(ctx, id, sw)
+
+	return id, nil
+}
+
+// Cancel stops a window; nodes already disabled are restored immediately
+func (s *Scheduler) Cancel(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sw, exists := s.windows[id]
+	if !exists {
+		return ErrWindowNotFound
+	}
+
+	sw.cancel()
+	return nil
+}
+
+// Phase returns the current phase of a pending or running window
+func (s *Scheduler) Phase(id int) (Phase, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sw, exists := s.windows[id]
+	if !exists {
+		return 0, ErrWindowNotFound
+	}
+	return sw.phase, nil
+}
+
+// Close cancels every window, restores disabled nodes and waits for the
+// window goroutines to exit
+func (s *Scheduler) Close() {
+	s.mu.Lock()
+	s.closed = true
+	for _, sw := range s.windows {
+		sw.cancel()
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+}
+
+// run drives a window through its phases
+func (s *Scheduler) run(ctx context.Context, id int, sw *scheduled) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.windows, id)
+		s.mu.Unlock()
+	}()
+
+	window := sw.window
+
+	if !s.sleepUntil(ctx, window.Start.Add(-s.config.WarmLead)) {
+		s.transition(id, sw, Cancelled, nil, nil)
+		return
+	}
+
+	plan := s.config.Ring.TakeoverPlan(window.Nodes...)
+	var warmErr error
+	if s.config.Warm != nil {
+		warmErr = s.config.Warm(ctx, window, plan)
+	}
+	s.transition(id, sw, Warming, plan, warmErr)
+
+	if !s.sleepUntil(ctx, window.Start) {
+		s.transition(id, sw, Cancelled, plan, nil)
+		return
+	}
+
+	s.transition(id, sw, Draining, plan, nil)
+	disabled, drainErr := s.drain(ctx, window)
+	s.transition(id, sw, Active, plan, drainErr)
+
+	finished := s.sleepUntil(ctx, window.End)
+	restoreErr := s.restore(disabled)
+
+	if finished {
+		s.transition(id, sw, Completed, plan, restoreErr)
+	} else {
+		s.transition(id, sw, Cancelled, plan, restoreErr)
+	}
+}
+
+// drain marks the window's nodes draining, waits for in-flight requests and
+// disables them so lookups skip them, returning the state each node had before
+// Nodes stay in the ring, so their weights, tokens, topology, metadata and
+// role survive the window untouched
+func (s *Scheduler) drain(ctx context.Context, window Window) (map[string]chash.NodeState, error) {
+	disabled := make(map[string]chash.NodeState, len(window.Nodes))
+	var errs []error
+
+	for _, node := range window.Nodes {
+		state, err := s.config.Ring.NodeState(node)
+		if err != nil {
+			continue // Not in the ring; nothing to take down
+		}
+
+		if state == chash.NodeActive {
+			if err := s.config.Ring.SetNodeState(node, chash.NodeDraining); err != nil {
+				errs = append(errs, fmt.Errorf("node %s: %w", node, err))
+				continue
+			}
+		}
+
+		if s.config.Tracker != nil {
+			drainCtx, cancel := clock.WithTimeout(ctx, s.config.Clock, s.config.DrainTimeout)
+			if err := s.config.Tracker.Drain(drainCtx, node); err != nil {
+				errs = append(errs, err)
+			}
+			cancel()
+		}
+
+		if err := s.config.Ring.SetNodeState(node, chash.NodeDisabled); err != nil {
+			errs = append(errs, fmt.Errorf("node %s: %w", node, err))
+			continue
+		}
+		disabled[node] = state
+	}
+
+	return disabled, errors.Join(errs...)
+}
+
+// restore returns disabled nodes to the state they had before the window and
+// puts them back in service
+func (s *Scheduler) restore(disabled map[string]chash.NodeState) error {
+	var errs []error
+	for node, state := range disabled {
+		if err := s.config.Ring.SetNodeState(node, state); err != nil {
+			errs = append(errs, fmt.Errorf("node %s: %w", node, err))
+		}
+
+		if s.config.Tracker != nil {
+			s.config.Tracker.Undrain(node)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// transition records a window's new phase and notifies the listener
+func (s *Scheduler) transition(id int, sw *scheduled, phase Phase, plan []chash.Handoff, err error) {
+	s.mu.Lock()
+	sw.phase = phase
+	s.mu.Unlock()
+
+	if s.config.Notify != nil {
+		s.config.Notify(Event{ID: id, Window: sw.window, Phase: phase, Plan: plan, Err: err})
+	}
+}
+
+// sleepUntil waits until t and returns false if ctx is cancelled first
+func (s *Scheduler) sleepUntil(ctx context.Context, t time.Time) bool {
+	d := s.config.Clock.Until(t)
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+
+	timer := s.config.Clock.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C():
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
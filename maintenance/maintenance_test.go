@@ -0,0 +1,319 @@
+package maintenance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mohdrashid9678/dcore/chash"
+	"github.com/mohdrashid9678/dcore/clock"
+	"github.com/mohdrashid9678/dcore/inflight"
+)
+
+// recorder collects notifications from window goroutines
+type recorder struct {
+	mu     sync.Mutex
+	events []Event
+	ch     chan Event
+}
+
+func newRecorder() *recorder {
+	return &recorder{ch: make(chan Event, 16)}
+}
+
+func (r *recorder) notify(e Event) {
+	r.mu.Lock()
+	r.events = append(r.events, e)
+	r.mu.Unlock()
+	r.ch <- e
+}
+
+// waitFor blocks until an event with the given phase arrives
+func (r *recorder) waitFor(t *testing.T, phase Phase) Event {
+	t.Helper()
+
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case e := <-r.ch:
+			if e.Phase == phase {
+				return e
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for %s", phase)
+		}
+	}
+}
+
+func TestWindowLifecycle(t *testing.T) {
+	ring := chash.NewWithNodes(chash.Config{Replicas: 20}, []string{"server1", "server3"})
+	ring.AddNodeWithWeight("server2", 2)
+
+	rec := newRecorder()
+	var warmed []chash.Handoff
+	s, _ := New(Config{
+		Ring:     ring,
+		WarmLead: 20 * time.Millisecond,
+		Warm: func(ctx context.Context, w Window, plan []chash.Handoff) error {
+			warmed = plan
+			return nil
+		},
+		Notify: rec.notify,
+	})
+	defer s.Close()
+
+	start := time.Now().Add(40 * time.Millisecond)
+	id, err := s.Schedule(Window{
+		Nodes:  []string{"server2"},
+		Start:  start,
+		End:    start.Add(40 * time.Millisecond),
+		Reason: "kernel upgrade",
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	warming := rec.waitFor(t, Warming)
+	if time.Now().After(start) {
+		t.Error("expected warm-up before the window starts")
+	}
+	if len(warming.Plan) == 0 || len(warmed) != len(warming.Plan) {
+		t.Errorf("expected takeover plan to be warmed, got %d handoffs", len(warmed))
+	}
+	for _, h := range warming.Plan {
+		if h.From != "server2" {
+			t.Errorf("unexpected handoff %+v", h)
+		}
+	}
+
+	rec.waitFor(t, Active)
+	if phase, _ := s.Phase(id); phase != Active {
+		t.Errorf("expected active phase, got %s", phase)
+	}
+	if state, _ := ring.NodeState("server2"); state != chash.NodeDisabled {
+		t.Errorf("expected server2 disabled during maintenance, got %s", state)
+	}
+	for i := 0; i < 100; i++ {
+		if node, _ := ring.GetNode(fmt.Sprintf("key%d", i)); node == "server2" {
+			t.Fatal("expected lookups to skip server2 during maintenance")
+		}
+	}
+
+	e := rec.waitFor(t, Completed)
+	if e.Err != nil {
+		t.Errorf("expected a clean restore, got %v", e.Err)
+	}
+	if state, _ := ring.NodeState("server2"); state != chash.NodeActive {
+		t.Errorf("expected server2 active again, got %s", state)
+	}
+	if weight, err := ring.Weight("server2"); err != nil || weight != 2 {
+		t.Errorf("expected server2 kept with weight 2, got %v (%v)", weight, err)
+	}
+}
+
+func TestWindowWaitsForInflight(t *testing.T) {
+	ring := chash.NewWithNodes(chash.Config{Replicas: 20}, []string{"server1", "server2"})
+	tracker := inflight.New()
+	rec := newRecorder()
+
+	s, _ := New(Config{Ring: ring, Tracker: tracker, Notify: rec.notify})
+	defer s.Close()
+
+	release, _ := tracker.Acquire("server2")
+
+	now := time.Now()
+	s.Schedule(Window{Nodes: []string{"server2"}, Start: now, End: now.Add(time.Hour)})
+
+	rec.waitFor(t, Draining)
+	time.Sleep(10 * time.Millisecond)
+	if state, _ := ring.NodeState("server2"); state != chash.NodeDraining {
+		t.Errorf("expected server2 draining while requests are in flight, got %s", state)
+	}
+
+	release()
+	rec.waitFor(t, Active)
+	if state, _ := ring.NodeState("server2"); state != chash.NodeDisabled {
+		t.Errorf("expected server2 disabled after draining, got %s", state)
+	}
+	if !tracker.IsDraining("server2") {
+		t.Error("expected server2 draining during maintenance")
+	}
+}
+
+func TestWindowOnFakeClock(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	ring := chash.NewWithNodes(chash.Config{Replicas: 20}, []string{"server1", "server2"})
+	tracker := inflight.New()
+	rec := newRecorder()
+
+	s, _ := New(Config{Ring: ring, Tracker: tracker, Notify: rec.notify, Clock: clk})
+	defer s.Close()
+
+	// A request that never finishes: the drain must time out on the fake clock
+	tracker.Acquire("server2")
+
+	start := clk.Now().Add(time.Hour)
+	s.Schedule(Window{Nodes: []string{"server2"}, Start: start, End: start.Add(time.Hour)})
+
+	clk.BlockUntil(1)
+	clk.Advance(55 * time.Minute)
+	rec.waitFor(t, Warming)
+
+	clk.BlockUntil(1)
+	clk.Advance(5 * time.Minute)
+	rec.waitFor(t, Draining)
+
+	clk.BlockUntil(1)
+	clk.Advance(30 * time.Second)
+	rec.waitFor(t, Active)
+	if state, _ := ring.NodeState("server2"); state != chash.NodeDisabled {
+		t.Errorf("expected server2 disabled once the drain timed out, got %s", state)
+	}
+
+	clk.BlockUntil(1)
+	clk.Advance(time.Hour)
+	rec.waitFor(t, Completed)
+	if state, _ := ring.NodeState("server2"); state != chash.NodeActive {
+		t.Errorf("expected server2 restored, got %s", state)
+	}
+}
+
+func TestCancelRestoresNodes(t *testing.T) {
+	ring := chash.NewWithNodes(chash.Config{Replicas: 20, Quarantine: time.Hour}, []string{"server1", "server2"})
+	tracker := inflight.New()
+	rec := newRecorder()
+
+	s, _ := New(Config{Ring: ring, Tracker: tracker, Notify: rec.notify})
+	defer s.Close()
+
+	now := time.Now()
+	id, _ := s.Schedule(Window{Nodes: []string{"server2"}, Start: now, End: now.Add(time.Hour)})
+	rec.waitFor(t, Active)
+
+	if err := s.Cancel(id); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	rec.waitFor(t, Cancelled)
+
+	// Never removed, so the quarantine does not apply
+	if state, _ := ring.NodeState("server2"); state != chash.NodeActive {
+		t.Errorf("expected server2 restored, got %s", state)
+	}
+	if tracker.IsDraining("server2") {
+		t.Error("expected server2 back in service")
+	}
+
+	// The window is gone once it finishes
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if _, err := s.Phase(id); err == ErrWindowNotFound {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Error("expected finished window to be forgotten")
+}
+
+func TestWindowKeepsNodeConfiguration(t *testing.T) {
+	ring := chash.New(chash.Config{Replicas: 20})
+	ring.AddNode("server1")
+	if err := ring.AddNodeWithTokens("server2", []uint64{1 << 62, 1 << 63}); err != nil {
+		t.Fatalf("AddNodeWithTokens failed: %v", err)
+	}
+	ring.SetTopology("server2", chash.Topology{Zone: "a"})
+	ring.SetMeta("server2", "conn2")
+	ring.SetNodeRole("server2", chash.RoleReplica)
+	ring.AddNode("server3")
+	ring.SetNodeState("server3", chash.NodeDraining)
+	rec := newRecorder()
+
+	s, _ := New(Config{Ring: ring, Notify: rec.notify})
+	defer s.Close()
+
+	now := time.Now()
+	s.Schedule(Window{Nodes: []string{"server2", "server3"}, Start: now, End: now.Add(20 * time.Millisecond)})
+	rec.waitFor(t, Completed)
+
+	info, err := ring.NodeInfo("server2")
+	if err != nil {
+		t.Fatalf("expected server2 kept, got %v", err)
+	}
+	if tokens, _ := ring.Tokens("server2"); len(tokens) != 2 || tokens[0] != 1<<62 {
+		t.Errorf("expected manual tokens kept, got %v", tokens)
+	}
+	if info.Topology.Zone != "a" || info.Meta != "conn2" || info.Role != chash.RoleReplica || info.State != chash.NodeActive {
+		t.Errorf("expected server2 configuration kept, got %+v", info)
+	}
+	if state, _ := ring.NodeState("server3"); state != chash.NodeDraining {
+		t.Errorf("expected server3 back to its previous state, got %s", state)
+	}
+}
+
+func TestWindowReportsRestoreFailure(t *testing.T) {
+	ring := chash.NewWithNodes(chash.Config{Replicas: 20}, []string{"server1", "server2"})
+	rec := newRecorder()
+
+	s, _ := New(Config{Ring: ring, Notify: rec.notify})
+	defer s.Close()
+
+	now := time.Now()
+	id, _ := s.Schedule(Window{Nodes: []string{"server2"}, Start: now, End: now.Add(time.Hour)})
+	rec.waitFor(t, Active)
+
+	// The node leaves the ring during the window
+	ring.RemoveNode("server2")
+	s.Cancel(id)
+
+	if e := rec.waitFor(t, Cancelled); !errors.Is(e.Err, chash.ErrNodeNotFound) {
+		t.Errorf("expected the restore failure reported, got %v", e.Err)
+	}
+}
+
+func TestCancelBeforeStart(t *testing.T) {
+	ring := chash.NewWithNodes(chash.Config{Replicas: 20}, []string{"server1", "server2"})
+	rec := newRecorder()
+
+	s, _ := New(Config{Ring: ring, WarmLead: time.Minute, Notify: rec.notify})
+
+	start := time.Now().Add(time.Hour)
+	id, _ := s.Schedule(Window{Nodes: []string{"server2"}, Start: start, End: start.Add(time.Hour)})
+
+	if phase, _ := s.Phase(id); phase != Scheduled {
+		t.Errorf("expected scheduled phase, got %s", phase)
+	}
+
+	s.Close()
+
+	if len(rec.events) != 1 || rec.events[0].Phase != Cancelled {
+		t.Errorf("expected a single cancelled event, got %+v", rec.events)
+	}
+	if ring.NodeCount() != 2 {
+		t.Errorf("expected ring untouched, got %d nodes", ring.NodeCount())
+	}
+	if _, err := s.Schedule(Window{Nodes: []string{"server1"}, Start: start, End: start.Add(time.Hour)}); err != ErrClosed {
+		t.Errorf("expected ErrClosed, got %v", err)
+	}
+}
+
+func TestValidation(t *testing.T) {
+	if _, err := New(Config{}); err != ErrNoRing {
+		t.Errorf("expected ErrNoRing, got %v", err)
+	}
+
+	s, _ := New(Config{Ring: chash.New(chash.Config{})})
+	defer s.Close()
+
+	now := time.Now()
+	if _, err := s.Schedule(Window{Start: now, End: now.Add(time.Hour)}); err != ErrInvalidWindow {
+		t.Errorf("expected ErrInvalidWindow, got %v", err)
+	}
+	if _, err := s.Schedule(Window{Nodes: []string{"a"}, Start: now, End: now}); err != ErrInvalidWindow {
+		t.Errorf("expected ErrInvalidWindow, got %v", err)
+	}
+	if err := s.Cancel(42); err != ErrWindowNotFound {
+		t.Errorf("expected ErrWindowNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,249 @@
+// Package membership keeps a chash ring in step with a gossip membership
+// cluster such as hashicorp/memberlist, and publishes each node's ring
+// metadata as its gossip node metadata so every member routes alike.
+
+package membership
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/mohdrashid9678/dcore/chash"
+)
+
+var (
+	// ErrNoRing is returned when an adapter is created without a ring
+	ErrNoRing = errors.New("ring is required")
+
+	// ErrInvalidMeta is returned when node metadata carries the dcore prefix
+	// but does not decode
+	ErrInvalidMeta = errors.New("invalid node metadata")
+)
+
+// metaPrefix marks node metadata written by EncodeMeta, so members that
+// publish other metadata are told apart from members running dcore
+var metaPrefix = []byte("dcore1:")
+
+// Meta is the ring metadata a member publishes about itself
+type Meta struct {
+	// Weight is the node's ring weight; zero means 1
+	Weight float64
+
+	// Topology labels the node's failure domains
+	Topology chash.Topology
+
+	// State is the node's lookup state
+	State chash.NodeState
+}
+
+// wireMeta is the JSON form of Meta; short keys fit memberlist's 512-byte limit
+type wireMeta struct {
+	Weight float64 `json:"w,omitempty"`
+	Region string  `json:"r,omitempty"`
+	Zone   string  `json:"z,omitempty"`
+	Rack   string  `json:"k,omitempty"`
+	State  string  `json:"s,omitempty"`
+}
+
+// EncodeMeta returns the node metadata encoding of m
+func EncodeMeta(m Meta) []byte {
+	w := wireMeta{
+		Region: m.Topology.Region,
+		Zone:   m.Topology.Zone,
+		Rack:   m.Topology.Rack,
+	}
+	if m.Weight != 1 {
+		w.Weight = m.Weight
+	}
+	if m.State != chash.NodeActive {
+		w.State = m.State.String()
+	}
+
+	encoded, _ := json.Marshal(w)
+	return append(append([]byte(nil), metaPrefix...), encoded...)
+}
+
+// DecodeMeta parses node metadata written by EncodeMeta
+// ok is false if b was not written by EncodeMeta, such as the metadata of a
+// member that does not run dcore yet
+func DecodeMeta(b []byte) (m Meta, ok bool, err error) {
+	if !bytes.HasPrefix(b, metaPrefix) {
+		return Meta{Weight: 1}, false, nil
+	}
+
+	var w wireMeta
+	if err := json.Unmarshal(b[len(metaPrefix):], &w); err != nil {
+		return Meta{}, true, fmt.Errorf("%w: %v", ErrInvalidMeta, err)
+	}
+
+	m = Meta{
+		Weight:   w.Weight,
+		Topology: chash.Topology{Region: w.Region, Zone: w.Zone, Rack: w.Rack},
+	}
+	if m.Weight == 0 {
+		m.Weight = 1
+	}
+	if m.Weight < 0 || math.IsNaN(m.Weight) || math.IsInf(m.Weight, 0) {
+		return Meta{}, true, fmt.Errorf("%w: %v", ErrInvalidMeta, chash.ErrInvalidWeight)
+	}
+
+	switch w.State {
+	case "", chash.NodeActive.String():
+		m.State = chash.NodeActive
+	case chash.NodeDraining.String():
+		m.State = chash.NodeDraining
+	case chash.NodeDisabled.String():
+		m.State = chash.NodeDisabled
+	default:
+		return Meta{}, true, fmt.Errorf("%w: %w: %s", ErrInvalidMeta, chash.ErrInvalidNodeState, w.State)
+	}
+	return m, true, nil
+}
+
+// Config holds configuration options for creating a new Adapter
+type Config struct {
+	// Local is the local member's name; NodeMeta publishes its metadata
+	// Default: none (NodeMeta publishes Meta)
+	Local string
+
+	// Meta is published by NodeMeta while Local is not in the ring, such as
+	// before the local member has joined
+	// Default: weight 1, active, unlabeled
+	Meta Meta
+
+	// RequireMeta ignores members that do not publish dcore metadata, so a
+	// cluster can move to dcore routing one member at a time; otherwise they
+	// join with weight 1
+	// Default: false
+	RequireMeta bool
+}
+
+// Adapter applies membership events to a ring
+// Join, Leave and Update take a member's name and metadata, so they can be
+// called from a memberlist.EventDelegate without this package importing
+// memberlist. Adapter also implements memberlist.Delegate: passing it as the
+// delegate publishes the local node's ring metadata to the cluster
+type Adapter struct {
+	ring   *chash.Ring
+	config Config
+}
+
+// New creates an adapter that applies membership events to ring
+func New(ring *chash.Ring, config Config) (*Adapter, error) {
+	if ring == nil {
+		return nil, ErrNoRing
+	}
+	if config.Meta.Weight == 0 {
+		config.Meta.Weight = 1
+	}
+
+	return &Adapter{ring: ring, config: config}, nil
+}
+
+// Join adds a member that joined the cluster, or updates it if it is already
+// in the ring
+// Members without dcore metadata are skipped if RequireMeta is set
+func (a *Adapter) Join(name string, meta []byte) error {
+	m, ok, err := DecodeMeta(meta)
+	if err != nil {
+		return fmt.Errorf("member %s: %w", name, err)
+	}
+	if !ok && a.config.RequireMeta {
+		return nil
+	}
+
+	if err := a.ring.AddNodeWithWeight(name, m.Weight); err != nil {
+		if _, missing := a.ring.Weight(name); missing != nil {
+			return err
+		}
+		// Already present, such as a member that rejoined before its leave
+		// was seen
+		if err := a.ring.UpdateWeight(name, m.Weight); err != nil {
+			return err
+		}
+	}
+	return a.apply(name, m)
+}
+
+// Update applies the metadata of a member that changed it, such as to drain
+// A member that starts publishing dcore metadata under RequireMeta joins
+func (a *Adapter) Update(name string, meta []byte) error {
+	m, ok, err := DecodeMeta(meta)
+	if err != nil {
+		return fmt.Errorf("member %s: %w", name, err)
+	}
+
+	if _, err := a.ring.Weight(name); err != nil {
+		if !ok && a.config.RequireMeta {
+			return nil
+		}
+		return a.Join(name, meta)
+	}
+	if !ok && a.config.RequireMeta {
+		// The member stopped running dcore
+		return a.Leave(name)
+	}
+
+	if err := a.ring.UpdateWeight(name, m.Weight); err != nil {
+		return err
+	}
+	return a.apply(name, m)
+}
+
+// Leave removes a member that left or was declared dead
+// Members not in the ring are ignored
+func (a *Adapter) Leave(name string) error {
+	err := a.ring.RemoveNodeWithReason(name, "left membership")
+	if errors.Is(err, chash.ErrNodeNotFound) {
+		return nil
+	}
+	return err
+}
+
+// apply sets the topology and state of a member already in the ring
+func (a *Adapter) apply(name string, m Meta) error {
+	if err := a.ring.SetTopology(name, m.Topology); err != nil {
+		return err
+	}
+	return a.ring.SetNodeState(name, m.State)
+}
+
+// LocalMeta returns the metadata the local member publishes: its weight,
+// topology and state in the ring, or Config.Meta if it is not in the ring
+func (a *Adapter) LocalMeta() Meta {
+	weight, err := a.ring.Weight(a.config.Local)
+	if err != nil {
+		return a.config.Meta
+	}
+
+	m := Meta{Weight: weight}
+	m.Topology, _ = a.ring.Topology(a.config.Local)
+	m.State, _ = a.ring.NodeState(a.config.Local)
+	return m
+}
+
+// NodeMeta returns the encoded LocalMeta, or nil if it exceeds limit
+// It implements memberlist.Delegate; call the memberlist's UpdateNode after
+// changing the local node's ring metadata to gossip the change
+func (a *Adapter) NodeMeta(limit int) []byte {
+	meta := EncodeMeta(a.LocalMeta())
+	if len(meta) > limit {
+		return nil
+	}
+	return meta
+}
+
+// NotifyMsg implements memberlist.Delegate; the adapter sends no messages
+func (a *Adapter) NotifyMsg([]byte) {}
+
+// GetBroadcasts implements memberlist.Delegate; the adapter sends no broadcasts
+func (a *Adapter) GetBroadcasts(overhead, limit int) [][]byte { return nil }
+
+// LocalState implements memberlist.Delegate; membership alone defines the ring
+func (a *Adapter) LocalState(join bool) []byte { return nil }
+
+// MergeRemoteState implements memberlist.Delegate; see LocalState
+func (a *Adapter) MergeRemoteState(buf []byte, join bool) {}
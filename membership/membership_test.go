@@ -0,0 +1,148 @@
+package membership
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mohdrashid9678/dcore/chash"
+)
+
+func TestMetaRoundTrip(t *testing.T) {
+	want := Meta{
+		Weight:   2.5,
+		Topology: chash.Topology{Region: "eu", Zone: "eu-1a", Rack: "r7"},
+		State:    chash.NodeDraining,
+	}
+
+	got, ok, err := DecodeMeta(EncodeMeta(want))
+	if err != nil || !ok {
+		t.Fatalf("DecodeMeta failed: ok=%v err=%v", ok, err)
+	}
+	if got != want {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+
+	// Defaults are left out of the encoding
+	if enc := EncodeMeta(Meta{Weight: 1}); string(enc) != "dcore1:{}" {
+		t.Errorf("expected compact encoding, got %s", enc)
+	}
+
+	if _, ok, _ := DecodeMeta([]byte("role=web")); ok {
+		t.Error("expected foreign metadata to be reported as not dcore")
+	}
+	for _, bad := range []string{"dcore1:{", `dcore1:{"w":-1}`, `dcore1:{"s":"asleep"}`} {
+		if _, _, err := DecodeMeta([]byte(bad)); !errors.Is(err, ErrInvalidMeta) {
+			t.Errorf("%s: expected ErrInvalidMeta, got %v", bad, err)
+		}
+	}
+}
+
+func TestAdapterAppliesEvents(t *testing.T) {
+	ring := chash.New(chash.Config{Replicas: 10})
+	adapter, err := New(ring, Config{})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	meta := EncodeMeta(Meta{Weight: 2, Topology: chash.Topology{Zone: "a"}})
+	if err := adapter.Join("node1", meta); err != nil {
+		t.Fatalf("Join failed: %v", err)
+	}
+	if err := adapter.Join("node2", nil); err != nil {
+		t.Fatalf("Join without metadata failed: %v", err)
+	}
+
+	if w, _ := ring.Weight("node1"); w != 2 {
+		t.Errorf("expected weight 2, got %v", w)
+	}
+	if topo, _ := ring.Topology("node1"); topo.Zone != "a" {
+		t.Errorf("expected zone a, got %+v", topo)
+	}
+	if w, _ := ring.Weight("node2"); w != 1 {
+		t.Errorf("expected members without metadata to get weight 1, got %v", w)
+	}
+
+	// Draining is gossiped as an update
+	if err := adapter.Update("node1", EncodeMeta(Meta{Weight: 2, State: chash.NodeDraining})); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if state, _ := ring.NodeState("node1"); state != chash.NodeDraining {
+		t.Errorf("expected node1 draining, got %v", state)
+	}
+
+	// A repeated join updates instead of failing
+	if err := adapter.Join("node1", EncodeMeta(Meta{Weight: 3})); err != nil {
+		t.Fatalf("repeated Join failed: %v", err)
+	}
+	if w, _ := ring.Weight("node1"); w != 3 {
+		t.Errorf("expected weight 3 after rejoin, got %v", w)
+	}
+
+	if err := adapter.Leave("node1"); err != nil {
+		t.Fatalf("Leave failed: %v", err)
+	}
+	if err := adapter.Leave("node1"); err != nil {
+		t.Errorf("expected unknown member leave to be ignored, got %v", err)
+	}
+	if nodes := ring.Nodes(); len(nodes) != 1 || nodes[0] != "node2" {
+		t.Errorf("expected only node2, got %v", nodes)
+	}
+
+	if err := adapter.Join("node3", []byte("dcore1:{")); !errors.Is(err, ErrInvalidMeta) {
+		t.Errorf("expected ErrInvalidMeta, got %v", err)
+	}
+}
+
+func TestAdapterRequireMeta(t *testing.T) {
+	ring := chash.New(chash.Config{Replicas: 10})
+	adapter, _ := New(ring, Config{RequireMeta: true})
+
+	adapter.Join("legacy", []byte("role=web"))
+	if !ring.IsEmpty() {
+		t.Fatal("expected members without dcore metadata to be skipped")
+	}
+
+	// The member adopts dcore and gossips its metadata
+	adapter.Update("legacy", EncodeMeta(Meta{Weight: 1}))
+	if nodes := ring.Nodes(); len(nodes) != 1 {
+		t.Fatalf("expected member to join once it publishes metadata, got %v", nodes)
+	}
+
+	// ... and rolls back
+	adapter.Update("legacy", nil)
+	if !ring.IsEmpty() {
+		t.Error("expected member to leave once it stops publishing metadata")
+	}
+}
+
+func TestAdapterNodeMeta(t *testing.T) {
+	ring := chash.New(chash.Config{Replicas: 10})
+	adapter, _ := New(ring, Config{
+		Local: "self",
+		Meta:  Meta{Topology: chash.Topology{Zone: "b"}},
+	})
+
+	// Before joining, the configured metadata is published
+	m, _, _ := DecodeMeta(adapter.NodeMeta(512))
+	if m.Weight != 1 || m.Topology.Zone != "b" {
+		t.Errorf("expected configured metadata, got %+v", m)
+	}
+
+	adapter.Join("self", adapter.NodeMeta(512))
+	ring.SetNodeState("self", chash.NodeDraining)
+
+	m, _, _ = DecodeMeta(adapter.NodeMeta(512))
+	if m.State != chash.NodeDraining || m.Topology.Zone != "b" {
+		t.Errorf("expected ring metadata of the local node, got %+v", m)
+	}
+
+	if meta := adapter.NodeMeta(4); meta != nil {
+		t.Errorf("expected nil metadata over the limit, got %s", meta)
+	}
+}
+
+func TestNewRequiresRing(t *testing.T) {
+	if _, err := New(nil, Config{}); err != ErrNoRing {
+		t.Errorf("expected ErrNoRing, got %v", err)
+	}
+}
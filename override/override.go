@@ -0,0 +1,187 @@
+// Package override carries per-request routing and resilience overrides in a
+// context, for debugging and emergency traffic steering.
+
+package override
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+
+	"github.com/mohdrashid9678/dcore/chash"
+)
+
+var (
+	// ErrInvalidPriority is returned when a priority is outside the known levels
+	ErrInvalidPriority = errors.New("invalid priority")
+
+	// ErrInvalidConsistency is returned when a consistency level is unknown
+	ErrInvalidConsistency = errors.New("invalid consistency level")
+
+	// ErrUnknownReplica is returned when a forced replica is not a member of the ring
+	ErrUnknownReplica = errors.New("forced replica is not in the ring")
+)
+
+// Priority is the scheduling priority of a request
+type Priority int
+
+const (
+	// PriorityDefault leaves the priority unchanged
+	PriorityDefault Priority = iota
+	PriorityLow
+	PriorityNormal
+	PriorityHigh
+	PriorityCritical
+)
+
+// Consistency is the number of replicas that must participate in a request
+type Consistency int
+
+const (
+	// ConsistencyDefault leaves the consistency level unchanged
+	ConsistencyDefault Consistency = iota
+	ConsistencyOne
+	ConsistencyQuorum
+	ConsistencyAll
+)
+
+// String returns the name of the consistency level
+func (c Consistency) String() string {
+	switch c {
+	case ConsistencyOne:
+		return "one"
+	case ConsistencyQuorum:
+		return "quorum"
+	case ConsistencyAll:
+		return "all"
+	default:
+		return "default"
+	}
+}
+
+// Overrides holds the per-request policy overrides
+// The zero value overrides nothing
+type Overrides struct {
+	// Replica forces the request to a specific node instead of the ring's choice
+	Replica string
+
+	// DisableHedging turns off hedged requests
+	DisableHedging bool
+
+	// Priority raises or lowers the scheduling priority
+	Priority Priority
+
+	// Consistency sets the consistency level
+	Consistency Consistency
+}
+
+// Validate returns an error if any override holds an unknown value
+func (o Overrides) Validate() error {
+	if o.Priority < PriorityDefault || o.Priority > PriorityCritical {
+		return fmt.Errorf("%w: %d", ErrInvalidPriority, o.Priority)
+	}
+
+	if o.Consistency < ConsistencyDefault || o.Consistency > ConsistencyAll {
+		return fmt.Errorf("%w: %d", ErrInvalidConsistency, o.Consistency)
+	}
+
+	return nil
+}
+
+// IsZero returns true if no override is set
+func (o Overrides) IsZero() bool {
+	return o == Overrides{}
+}
+
+// contextKey is the private key type for storing overrides in a context
+type contextKey struct{}
+
+// logger receives a record every time overrides are attached or applied
+var logger atomic.Pointer[slog.Logger]
+
+// SetLogger sets the logger used to record applied overrides
+// Passing nil restores slog.Default()
+func SetLogger(l *slog.Logger) {
+	logger.Store(l)
+}
+
+// log returns the configured logger
+func log() *slog.Logger {
+	if l := logger.Load(); l != nil {
+		return l
+	}
+	return slog.Default()
+}
+
+// WithOverrides returns a copy of ctx carrying the given overrides
+// The overrides are validated and logged; invalid overrides are rejected
+func WithOverrides(ctx context.Context, o Overrides) (context.Context, error) {
+	if err := o.Validate(); err != nil {
+		return ctx, err
+	}
+
+	log().InfoContext(ctx, "request overrides attached",
+		slog.String("replica", o.Replica),
+		slog.Bool("disable_hedging", o.DisableHedging),
+		slog.Int("priority", int(o.Priority)),
+		slog.String("consistency", o.Consistency.String()),
+	)
+
+	return context.WithValue(ctx, contextKey{}, o), nil
+}
+
+// FromContext returns the overrides carried by ctx
+// The boolean is false if ctx carries no overrides
+func FromContext(ctx context.Context) (Overrides, bool) {
+	o, ok := ctx.Value(contextKey{}).(Overrides)
+	return o, ok
+}
+
+// HedgingDisabled returns true if ctx disables hedged requests
+func HedgingDisabled(ctx context.Context) bool {
+	o, _ := FromContext(ctx)
+	return o.DisableHedging
+}
+
+// PriorityFrom returns the priority carried by ctx, or fallback if none is set
+func PriorityFrom(ctx context.Context, fallback Priority) Priority {
+	if o, ok := FromContext(ctx); ok && o.Priority != PriorityDefault {
+		return o.Priority
+	}
+	return fallback
+}
+
+// ConsistencyFrom returns the consistency level carried by ctx, or fallback if none is set
+func ConsistencyFrom(ctx context.Context, fallback Consistency) Consistency {
+	if o, ok := FromContext(ctx); ok && o.Consistency != ConsistencyDefault {
+		return o.Consistency
+	}
+	return fallback
+}
+
+// GetNode returns the node for key, honouring a forced replica in ctx
+// A forced replica must be a member of the ring
+func GetNode(ctx context.Context, ring *chash.Ring, key string) (string, error) {
+	o, ok := FromContext(ctx)
+	if !ok || o.Replica == "" {
+		return ring.GetNode(key)
+	}
+
+	if key == "" {
+		return "", chash.ErrEmptyKey
+	}
+
+	// NodeState looks the node up without copying the member list
+	if _, err := ring.NodeState(o.Replica); err != nil {
+		return "", fmt.Errorf("%w: %s", ErrUnknownReplica, o.Replica)
+	}
+
+	log().InfoContext(ctx, "forced replica applied",
+		slog.String("key", key),
+		slog.String("replica", o.Replica),
+	)
+
+	return o.Replica, nil
+}
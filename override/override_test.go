@@ -0,0 +1,126 @@
+package override
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/mohdrashid9678/dcore/chash"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		overrides Overrides
+		expected  error
+	}{
+		{"zero value", Overrides{}, nil},
+		{"valid", Overrides{Priority: PriorityHigh, Consistency: ConsistencyQuorum}, nil},
+		{"priority too high", Overrides{Priority: PriorityCritical + 1}, ErrInvalidPriority},
+		{"negative priority", Overrides{Priority: -1}, ErrInvalidPriority},
+		{"unknown consistency", Overrides{Consistency: ConsistencyAll + 1}, ErrInvalidConsistency},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.overrides.Validate()
+			if !errors.Is(err, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestWithOverrides(t *testing.T) {
+	var buf bytes.Buffer
+	SetLogger(slog.New(slog.NewTextHandler(&buf, nil)))
+	defer SetLogger(nil)
+
+	ctx := context.Background()
+
+	if _, ok := FromContext(ctx); ok {
+		t.Error("expected no overrides in plain context")
+	}
+
+	// Invalid overrides are rejected
+	if _, err := WithOverrides(ctx, Overrides{Priority: 99}); !errors.Is(err, ErrInvalidPriority) {
+		t.Errorf("expected ErrInvalidPriority, got %v", err)
+	}
+
+	ctx, err := WithOverrides(ctx, Overrides{
+		DisableHedging: true,
+		Priority:       PriorityCritical,
+		Consistency:    ConsistencyAll,
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !strings.Contains(buf.String(), "request overrides attached") {
+		t.Errorf("expected overrides to be logged, got %q", buf.String())
+	}
+
+	if !HedgingDisabled(ctx) {
+		t.Error("expected hedging to be disabled")
+	}
+	if PriorityFrom(ctx, PriorityNormal) != PriorityCritical {
+		t.Error("expected critical priority")
+	}
+	if ConsistencyFrom(ctx, ConsistencyOne) != ConsistencyAll {
+		t.Error("expected consistency all")
+	}
+
+	// Fallbacks apply when nothing is overridden
+	plain := context.Background()
+	if PriorityFrom(plain, PriorityNormal) != PriorityNormal {
+		t.Error("expected fallback priority")
+	}
+	if ConsistencyFrom(plain, ConsistencyQuorum) != ConsistencyQuorum {
+		t.Error("expected fallback consistency")
+	}
+}
+
+func TestGetNode(t *testing.T) {
+	SetLogger(slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil)))
+	defer SetLogger(nil)
+
+	ring := chash.NewWithNodes(chash.Config{Replicas: 10}, []string{"server1", "server2", "server3"})
+
+	// Without overrides the ring decides
+	expected, _ := ring.GetNode("user123")
+	node, err := GetNode(context.Background(), ring, "user123")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if node != expected {
+		t.Errorf("expected %s, got %s", expected, node)
+	}
+
+	// Forced replica wins
+	forced := "server1"
+	if expected == forced {
+		forced = "server2"
+	}
+	ctx, _ := WithOverrides(context.Background(), Overrides{Replica: forced})
+	node, err = GetNode(ctx, ring, "user123")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if node != forced {
+		t.Errorf("expected forced %s, got %s", forced, node)
+	}
+
+	// Forced replica must exist
+	ctx, _ = WithOverrides(context.Background(), Overrides{Replica: "server9"})
+	if _, err := GetNode(ctx, ring, "user123"); !errors.Is(err, ErrUnknownReplica) {
+		t.Errorf("expected ErrUnknownReplica, got %v", err)
+	}
+
+	// Empty keys are still rejected
+	if _, err := GetNode(ctx, ring, ""); err != chash.ErrEmptyKey {
+		t.Errorf("expected ErrEmptyKey, got %v", err)
+	}
+}
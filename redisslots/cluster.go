@@ -0,0 +1,150 @@
+package redisslots
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// ParseClusterSlots reads the reply to CLUSTER SLOTS as most clients decode
+// it: nested []any holding int64 integers and string or []byte bulk strings
+// Each entry is the start and end slot, the master and then its replicas,
+// each an [ip, port, id, ...] array
+func ParseClusterSlots(reply []any) ([]Range, error) {
+	ranges := make([]Range, 0, len(reply))
+	for i, item := range reply {
+		entry, ok := item.([]any)
+		if !ok || len(entry) < 3 {
+			return nil, fmt.Errorf("%w: entry %d is not a slot range", ErrInvalidSlotMap, i)
+		}
+
+		start, ok1 := entry[0].(int64)
+		end, ok2 := entry[1].(int64)
+		if !ok1 || !ok2 {
+			return nil, fmt.Errorf("%w: entry %d has no slot bounds", ErrInvalidSlotMap, i)
+		}
+
+		rg := Range{Start: int(start), End: int(end)}
+		for j, n := range entry[2:] {
+			addr, err := parseSlotNode(n)
+			if err != nil {
+				return nil, fmt.Errorf("%w: entry %d: %v", ErrInvalidSlotMap, i, err)
+			}
+			if j == 0 {
+				rg.Master = addr
+			} else {
+				rg.Replicas = append(rg.Replicas, addr)
+			}
+		}
+		ranges = append(ranges, rg)
+	}
+	return ranges, nil
+}
+
+// parseSlotNode returns the "host:port" address of a CLUSTER SLOTS node
+func parseSlotNode(item any) (string, error) {
+	node, ok := item.([]any)
+	if !ok || len(node) < 2 {
+		return "", fmt.Errorf("malformed node %v", item)
+	}
+
+	var host string
+	switch h := node[0].(type) {
+	case string:
+		host = h
+	case []byte:
+		host = string(h)
+	}
+	port, ok := node[1].(int64)
+	if host == "" || host == "?" || !ok {
+		return "", fmt.Errorf("node %v has no address", node)
+	}
+	return net.JoinHostPort(host, strconv.FormatInt(port, 10)), nil
+}
+
+// FormatClusterSlots writes ranges in the CLUSTER SLOTS reply shape that
+// ParseClusterSlots reads, such as for a proxy or a test server answering
+// cluster clients; node ids are left empty
+func FormatClusterSlots(ranges []Range) ([]any, error) {
+	reply := make([]any, 0, len(ranges))
+	for _, rg := range ranges {
+		entry := []any{int64(rg.Start), int64(rg.End)}
+		for _, addr := range append([]string{rg.Master}, rg.Replicas...) {
+			host, port, err := net.SplitHostPort(addr)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidSlotMap, err)
+			}
+			p, err := strconv.ParseInt(port, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%w: node %s: bad port", ErrInvalidSlotMap, addr)
+			}
+			entry = append(entry, []any{host, p, ""})
+		}
+		reply = append(reply, entry)
+	}
+	return reply, nil
+}
+
+// ParseClusterNodes reads the text reply to CLUSTER NODES
+// Slots being imported or migrated are left to their current master; failed
+// nodes are still listed, as the cluster itself has not reassigned them
+func ParseClusterNodes(text string) ([]Range, error) {
+	type clusterNode struct {
+		addr   string
+		master string // Master id, "-" for masters
+		slots  []string
+	}
+
+	nodes := make(map[string]clusterNode)
+	var order []string
+	for _, line := range strings.Split(strings.TrimSpace(text), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) < 8 {
+			return nil, fmt.Errorf("%w: short line %q", ErrInvalidSlotMap, line)
+		}
+
+		// The address is ip:port@cport[,hostname]
+		addr, _, _ := strings.Cut(fields[1], "@")
+		id := fields[0]
+		nodes[id] = clusterNode{addr: addr, master: fields[3], slots: fields[8:]}
+		order = append(order, id)
+	}
+
+	replicas := make(map[string][]string)
+	for _, id := range order {
+		if n := nodes[id]; n.master != "-" {
+			replicas[n.master] = append(replicas[n.master], n.addr)
+		}
+	}
+
+	var ranges []Range
+	for _, id := range order {
+		n := nodes[id]
+		for _, slots := range n.slots {
+			if strings.HasPrefix(slots, "[") {
+				continue // [slot->-id] or [slot-<-id]: migration in progress
+			}
+
+			first, last, isRange := strings.Cut(slots, "-")
+			if !isRange {
+				last = first
+			}
+			start, err1 := strconv.Atoi(first)
+			end, err2 := strconv.Atoi(last)
+			if err1 != nil || err2 != nil {
+				return nil, fmt.Errorf("%w: bad slots %q", ErrInvalidSlotMap, slots)
+			}
+			ranges = append(ranges, Range{
+				Start:    start,
+				End:      end,
+				Master:   n.addr,
+				Replicas: append([]string(nil), replicas[id]...),
+			})
+		}
+	}
+	return ranges, nil
+}
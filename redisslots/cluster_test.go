@@ -0,0 +1,81 @@
+package redisslots
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseClusterSlots(t *testing.T) {
+	reply := []any{
+		[]any{int64(0), int64(5460),
+			[]any{"10.0.0.1", int64(6379), "id1"},
+			[]any{[]byte("10.0.0.4"), int64(6379), "id4"}},
+		[]any{int64(5461), int64(16383),
+			[]any{"10.0.0.2", int64(6380), "id2", map[string]any{"hostname": "b"}}},
+	}
+
+	ranges, err := ParseClusterSlots(reply)
+	if err != nil {
+		t.Fatalf("ParseClusterSlots failed: %v", err)
+	}
+	want := []Range{
+		{Start: 0, End: 5460, Master: "10.0.0.1:6379", Replicas: []string{"10.0.0.4:6379"}},
+		{Start: 5461, End: 16383, Master: "10.0.0.2:6380"},
+	}
+	if !reflect.DeepEqual(ranges, want) {
+		t.Errorf("expected %+v, got %+v", want, ranges)
+	}
+
+	// The writer produces what the reader reads
+	formatted, err := FormatClusterSlots(ranges)
+	if err != nil {
+		t.Fatalf("FormatClusterSlots failed: %v", err)
+	}
+	if again, _ := ParseClusterSlots(formatted); !reflect.DeepEqual(again, want) {
+		t.Errorf("expected round trip, got %+v", again)
+	}
+
+	bad := [][]any{
+		{"not an entry"},
+		{[]any{"0", int64(1), []any{"a", int64(1)}}},
+		{[]any{int64(0), int64(1), []any{"?", int64(1)}}},
+	}
+	for _, reply := range bad {
+		if _, err := ParseClusterSlots(reply); !errors.Is(err, ErrInvalidSlotMap) {
+			t.Errorf("%v: expected ErrInvalidSlotMap, got %v", reply, err)
+		}
+	}
+}
+
+func TestParseClusterNodes(t *testing.T) {
+	text := `07c37dfeb235213a872192d90877d0cd55635b91 127.0.0.1:30004@31004,host4 slave e7d1eecce10fd6bb5eb35b9f99a514335d9ba9ca 0 1426238317239 4 connected
+67ed2db8d677e59ec4a4cefb06858cf2a1a89fa1 127.0.0.1:30002@31002 master - 0 1426238316232 2 connected 5461-10922
+292f8b365bb7edb5e285caf0b7e6ddc7265d2f4f 127.0.0.1:30003@31003 master - 0 1426238318243 3 connected 10923-16383
+e7d1eecce10fd6bb5eb35b9f99a514335d9ba9ca 127.0.0.1:30001@31001 myself,master - 0 0 1 connected 0-5459 5460 [5460->-67ed2db8d677e59ec4a4cefb06858cf2a1a89fa1]
+`
+
+	ranges, err := ParseClusterNodes(text)
+	if err != nil {
+		t.Fatalf("ParseClusterNodes failed: %v", err)
+	}
+
+	m, err := New(ranges)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if node, _ := m.NodeForSlot(5460); node != "127.0.0.1:30001" {
+		t.Errorf("expected migrating slot to stay with its master, got %s", node)
+	}
+	nodes, _ := m.GetNodes("hello") // slot 866
+	if !reflect.DeepEqual(nodes, []string{"127.0.0.1:30001", "127.0.0.1:30004"}) {
+		t.Errorf("expected master and replica, got %v", nodes)
+	}
+	if got := m.Ranges(); len(got) != 3 {
+		t.Errorf("expected adjacent slots to merge into 3 ranges, got %+v", got)
+	}
+
+	if _, err := ParseClusterNodes("abc 1.2.3.4:1 master"); !errors.Is(err, ErrInvalidSlotMap) {
+		t.Errorf("expected ErrInvalidSlotMap, got %v", err)
+	}
+}
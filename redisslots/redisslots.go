@@ -0,0 +1,271 @@
+// Package redisslots routes keys with a Redis Cluster slot map, so clients
+// routed by dcore send every key to the node Redis Cluster assigns it to.
+
+package redisslots
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/mohdrashid9678/dcore/chash"
+)
+
+// Slots is the number of hash slots in a Redis Cluster
+const Slots = 16384
+
+var (
+	// ErrInvalidSlotMap is returned when slot ranges are malformed or overlap
+	ErrInvalidSlotMap = errors.New("invalid slot map")
+
+	// ErrSlotUnassigned is returned when a key's slot has no node, such as
+	// while a cluster is being resharded
+	ErrSlotUnassigned = errors.New("slot is not assigned to a node")
+)
+
+// Slot returns the hash slot of key: the CRC16 of its hash tag, or of the
+// whole key if it has none, modulo Slots
+func Slot(key string) int {
+	if tag, ok := chash.HashTag(key); ok {
+		key = tag
+	}
+	return int(crc16(key) % Slots)
+}
+
+// crc16 is CRC-16/XMODEM, the checksum Redis Cluster assigns slots with
+func crc16(s string) uint16 {
+	var crc uint16
+	for i := 0; i < len(s); i++ {
+		crc ^= uint16(s[i]) << 8
+		for bit := 0; bit < 8; bit++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// Range is a run of consecutive slots served by one master
+type Range struct {
+	// Start and End are the first and last slot, inclusive
+	Start int
+	End   int
+
+	// Master is the "host:port" address of the master serving the slots
+	Master string
+
+	// Replicas are the addresses of the master's replicas
+	Replicas []string
+}
+
+// Map holds the slot assignment of a cluster and routes keys with it
+// It is safe for concurrent use; MOVED redirects update it in place
+type Map struct {
+	// mu protects the fields below
+	mu sync.RWMutex
+
+	// masters holds the master of each slot, "" if unassigned
+	masters [Slots]string
+
+	// replicas maps each master to its replicas
+	replicas map[string][]string
+}
+
+// New creates a slot map from ranges; slots in no range are unassigned
+func New(ranges []Range) (*Map, error) {
+	m := &Map{}
+	if err := m.Reload(ranges); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Reload replaces the whole slot assignment, such as after re-reading
+// CLUSTER SLOTS; on error the map is unchanged
+func (m *Map) Reload(ranges []Range) error {
+	var masters [Slots]string
+	replicas := make(map[string][]string)
+	for _, rg := range ranges {
+		if rg.Start < 0 || rg.End >= Slots || rg.Start > rg.End {
+			return fmt.Errorf("%w: slots %d-%d out of range", ErrInvalidSlotMap, rg.Start, rg.End)
+		}
+		if rg.Master == "" {
+			return fmt.Errorf("%w: slots %d-%d have no master", ErrInvalidSlotMap, rg.Start, rg.End)
+		}
+		for slot := rg.Start; slot <= rg.End; slot++ {
+			if masters[slot] != "" {
+				return fmt.Errorf("%w: slot %d assigned twice", ErrInvalidSlotMap, slot)
+			}
+			masters[slot] = rg.Master
+		}
+		if len(rg.Replicas) > 0 {
+			replicas[rg.Master] = append([]string(nil), rg.Replicas...)
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.masters = masters
+	m.replicas = replicas
+	return nil
+}
+
+// GetNode returns the master serving key
+func (m *Map) GetNode(key string) (string, error) {
+	return m.NodeForSlot(Slot(key))
+}
+
+// NodeForSlot returns the master serving slot
+func (m *Map) NodeForSlot(slot int) (string, error) {
+	if slot < 0 || slot >= Slots {
+		return "", fmt.Errorf("%w: slot %d out of range", ErrInvalidSlotMap, slot)
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.masters[slot] == "" {
+		return "", fmt.Errorf("%w: %d", ErrSlotUnassigned, slot)
+	}
+	return m.masters[slot], nil
+}
+
+// GetNodes returns the master serving key followed by its replicas
+func (m *Map) GetNodes(key string) ([]string, error) {
+	slot := Slot(key)
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	master := m.masters[slot]
+	if master == "" {
+		return nil, fmt.Errorf("%w: %d", ErrSlotUnassigned, slot)
+	}
+	return append([]string{master}, m.replicas[master]...), nil
+}
+
+// Ranges returns the slot assignment as maximal runs of slots per master, in
+// slot order
+func (m *Map) Ranges() []Range {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var ranges []Range
+	for slot, master := range m.masters {
+		if master == "" {
+			continue
+		}
+		if n := len(ranges); n > 0 && ranges[n-1].End == slot-1 && ranges[n-1].Master == master {
+			ranges[n-1].End = slot
+			continue
+		}
+		ranges = append(ranges, Range{
+			Start:    slot,
+			End:      slot,
+			Master:   master,
+			Replicas: append([]string(nil), m.replicas[master]...),
+		})
+	}
+	return ranges
+}
+
+// Masters returns the distinct masters serving at least one slot, sorted
+func (m *Map) Masters() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	seen := make(map[string]struct{})
+	for _, master := range m.masters {
+		if master != "" {
+			seen[master] = struct{}{}
+		}
+	}
+
+	masters := make([]string, 0, len(seen))
+	for master := range seen {
+		masters = append(masters, master)
+	}
+	sort.Strings(masters)
+	return masters
+}
+
+// RedirectKind distinguishes the two redirections of Redis Cluster
+type RedirectKind int
+
+const (
+	// Moved means the slot now lives on another node for good
+	Moved RedirectKind = iota
+
+	// Ask means the slot is migrating; only this command goes to the other
+	// node, preceded by ASKING
+	Ask
+)
+
+// String returns the redirect name as Redis spells it
+func (k RedirectKind) String() string {
+	switch k {
+	case Moved:
+		return "MOVED"
+	case Ask:
+		return "ASK"
+	default:
+		return fmt.Sprintf("RedirectKind(%d)", int(k))
+	}
+}
+
+// Redirect is a MOVED or ASK error returned by a cluster node
+type Redirect struct {
+	Kind RedirectKind
+	Slot int
+	Addr string
+}
+
+// ParseRedirect parses the message of a Redis error reply such as
+// "MOVED 3999 127.0.0.1:6381"; ok is false for any other error
+func ParseRedirect(msg string) (r Redirect, ok bool) {
+	fields := strings.Fields(msg)
+	if len(fields) != 3 {
+		return Redirect{}, false
+	}
+
+	switch fields[0] {
+	case "MOVED":
+		r.Kind = Moved
+	case "ASK":
+		r.Kind = Ask
+	default:
+		return Redirect{}, false
+	}
+
+	slot, err := strconv.Atoi(fields[1])
+	if err != nil || slot < 0 || slot >= Slots {
+		return Redirect{}, false
+	}
+	r.Slot = slot
+	r.Addr = fields[2]
+	return r, true
+}
+
+// Follow parses a Redis error message and, for MOVED, reassigns the slot to
+// the new node so later keys of the slot go there directly
+// ASK leaves the map unchanged. It returns the redirect to retry the command
+// with; ok is false if msg is not a redirect
+func (m *Map) Follow(msg string) (r Redirect, ok bool) {
+	r, ok = ParseRedirect(msg)
+	if !ok || r.Kind != Moved {
+		return r, ok
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.masters[r.Slot] = r.Addr
+	return r, true
+}
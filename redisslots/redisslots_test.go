@@ -0,0 +1,125 @@
+package redisslots
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestSlot(t *testing.T) {
+	if got := crc16("123456789"); got != 0x31c3 {
+		t.Errorf("expected CRC16 check value 0x31c3, got %#x", got)
+	}
+
+	tests := map[string]int{
+		"foo":   12182,
+		"bar":   5061,
+		"hello": 866,
+		"":      0,
+	}
+	for key, want := range tests {
+		if got := Slot(key); got != want {
+			t.Errorf("Slot(%q): expected %d, got %d", key, want, got)
+		}
+	}
+
+	// Hash tags keep related keys in one slot
+	if Slot("{user1000}.following") != Slot("{user1000}.followers") {
+		t.Error("expected keys sharing a hash tag to share a slot")
+	}
+	if Slot("{user1000}.following") != Slot("user1000") {
+		t.Error("expected the tag alone to be hashed")
+	}
+}
+
+func newTestMap(t *testing.T) *Map {
+	t.Helper()
+	m, err := New([]Range{
+		{Start: 0, End: 5460, Master: "10.0.0.1:6379", Replicas: []string{"10.0.0.4:6379"}},
+		{Start: 5461, End: 10922, Master: "10.0.0.2:6379"},
+		{Start: 10923, End: 16383, Master: "10.0.0.3:6379"},
+	})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	return m
+}
+
+func TestMapRouting(t *testing.T) {
+	m := newTestMap(t)
+
+	if node, _ := m.GetNode("hello"); node != "10.0.0.1:6379" { // slot 866
+		t.Errorf("expected first master, got %s", node)
+	}
+	if node, _ := m.GetNode("foo"); node != "10.0.0.3:6379" { // slot 12182
+		t.Errorf("expected third master, got %s", node)
+	}
+
+	nodes, err := m.GetNodes("hello")
+	if err != nil || !reflect.DeepEqual(nodes, []string{"10.0.0.1:6379", "10.0.0.4:6379"}) {
+		t.Errorf("expected master then replica, got %v (%v)", nodes, err)
+	}
+
+	if masters := m.Masters(); len(masters) != 3 {
+		t.Errorf("expected 3 masters, got %v", masters)
+	}
+	if ranges := m.Ranges(); len(ranges) != 3 || ranges[2].End != 16383 {
+		t.Errorf("expected ranges to round-trip, got %+v", ranges)
+	}
+}
+
+func TestMapValidation(t *testing.T) {
+	bad := [][]Range{
+		{{Start: 0, End: Slots, Master: "a:1"}},
+		{{Start: 10, End: 5, Master: "a:1"}},
+		{{Start: 0, End: 10}},
+		{{Start: 0, End: 10, Master: "a:1"}, {Start: 10, End: 20, Master: "b:1"}},
+	}
+	for _, ranges := range bad {
+		if _, err := New(ranges); !errors.Is(err, ErrInvalidSlotMap) {
+			t.Errorf("%+v: expected ErrInvalidSlotMap, got %v", ranges, err)
+		}
+	}
+
+	// A partial map routes what it has
+	m, _ := New([]Range{{Start: 0, End: 100, Master: "a:1"}})
+	if _, err := m.GetNode("foo"); !errors.Is(err, ErrSlotUnassigned) {
+		t.Errorf("expected ErrSlotUnassigned, got %v", err)
+	}
+
+	// A failed reload keeps the old map
+	if err := m.Reload(bad[0]); err == nil {
+		t.Fatal("expected reload to fail")
+	}
+	if node, _ := m.NodeForSlot(50); node != "a:1" {
+		t.Error("expected failed reload to leave the map unchanged")
+	}
+}
+
+func TestFollowRedirects(t *testing.T) {
+	m := newTestMap(t)
+
+	r, ok := m.Follow("ASK 866 10.0.0.9:6379")
+	if !ok || r.Kind != Ask || r.Slot != 866 || r.Addr != "10.0.0.9:6379" {
+		t.Fatalf("unexpected redirect %+v", r)
+	}
+	if node, _ := m.GetNode("hello"); node != "10.0.0.1:6379" {
+		t.Error("expected ASK to leave the map unchanged")
+	}
+
+	if _, ok := m.Follow("MOVED 866 10.0.0.9:6379"); !ok {
+		t.Fatal("expected MOVED to parse")
+	}
+	if node, _ := m.GetNode("hello"); node != "10.0.0.9:6379" {
+		t.Errorf("expected MOVED to reassign the slot, got %s", node)
+	}
+	if node, _ := m.NodeForSlot(867); node != "10.0.0.1:6379" {
+		t.Error("expected neighbouring slots to keep their master")
+	}
+
+	for _, msg := range []string{"ERR unknown command", "MOVED x a:1", "MOVED 16384 a:1", "ASK 1"} {
+		if _, ok := ParseRedirect(msg); ok {
+			t.Errorf("%q: expected no redirect", msg)
+		}
+	}
+}
@@ -0,0 +1,389 @@
+// Package replay records routing decisions made by a chash.Ring to a compact
+// log and replays them offline against a modified ring or strategy, to
+// quantify how a proposed change would have shifted production traffic.
+
+package replay
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/mohdrashid9678/dcore/chash"
+)
+
+var (
+	// ErrInvalidLog is returned when reading a malformed log
+	ErrInvalidLog = errors.New("invalid routing log")
+
+	// ErrClosed is returned when recording to a closed recorder
+	ErrClosed = errors.New("recorder is closed")
+
+	// ErrTooLong is returned when recording a key or node name longer than
+	// MaxStringLength
+	ErrTooLong = errors.New("key or node name too long")
+)
+
+// logMagic prefixes every routing log
+const logMagic = "DCRL1"
+
+// MaxStringLength is the longest key or node name a log holds, so a corrupt
+// length cannot make a reader allocate without bound
+const MaxStringLength = 64 << 10
+
+// Record types within a log
+const (
+	// recordNode defines a node name for later decision records
+	recordNode byte = iota + 1
+
+	// recordDecision is a routed key
+	recordDecision
+)
+
+// Entry is one recorded routing decision
+type Entry struct {
+	// Key is the routed key
+	Key string
+
+	// Version is the ring's topology change count when the decision was made
+	Version uint64
+
+	// Node is the node the key was routed to
+	Node string
+}
+
+// RecorderConfig holds configuration options for creating a new Recorder
+type RecorderConfig struct {
+	// SampleRate is the fraction of lookups recorded, in (0, 1]
+	// Default: 1 (record everything)
+	SampleRate float64
+}
+
+// Recorder routes keys through a ring and logs the decisions
+type Recorder struct {
+	ring       *chash.Ring
+	sampleRate float64
+
+	// mu protects the writer and node dictionary
+	mu     sync.Mutex
+	w      *bufio.Writer
+	nodes  map[string]uint64
+	err    error
+	closed bool
+	buf    []byte
+}
+
+// NewRecorder creates a recorder writing to w
+func NewRecorder(ring *chash.Ring, w io.Writer, config RecorderConfig) (*Recorder, error) {
+	if config.SampleRate <= 0 || config.SampleRate > 1 {
+		config.SampleRate = 1 // Default: record every lookup
+	}
+
+	bw := bufio.NewWriter(w)
+	if _, err := bw.WriteString(logMagic); err != nil {
+		return nil, err
+	}
+
+	return &Recorder{
+		ring:       ring,
+		sampleRate: config.SampleRate,
+		w:          bw,
+		nodes:      make(map[string]uint64),
+	}, nil
+}
+
+// GetNode routes key like chash.Ring.GetNode and records the decision
+// A failure to write the log never fails the lookup; it is reported by Close
+func (r *Recorder) GetNode(key string) (string, error) {
+	// The node and version come from one locked lookup, so a concurrent
+	// topology change cannot pair the node with a later version
+	vn, err := r.ring.GetNodeVersioned(key)
+	if err != nil {
+		return "", err
+	}
+
+	if r.sampleRate >= 1 || rand.Float64() < r.sampleRate {
+		r.Record(Entry{Key: key, Version: vn.Version, Node: vn.Node})
+	}
+	return vn.Node, nil
+}
+
+// Record appends a decision made elsewhere to the log
+// Returns ErrTooLong, without recording, if the key or node name is longer
+// than MaxStringLength
+func (r *Recorder) Record(entry Entry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return ErrClosed
+	}
+	if r.err != nil {
+		return r.err
+	}
+	if len(entry.Key) > MaxStringLength || len(entry.Node) > MaxStringLength {
+		return fmt.Errorf("%w: limit is %d bytes", ErrTooLong, MaxStringLength)
+	}
+
+	// Node names are written once and referenced by ID afterwards
+	id, known := r.nodes[entry.Node]
+	if !known {
+		id = uint64(len(r.nodes))
+		r.nodes[entry.Node] = id
+
+		r.buf = append(r.buf[:0], recordNode)
+		r.buf = binary.AppendUvarint(r.buf, id)
+		r.buf = appendString(r.buf, entry.Node)
+		r.write(r.buf)
+	}
+
+	r.buf = append(r.buf[:0], recordDecision)
+	r.buf = appendString(r.buf, entry.Key)
+	r.buf = binary.AppendUvarint(r.buf, entry.Version)
+	r.buf = binary.AppendUvarint(r.buf, id)
+	r.write(r.buf)
+
+	return r.err
+}
+
+// write appends b to the log, remembering the first error
+// Caller must hold r.mu
+func (r *Recorder) write(b []byte) {
+	if r.err == nil {
+		_, r.err = r.w.Write(b)
+	}
+}
+
+// Flush writes buffered records to the underlying writer
+func (r *Recorder) Flush() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.err == nil {
+		r.err = r.w.Flush()
+	}
+	return r.err
+}
+
+// Close flushes the log and stops recording; the underlying writer is not closed
+func (r *Recorder) Close() error {
+	err := r.Flush()
+
+	r.mu.Lock()
+	r.closed = true
+	r.mu.Unlock()
+
+	return err
+}
+
+// Reader reads entries from a routing log
+type Reader struct {
+	r     *bufio.Reader
+	nodes []string
+	err   error
+}
+
+// NewReader creates a reader and checks the log header
+func NewReader(r io.Reader) (*Reader, error) {
+	br := bufio.NewReader(r)
+
+	magic := make([]byte, len(logMagic))
+	if _, err := io.ReadFull(br, magic); err != nil || string(magic) != logMagic {
+		return nil, fmt.Errorf("%w: bad header", ErrInvalidLog)
+	}
+
+	return &Reader{r: br}, nil
+}
+
+// Next returns the next decision, or io.EOF at the end of the log
+func (rd *Reader) Next() (Entry, error) {
+	for rd.err == nil {
+		typ, err := rd.r.ReadByte()
+		if err != nil {
+			rd.err = err
+			break
+		}
+
+		switch typ {
+		case recordNode:
+			id := rd.uvarint()
+			name := rd.string()
+			if rd.err == nil && id != uint64(len(rd.nodes)) {
+				rd.err = fmt.Errorf("%w: node %d defined out of order", ErrInvalidLog, id)
+			}
+			rd.nodes = append(rd.nodes, name)
+
+		case recordDecision:
+			entry := Entry{Key: rd.string(), Version: rd.uvarint()}
+			id := rd.uvarint()
+			if rd.err != nil {
+				break
+			}
+			if id >= uint64(len(rd.nodes)) {
+				rd.err = fmt.Errorf("%w: undefined node %d", ErrInvalidLog, id)
+				break
+			}
+			entry.Node = rd.nodes[id]
+			return entry, nil
+
+		default:
+			rd.err = fmt.Errorf("%w: unknown record type %d", ErrInvalidLog, typ)
+		}
+	}
+
+	return Entry{}, rd.err
+}
+
+// uvarint reads a varint like binary.ReadUvarint, reporting overflow as
+// ErrInvalidLog rather than an error of its own
+func (rd *Reader) uvarint() uint64 {
+	if rd.err != nil {
+		return 0
+	}
+
+	var v uint64
+	for i := 0; i < binary.MaxVarintLen64; i++ {
+		b, err := rd.r.ReadByte()
+		if err != nil {
+			rd.err = truncated(err)
+			return 0
+		}
+		if b < 0x80 {
+			if i == binary.MaxVarintLen64-1 && b > 1 {
+				break
+			}
+			return v | uint64(b)<<(7*i)
+		}
+		v |= uint64(b&0x7f) << (7 * i)
+	}
+	rd.err = fmt.Errorf("%w: varint overflows 64 bits", ErrInvalidLog)
+	return 0
+}
+
+func (rd *Reader) string() string {
+	n := rd.uvarint()
+	if rd.err != nil {
+		return ""
+	}
+	if n > MaxStringLength {
+		rd.err = fmt.Errorf("%w: string of %d bytes exceeds %d", ErrInvalidLog, n, MaxStringLength)
+		return ""
+	}
+
+	// Copying grows the buffer with the input, so a truncated log cannot
+	// allocate the full claimed length
+	var b strings.Builder
+	if _, err := io.CopyN(&b, rd.r, int64(n)); err != nil {
+		rd.err = truncated(err)
+		return ""
+	}
+	return b.String()
+}
+
+// truncated converts a mid-record EOF into ErrInvalidLog
+func truncated(err error) error {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return fmt.Errorf("%w: truncated record", ErrInvalidLog)
+	}
+	return err
+}
+
+// appendString appends a length-prefixed string
+func appendString(buf []byte, s string) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(s)))
+	return append(buf, s...)
+}
+
+// RouteFunc makes a routing decision for a key, e.g. a candidate ring's GetNode
+type RouteFunc func(key string) (string, error)
+
+// Shift counts recorded decisions that a candidate routed elsewhere
+type Shift struct {
+	From  string
+	To    string
+	Count int
+}
+
+// Report summarises a replay
+type Report struct {
+	// Total is the number of decisions replayed
+	Total int
+
+	// Changed is the number routed to a different node by the candidate
+	Changed int
+
+	// Errors is the number the candidate failed to route
+	Errors int
+
+	// Shifts breaks Changed down by old and new node, largest first
+	Shifts []Shift
+
+	// Load is the number of decisions each node receives under the candidate
+	Load map[string]int
+}
+
+// ChangedFraction returns the share of replayed traffic that would move
+func (r Report) ChangedFraction() float64 {
+	if r.Total == 0 {
+		return 0
+	}
+	return float64(r.Changed) / float64(r.Total)
+}
+
+// Replay routes every recorded key with route and compares the outcome with
+// the recorded decision
+func Replay(log io.Reader, route RouteFunc) (Report, error) {
+	reader, err := NewReader(log)
+	if err != nil {
+		return Report{}, err
+	}
+
+	report := Report{Load: make(map[string]int)}
+	shifts := make(map[[2]string]int)
+
+	for {
+		entry, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return report, err
+		}
+
+		report.Total++
+
+		node, err := route(entry.Key)
+		if err != nil {
+			report.Errors++
+			continue
+		}
+
+		report.Load[node]++
+		if node != entry.Node {
+			report.Changed++
+			shifts[[2]string{entry.Node, node}]++
+		}
+	}
+
+	for pair, count := range shifts {
+		report.Shifts = append(report.Shifts, Shift{From: pair[0], To: pair[1], Count: count})
+	}
+	sort.Slice(report.Shifts, func(i, j int) bool {
+		a, b := report.Shifts[i], report.Shifts[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		return a.To < b.To
+	})
+
+	return report, nil
+}
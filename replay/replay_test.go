@@ -0,0 +1,221 @@
+package replay
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/mohdrashid9678/dcore/chash"
+)
+
+func recordTraffic(t testing.TB, ring *chash.Ring, n int) *bytes.Buffer {
+	t.Helper()
+
+	var log bytes.Buffer
+	rec, err := NewRecorder(ring, &log, RecorderConfig{})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	for i := 0; i < n; i++ {
+		if _, err := rec.GetNode(fmt.Sprintf("key%d", i)); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+
+	if err := rec.Close(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	return &log
+}
+
+func TestRecordAndRead(t *testing.T) {
+	ring := chash.NewWithNodes(chash.Config{Replicas: 20}, []string{"server1", "server2", "server3"})
+	log := recordTraffic(t, ring, 100)
+
+	reader, err := NewReader(bytes.NewReader(log.Bytes()))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	version := ring.GetStats().TopologyChanges
+	for i := 0; ; i++ {
+		entry, err := reader.Next()
+		if err == io.EOF {
+			if i != 100 {
+				t.Errorf("expected 100 entries, got %d", i)
+			}
+			break
+		}
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		key := fmt.Sprintf("key%d", i)
+		owner, _ := ring.GetNode(key)
+		if entry.Key != key || entry.Node != owner || entry.Version != version {
+			t.Errorf("unexpected entry %+v, expected %s on %s at %d", entry, key, owner, version)
+		}
+	}
+}
+
+func TestReplayAgainstModifiedRing(t *testing.T) {
+	nodes := []string{"server1", "server2", "server3"}
+	ring := chash.NewWithNodes(chash.Config{Replicas: 20}, nodes)
+	log := recordTraffic(t, ring, 1000)
+
+	// Replaying against the same topology changes nothing
+	report, err := Replay(bytes.NewReader(log.Bytes()), ring.GetNode)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if report.Total != 1000 || report.Changed != 0 {
+		t.Errorf("expected no changes, got %+v", report)
+	}
+
+	// Adding a node only moves keys onto it
+	candidate := chash.NewWithNodes(chash.Config{Replicas: 20}, append(nodes, "server4"))
+	report, err = Replay(bytes.NewReader(log.Bytes()), candidate.GetNode)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if report.Changed == 0 || report.Changed != report.Load["server4"] {
+		t.Errorf("expected moved keys to land on server4, got %+v", report)
+	}
+	for _, shift := range report.Shifts {
+		if shift.To != "server4" {
+			t.Errorf("unexpected shift %+v", shift)
+		}
+	}
+	if f := report.ChangedFraction(); f <= 0 || f >= 0.5 {
+		t.Errorf("unexpected changed fraction %f", f)
+	}
+}
+
+func TestReplayCountsRouteErrors(t *testing.T) {
+	ring := chash.NewWithNodes(chash.Config{Replicas: 20}, []string{"server1"})
+	log := recordTraffic(t, ring, 10)
+
+	report, err := Replay(log, func(string) (string, error) {
+		return "", chash.ErrNoNodes
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if report.Errors != 10 || report.Changed != 0 {
+		t.Errorf("expected 10 errors, got %+v", report)
+	}
+}
+
+func TestInvalidLog(t *testing.T) {
+	if _, err := NewReader(bytes.NewReader([]byte("nope"))); !errors.Is(err, ErrInvalidLog) {
+		t.Errorf("expected ErrInvalidLog, got %v", err)
+	}
+
+	ring := chash.NewWithNodes(chash.Config{Replicas: 20}, []string{"server1"})
+	log := recordTraffic(t, ring, 3)
+
+	// A log cut mid-record is reported rather than silently shortened
+	_, err := Replay(bytes.NewReader(log.Bytes()[:log.Len()-1]), ring.GetNode)
+	if !errors.Is(err, ErrInvalidLog) {
+		t.Errorf("expected ErrInvalidLog, got %v", err)
+	}
+}
+
+func TestCorruptLength(t *testing.T) {
+	// A decision whose key claims 2^62 bytes
+	log := append([]byte(logMagic), recordDecision)
+	log = binary.AppendUvarint(log, 1<<62)
+
+	rd, _ := NewReader(bytes.NewReader(log))
+	if _, err := rd.Next(); !errors.Is(err, ErrInvalidLog) {
+		t.Errorf("expected ErrInvalidLog, got %v", err)
+	}
+
+	// A length that overflows a varint
+	log = append([]byte(logMagic), recordDecision)
+	log = append(log, bytes.Repeat([]byte{0xff}, 10)...)
+	rd, _ = NewReader(bytes.NewReader(log))
+	if _, err := rd.Next(); !errors.Is(err, ErrInvalidLog) {
+		t.Errorf("expected ErrInvalidLog, got %v", err)
+	}
+
+	// A length within the limit but past the end of the log
+	log = append([]byte(logMagic), recordNode, 0)
+	log = binary.AppendUvarint(log, MaxStringLength)
+	rd, _ = NewReader(bytes.NewReader(append(log, "server1"...)))
+	if _, err := rd.Next(); !errors.Is(err, ErrInvalidLog) {
+		t.Errorf("expected ErrInvalidLog, got %v", err)
+	}
+}
+
+func TestRecordTooLong(t *testing.T) {
+	ring := chash.NewWithNodes(chash.Config{Replicas: 20}, []string{"server1"})
+
+	var log bytes.Buffer
+	rec, _ := NewRecorder(ring, &log, RecorderConfig{})
+	err := rec.Record(Entry{Key: strings.Repeat("k", MaxStringLength+1), Node: "server1"})
+	if !errors.Is(err, ErrTooLong) {
+		t.Errorf("expected ErrTooLong, got %v", err)
+	}
+
+	// The recorder keeps working
+	if _, err := rec.GetNode("key"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := rec.Close(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	rd, _ := NewReader(&log)
+	if entry, err := rd.Next(); err != nil || entry.Key != "key" {
+		t.Errorf("expected the short key recorded, got %+v, %v", entry, err)
+	}
+	if _, err := rd.Next(); err != io.EOF {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+}
+
+func FuzzReader(f *testing.F) {
+	ring := chash.NewWithNodes(chash.Config{Replicas: 20}, []string{"server1", "server2"})
+	f.Add(recordTraffic(f, ring, 5).Bytes())
+	f.Add(binary.AppendUvarint([]byte(logMagic+"\x02"), 1<<62))
+
+	f.Fuzz(func(t *testing.T, log []byte) {
+		rd, err := NewReader(bytes.NewReader(log))
+		if err != nil {
+			return
+		}
+		for {
+			entry, err := rd.Next()
+			if err != nil {
+				if err != io.EOF && !errors.Is(err, ErrInvalidLog) {
+					t.Fatalf("expected io.EOF or ErrInvalidLog, got %v", err)
+				}
+				return
+			}
+			if len(entry.Key) > MaxStringLength {
+				t.Fatalf("read a key of %d bytes", len(entry.Key))
+			}
+		}
+	})
+}
+
+func TestRecorderClosed(t *testing.T) {
+	ring := chash.NewWithNodes(chash.Config{Replicas: 20}, []string{"server1"})
+
+	rec, _ := NewRecorder(ring, io.Discard, RecorderConfig{})
+	rec.Close()
+
+	if err := rec.Record(Entry{Key: "key1", Node: "server1"}); err != ErrClosed {
+		t.Errorf("expected ErrClosed, got %v", err)
+	}
+
+	// Lookups keep working after the recorder closes
+	if node, err := rec.GetNode("key1"); err != nil || node != "server1" {
+		t.Errorf("expected server1, got %s (%v)", node, err)
+	}
+}
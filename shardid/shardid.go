@@ -0,0 +1,322 @@
+// Package shardid generates 64-bit IDs that embed a routing hint, so the
+// owner of an ID can be found without a lookup table.
+//
+// An ID is laid out from the most significant bit as:
+//
+//	[segment][fingerprint][timestamp][sequence]
+//
+// The segment names a slice of the hash ring owned by the creating node, and
+// the fingerprint is a short hash of that node's name. A resolver routes the
+// ID to the current owner of its segment as long as that owner still matches
+// the fingerprint, and falls back to hashing the ID otherwise.
+
+package shardid
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mohdrashid9678/dcore/chash"
+	"github.com/mohdrashid9678/dcore/clock"
+)
+
+var (
+	// ErrNoSegment is returned when a node owns no segment of the ring
+	ErrNoSegment = errors.New("node owns no ring segment")
+
+	// ErrInvalidLayout is returned when the configured bit widths do not fit in 64 bits
+	ErrInvalidLayout = errors.New("invalid ID layout")
+
+	// ErrClockBackwards is returned when the clock moves behind the last issued ID
+	ErrClockBackwards = errors.New("clock moved backwards")
+)
+
+// ID is a generated identifier carrying a shard hint
+type ID uint64
+
+// String returns the decimal form of the ID
+func (id ID) String() string {
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+// Layout describes the bit widths of the ID fields
+type Layout struct {
+	// SegmentBits is the number of bits naming the ring segment
+	// The ring is split into 2^SegmentBits equal segments
+	// Default: 8
+	SegmentBits uint
+
+	// FingerprintBits is the number of bits of the creating node's fingerprint
+	// Default: 4
+	FingerprintBits uint
+
+	// SequenceBits is the number of bits of the per-millisecond sequence
+	// Default: 12
+	SequenceBits uint
+
+	// Epoch is the zero point of the embedded timestamp
+	// Default: 2024-01-01 UTC
+	Epoch time.Time
+}
+
+// withDefaults returns l with zero fields replaced by their defaults
+func (l Layout) withDefaults() (Layout, error) {
+	if l.SegmentBits == 0 {
+		l.SegmentBits = 8
+	}
+	if l.FingerprintBits == 0 {
+		l.FingerprintBits = 4
+	}
+	if l.SequenceBits == 0 {
+		l.SequenceBits = 12
+	}
+	if l.Epoch.IsZero() {
+		l.Epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	// Leave at least 32 bits of milliseconds (~49 days) for the timestamp
+	if l.SegmentBits+l.FingerprintBits+l.SequenceBits > 32 {
+		return l, fmt.Errorf("%w: segment, fingerprint and sequence use %d bits",
+			ErrInvalidLayout, l.SegmentBits+l.FingerprintBits+l.SequenceBits)
+	}
+
+	return l, nil
+}
+
+// timestampBits returns the number of bits left for the timestamp
+func (l Layout) timestampBits() uint {
+	return 64 - l.SegmentBits - l.FingerprintBits - l.SequenceBits
+}
+
+// Parts are the decoded fields of an ID
+type Parts struct {
+	Segment     uint64
+	Fingerprint uint64
+	Timestamp   time.Time
+	Sequence    uint64
+}
+
+// Codec encodes and decodes IDs for a layout
+type Codec struct {
+	layout Layout
+}
+
+// NewCodec creates a codec for the given layout
+func NewCodec(layout Layout) (*Codec, error) {
+	layout, err := layout.withDefaults()
+	if err != nil {
+		return nil, err
+	}
+	return &Codec{layout: layout}, nil
+}
+
+// Encode packs parts into an ID
+// Fields wider than their bit width are truncated
+func (c *Codec) Encode(p Parts) ID {
+	l := c.layout
+	ms := uint64(p.Timestamp.Sub(l.Epoch).Milliseconds())
+
+	id := p.Segment & mask(l.SegmentBits)
+	id = id<<l.FingerprintBits | p.Fingerprint&mask(l.FingerprintBits)
+	id = id<<l.timestampBits() | ms&mask(l.timestampBits())
+	id = id<<l.SequenceBits | p.Sequence&mask(l.SequenceBits)
+
+	return ID(id)
+}
+
+// Decode unpacks an ID into its parts
+func (c *Codec) Decode(id ID) Parts {
+	l := c.layout
+	v := uint64(id)
+
+	sequence := v & mask(l.SequenceBits)
+	v >>= l.SequenceBits
+	ms := v & mask(l.timestampBits())
+	v >>= l.timestampBits()
+	fingerprint := v & mask(l.FingerprintBits)
+	v >>= l.FingerprintBits
+	segment := v & mask(l.SegmentBits)
+
+	return Parts{
+		Segment:     segment,
+		Fingerprint: fingerprint,
+		Timestamp:   l.Epoch.Add(time.Duration(ms) * time.Millisecond),
+		Sequence:    sequence,
+	}
+}
+
+// Segments returns the number of ring segments in the layout
+func (c *Codec) Segments() uint64 {
+	return 1 << c.layout.SegmentBits
+}
+
+// SegmentHash returns the ring position that represents a segment
+// It is the midpoint of the segment's hash range
+func (c *Codec) SegmentHash(segment uint64) uint64 {
+	shift := 64 - c.layout.SegmentBits
+	return segment<<shift | 1<<(shift-1)
+}
+
+// Fingerprint returns the short fingerprint of a node name
+func (c *Codec) Fingerprint(node string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(node))
+	return h.Sum64() & mask(c.layout.FingerprintBits)
+}
+
+// OwnedSegments returns the segments whose representative position is owned by node
+func (c *Codec) OwnedSegments(ring *chash.Ring, node string) ([]uint64, error) {
+	var owned []uint64
+	for s := uint64(0); s < c.Segments(); s++ {
+		owner, err := ring.GetNodeForHash(c.SegmentHash(s))
+		if err != nil {
+			return nil, err
+		}
+		if owner == node {
+			owned = append(owned, s)
+		}
+	}
+	return owned, nil
+}
+
+// Generator issues IDs whose segment is owned by a given node
+type Generator struct {
+	// mu protects all fields below
+	mu sync.Mutex
+
+	codec *Codec
+	ring  *chash.Ring
+	node  string
+	clock clock.Clock
+
+	// segments owned by node, refreshed by Refresh
+	segments []uint64
+	next     int
+
+	lastMs   int64
+	sequence uint64
+}
+
+// NewGenerator creates a generator for IDs created on node
+// Returns ErrNoSegment if node does not own any segment of the ring
+func NewGenerator(codec *Codec, ring *chash.Ring, node string) (*Generator, error) {
+	return NewGeneratorWithClock(codec, ring, node, clock.Real())
+}
+
+// NewGeneratorWithClock creates a generator that timestamps IDs with clk
+func NewGeneratorWithClock(codec *Codec, ring *chash.Ring, node string, clk clock.Clock) (*Generator, error) {
+	g := &Generator{
+		codec: codec,
+		ring:  ring,
+		node:  node,
+		clock: clock.OrReal(clk),
+	}
+
+	if err := g.Refresh(); err != nil {
+		return nil, err
+	}
+
+	return g, nil
+}
+
+// Refresh recomputes the segments owned by the node
+// Call it after ring membership changes
+func (g *Generator) Refresh() error {
+	segments, err := g.codec.OwnedSegments(g.ring, g.node)
+	if err != nil {
+		return err
+	}
+	if len(segments) == 0 {
+		return fmt.Errorf("%w: %s", ErrNoSegment, g.node)
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.segments = segments
+	g.next = 0
+	return nil
+}
+
+// Next returns a new unique ID
+// Segments owned by the node are used round-robin to spread IDs
+// Once the sequence is exhausted for a millisecond, Next sleeps on the clock
+// until the next one
+func (g *Generator) Next() (ID, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for {
+		now := g.clock.Now()
+		ms := now.Sub(g.codec.layout.Epoch).Milliseconds()
+
+		switch {
+		case ms < g.lastMs:
+			return 0, ErrClockBackwards
+		case ms == g.lastMs:
+			if g.sequence >= mask(g.codec.layout.SequenceBits) {
+				// Sleep without the lock so Refresh is not held up
+				next := g.codec.layout.Epoch.Add(time.Duration(ms+1) * time.Millisecond)
+				g.mu.Unlock()
+				g.clock.Sleep(next.Sub(now))
+				g.mu.Lock()
+				continue
+			}
+			g.sequence++
+		default:
+			g.sequence = 0
+		}
+		g.lastMs = ms
+
+		segment := g.segments[g.next%len(g.segments)]
+		g.next++
+
+		return g.codec.Encode(Parts{
+			Segment:     segment,
+			Fingerprint: g.codec.Fingerprint(g.node),
+			Timestamp:   now,
+			Sequence:    g.sequence,
+		}), nil
+	}
+}
+
+// Resolver finds the node that holds an ID
+type Resolver struct {
+	codec *Codec
+	ring  *chash.Ring
+}
+
+// NewResolver creates a resolver for IDs encoded with codec
+func NewResolver(codec *Codec, ring *chash.Ring) *Resolver {
+	return &Resolver{codec: codec, ring: ring}
+}
+
+// Resolve returns the node holding id
+// The embedded segment hint is used while the segment's current owner
+// matches the embedded fingerprint; otherwise the hint is stale and the ID
+// is routed by hashing its decimal form
+// The boolean reports whether the hint was used
+func (r *Resolver) Resolve(id ID) (string, bool, error) {
+	parts := r.codec.Decode(id)
+
+	owner, err := r.ring.GetNodeForHash(r.codec.SegmentHash(parts.Segment))
+	if err != nil {
+		return "", false, err
+	}
+
+	if r.codec.Fingerprint(owner) == parts.Fingerprint {
+		return owner, true, nil
+	}
+
+	node, err := r.ring.GetNode(id.String())
+	return node, false, err
+}
+
+// mask returns a value with the low n bits set
+func mask(n uint) uint64 {
+	return 1<<n - 1
+}
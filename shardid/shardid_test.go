@@ -0,0 +1,186 @@
+package shardid
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mohdrashid9678/dcore/chash"
+	"github.com/mohdrashid9678/dcore/clock"
+)
+
+func TestNewCodecLayout(t *testing.T) {
+	if _, err := NewCodec(Layout{}); err != nil {
+		t.Fatalf("expected default layout to be valid, got %v", err)
+	}
+
+	_, err := NewCodec(Layout{SegmentBits: 16, FingerprintBits: 8, SequenceBits: 12})
+	if !errors.Is(err, ErrInvalidLayout) {
+		t.Errorf("expected ErrInvalidLayout, got %v", err)
+	}
+}
+
+func TestEncodeDecode(t *testing.T) {
+	codec, _ := NewCodec(Layout{})
+
+	parts := Parts{
+		Segment:     200,
+		Fingerprint: 9,
+		Timestamp:   time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC),
+		Sequence:    4000,
+	}
+
+	decoded := codec.Decode(codec.Encode(parts))
+
+	if decoded.Segment != parts.Segment {
+		t.Errorf("expected segment %d, got %d", parts.Segment, decoded.Segment)
+	}
+	if decoded.Fingerprint != parts.Fingerprint {
+		t.Errorf("expected fingerprint %d, got %d", parts.Fingerprint, decoded.Fingerprint)
+	}
+	if !decoded.Timestamp.Equal(parts.Timestamp) {
+		t.Errorf("expected timestamp %v, got %v", parts.Timestamp, decoded.Timestamp)
+	}
+	if decoded.Sequence != parts.Sequence {
+		t.Errorf("expected sequence %d, got %d", parts.Sequence, decoded.Sequence)
+	}
+
+	// The segment occupies the top bits
+	if uint64(codec.Encode(parts))>>56 != parts.Segment {
+		t.Error("expected segment in the top 8 bits")
+	}
+}
+
+func TestGeneratorAndResolver(t *testing.T) {
+	ring := chash.NewWithNodes(chash.Config{Replicas: 50}, []string{"server1", "server2", "server3"})
+	codec, _ := NewCodec(Layout{})
+
+	gen, err := NewGenerator(codec, ring, "server2")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	resolver := NewResolver(codec, ring)
+	seen := make(map[ID]struct{})
+
+	for i := 0; i < 1000; i++ {
+		id, err := gen.Next()
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if _, dup := seen[id]; dup {
+			t.Fatalf("duplicate ID %d", id)
+		}
+		seen[id] = struct{}{}
+
+		node, hinted, err := resolver.Resolve(id)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if !hinted || node != "server2" {
+			t.Fatalf("expected hinted resolution to server2, got %s (hinted=%v)", node, hinted)
+		}
+	}
+}
+
+func TestResolverFallsBackWhenStale(t *testing.T) {
+	ring := chash.NewWithNodes(chash.Config{Replicas: 50}, []string{"server1", "server2", "server3"})
+	codec, _ := NewCodec(Layout{})
+
+	gen, _ := NewGenerator(codec, ring, "server2")
+	id, _ := gen.Next()
+
+	// The creating node leaves; its segments move to other nodes
+	ring.RemoveNode("server2")
+
+	resolver := NewResolver(codec, ring)
+	node, hinted, err := resolver.Resolve(id)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// None of the remaining nodes shares server2's fingerprint
+	if hinted {
+		t.Fatal("expected stale hint to be ignored")
+	}
+
+	expected, _ := ring.GetNode(id.String())
+	if node != expected {
+		t.Errorf("expected hash fallback to %s, got %s", expected, node)
+	}
+}
+
+func TestGeneratorSequence(t *testing.T) {
+	ring := chash.NewWithNodes(chash.Config{Replicas: 50}, []string{"server1"})
+	codec, _ := NewCodec(Layout{})
+	clk := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	gen, _ := NewGeneratorWithClock(codec, ring, "server1", clk)
+
+	first, _ := gen.Next()
+	second, _ := gen.Next()
+
+	if codec.Decode(second).Sequence != codec.Decode(first).Sequence+1 {
+		t.Error("expected sequence to increase within the same millisecond")
+	}
+
+	gen.clock = clock.NewFake(time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC))
+	if _, err := gen.Next(); err != ErrClockBackwards {
+		t.Errorf("expected ErrClockBackwards, got %v", err)
+	}
+}
+
+func TestGeneratorSequenceExhausted(t *testing.T) {
+	ring := chash.NewWithNodes(chash.Config{Replicas: 50}, []string{"server1"})
+	codec, _ := NewCodec(Layout{SequenceBits: 2})
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	clk := clock.NewFake(start)
+	gen, _ := NewGeneratorWithClock(codec, ring, "server1", clk)
+
+	// Four IDs fit in a millisecond
+	for i := 0; i < 4; i++ {
+		if _, err := gen.Next(); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+
+	ids := make(chan ID)
+	go func() {
+		id, err := gen.Next()
+		if err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+		ids <- id
+	}()
+
+	// The fifth waits on the clock for the next millisecond
+	clk.BlockUntil(1)
+	select {
+	case <-ids:
+		t.Fatal("expected Next to wait for the next millisecond")
+	default:
+	}
+
+	// Refresh is not blocked while Next waits
+	if err := gen.Refresh(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	clk.Advance(time.Millisecond)
+	parts := codec.Decode(<-ids)
+	if parts.Sequence != 0 {
+		t.Errorf("expected sequence 0, got %d", parts.Sequence)
+	}
+	if want := start.Add(time.Millisecond); !parts.Timestamp.Equal(want) {
+		t.Errorf("expected timestamp %v, got %v", want, parts.Timestamp)
+	}
+}
+
+func TestNewGeneratorUnknownNode(t *testing.T) {
+	ring := chash.NewWithNodes(chash.Config{Replicas: 50}, []string{"server1"})
+	codec, _ := NewCodec(Layout{})
+
+	if _, err := NewGenerator(codec, ring, "server9"); !errors.Is(err, ErrNoSegment) {
+		t.Errorf("expected ErrNoSegment, got %v", err)
+	}
+}
@@ -0,0 +1,134 @@
+package shuffleshard
+
+import "sort"
+
+// Report summarises how the shards of a set of tenants overlap
+type Report struct {
+	// Tenants is the number of tenants analysed
+	Tenants int
+
+	// Combinations is the number of distinct shards the ring can form
+	Combinations float64
+
+	// Histogram counts tenant pairs by the number of nodes they share; index
+	// ShardSize counts pairs sharing their exact shard
+	Histogram []int
+
+	// MaxOverlap is the most nodes any two tenants share
+	MaxOverlap int
+
+	// Identical lists groups of tenants assigned the exact same shard, largest
+	// group first; a poison tenant takes down every tenant in its group
+	Identical [][]string
+}
+
+// Overlap returns the number of nodes the shards of tenants x and y share
+func (a *Allocator) Overlap(x, y string) (int, error) {
+	sx, err := a.Shard(x)
+	if err != nil {
+		return 0, err
+	}
+	sy, err := a.Shard(y)
+	if err != nil {
+		return 0, err
+	}
+	return shared(sx, sy), nil
+}
+
+// Affected returns the tenants whose every node is also a node of poison's
+// shard, in the order given; they lose all capacity if poison takes its
+// nodes down, while the other tenants keep at least one healthy node
+func (a *Allocator) Affected(poison string, tenants []string) ([]string, error) {
+	shard, err := a.Shard(poison)
+	if err != nil {
+		return nil, err
+	}
+
+	var affected []string
+	for _, tenant := range tenants {
+		if tenant == poison {
+			continue
+		}
+		s, err := a.Shard(tenant)
+		if err != nil {
+			return nil, err
+		}
+		if shared(shard, s) == len(s) {
+			affected = append(affected, tenant)
+		}
+	}
+	return affected, nil
+}
+
+// Analyze compares the shards of every pair of tenants
+// It runs in time quadratic in the number of tenants, so it is meant for
+// offline capacity planning rather than the request path
+func (a *Allocator) Analyze(tenants []string) (Report, error) {
+	shards := make([][]string, len(tenants))
+	groups := make(map[string][]string)
+	for i, tenant := range tenants {
+		s, err := a.Shard(tenant)
+		if err != nil {
+			return Report{}, err
+		}
+		shards[i] = s
+
+		key := shardKey(s)
+		groups[key] = append(groups[key], tenant)
+	}
+
+	report := Report{
+		Tenants:      len(tenants),
+		Combinations: a.Combinations(),
+		Histogram:    make([]int, a.shardSize+1),
+	}
+
+	for i := range shards {
+		for j := i + 1; j < len(shards); j++ {
+			n := shared(shards[i], shards[j])
+			report.Histogram[n]++
+			report.MaxOverlap = max(report.MaxOverlap, n)
+		}
+	}
+
+	for _, group := range groups {
+		if len(group) > 1 {
+			report.Identical = append(report.Identical, group)
+		}
+	}
+	sort.Slice(report.Identical, func(i, j int) bool {
+		gi, gj := report.Identical[i], report.Identical[j]
+		if len(gi) != len(gj) {
+			return len(gi) > len(gj)
+		}
+		return gi[0] < gj[0]
+	})
+
+	return report, nil
+}
+
+// shared counts the nodes present in both shards
+func shared(x, y []string) int {
+	n := 0
+	for _, a := range x {
+		for _, b := range y {
+			if a == b {
+				n++
+				break
+			}
+		}
+	}
+	return n
+}
+
+// shardKey identifies a shard independently of node order
+func shardKey(shard []string) string {
+	sorted := append([]string(nil), shard...)
+	sort.Strings(sorted)
+
+	key := ""
+	for _, node := range sorted {
+		key += node + "\x00"
+	}
+	return key
+}
@@ -0,0 +1,91 @@
+package shuffleshard
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestOverlap(t *testing.T) {
+	alloc, _ := New(newRing(16), Config{ShardSize: 4})
+
+	if n, _ := alloc.Overlap("tenant1", "tenant1"); n != 4 {
+		t.Errorf("expected a tenant to fully overlap itself, got %d", n)
+	}
+
+	a, _ := alloc.Shard("tenant1")
+	b, _ := alloc.Shard("tenant2")
+	if n, _ := alloc.Overlap("tenant1", "tenant2"); n != shared(a, b) {
+		t.Errorf("expected %d shared nodes, got %d", shared(a, b), n)
+	}
+	if _, err := alloc.Overlap("tenant1", ""); err == nil {
+		t.Error("expected error for empty tenant")
+	}
+}
+
+func TestAffected(t *testing.T) {
+	// Three nodes and shards of two give only three combinations
+	alloc, _ := New(newRing(3), Config{ShardSize: 2})
+
+	tenants := make([]string, 30)
+	for i := range tenants {
+		tenants[i] = fmt.Sprintf("tenant%d", i)
+	}
+
+	poison, _ := alloc.Shard(tenants[0])
+	affected, err := alloc.Affected(tenants[0], tenants)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(affected) == 0 {
+		t.Fatal("expected other tenants on the same shard")
+	}
+	for _, tenant := range affected {
+		if tenant == tenants[0] {
+			t.Error("expected the poison tenant to be left out")
+		}
+		if n, _ := alloc.Overlap(tenants[0], tenant); n != len(poison) {
+			t.Errorf("expected %s to share every node, got %d", tenant, n)
+		}
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	alloc, _ := New(newRing(3), Config{ShardSize: 2})
+
+	tenants := make([]string, 30)
+	for i := range tenants {
+		tenants[i] = fmt.Sprintf("tenant%d", i)
+	}
+
+	report, err := alloc.Analyze(tenants)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if report.Tenants != 30 || report.Combinations != 3 {
+		t.Errorf("unexpected report %+v", report)
+	}
+
+	pairs := 0
+	for _, n := range report.Histogram {
+		pairs += n
+	}
+	if pairs != 30*29/2 {
+		t.Errorf("expected %d pairs, got %d", 30*29/2, pairs)
+	}
+
+	// Any two shards of two out of three nodes share at least one node
+	if report.Histogram[0] != 0 || report.MaxOverlap != 2 {
+		t.Errorf("unexpected histogram %v", report.Histogram)
+	}
+
+	grouped := 0
+	for i, group := range report.Identical {
+		grouped += len(group)
+		if i > 0 && len(group) > len(report.Identical[i-1]) {
+			t.Error("expected groups sorted largest first")
+		}
+	}
+	if len(report.Identical) != 3 || grouped != 30 {
+		t.Errorf("expected 30 tenants in 3 groups, got %v", report.Identical)
+	}
+}
@@ -0,0 +1,171 @@
+// Package shuffleshard assigns each tenant a small, deterministic subset of
+// the nodes of a consistent hash ring, so a tenant that overloads or crashes
+// its nodes only affects the tenants sharing its exact combination of nodes.
+
+package shuffleshard
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/mohdrashid9678/dcore/chash"
+)
+
+var (
+	// ErrNoRing is returned when an allocator is created without a ring
+	ErrNoRing = errors.New("ring is required")
+
+	// ErrInvalidShardSize is returned when the configured shard size is negative
+	ErrInvalidShardSize = errors.New("shard size must be positive")
+)
+
+// Config holds configuration options for creating a new Allocator
+type Config struct {
+	// ShardSize is the number of nodes assigned to each tenant
+	// Default: 2
+	ShardSize int
+
+	// HashFunc hashes tenant, key and node names
+	// Default: chash.DefaultHashFunc
+	HashFunc chash.HashFunc
+}
+
+// Allocator picks each tenant's shard from the nodes of a ring
+// Membership is read from the ring on every call, so the allocator follows
+// node additions and removals without being told about them
+// A tenant's nodes are the ShardSize highest rendezvous scores for the
+// tenant, so a membership change only reshuffles the tenants whose shard
+// gains or loses the changed node
+type Allocator struct {
+	ring      *chash.Ring
+	shardSize int
+	hashFunc  chash.HashFunc
+}
+
+// New creates an allocator over the nodes of ring
+func New(ring *chash.Ring, config Config) (*Allocator, error) {
+	if ring == nil {
+		return nil, ErrNoRing
+	}
+	if config.ShardSize < 0 {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidShardSize, config.ShardSize)
+	}
+	if config.ShardSize == 0 {
+		config.ShardSize = 2
+	}
+	if config.HashFunc == nil {
+		config.HashFunc = chash.DefaultHashFunc
+	}
+
+	return &Allocator{
+		ring:      ring,
+		shardSize: config.ShardSize,
+		hashFunc:  config.HashFunc,
+	}, nil
+}
+
+// ShardSize returns the number of nodes assigned to each tenant
+func (a *Allocator) ShardSize() int {
+	return a.shardSize
+}
+
+// Shard returns the nodes assigned to tenant, highest score first
+// Returns every node if the ring has fewer than ShardSize nodes
+func (a *Allocator) Shard(tenant string) ([]string, error) {
+	if tenant == "" {
+		return nil, chash.ErrEmptyKey
+	}
+
+	nodes := a.ring.Nodes()
+	if len(nodes) == 0 {
+		return nil, chash.ErrNoNodes
+	}
+	return a.rank(a.hashFunc(tenant), nodes, a.shardSize), nil
+}
+
+// GetNode routes key of tenant to one of the tenant's nodes
+// Keys spread evenly over the shard; nodes that are draining or disabled on
+// the ring are skipped, and a key only falls back to a draining node when
+// none of the shard is active
+// Returns chash.ErrNoNodes if every node of the shard is disabled: the tenant
+// is isolated rather than spilled onto other tenants' nodes
+func (a *Allocator) GetNode(tenant, key string) (string, error) {
+	if key == "" {
+		return "", chash.ErrEmptyKey
+	}
+
+	shard, err := a.Shard(tenant)
+	if err != nil {
+		return "", err
+	}
+
+	draining := ""
+	for _, node := range a.rank(a.hashFunc(key), shard, len(shard)) {
+		state, err := a.ring.NodeState(node)
+		if err != nil {
+			continue // Removed since Shard read the ring
+		}
+		switch state {
+		case chash.NodeActive:
+			return node, nil
+		case chash.NodeDraining:
+			if draining == "" {
+				draining = node
+			}
+		}
+	}
+
+	if draining == "" {
+		return "", chash.ErrNoNodes
+	}
+	return draining, nil
+}
+
+// Combinations returns the number of distinct shards the ring can form, the
+// binomial coefficient of the node count and the shard size
+// Two tenants share their exact shard with probability 1 / Combinations
+func (a *Allocator) Combinations() float64 {
+	n := len(a.ring.Nodes())
+	k := min(a.shardSize, n)
+
+	lgN, _ := math.Lgamma(float64(n + 1))
+	lgK, _ := math.Lgamma(float64(k + 1))
+	lgNK, _ := math.Lgamma(float64(n - k + 1))
+	return math.Round(math.Exp(lgN - lgK - lgNK))
+}
+
+// rank returns the count nodes with the highest scores for hash, highest first
+func (a *Allocator) rank(hash uint64, nodes []string, count int) []string {
+	type scored struct {
+		node  string
+		score uint64
+	}
+
+	scores := make([]scored, len(nodes))
+	for i, node := range nodes {
+		scores[i] = scored{node: node, score: mix64(hash ^ a.hashFunc(node))}
+	}
+	// Stable sort keeps name order on ties
+	sort.SliceStable(scores, func(i, j int) bool {
+		return scores[i].score > scores[j].score
+	})
+
+	result := make([]string, min(count, len(scores)))
+	for i := range result {
+		result[i] = scores[i].node
+	}
+	return result
+}
+
+// mix64 is the splitmix64 finalizer; it spreads the combined tenant and node
+// hashes so every pair scores independently
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
@@ -0,0 +1,148 @@
+package shuffleshard
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/mohdrashid9678/dcore/chash"
+)
+
+func newRing(n int) *chash.Ring {
+	ring := chash.New(chash.Config{Replicas: 20})
+	for i := 0; i < n; i++ {
+		ring.AddNode(fmt.Sprintf("server%d", i))
+	}
+	return ring
+}
+
+func TestNewValidation(t *testing.T) {
+	if _, err := New(nil, Config{}); err != ErrNoRing {
+		t.Errorf("expected ErrNoRing, got %v", err)
+	}
+	if _, err := New(newRing(1), Config{ShardSize: -1}); !errors.Is(err, ErrInvalidShardSize) {
+		t.Errorf("expected ErrInvalidShardSize, got %v", err)
+	}
+
+	alloc, _ := New(newRing(1), Config{})
+	if alloc.ShardSize() != 2 {
+		t.Errorf("expected default shard size 2, got %d", alloc.ShardSize())
+	}
+}
+
+func TestShardDeterministic(t *testing.T) {
+	alloc, _ := New(newRing(16), Config{ShardSize: 4})
+	other, _ := New(newRing(16), Config{ShardSize: 4})
+
+	for i := 0; i < 100; i++ {
+		tenant := fmt.Sprintf("tenant%d", i)
+		shard, err := alloc.Shard(tenant)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(shard) != 4 || shared(shard, shard) != 4 {
+			t.Fatalf("expected 4 distinct nodes, got %v", shard)
+		}
+
+		again, _ := other.Shard(tenant)
+		for j := range shard {
+			if shard[j] != again[j] {
+				t.Fatalf("%s: expected %v, got %v", tenant, shard, again)
+			}
+		}
+	}
+}
+
+func TestShardErrors(t *testing.T) {
+	alloc, _ := New(newRing(0), Config{})
+	if _, err := alloc.Shard("tenant"); err != chash.ErrNoNodes {
+		t.Errorf("expected ErrNoNodes, got %v", err)
+	}
+	if _, err := alloc.Shard(""); err != chash.ErrEmptyKey {
+		t.Errorf("expected ErrEmptyKey, got %v", err)
+	}
+
+	// A small ring gives every tenant every node
+	alloc, _ = New(newRing(2), Config{ShardSize: 3})
+	if shard, _ := alloc.Shard("tenant"); len(shard) != 2 {
+		t.Errorf("expected 2 nodes, got %v", shard)
+	}
+}
+
+func TestShardStableOnMembershipChange(t *testing.T) {
+	ring := newRing(16)
+	alloc, _ := New(ring, Config{ShardSize: 3})
+
+	before := make(map[string][]string)
+	for i := 0; i < 500; i++ {
+		tenant := fmt.Sprintf("tenant%d", i)
+		before[tenant], _ = alloc.Shard(tenant)
+	}
+
+	ring.AddNode("server16")
+
+	for tenant, shard := range before {
+		after, _ := alloc.Shard(tenant)
+		if n := shared(shard, after); n < 2 {
+			t.Fatalf("%s: expected at most one node to change, got %v then %v", tenant, shard, after)
+		} else if n == 2 && shared(after, []string{"server16"}) != 1 {
+			t.Fatalf("%s: expected only the new node to join, got %v then %v", tenant, shard, after)
+		}
+	}
+}
+
+func TestGetNode(t *testing.T) {
+	ring := newRing(16)
+	alloc, _ := New(ring, Config{ShardSize: 3})
+
+	shard, _ := alloc.Shard("tenant")
+	used := make(map[string]int)
+	for i := 0; i < 300; i++ {
+		node, err := alloc.GetNode("tenant", fmt.Sprintf("key%d", i))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if shared(shard, []string{node}) != 1 {
+			t.Fatalf("expected node in shard %v, got %s", shard, node)
+		}
+		used[node]++
+	}
+	if len(used) != 3 {
+		t.Errorf("expected keys spread over the shard, got %v", used)
+	}
+
+	// Disabled nodes are skipped, draining nodes are a last resort
+	ring.SetNodeState(shard[0], chash.NodeDisabled)
+	ring.SetNodeState(shard[1], chash.NodeDraining)
+	for i := 0; i < 50; i++ {
+		if node, _ := alloc.GetNode("tenant", fmt.Sprintf("key%d", i)); node != shard[2] {
+			t.Fatalf("expected %s, got %s", shard[2], node)
+		}
+	}
+
+	ring.SetNodeState(shard[2], chash.NodeDisabled)
+	if node, _ := alloc.GetNode("tenant", "key"); node != shard[1] {
+		t.Errorf("expected draining fallback %s, got %s", shard[1], node)
+	}
+
+	// The tenant stays isolated once its whole shard is down
+	ring.SetNodeState(shard[1], chash.NodeDisabled)
+	if _, err := alloc.GetNode("tenant", "key"); err != chash.ErrNoNodes {
+		t.Errorf("expected ErrNoNodes, got %v", err)
+	}
+	if _, err := alloc.GetNode("tenant", ""); err != chash.ErrEmptyKey {
+		t.Errorf("expected ErrEmptyKey, got %v", err)
+	}
+}
+
+func TestCombinations(t *testing.T) {
+	alloc, _ := New(newRing(8), Config{ShardSize: 2})
+	if c := alloc.Combinations(); c != 28 {
+		t.Errorf("expected 28 combinations, got %v", c)
+	}
+
+	alloc, _ = New(newRing(100), Config{ShardSize: 5})
+	if c := alloc.Combinations(); c != 75287520 {
+		t.Errorf("expected 75287520 combinations, got %v", c)
+	}
+}
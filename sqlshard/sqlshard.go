@@ -0,0 +1,399 @@
+// Package sqlshard routes SQL statements to per-shard database pools using a
+// consistent hash ring keyed by a sharding key.
+
+package sqlshard
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/mohdrashid9678/dcore/chash"
+)
+
+var (
+	// ErrNoShardKey is returned when the sharding key cannot be found in a query
+	ErrNoShardKey = errors.New("sharding key not found in query")
+
+	// ErrNoPool is returned when a shard has no open database pool
+	ErrNoPool = errors.New("no database pool for shard")
+)
+
+// OpenFunc opens a database pool for the given DSN
+type OpenFunc func(driver, dsn string) (*sql.DB, error)
+
+// Config holds configuration options for creating a new Router
+type Config struct {
+	// Ring decides which shard owns a sharding key
+	Ring *chash.Ring
+
+	// Driver is the database/sql driver name
+	Driver string
+
+	// DSN maps a ring node to its data source name
+	// Default: the node name is used as the DSN
+	DSN func(node string) string
+
+	// KeyColumn is the column whose placeholder carries the sharding key
+	// Used by the methods that extract the key from the query
+	KeyColumn string
+
+	// Open opens a database pool
+	// Default: sql.Open
+	Open OpenFunc
+}
+
+// Router routes SQL statements to shard pools
+type Router struct {
+	// mu protects dbs
+	mu sync.RWMutex
+
+	ring      *chash.Ring
+	driver    string
+	dsn       func(node string) string
+	keyColumn string
+	open      OpenFunc
+
+	// dbs maps ring nodes to their open pools
+	dbs map[string]*sql.DB
+}
+
+// New creates a new Router and opens a pool for every node in the ring
+func New(config Config) (*Router, error) {
+	if config.Ring == nil {
+		return nil, errors.New("ring is required")
+	}
+
+	if config.DSN == nil {
+		config.DSN = func(node string) string { return node }
+	}
+
+	if config.Open == nil {
+		config.Open = sql.Open
+	}
+
+	r := &Router{
+		ring:      config.Ring,
+		driver:    config.Driver,
+		dsn:       config.DSN,
+		keyColumn: config.KeyColumn,
+		open:      config.Open,
+		dbs:       make(map[string]*sql.DB),
+	}
+
+	if err := r.Reconcile(); err != nil {
+		r.Close()
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Reconcile opens pools for nodes that joined the ring and closes pools for
+// nodes that left it
+// Call it after every ring membership change
+func (r *Router) Reconcile() error {
+	nodes := r.ring.Nodes()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	wanted := make(map[string]struct{}, len(nodes))
+	for _, node := range nodes {
+		wanted[node] = struct{}{}
+	}
+
+	// Close pools of removed shards
+	var errs []error
+	for node, db := range r.dbs {
+		if _, exists := wanted[node]; !exists {
+			if err := db.Close(); err != nil {
+				errs = append(errs, fmt.Errorf("close shard %s: %w", node, err))
+			}
+			delete(r.dbs, node)
+		}
+	}
+
+	// Open pools of new shards
+	for _, node := range nodes {
+		if _, exists := r.dbs[node]; exists {
+			continue
+		}
+
+		db, err := r.open(r.driver, r.dsn(node))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("open shard %s: %w", node, err))
+			continue
+		}
+		r.dbs[node] = db
+	}
+
+	return errors.Join(errs...)
+}
+
+// Close closes every shard pool
+func (r *Router) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var errs []error
+	for node, db := range r.dbs {
+		if err := db.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("close shard %s: %w", node, err))
+		}
+		delete(r.dbs, node)
+	}
+
+	return errors.Join(errs...)
+}
+
+// Shards returns the nodes that currently have an open pool
+func (r *Router) Shards() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	shards := make([]string, 0, len(r.dbs))
+	for node := range r.dbs {
+		shards = append(shards, node)
+	}
+
+	// Sort for consistent ordering
+	sort.Strings(shards)
+	return shards
+}
+
+// DB returns the pool of the shard that owns the sharding key
+func (r *Router) DB(key string) (*sql.DB, string, error) {
+	node, err := r.ring.GetNode(key)
+	if err != nil {
+		return nil, "", err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	db, exists := r.dbs[node]
+	if !exists {
+		return nil, node, fmt.Errorf("%w: %s", ErrNoPool, node)
+	}
+
+	return db, node, nil
+}
+
+// ExecContext executes query on the shard that owns key
+func (r *Router) ExecContext(ctx context.Context, key, query string, args ...any) (sql.Result, error) {
+	db, _, err := r.DB(key)
+	if err != nil {
+		return nil, err
+	}
+	return db.ExecContext(ctx, query, args...)
+}
+
+// QueryContext runs query on the shard that owns key
+func (r *Router) QueryContext(ctx context.Context, key, query string, args ...any) (*sql.Rows, error) {
+	db, _, err := r.DB(key)
+	if err != nil {
+		return nil, err
+	}
+	return db.QueryContext(ctx, query, args...)
+}
+
+// ExecRouted executes query on the shard owning the key extracted from the
+// query's KeyColumn placeholder
+func (r *Router) ExecRouted(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	key, err := ExtractKey(query, r.keyColumn, args)
+	if err != nil {
+		return nil, err
+	}
+	return r.ExecContext(ctx, key, query, args...)
+}
+
+// QueryRouted runs query on the shard owning the key extracted from the
+// query's KeyColumn placeholder
+func (r *Router) QueryRouted(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	key, err := ExtractKey(query, r.keyColumn, args)
+	if err != nil {
+		return nil, err
+	}
+	return r.QueryContext(ctx, key, query, args...)
+}
+
+// keyPatterns caches the compiled placeholder pattern of each key column
+var keyPatterns sync.Map // map[string]*regexp.Regexp
+
+// keyPattern returns the pattern matching a placeholder compared against
+// column, compiling it on first use
+func keyPattern(column string) *regexp.Regexp {
+	if pattern, ok := keyPatterns.Load(column); ok {
+		return pattern.(*regexp.Regexp)
+	}
+
+	pattern := regexp.MustCompile(`(?i)(?:^|[^A-Za-z0-9_.])(?:[A-Za-z0-9_]+\.)?` +
+		regexp.QuoteMeta(column) + `\s*=\s*(\?|\$\d+|[@:][A-Za-z_][A-Za-z0-9_]*)`)
+	actual, _ := keyPatterns.LoadOrStore(column, pattern)
+	return actual.(*regexp.Regexp)
+}
+
+// ExtractKey finds the placeholder compared against column with "=" in query
+// and returns the matching argument formatted as a sharding key
+// Supports positional (?), numbered ($1) and named (:name, @name) placeholders;
+// named placeholders are resolved from sql.NamedArg arguments
+// Positional counting does not skip ? characters inside string literals
+func ExtractKey(query, column string, args []any) (string, error) {
+	if column == "" {
+		return "", fmt.Errorf("%w: no key column configured", ErrNoShardKey)
+	}
+
+	loc := keyPattern(column).FindStringSubmatchIndex(query)
+	if loc == nil {
+		return "", fmt.Errorf("%w: column %s", ErrNoShardKey, column)
+	}
+
+	placeholder := query[loc[2]:loc[3]]
+
+	var arg any
+	switch {
+	case placeholder == "?":
+		// Positional: count the ? placeholders before this one
+		idx := strings.Count(query[:loc[2]], "?")
+		if idx >= len(args) {
+			return "", fmt.Errorf("%w: missing argument %d", ErrNoShardKey, idx+1)
+		}
+		arg = args[idx]
+
+	case placeholder[0] == '$':
+		n, _ := strconv.Atoi(placeholder[1:])
+		if n < 1 || n > len(args) {
+			return "", fmt.Errorf("%w: missing argument %d", ErrNoShardKey, n)
+		}
+		arg = args[n-1]
+
+	default:
+		name := placeholder[1:]
+		found := false
+		for _, a := range args {
+			if named, ok := a.(sql.NamedArg); ok && named.Name == name {
+				arg = named.Value
+				found = true
+				break
+			}
+		}
+		if !found {
+			return "", fmt.Errorf("%w: missing named argument %s", ErrNoShardKey, name)
+		}
+	}
+
+	if named, ok := arg.(sql.NamedArg); ok {
+		arg = named.Value
+	}
+
+	key := fmt.Sprint(arg)
+	if key == "" {
+		return "", chash.ErrEmptyKey
+	}
+
+	return key, nil
+}
+
+// ScanFunc converts the current row into a value
+type ScanFunc[T any] func(rows *sql.Rows) (T, error)
+
+// Scatter runs query on every shard concurrently and merges the rows
+// If less is non-nil each shard's rows are assumed sorted by it and the
+// result is a sorted k-way merge; otherwise rows are concatenated in shard order
+func Scatter[T any](ctx context.Context, r *Router, scan ScanFunc[T], less func(a, b T) bool, query string, args ...any) ([]T, error) {
+	r.mu.RLock()
+	shards := make([]string, 0, len(r.dbs))
+	pools := make([]*sql.DB, 0, len(r.dbs))
+	for node := range r.dbs {
+		shards = append(shards, node)
+	}
+	sort.Strings(shards)
+	for _, node := range shards {
+		pools = append(pools, r.dbs[node])
+	}
+	r.mu.RUnlock()
+
+	results := make([][]T, len(pools))
+	errs := make([]error, len(pools))
+
+	var wg sync.WaitGroup
+	for i, db := range pools {
+		wg.Add(1)
+		go func(i int, db *sql.DB) {
+			defer wg.Done()
+			results[i], errs[i] = queryShard(ctx, db, scan, query, args)
+			if errs[i] != nil {
+				errs[i] = fmt.Errorf("shard %s: %w", shards[i], errs[i])
+			}
+		}(i, db)
+	}
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	if less == nil {
+		var merged []T
+		for _, rows := range results {
+			merged = append(merged, rows...)
+		}
+		return merged, nil
+	}
+
+	return mergeSorted(results, less), nil
+}
+
+// queryShard runs query on a single pool and scans every row
+func queryShard[T any](ctx context.Context, db *sql.DB, scan ScanFunc[T], query string, args []any) ([]T, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []T
+	for rows.Next() {
+		v, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+
+	return out, rows.Err()
+}
+
+// mergeSorted merges individually sorted slices into one sorted slice
+func mergeSorted[T any](lists [][]T, less func(a, b T) bool) []T {
+	total := 0
+	for _, l := range lists {
+		total += len(l)
+	}
+
+	merged := make([]T, 0, total)
+	pos := make([]int, len(lists))
+
+	for len(merged) < total {
+		best := -1
+		for i, l := range lists {
+			if pos[i] >= len(l) {
+				continue
+			}
+			if best == -1 || less(l[pos[i]], lists[best][pos[best]]) {
+				best = i
+			}
+		}
+		merged = append(merged, lists[best][pos[best]])
+		pos[best]++
+	}
+
+	return merged
+}
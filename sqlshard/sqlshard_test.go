@@ -0,0 +1,267 @@
+package sqlshard
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/mohdrashid9678/dcore/chash"
+)
+
+// fakeDriver is a minimal database/sql driver where every DSN is a shard
+// Queries return the rows registered for the shard; execs are recorded
+type fakeDriver struct {
+	mu    sync.Mutex
+	rows  map[string][]int64
+	execs map[string][]string
+	open  map[string]int
+}
+
+var fake = &fakeDriver{
+	rows:  make(map[string][]int64),
+	execs: make(map[string][]string),
+	open:  make(map[string]int),
+}
+
+func init() {
+	sql.Register("sqlshardfake", fake)
+}
+
+func (d *fakeDriver) Open(dsn string) (driver.Conn, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.open[dsn]++
+	return &fakeConn{dsn: dsn}, nil
+}
+
+func (d *fakeDriver) reset() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.rows = make(map[string][]int64)
+	d.execs = make(map[string][]string)
+	d.open = make(map[string]int)
+}
+
+type fakeConn struct{ dsn string }
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{dsn: c.dsn, query: query}, nil
+}
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return nil, errors.New("not supported") }
+
+type fakeStmt struct{ dsn, query string }
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	fake.execs[s.dsn] = append(fake.execs[s.dsn], s.query)
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	return &fakeRows{dsn: s.dsn, values: append([]int64(nil), fake.rows[s.dsn]...)}, nil
+}
+
+type fakeRows struct {
+	dsn    string
+	values []int64
+	pos    int
+}
+
+func (r *fakeRows) Columns() []string { return []string{"shard", "value"} }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.pos >= len(r.values) {
+		return io.EOF
+	}
+	dest[0] = r.dsn
+	dest[1] = r.values[r.pos]
+	r.pos++
+	return nil
+}
+
+func newTestRouter(t *testing.T, nodes ...string) (*Router, *chash.Ring) {
+	t.Helper()
+	fake.reset()
+
+	ring := chash.NewWithNodes(chash.Config{Replicas: 10}, nodes)
+	router, err := New(Config{
+		Ring:      ring,
+		Driver:    "sqlshardfake",
+		KeyColumn: "user_id",
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	t.Cleanup(func() { router.Close() })
+
+	return router, ring
+}
+
+func TestNewRequiresRing(t *testing.T) {
+	if _, err := New(Config{Driver: "sqlshardfake"}); err == nil {
+		t.Error("expected error without ring")
+	}
+}
+
+func TestExtractKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		args     []any
+		expected string
+		err      error
+	}{
+		{
+			name:     "positional",
+			query:    "SELECT * FROM orders WHERE status = ? AND user_id = ?",
+			args:     []any{"open", 42},
+			expected: "42",
+		},
+		{
+			name:     "numbered",
+			query:    "UPDATE users SET name = $1 WHERE user_id = $2",
+			args:     []any{"alice", "u-7"},
+			expected: "u-7",
+		},
+		{
+			name:     "named",
+			query:    "DELETE FROM carts WHERE u.user_id = :uid",
+			args:     []any{sql.Named("uid", "u-9")},
+			expected: "u-9",
+		},
+		{
+			name:     "case insensitive column",
+			query:    "select * from t where USER_ID=?",
+			args:     []any{"u-1"},
+			expected: "u-1",
+		},
+		{
+			name:  "column missing",
+			query: "SELECT * FROM t WHERE id = ?",
+			args:  []any{1},
+			err:   ErrNoShardKey,
+		},
+		{
+			name:  "suffix column does not match",
+			query: "SELECT * FROM t WHERE parent_user_id = ?",
+			args:  []any{1},
+			err:   ErrNoShardKey,
+		},
+		{
+			name:  "argument missing",
+			query: "SELECT * FROM t WHERE user_id = $3",
+			args:  []any{1},
+			err:   ErrNoShardKey,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := ExtractKey(tt.query, "user_id", tt.args)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("expected error %v, got %v", tt.err, err)
+			}
+			if key != tt.expected {
+				t.Errorf("expected key %q, got %q", tt.expected, key)
+			}
+		})
+	}
+}
+
+func TestKeyPatternCached(t *testing.T) {
+	if keyPattern("user_id") != keyPattern("user_id") {
+		t.Error("expected the pattern compiled once per column")
+	}
+	if keyPattern("user_id") == keyPattern("tenant_id") {
+		t.Error("expected a pattern per column")
+	}
+}
+
+func TestRoutedExec(t *testing.T) {
+	router, ring := newTestRouter(t, "shard1", "shard2", "shard3")
+
+	expected, _ := ring.GetNode("42")
+
+	_, err := router.ExecRouted(context.Background(), "UPDATE users SET name = ? WHERE user_id = ?", "bob", 42)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	if len(fake.execs[expected]) != 1 {
+		t.Errorf("expected exec on %s, got %v", expected, fake.execs)
+	}
+	if len(fake.execs) != 1 {
+		t.Errorf("expected exactly one shard to receive the exec, got %v", fake.execs)
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	router, ring := newTestRouter(t, "shard1", "shard2")
+
+	if shards := router.Shards(); len(shards) != 2 {
+		t.Fatalf("expected 2 shards, got %v", shards)
+	}
+
+	ring.AddNode("shard3")
+	ring.RemoveNode("shard1")
+
+	if err := router.Reconcile(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	shards := router.Shards()
+	if len(shards) != 2 || shards[0] != "shard2" || shards[1] != "shard3" {
+		t.Errorf("expected [shard2 shard3], got %v", shards)
+	}
+}
+
+func TestScatterMerge(t *testing.T) {
+	router, _ := newTestRouter(t, "shard1", "shard2", "shard3")
+
+	fake.mu.Lock()
+	fake.rows["shard1"] = []int64{1, 4, 7}
+	fake.rows["shard2"] = []int64{2, 5, 8}
+	fake.rows["shard3"] = []int64{3, 6, 9}
+	fake.mu.Unlock()
+
+	scan := func(rows *sql.Rows) (int64, error) {
+		var shard string
+		var v int64
+		err := rows.Scan(&shard, &v)
+		return v, err
+	}
+
+	// Sorted k-way merge
+	merged, err := Scatter(context.Background(), router, scan, func(a, b int64) bool { return a < b }, "SELECT shard, value FROM t ORDER BY value")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	for i, v := range merged {
+		if v != int64(i+1) {
+			t.Fatalf("expected sorted merge, got %v", merged)
+		}
+	}
+
+	// Concatenation without ordering
+	all, err := Scatter(context.Background(), router, scan, nil, "SELECT shard, value FROM t")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(all) != 9 {
+		t.Errorf("expected 9 rows, got %d", len(all))
+	}
+}
@@ -0,0 +1,135 @@
+// Package tenant carries tenant identity through contexts and derives every
+// per-tenant value from it: routing namespaces, rate-limit and quota keys,
+// metrics labels and log attributes, so all subsystems agree on who a
+// request belongs to.
+
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"unicode"
+
+	"github.com/mohdrashid9678/dcore/chash"
+)
+
+// MaxLength is the longest tenant ID accepted
+const MaxLength = 128
+
+// None is the Label of requests without a tenant
+const None = "none"
+
+// untenanted scopes the Key of requests without a tenant; Validate rejects
+// it as an ID so such keys never collide with a tenant's
+const untenanted = "-"
+
+// ErrInvalidTenant is returned when a tenant ID is empty, too long, or holds
+// characters that would be ambiguous in derived keys
+var ErrInvalidTenant = errors.New("invalid tenant id")
+
+// contextKey is the private key type for storing the tenant in a context
+type contextKey struct{}
+
+// Validate returns an error if id cannot be used as a tenant ID
+// IDs may not contain '/', which separates the tenant in Key, nor spaces or
+// control characters, which break log and metrics formats. The IDs "-" and
+// None are reserved for requests without a tenant
+func Validate(id string) error {
+	if id == "" || len(id) > MaxLength {
+		return fmt.Errorf("%w: length %d", ErrInvalidTenant, len(id))
+	}
+	if id == untenanted || id == None {
+		return fmt.Errorf("%w: %q is reserved", ErrInvalidTenant, id)
+	}
+	for _, r := range id {
+		if r == '/' || unicode.IsSpace(r) || unicode.IsControl(r) {
+			return fmt.Errorf("%w: %q", ErrInvalidTenant, id)
+		}
+	}
+	return nil
+}
+
+// WithTenant returns a copy of ctx carrying tenant id
+func WithTenant(ctx context.Context, id string) (context.Context, error) {
+	if err := Validate(id); err != nil {
+		return ctx, err
+	}
+	return context.WithValue(ctx, contextKey{}, id), nil
+}
+
+// FromContext returns the tenant carried by ctx
+// The boolean is false if ctx carries no tenant
+func FromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(contextKey{}).(string)
+	return id, ok
+}
+
+// Namespace returns the ring namespace of the tenant in ctx, so tenants that
+// reuse key names spread independently
+// Requests without a tenant use the ring's own keyspace
+func Namespace(ctx context.Context, ring *chash.Ring) *chash.Namespace {
+	id, _ := FromContext(ctx)
+	return ring.Namespace(id)
+}
+
+// GetNode routes key in the namespace of the tenant in ctx
+func GetNode(ctx context.Context, ring *chash.Ring, key string) (string, error) {
+	return Namespace(ctx, ring).GetNode(key)
+}
+
+// Key scopes key to the tenant in ctx as "tenant/key", for rate-limit
+// buckets, quota counters and caches shared between tenants
+// Requests without a tenant get "-/key", which no tenant's key can equal
+func Key(ctx context.Context, key string) string {
+	id, ok := FromContext(ctx)
+	if !ok {
+		id = untenanted
+	}
+	return id + "/" + key
+}
+
+// Label returns the tenant in ctx as a metrics label value, or None
+func Label(ctx context.Context) string {
+	if id, ok := FromContext(ctx); ok {
+		return id
+	}
+	return None
+}
+
+// Attr returns the tenant in ctx as a "tenant" log attribute
+func Attr(ctx context.Context) slog.Attr {
+	return slog.String("tenant", Label(ctx))
+}
+
+// NewLogHandler wraps h so every record logged with a context carrying a
+// tenant gets the tenant attribute, such as audit records written deep in a
+// subsystem that never sees the tenant itself
+func NewLogHandler(h slog.Handler) slog.Handler {
+	return logHandler{h}
+}
+
+// logHandler adds the tenant attribute to records
+type logHandler struct {
+	slog.Handler
+}
+
+// Handle adds the tenant of ctx, if any, and passes the record on
+func (h logHandler) Handle(ctx context.Context, record slog.Record) error {
+	if _, ok := FromContext(ctx); ok {
+		record = record.Clone()
+		record.AddAttrs(Attr(ctx))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+// WithAttrs keeps the wrapper around the derived handler
+func (h logHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return logHandler{h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup keeps the wrapper around the derived handler
+func (h logHandler) WithGroup(name string) slog.Handler {
+	return logHandler{h.Handler.WithGroup(name)}
+}
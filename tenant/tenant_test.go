@@ -0,0 +1,86 @@
+package tenant
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/mohdrashid9678/dcore/chash"
+)
+
+func TestContextRoundTrip(t *testing.T) {
+	ctx, err := WithTenant(context.Background(), "acme")
+	if err != nil {
+		t.Fatalf("WithTenant failed: %v", err)
+	}
+	if id, ok := FromContext(ctx); !ok || id != "acme" {
+		t.Errorf("expected acme, got %q %v", id, ok)
+	}
+	if _, ok := FromContext(context.Background()); ok {
+		t.Error("expected no tenant in a bare context")
+	}
+
+	for _, bad := range []string{"", "a/b", "a b", "a\nb", strings.Repeat("x", MaxLength+1), "-", None} {
+		if _, err := WithTenant(context.Background(), bad); !errors.Is(err, ErrInvalidTenant) {
+			t.Errorf("%q: expected ErrInvalidTenant, got %v", bad, err)
+		}
+	}
+}
+
+func TestDerivedValues(t *testing.T) {
+	ctx, _ := WithTenant(context.Background(), "acme")
+	bare := context.Background()
+
+	if k := Key(ctx, "requests"); k != "acme/requests" {
+		t.Errorf("unexpected key %q", k)
+	}
+	if k := Key(bare, "requests"); k != "-/requests" {
+		t.Errorf("expected unscoped key under the reserved prefix, got %q", k)
+	}
+
+	// An untenanted key never equals a tenant's
+	if Key(bare, "acme/requests") == Key(ctx, "requests") {
+		t.Error("expected untenanted and tenanted keys to differ")
+	}
+	if Label(ctx) != "acme" || Label(bare) != None {
+		t.Error("unexpected labels")
+	}
+	if a := Attr(ctx); a.Key != "tenant" || a.Value.String() != "acme" {
+		t.Errorf("unexpected attribute %v", a)
+	}
+}
+
+func TestGetNodeUsesNamespace(t *testing.T) {
+	ring := chash.NewWithNodes(chash.Config{Replicas: 10}, []string{"server1", "server2", "server3"})
+	ctx, _ := WithTenant(context.Background(), "acme")
+
+	want, _ := ring.Namespace("acme").GetNode("user:1")
+	if got, _ := GetNode(ctx, ring, "user:1"); got != want {
+		t.Errorf("expected the tenant namespace's node %s, got %s", want, got)
+	}
+
+	want, _ = ring.GetNode("user:1")
+	if got, _ := GetNode(context.Background(), ring, "user:1"); got != want {
+		t.Errorf("expected the ring's own keyspace without a tenant, got %s", got)
+	}
+}
+
+func TestLogHandler(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(NewLogHandler(slog.NewTextHandler(&buf, nil))).With("subsystem", "quota")
+
+	ctx, _ := WithTenant(context.Background(), "acme")
+	logger.InfoContext(ctx, "quota exceeded")
+	if !strings.Contains(buf.String(), "tenant=acme") || !strings.Contains(buf.String(), "subsystem=quota") {
+		t.Errorf("expected tenant and inherited attributes, got %q", buf.String())
+	}
+
+	buf.Reset()
+	logger.InfoContext(context.Background(), "startup")
+	if strings.Contains(buf.String(), "tenant=") {
+		t.Errorf("expected no tenant attribute without a tenant, got %q", buf.String())
+	}
+}
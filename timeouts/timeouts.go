@@ -0,0 +1,238 @@
+// Package timeouts estimates per-destination request timeouts from observed
+// latency histograms instead of static constants.
+
+package timeouts
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	// bucketBase is the upper bound of the first histogram bucket
+	bucketBase = 100 * time.Microsecond
+
+	// bucketGrowth is the ratio between consecutive bucket bounds
+	bucketGrowth = 1.25
+
+	// bucketCount covers latencies from 100µs to roughly two minutes
+	bucketCount = 64
+)
+
+// bounds holds the upper bound of every histogram bucket
+var bounds = func() [bucketCount]time.Duration {
+	var b [bucketCount]time.Duration
+	for i := range b {
+		b[i] = time.Duration(float64(bucketBase) * math.Pow(bucketGrowth, float64(i)))
+	}
+	return b
+}()
+
+// Config holds configuration options for creating a new Estimator
+type Config struct {
+	// Percentile is the latency quantile the timeout is derived from
+	// Default: 0.99
+	Percentile float64
+
+	// Multiplier is applied to the percentile latency
+	// Default: 2
+	Multiplier float64
+
+	// Floor is the smallest timeout ever returned
+	// Default: 10ms
+	Floor time.Duration
+
+	// Ceiling is the largest timeout ever returned
+	// Default: 30s
+	Ceiling time.Duration
+
+	// Default is returned until a destination has MinSamples observations
+	// Default: 1s
+	Default time.Duration
+
+	// MinSamples is the number of observations required before estimating
+	// Default: 20
+	MinSamples int
+
+	// DecayEvery halves all bucket counts after this many observations so
+	// the estimate follows recent behaviour
+	// Default: 1000
+	DecayEvery int
+}
+
+// Estimator derives timeouts per destination from latency histograms
+type Estimator struct {
+	// mu protects dests
+	mu sync.RWMutex
+
+	// config holds the validated configuration
+	config Config
+
+	// dests maps destination names to their latency histogram
+	dests map[string]*histogram
+}
+
+// histogram is a log-scale latency histogram for one destination
+type histogram struct {
+	counts [bucketCount]float64
+	total  float64
+
+	// samples counts observations since the last decay
+	samples int
+
+	// seen counts all observations ever made
+	seen int
+}
+
+// New creates a new timeout estimator with the given configuration
+func New(config Config) *Estimator {
+	if config.Percentile <= 0 || config.Percentile > 1 {
+		config.Percentile = 0.99
+	}
+	if config.Multiplier <= 0 {
+		config.Multiplier = 2
+	}
+	if config.Floor <= 0 {
+		config.Floor = 10 * time.Millisecond
+	}
+	if config.Ceiling <= 0 {
+		config.Ceiling = 30 * time.Second
+	}
+	if config.Ceiling < config.Floor {
+		config.Ceiling = config.Floor
+	}
+	if config.Default <= 0 {
+		config.Default = time.Second
+	}
+	if config.MinSamples <= 0 {
+		config.MinSamples = 20
+	}
+	if config.DecayEvery <= 0 {
+		config.DecayEvery = 1000
+	}
+
+	return &Estimator{
+		config: config,
+		dests:  make(map[string]*histogram),
+	}
+}
+
+// Observe records the latency of a completed request to the destination
+func (e *Estimator) Observe(dest string, latency time.Duration) {
+	if dest == "" || latency < 0 {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	h, exists := e.dests[dest]
+	if !exists {
+		h = &histogram{}
+		e.dests[dest] = h
+	}
+
+	h.counts[bucketFor(latency)]++
+	h.total++
+	h.samples++
+	h.seen++
+
+	// Decay old observations so the histogram tracks recent latency
+	if h.samples >= e.config.DecayEvery {
+		h.total = 0
+		for i := range h.counts {
+			h.counts[i] /= 2
+			h.total += h.counts[i]
+		}
+		h.samples = 0
+	}
+}
+
+// Quantile returns the estimated latency at quantile q for the destination
+// The boolean is false if the destination has not been observed
+func (e *Estimator) Quantile(dest string, q float64) (time.Duration, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	h, exists := e.dests[dest]
+	if !exists || h.total == 0 {
+		return 0, false
+	}
+
+	return h.quantile(q), true
+}
+
+// Timeout returns the timeout to use for the next request to the destination
+// It is Percentile latency × Multiplier clamped to [Floor, Ceiling], or
+// Default until enough samples have been observed
+func (e *Estimator) Timeout(dest string) time.Duration {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	h, exists := e.dests[dest]
+	if !exists || h.seen < e.config.MinSamples {
+		return e.clamp(e.config.Default)
+	}
+
+	timeout := time.Duration(float64(h.quantile(e.config.Percentile)) * e.config.Multiplier)
+	return e.clamp(timeout)
+}
+
+// WithTimeout returns a context bounded by the destination's current timeout
+func (e *Estimator) WithTimeout(ctx context.Context, dest string) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, e.Timeout(dest))
+}
+
+// Forget drops the latency history for the destination
+func (e *Estimator) Forget(dest string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	delete(e.dests, dest)
+}
+
+// clamp bounds d to the configured floor and ceiling
+func (e *Estimator) clamp(d time.Duration) time.Duration {
+	if d < e.config.Floor {
+		return e.config.Floor
+	}
+	if d > e.config.Ceiling {
+		return e.config.Ceiling
+	}
+	return d
+}
+
+// quantile returns the upper bound of the bucket containing quantile q
+func (h *histogram) quantile(q float64) time.Duration {
+	target := q * h.total
+	var cumulative float64
+	for i, count := range h.counts {
+		cumulative += count
+		if cumulative >= target && count > 0 {
+			return bounds[i]
+		}
+	}
+	return bounds[bucketCount-1]
+}
+
+// bucketFor returns the index of the first bucket whose bound covers latency
+func bucketFor(latency time.Duration) int {
+	if latency <= bucketBase {
+		return 0
+	}
+
+	idx := int(math.Ceil(math.Log(float64(latency)/float64(bucketBase)) / math.Log(bucketGrowth)))
+	if idx >= bucketCount {
+		return bucketCount - 1
+	}
+	// Guard against floating point rounding at bucket edges
+	for idx > 0 && bounds[idx-1] >= latency {
+		idx--
+	}
+	for idx < bucketCount-1 && bounds[idx] < latency {
+		idx++
+	}
+	return idx
+}
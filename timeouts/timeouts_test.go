@@ -0,0 +1,151 @@
+package timeouts
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestNewDefaults(t *testing.T) {
+	e := New(Config{})
+
+	if e.config.Percentile != 0.99 {
+		t.Errorf("expected percentile 0.99, got %v", e.config.Percentile)
+	}
+	if e.config.Multiplier != 2 {
+		t.Errorf("expected multiplier 2, got %v", e.config.Multiplier)
+	}
+	if e.config.Floor != 10*time.Millisecond {
+		t.Errorf("expected floor 10ms, got %v", e.config.Floor)
+	}
+	if e.config.Ceiling != 30*time.Second {
+		t.Errorf("expected ceiling 30s, got %v", e.config.Ceiling)
+	}
+	if e.config.Default != time.Second {
+		t.Errorf("expected default 1s, got %v", e.config.Default)
+	}
+}
+
+func TestBucketFor(t *testing.T) {
+	tests := []time.Duration{
+		0,
+		50 * time.Microsecond,
+		bucketBase,
+		time.Millisecond,
+		37 * time.Millisecond,
+		time.Second,
+		time.Hour,
+	}
+
+	for _, latency := range tests {
+		idx := bucketFor(latency)
+		if idx < bucketCount-1 && bounds[idx] < latency {
+			t.Errorf("bucket %d bound %v does not cover %v", idx, bounds[idx], latency)
+		}
+		if idx > 0 && bounds[idx-1] >= latency {
+			t.Errorf("latency %v should fall in an earlier bucket than %d", latency, idx)
+		}
+	}
+}
+
+func TestTimeoutDefaultUntilMinSamples(t *testing.T) {
+	e := New(Config{MinSamples: 10, Default: 500 * time.Millisecond})
+
+	if timeout := e.Timeout("server1"); timeout != 500*time.Millisecond {
+		t.Errorf("expected default timeout for unknown destination, got %v", timeout)
+	}
+
+	for i := 0; i < 9; i++ {
+		e.Observe("server1", time.Millisecond)
+	}
+	if timeout := e.Timeout("server1"); timeout != 500*time.Millisecond {
+		t.Errorf("expected default timeout below MinSamples, got %v", timeout)
+	}
+
+	e.Observe("server1", time.Millisecond)
+	if timeout := e.Timeout("server1"); timeout == 500*time.Millisecond {
+		t.Error("expected estimated timeout once MinSamples is reached")
+	}
+}
+
+func TestTimeoutFromPercentile(t *testing.T) {
+	e := New(Config{MinSamples: 1, Multiplier: 2, Floor: time.Millisecond})
+
+	// 99 fast requests and a single slow one
+	for i := 0; i < 99; i++ {
+		e.Observe("server1", 20*time.Millisecond)
+	}
+	e.Observe("server1", 2*time.Second)
+
+	p99, ok := e.Quantile("server1", 0.99)
+	if !ok {
+		t.Fatal("expected quantile for observed destination")
+	}
+	if p99 < 20*time.Millisecond || p99 > 25*time.Millisecond {
+		t.Errorf("expected p99 close to 20ms, got %v", p99)
+	}
+
+	timeout := e.Timeout("server1")
+	if timeout != 2*p99 {
+		t.Errorf("expected timeout %v, got %v", 2*p99, timeout)
+	}
+}
+
+func TestTimeoutClamping(t *testing.T) {
+	e := New(Config{
+		MinSamples: 1,
+		Floor:      50 * time.Millisecond,
+		Ceiling:    time.Second,
+	})
+
+	e.Observe("fast", time.Millisecond)
+	if timeout := e.Timeout("fast"); timeout != 50*time.Millisecond {
+		t.Errorf("expected floor 50ms, got %v", timeout)
+	}
+
+	e.Observe("slow", 10*time.Second)
+	if timeout := e.Timeout("slow"); timeout != time.Second {
+		t.Errorf("expected ceiling 1s, got %v", timeout)
+	}
+}
+
+func TestDecayFollowsRecentLatency(t *testing.T) {
+	e := New(Config{MinSamples: 1, DecayEvery: 10, Floor: time.Millisecond})
+
+	for i := 0; i < 10; i++ {
+		e.Observe("server1", time.Second)
+	}
+	for i := 0; i < 200; i++ {
+		e.Observe("server1", 5*time.Millisecond)
+	}
+
+	p99, _ := e.Quantile("server1", 0.99)
+	if p99 > 10*time.Millisecond {
+		t.Errorf("expected old slow samples to decay away, got p99 %v", p99)
+	}
+}
+
+func TestWithTimeout(t *testing.T) {
+	e := New(Config{Default: 200 * time.Millisecond})
+
+	ctx, cancel := e.WithTimeout(context.Background(), "server1")
+	defer cancel()
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		t.Fatal("expected context deadline")
+	}
+	if remaining := time.Until(deadline); remaining > 200*time.Millisecond {
+		t.Errorf("expected deadline within 200ms, got %v", remaining)
+	}
+}
+
+func TestForget(t *testing.T) {
+	e := New(Config{})
+	e.Observe("server1", time.Millisecond)
+	e.Forget("server1")
+
+	if _, ok := e.Quantile("server1", 0.5); ok {
+		t.Error("expected no quantile after Forget")
+	}
+}
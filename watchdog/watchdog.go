@@ -0,0 +1,297 @@
+// Package watchdog detects components that are alive but stuck: each component
+// registers a liveness signal it must beat regularly, and the watchdog raises
+// an event (or panics) when a signal goes quiet for longer than allowed.
+
+package watchdog
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mohdrashid9678/dcore/clock"
+)
+
+var (
+	// ErrInvalidComponent is returned when a component has no name or deadline
+	ErrInvalidComponent = errors.New("component must have a name and a positive deadline")
+
+	// ErrDuplicateComponent is returned when registering a name twice
+	ErrDuplicateComponent = errors.New("component already registered")
+)
+
+// Action is what the watchdog does when a component stalls
+type Action int
+
+const (
+	// Notify reports the stall through the OnStall callback
+	Notify Action = iota
+
+	// Panic reports the stall and then panics, for failures that must crash
+	// the process so a supervisor restarts it
+	Panic
+)
+
+// Component describes an expected liveness signal
+type Component struct {
+	// Name identifies the component in events and stats
+	Name string
+
+	// Deadline is the longest the component may go without a beat
+	Deadline time.Duration
+
+	// Busy reports whether the component has work to do; an idle component
+	// is never considered stalled, e.g. a consumer with an empty queue
+	// Default: nil (always busy)
+	Busy func() bool
+
+	// Action is taken when the component stalls
+	// Default: Notify
+	Action Action
+}
+
+// Event reports a component stalling or recovering
+type Event struct {
+	// Component is the component name
+	Component string
+
+	// LastBeat is when the component last signalled progress
+	LastBeat time.Time
+
+	// Stalled is how long the component has gone without a beat
+	Stalled time.Duration
+}
+
+// Status is the current state of a registered component
+type Status struct {
+	Name     string
+	LastBeat time.Time
+	Beats    uint64
+
+	// Stalls counts how many times the component has stalled
+	Stalls uint64
+
+	// Stalled is true while the component is stalled
+	Stalled bool
+}
+
+// Config holds configuration options for creating a new Watchdog
+type Config struct {
+	// OnStall is called when a component misses its deadline
+	// Default: nil
+	OnStall func(Event)
+
+	// OnRecover is called when a stalled component beats again
+	// Default: nil
+	OnRecover func(Event)
+
+	// Clock supplies the time for beats, checks and the Start ticker
+	// Default: the wall clock
+	Clock clock.Clock
+}
+
+// Watchdog checks registered components for missed deadlines
+type Watchdog struct {
+	config Config
+
+	// mu protects all fields below
+	mu         sync.Mutex
+	components map[string]*Signal
+	stop       chan struct{}
+}
+
+// Signal is a registered component's liveness signal
+type Signal struct {
+	watchdog  *Watchdog
+	component Component
+
+	// State guarded by watchdog.mu
+	lastBeat time.Time
+	beats    uint64
+	stalls   uint64
+	stalled  bool
+}
+
+// New creates a new watchdog with the given configuration
+func New(config Config) *Watchdog {
+	config.Clock = clock.OrReal(config.Clock)
+
+	return &Watchdog{
+		config:     config,
+		components: make(map[string]*Signal),
+	}
+}
+
+// Register adds a component and returns the signal it must beat
+// The deadline starts counting from registration
+func (w *Watchdog) Register(component Component) (*Signal, error) {
+	if component.Name == "" || component.Deadline <= 0 {
+		return nil, ErrInvalidComponent
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if _, exists := w.components[component.Name]; exists {
+		return nil, fmt.Errorf("%w: %s", ErrDuplicateComponent, component.Name)
+	}
+
+	s := &Signal{
+		watchdog:  w,
+		component: component,
+		lastBeat:  w.config.Clock.Now(),
+	}
+	w.components[component.Name] = s
+	return s, nil
+}
+
+// Beat records progress, e.g. once per loop iteration or consumed message
+func (s *Signal) Beat() {
+	s.beatAt(s.watchdog.config.Clock.Now())
+}
+
+// beatAt records progress at the given time
+func (s *Signal) beatAt(now time.Time) {
+	w := s.watchdog
+
+	w.mu.Lock()
+	s.lastBeat = now
+	s.beats++
+	recovered := s.stalled
+	s.stalled = false
+	w.mu.Unlock()
+
+	if recovered && w.config.OnRecover != nil {
+		w.config.OnRecover(Event{Component: s.component.Name, LastBeat: now})
+	}
+}
+
+// Unregister stops watching the component
+func (s *Signal) Unregister() {
+	w := s.watchdog
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.components[s.component.Name] == s {
+		delete(w.components, s.component.Name)
+	}
+}
+
+// Status returns the state of every component, sorted by name
+func (w *Watchdog) Status() []Status {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	statuses := make([]Status, 0, len(w.components))
+	for _, s := range w.components {
+		statuses = append(statuses, Status{
+			Name:     s.component.Name,
+			LastBeat: s.lastBeat,
+			Beats:    s.beats,
+			Stalls:   s.stalls,
+			Stalled:  s.stalled,
+		})
+	}
+
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+// Check evaluates every component now and returns the newly stalled ones
+// Start calls it periodically; call it directly to drive checks yourself
+func (w *Watchdog) Check() []Event {
+	return w.checkAt(w.config.Clock.Now())
+}
+
+// checkAt evaluates every component at the given time
+func (w *Watchdog) checkAt(now time.Time) []Event {
+	w.mu.Lock()
+	candidates := make([]*Signal, 0, len(w.components))
+	for _, s := range w.components {
+		if !s.stalled && now.Sub(s.lastBeat) > s.component.Deadline {
+			candidates = append(candidates, s)
+		}
+	}
+	w.mu.Unlock()
+
+	// Busy callbacks run without the lock; they may be slow or call back in
+	var events []Event
+	var fatal []string
+	for _, s := range candidates {
+		if s.component.Busy != nil && !s.component.Busy() {
+			continue
+		}
+
+		w.mu.Lock()
+		// Re-check: the component may have beaten meanwhile
+		if s.stalled || now.Sub(s.lastBeat) <= s.component.Deadline {
+			w.mu.Unlock()
+			continue
+		}
+		s.stalled = true
+		s.stalls++
+		event := Event{Component: s.component.Name, LastBeat: s.lastBeat, Stalled: now.Sub(s.lastBeat)}
+		w.mu.Unlock()
+
+		events = append(events, event)
+		if s.component.Action == Panic {
+			fatal = append(fatal, s.component.Name)
+		}
+	}
+
+	sort.Slice(events, func(i, j int) bool { return events[i].Component < events[j].Component })
+
+	if w.config.OnStall != nil {
+		for _, event := range events {
+			w.config.OnStall(event)
+		}
+	}
+
+	if len(fatal) > 0 {
+		sort.Strings(fatal)
+		panic(fmt.Sprintf("watchdog: components stalled: %v", fatal))
+	}
+
+	return events
+}
+
+// Start checks components every interval in a background goroutine
+// Calling Start on a running watchdog has no effect; call Stop to end checks
+func (w *Watchdog) Start(interval time.Duration) {
+	w.mu.Lock()
+	if w.stop != nil {
+		w.mu.Unlock()
+		return
+	}
+	stop := make(chan struct{})
+	w.stop = stop
+	w.mu.Unlock()
+
+	go func() {
+		ticker := w.config.Clock.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case now := <-ticker.C():
+				w.checkAt(now)
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends background checks started by Start
+func (w *Watchdog) Stop() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.stop != nil {
+		close(w.stop)
+		w.stop = nil
+	}
+}
@@ -0,0 +1,157 @@
+package watchdog
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mohdrashid9678/dcore/clock"
+)
+
+func TestStallAndRecover(t *testing.T) {
+	var stalls, recoveries []Event
+	w := New(Config{
+		OnStall:   func(e Event) { stalls = append(stalls, e) },
+		OnRecover: func(e Event) { recoveries = append(recoveries, e) },
+	})
+
+	loop, err := w.Register(Component{Name: "loop", Deadline: time.Second})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	start := time.Now()
+	loop.beatAt(start)
+
+	if events := w.checkAt(start.Add(500 * time.Millisecond)); len(events) != 0 {
+		t.Errorf("expected no stall within deadline, got %+v", events)
+	}
+
+	events := w.checkAt(start.Add(2 * time.Second))
+	if len(events) != 1 || events[0].Component != "loop" || events[0].Stalled != 2*time.Second {
+		t.Fatalf("expected loop stalled for 2s, got %+v", events)
+	}
+
+	// A stall is reported once, not on every check
+	if events := w.checkAt(start.Add(3 * time.Second)); len(events) != 0 {
+		t.Errorf("expected stall reported once, got %+v", events)
+	}
+
+	status := w.Status()
+	if len(status) != 1 || !status[0].Stalled || status[0].Stalls != 1 {
+		t.Errorf("unexpected status %+v", status)
+	}
+
+	loop.beatAt(start.Add(4 * time.Second))
+	if len(recoveries) != 1 || len(stalls) != 1 {
+		t.Errorf("expected one stall and one recovery, got %d and %d", len(stalls), len(recoveries))
+	}
+	if w.Status()[0].Stalled {
+		t.Error("expected component recovered")
+	}
+}
+
+func TestIdleComponentNotStalled(t *testing.T) {
+	w := New(Config{})
+
+	var pending atomic.Int64
+	consumer, _ := w.Register(Component{
+		Name:     "consumer",
+		Deadline: time.Second,
+		Busy:     func() bool { return pending.Load() > 0 },
+	})
+
+	start := time.Now()
+	consumer.beatAt(start)
+
+	// Empty queue: quiet is fine
+	if events := w.checkAt(start.Add(time.Minute)); len(events) != 0 {
+		t.Errorf("expected idle consumer not to stall, got %+v", events)
+	}
+
+	// Backlog but no progress: stuck
+	pending.Store(10)
+	if events := w.checkAt(start.Add(time.Minute)); len(events) != 1 {
+		t.Errorf("expected busy consumer to stall, got %+v", events)
+	}
+}
+
+func TestPanicAction(t *testing.T) {
+	w := New(Config{})
+	s, _ := w.Register(Component{Name: "critical", Deadline: time.Second, Action: Panic})
+	start := time.Now()
+	s.beatAt(start)
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for stalled critical component")
+		}
+	}()
+	w.checkAt(start.Add(time.Minute))
+}
+
+func TestRegisterValidation(t *testing.T) {
+	w := New(Config{})
+
+	if _, err := w.Register(Component{Deadline: time.Second}); err != ErrInvalidComponent {
+		t.Errorf("expected ErrInvalidComponent, got %v", err)
+	}
+	if _, err := w.Register(Component{Name: "a"}); err != ErrInvalidComponent {
+		t.Errorf("expected ErrInvalidComponent, got %v", err)
+	}
+
+	s, _ := w.Register(Component{Name: "a", Deadline: time.Second})
+	if _, err := w.Register(Component{Name: "a", Deadline: time.Second}); !errors.Is(err, ErrDuplicateComponent) {
+		t.Errorf("expected ErrDuplicateComponent, got %v", err)
+	}
+
+	s.Unregister()
+	if len(w.Status()) != 0 {
+		t.Error("expected component unregistered")
+	}
+	if _, err := w.Register(Component{Name: "a", Deadline: time.Second}); err != nil {
+		t.Errorf("expected re-register after unregister, got %v", err)
+	}
+}
+
+func TestStartStop(t *testing.T) {
+	stalled := make(chan Event, 1)
+	w := New(Config{OnStall: func(e Event) { stalled <- e }})
+	w.Register(Component{Name: "stuck", Deadline: 5 * time.Millisecond})
+
+	w.Start(time.Millisecond)
+	w.Start(time.Millisecond) // No effect while running
+	defer w.Stop()
+
+	select {
+	case e := <-stalled:
+		if e.Component != "stuck" {
+			t.Errorf("unexpected event %+v", e)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected background check to detect the stall")
+	}
+}
+
+func TestFakeClock(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	stalled := make(chan Event, 1)
+	w := New(Config{Clock: clk, OnStall: func(e Event) { stalled <- e }})
+	w.Register(Component{Name: "loop", Deadline: time.Minute})
+
+	w.Start(10 * time.Second)
+	defer w.Stop()
+	clk.BlockUntil(1)
+
+	// One advance past the deadline; the ticker delivers its latest tick, at 70s
+	clk.Advance(70 * time.Second)
+	select {
+	case e := <-stalled:
+		if e.Component != "loop" || e.Stalled <= time.Minute {
+			t.Errorf("unexpected event %+v", e)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected stall detected on the fake clock")
+	}
+}